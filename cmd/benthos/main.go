@@ -2,11 +2,12 @@ package main
 
 import (
 	"github.com/benthosdev/benthos/v4/internal/cli"
+	"github.com/benthosdev/benthos/v4/internal/serverless"
 
 	// Import all plugins defined within the repo.
 	_ "github.com/benthosdev/benthos/v4/public/components/all"
 )
 
 func main() {
-	cli.Run()
+	cli.RunWithOpts(cli.OptAddCommand(serverless.CliCommand()))
 }
@@ -29,6 +29,7 @@ type Config struct {
 	KeyFile        string              `json:"key_file" yaml:"key_file"`
 	CORS           httpdocs.ServerCORS `json:"cors" yaml:"cors"`
 	BasicAuth      httpdocs.BasicAuth  `json:"basic_auth" yaml:"basic_auth"`
+	Health         HealthConfig        `json:"health" yaml:"health"`
 }
 
 // NewConfig creates a new API config with default values.
@@ -42,6 +43,7 @@ func NewConfig() Config {
 		KeyFile:        "",
 		CORS:           httpdocs.NewServerCORS(),
 		BasicAuth:      httpdocs.NewBasicAuth(),
+		Health:         NewHealthConfig(),
 	}
 }
 
@@ -25,9 +25,28 @@ func Spec() docs.FieldSpecs {
 		docs.FieldString("key_file", "An optional key file for enabling TLS.").Advanced().HasDefault(""),
 		httpdocs.ServerCORSFieldSpec(),
 		httpdocs.BasicAuthFieldSpec(),
+		docs.FieldObject(
+			"health", "Determines which components must be healthy in order for the `/health` endpoint to report the service as ready or live.",
+		).WithChildren(
+			healthCheckFieldSpec("readiness", "The readiness check, which determines the status code of the `/health` endpoint by default.", NewHealthConfig().Readiness),
+			healthCheckFieldSpec("liveness", "The liveness check, which determines the status code of the `/health` endpoint when the query parameter `check` is set to `liveness`.", NewHealthConfig().Liveness),
+		).Advanced(),
 	}
 }
 
+func healthCheckFieldSpec(name, description string, defaults HealthCheckConfig) docs.FieldSpec {
+	return docs.FieldObject(name, description).WithChildren(
+		docs.FieldString(
+			"components", "The kinds of component that must be healthy for the check to pass.",
+		).Array().HasOptions(
+			HealthKindInput, HealthKindOutput, HealthKindProcessor, HealthKindCache, HealthKindRateLimit,
+		).HasDefault(defaults.Components),
+		docs.FieldString(
+			"unhealthy_after", "The period of time a component must be continuously unhealthy before the check fails. Components that are disconnected fail the readiness check immediately.",
+		).HasDefault(defaults.UnhealthyAfter),
+	)
+}
+
 //go:embed docs.md
 var httpDocs string
 
@@ -68,6 +87,15 @@ http:
     password_hash: ""
     algorithm: "sha256"
     salt: ""
+  health:
+    readiness:
+      components:
+        - input
+        - output
+      unhealthy_after: 10s
+    liveness:
+      components: []
+      unhealthy_after: 5m
 `,
 	})
 
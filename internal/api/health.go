@@ -0,0 +1,436 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+)
+
+// The kinds of component that report their health.
+const (
+	HealthKindInput     = "input"
+	HealthKindOutput    = "output"
+	HealthKindProcessor = "processor"
+	HealthKindCache     = "cache"
+	HealthKindRateLimit = "rate_limit"
+)
+
+func isHealthKind(kind string) bool {
+	switch kind {
+	case HealthKindInput, HealthKindOutput, HealthKindProcessor, HealthKindCache, HealthKindRateLimit:
+		return true
+	}
+	return false
+}
+
+// HealthCheckConfig determines which components must be healthy in order for
+// a health check to pass.
+type HealthCheckConfig struct {
+	Components     []string `json:"components" yaml:"components"`
+	UnhealthyAfter string   `json:"unhealthy_after" yaml:"unhealthy_after"`
+}
+
+// HealthConfig contains configuration for the readiness and liveness semantics
+// of the /health endpoint.
+type HealthConfig struct {
+	Readiness HealthCheckConfig `json:"readiness" yaml:"readiness"`
+	Liveness  HealthCheckConfig `json:"liveness" yaml:"liveness"`
+}
+
+// NewHealthConfig creates a new health config with default values.
+func NewHealthConfig() HealthConfig {
+	return HealthConfig{
+		Readiness: HealthCheckConfig{
+			Components:     []string{HealthKindInput, HealthKindOutput},
+			UnhealthyAfter: "10s",
+		},
+		Liveness: HealthCheckConfig{
+			Components:     []string{},
+			UnhealthyAfter: "5m",
+		},
+	}
+}
+
+type healthCheck struct {
+	kinds          map[string]struct{}
+	unhealthyAfter time.Duration
+
+	// Whether disconnected components fail the check immediately rather than
+	// after unhealthyAfter.
+	failDisconnected bool
+}
+
+func newHealthCheck(name string, conf HealthCheckConfig) (c healthCheck, err error) {
+	c.kinds = map[string]struct{}{}
+	for _, k := range conf.Components {
+		if !isHealthKind(k) {
+			return c, fmt.Errorf("%v component kind '%v' not recognised", name, k)
+		}
+		c.kinds[k] = struct{}{}
+	}
+	if conf.UnhealthyAfter != "" {
+		if c.unhealthyAfter, err = time.ParseDuration(conf.UnhealthyAfter); err != nil {
+			return c, fmt.Errorf("failed to parse %v unhealthy_after: %w", name, err)
+		}
+	}
+	return c, nil
+}
+
+// passes returns whether all components of the kinds covered by the check have
+// been healthy, or unhealthy for no longer than the configured duration.
+func (c healthCheck) passes(now time.Time, statuses []HealthStatus) bool {
+	for _, s := range statuses {
+		if _, exists := c.kinds[s.Kind]; !exists || s.Healthy {
+			continue
+		}
+		if c.failDisconnected && !s.Connected {
+			return false
+		}
+		if now.Sub(s.unhealthySince) >= c.unhealthyAfter {
+			return false
+		}
+	}
+	return true
+}
+
+//------------------------------------------------------------------------------
+
+// HealthProvider is implemented by managers that are able to provide a health
+// tracker for the component holding them.
+type HealthProvider interface {
+	HealthTracker(kind string) *HealthTracker
+}
+
+// HealthTrackerFor returns a new health tracker for the component holding a
+// manager, or nil if the manager does not support health tracking.
+func HealthTrackerFor(mgr interface{}, kind string) *HealthTracker {
+	if p, ok := mgr.(HealthProvider); ok {
+		return p.HealthTracker(kind)
+	}
+	return nil
+}
+
+// HealthStatus describes the health of a component at a point in time.
+type HealthStatus struct {
+	Kind          string `json:"kind"`
+	Stream        string `json:"stream,omitempty"`
+	Label         string `json:"label,omitempty"`
+	Path          string `json:"path"`
+	Healthy       bool   `json:"healthy"`
+	Connected     bool   `json:"connected"`
+	LastError     string `json:"last_error,omitempty"`
+	LastErrorAt   string `json:"last_error_at,omitempty"`
+	LastSuccessAt string `json:"last_success_at,omitempty"`
+
+	unhealthySince time.Time
+	lastErrorAt    time.Time
+	lastSuccessAt  time.Time
+}
+
+// HealthTracker records the health of a single component, which is healthy
+// when it is connected and its most recent operation did not fail. All methods
+// are safe to call on a nil tracker, which does nothing.
+type HealthTracker struct {
+	kind   string
+	stream string
+	label  string
+	path   string
+
+	registry *Health
+	mHealthy metrics.StatGauge
+
+	lastSuccess int64
+	failing     int32
+
+	mut            sync.Mutex
+	connected      bool
+	lastErr        string
+	lastErrAt      time.Time
+	unhealthySince time.Time
+}
+
+func (t *HealthTracker) healthyLocked() bool {
+	return t.connected && atomic.LoadInt32(&t.failing) == 0
+}
+
+// updateLocked records a transition between healthy and unhealthy states.
+func (t *HealthTracker) updateLocked(wasHealthy bool) {
+	healthy := t.healthyLocked()
+	if healthy == wasHealthy {
+		return
+	}
+	if healthy {
+		t.unhealthySince = time.Time{}
+		t.mHealthy.Set(1)
+	} else {
+		t.unhealthySince = time.Now()
+		t.mHealthy.Set(0)
+	}
+}
+
+// SetConnected records whether the component is connected to its external
+// system.
+func (t *HealthTracker) SetConnected(connected bool) {
+	if t == nil {
+		return
+	}
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	wasHealthy := t.healthyLocked()
+	t.connected = connected
+	t.updateLocked(wasHealthy)
+}
+
+// Success records that an operation of the component succeeded. This is cheap
+// enough to call for every message.
+func (t *HealthTracker) Success() {
+	if t == nil {
+		return
+	}
+	atomic.StoreInt64(&t.lastSuccess, time.Now().UnixNano())
+	if atomic.LoadInt32(&t.failing) == 0 {
+		return
+	}
+
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	wasHealthy := t.healthyLocked()
+	atomic.StoreInt32(&t.failing, 0)
+	t.updateLocked(wasHealthy)
+}
+
+// Error records that an operation of the component failed.
+func (t *HealthTracker) Error(err error) {
+	if t == nil || err == nil {
+		return
+	}
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	wasHealthy := t.healthyLocked()
+	t.lastErr = err.Error()
+	t.lastErrAt = time.Now()
+	atomic.StoreInt32(&t.failing, 1)
+	t.updateLocked(wasHealthy)
+}
+
+// Close removes the tracker from its registry, this should be called when the
+// component is closed.
+func (t *HealthTracker) Close() {
+	if t == nil {
+		return
+	}
+	t.registry.remove(t)
+}
+
+// Status returns the current health of the component.
+func (t *HealthTracker) Status() HealthStatus {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	s := HealthStatus{
+		Kind:           t.kind,
+		Stream:         t.stream,
+		Label:          t.label,
+		Path:           t.path,
+		Healthy:        t.healthyLocked(),
+		Connected:      t.connected,
+		LastError:      t.lastErr,
+		unhealthySince: t.unhealthySince,
+		lastErrorAt:    t.lastErrAt,
+	}
+	if !t.lastErrAt.IsZero() {
+		s.LastErrorAt = t.lastErrAt.Format(time.RFC3339Nano)
+	}
+	if lastSuccess := atomic.LoadInt64(&t.lastSuccess); lastSuccess > 0 {
+		s.lastSuccessAt = time.Unix(0, lastSuccess)
+		s.LastSuccessAt = s.lastSuccessAt.Format(time.RFC3339Nano)
+	}
+	return s
+}
+
+//------------------------------------------------------------------------------
+
+// Health is a registry of the health trackers of components across all streams
+// of a service, and provides an HTTP handler reporting their aggregated health.
+type Health struct {
+	readiness healthCheck
+	liveness  healthCheck
+
+	mut      sync.Mutex
+	trackers map[*HealthTracker]struct{}
+}
+
+// NewHealth creates an empty registry of health trackers.
+func NewHealth(conf HealthConfig) (*Health, error) {
+	readiness, err := newHealthCheck("readiness", conf.Readiness)
+	if err != nil {
+		return nil, err
+	}
+	// A service isn't ready until its components have connected, and
+	// otherwise only fails readiness when errors persist.
+	readiness.failDisconnected = true
+	liveness, err := newHealthCheck("liveness", conf.Liveness)
+	if err != nil {
+		return nil, err
+	}
+	return &Health{
+		readiness: readiness,
+		liveness:  liveness,
+		trackers:  map[*HealthTracker]struct{}{},
+	}, nil
+}
+
+// NewTracker creates and registers a tracker for a component. Components that
+// hold a connection should begin disconnected, and all others connected. The
+// health of the component is exposed via the provided metrics as the gauge
+// component_healthy.
+func (h *Health) NewTracker(kind, stream, label, path string, connected bool, stats metrics.Type) *HealthTracker {
+	t := &HealthTracker{
+		kind:      kind,
+		stream:    stream,
+		label:     label,
+		path:      path,
+		registry:  h,
+		mHealthy:  stats.GetGauge("component_healthy"),
+		connected: connected,
+	}
+	if connected {
+		t.mHealthy.Set(1)
+	} else {
+		t.unhealthySince = time.Now()
+		t.mHealthy.Set(0)
+	}
+
+	h.mut.Lock()
+	h.trackers[t] = struct{}{}
+	h.mut.Unlock()
+	return t
+}
+
+func (h *Health) remove(t *HealthTracker) {
+	h.mut.Lock()
+	delete(h.trackers, t)
+	h.mut.Unlock()
+}
+
+// mergeHealthStatus combines the status of two instances of the same
+// component, such as the processors of parallel pipeline threads, where the
+// component is only healthy when all instances are.
+func mergeHealthStatus(a, b HealthStatus) HealthStatus {
+	if !b.Healthy && (a.Healthy || b.unhealthySince.Before(a.unhealthySince)) {
+		a.unhealthySince = b.unhealthySince
+	}
+	a.Healthy = a.Healthy && b.Healthy
+	a.Connected = a.Connected && b.Connected
+
+	if b.lastErrorAt.After(a.lastErrorAt) {
+		a.LastError, a.LastErrorAt, a.lastErrorAt = b.LastError, b.LastErrorAt, b.lastErrorAt
+	}
+	if b.lastSuccessAt.After(a.lastSuccessAt) {
+		a.LastSuccessAt, a.lastSuccessAt = b.LastSuccessAt, b.lastSuccessAt
+	}
+	return a
+}
+
+// Statuses returns the health of all registered components, sorted by their
+// stream and path. Multiple instances of the same component are merged into a
+// single status.
+func (h *Health) Statuses() []HealthStatus {
+	h.mut.Lock()
+	trackers := make([]*HealthTracker, 0, len(h.trackers))
+	for t := range h.trackers {
+		trackers = append(trackers, t)
+	}
+	h.mut.Unlock()
+
+	type statusKey struct {
+		kind, stream, label, path string
+	}
+	indexes := map[statusKey]int{}
+	statuses := make([]HealthStatus, 0, len(trackers))
+	for _, t := range trackers {
+		s := t.Status()
+		key := statusKey{s.Kind, s.Stream, s.Label, s.Path}
+		if i, exists := indexes[key]; exists {
+			statuses[i] = mergeHealthStatus(statuses[i], s)
+			continue
+		}
+		indexes[key] = len(statuses)
+		statuses = append(statuses, s)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Stream != statuses[j].Stream {
+			return statuses[i].Stream < statuses[j].Stream
+		}
+		if statuses[i].Path != statuses[j].Path {
+			return statuses[i].Path < statuses[j].Path
+		}
+		return statuses[i].Kind < statuses[j].Kind
+	})
+	return statuses
+}
+
+// Ready returns whether the components covered by the readiness check are
+// healthy.
+func (h *Health) Ready() bool {
+	return h.readiness.passes(time.Now(), h.Statuses())
+}
+
+// Live returns whether the components covered by the liveness check are
+// healthy.
+func (h *Health) Live() bool {
+	return h.liveness.passes(time.Now(), h.Statuses())
+}
+
+type healthBody struct {
+	Ready      bool           `json:"ready"`
+	Live       bool           `json:"live"`
+	Components []HealthStatus `json:"components"`
+}
+
+// HandlerFunc returns an http.HandlerFunc that responds with the health of all
+// components as a JSON object. The status code is 503 when the readiness check
+// fails, or when the liveness check fails if the query parameter `check` is set
+// to `liveness`.
+func (h *Health) HandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		statuses := h.Statuses()
+
+		res := healthBody{
+			Ready:      h.readiness.passes(now, statuses),
+			Live:       h.liveness.passes(now, statuses),
+			Components: statuses,
+		}
+
+		passed := res.Ready
+		switch check := r.URL.Query().Get("check"); check {
+		case "", "readiness":
+		case "liveness":
+			passed = res.Live
+		default:
+			http.Error(w, fmt.Sprintf("Check '%v' not recognised", check), http.StatusBadRequest)
+			return
+		}
+
+		resBytes, err := json.Marshal(res)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if !passed {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_, _ = w.Write(resBytes)
+	}
+}
@@ -0,0 +1,159 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/api"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+)
+
+func getHealth(t *testing.T, h *api.Health, query string) (int, map[string]interface{}) {
+	t.Helper()
+
+	res := httptest.NewRecorder()
+	h.HandlerFunc()(res, httptest.NewRequest(http.MethodGet, "/health"+query, nil))
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+	return res.Code, body
+}
+
+func TestHealthReadiness(t *testing.T) {
+	h, err := api.NewHealth(api.NewHealthConfig())
+	require.NoError(t, err)
+
+	stats := metrics.NewLocal()
+
+	in := h.NewTracker(api.HealthKindInput, "", "foo", "root.input", false, stats)
+	c := h.NewTracker(api.HealthKindCache, "", "bar", "root.cache_resources", true, metrics.Noop())
+
+	code, body := getHealth(t, h, "")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, false, body["ready"])
+	assert.Equal(t, true, body["live"])
+	assert.Equal(t, int64(0), stats.GetCounters()["component_healthy"])
+
+	in.SetConnected(true)
+	in.Success()
+	c.Error(errors.New("cache is down"))
+
+	code, body = getHealth(t, h, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, body["ready"])
+	assert.Equal(t, int64(1), stats.GetCounters()["component_healthy"])
+
+	components := body["components"].([]interface{})
+	require.Len(t, components, 2)
+
+	cacheStatus := components[0].(map[string]interface{})
+	assert.Equal(t, "cache", cacheStatus["kind"])
+	assert.Equal(t, "bar", cacheStatus["label"])
+	assert.Equal(t, false, cacheStatus["healthy"])
+	assert.Equal(t, true, cacheStatus["connected"])
+	assert.Equal(t, "cache is down", cacheStatus["last_error"])
+	assert.Contains(t, cacheStatus, "last_error_at")
+	assert.NotContains(t, cacheStatus, "last_success_at")
+
+	inputStatus := components[1].(map[string]interface{})
+	assert.Equal(t, "input", inputStatus["kind"])
+	assert.Equal(t, true, inputStatus["healthy"])
+	assert.Contains(t, inputStatus, "last_success_at")
+
+	in.SetConnected(false)
+	assert.False(t, h.Ready())
+
+	in.Close()
+	assert.True(t, h.Ready())
+	_, body = getHealth(t, h, "")
+	assert.Len(t, body["components"], 1)
+}
+
+func TestHealthReadinessUnhealthyAfter(t *testing.T) {
+	h, err := api.NewHealth(api.NewHealthConfig())
+	require.NoError(t, err)
+
+	in := h.NewTracker(api.HealthKindInput, "", "foo", "root.input", false, metrics.Noop())
+	in.SetConnected(true)
+	in.Success()
+	assert.True(t, h.Ready())
+
+	// A single error does not fail the default readiness check.
+	in.Error(errors.New("read failed"))
+	assert.True(t, h.Ready())
+
+	conf := api.NewHealthConfig()
+	conf.Readiness.UnhealthyAfter = "50ms"
+
+	h, err = api.NewHealth(conf)
+	require.NoError(t, err)
+
+	out := h.NewTracker(api.HealthKindOutput, "", "bar", "root.output", false, metrics.Noop())
+	assert.False(t, h.Ready())
+
+	out.SetConnected(true)
+	out.Error(errors.New("write failed"))
+	assert.True(t, h.Ready())
+
+	require.Eventually(t, func() bool {
+		return !h.Ready()
+	}, time.Second*5, time.Millisecond*10)
+
+	out.Success()
+	assert.True(t, h.Ready())
+}
+
+func TestHealthLiveness(t *testing.T) {
+	conf := api.NewHealthConfig()
+	conf.Liveness.Components = []string{api.HealthKindProcessor}
+	conf.Liveness.UnhealthyAfter = "50ms"
+
+	h, err := api.NewHealth(conf)
+	require.NoError(t, err)
+
+	// Two instances of the same processor are reported as one component.
+	pA := h.NewTracker(api.HealthKindProcessor, "", "", "root.pipeline.processors.0", true, metrics.Noop())
+	pB := h.NewTracker(api.HealthKindProcessor, "", "", "root.pipeline.processors.0", true, metrics.Noop())
+
+	pA.Success()
+	pB.Error(errors.New("nope"))
+
+	code, body := getHealth(t, h, "?check=liveness")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, body["live"])
+	require.Len(t, body["components"], 1)
+	assert.Equal(t, false, body["components"].([]interface{})[0].(map[string]interface{})["healthy"])
+
+	require.Eventually(t, func() bool {
+		return !h.Live()
+	}, time.Second*5, time.Millisecond*10)
+
+	code, _ = getHealth(t, h, "?check=liveness")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	pB.Success()
+	assert.True(t, h.Live())
+
+	res := httptest.NewRecorder()
+	h.HandlerFunc()(res, httptest.NewRequest(http.MethodGet, "/health?check=nope", nil))
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+}
+
+func TestHealthBadConfig(t *testing.T) {
+	conf := api.NewHealthConfig()
+	conf.Readiness.Components = []string{"nope"}
+	_, err := api.NewHealth(conf)
+	require.Error(t, err)
+
+	conf = api.NewHealthConfig()
+	conf.Liveness.UnhealthyAfter = "nope"
+	_, err = api.NewHealth(conf)
+	require.Error(t, err)
+}
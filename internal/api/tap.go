@@ -0,0 +1,471 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+const (
+	defaultTapDuration = time.Second * 30
+	maxTapDuration     = time.Minute * 10
+	tapBufferSize      = 100
+)
+
+// TapProvider is implemented by managers that are able to provide the tap
+// point of the component holding them.
+type TapProvider interface {
+	TapPoint() *TapPoint
+}
+
+// TapPointFor returns the tap point of the component holding a manager, or nil
+// if the manager does not support taps.
+func TapPointFor(mgr interface{}) *TapPoint {
+	if p, ok := mgr.(TapProvider); ok {
+		return p.TapPoint()
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// TapPoint is a place within a running stream, identified by the label of a
+// component, where samples of the messages passing through can be streamed to
+// subscribers. When no subscribers are attached offering messages to a tap
+// point is a single atomic load.
+//
+// Each call to Taps.Point returns a new TapPoint sharing the subscribers of
+// all other points of the same component, and Close should be called once the
+// point is no longer needed.
+type TapPoint struct {
+	taps      *Taps
+	key       string
+	state     *tapPointState
+	closeOnce sync.Once
+}
+
+// Active returns true if at least one subscriber is attached to the tap point.
+func (p *TapPoint) Active() bool {
+	return p != nil && len(p.state.subscribers()) > 0
+}
+
+// Offer a batch of messages that passed through the component to any attached
+// subscribers. Messages are serialised before this call returns and therefore
+// the batch can be safely modified afterwards. This call never blocks, when a
+// subscriber is unable to keep up the messages are dropped.
+func (p *TapPoint) Offer(batch *message.Batch) {
+	if p == nil {
+		return
+	}
+	for _, s := range p.state.subscribers() {
+		s.offer(p.state, batch)
+	}
+}
+
+// Close releases the tap point, this should be called when the component is
+// closed. Once all points of a component are closed it is removed from the
+// registry.
+func (p *TapPoint) Close() {
+	if p == nil {
+		return
+	}
+	p.closeOnce.Do(func() {
+		p.taps.release(p.key)
+	})
+}
+
+type tapPointState struct {
+	stream string
+	label  string
+	refs   int
+
+	writeMut sync.Mutex
+	subs     atomic.Value
+}
+
+func (p *tapPointState) subscribers() []*tapSubscriber {
+	subs, _ := p.subs.Load().([]*tapSubscriber)
+	return subs
+}
+
+func (p *tapPointState) attach(s *tapSubscriber) {
+	p.writeMut.Lock()
+	defer p.writeMut.Unlock()
+
+	prev := p.subscribers()
+	subs := make([]*tapSubscriber, 0, len(prev)+1)
+	subs = append(subs, prev...)
+	p.subs.Store(append(subs, s))
+}
+
+func (p *tapPointState) detach(s *tapSubscriber) {
+	p.writeMut.Lock()
+	defer p.writeMut.Unlock()
+
+	prev := p.subscribers()
+	subs := make([]*tapSubscriber, 0, len(prev))
+	for _, v := range prev {
+		if v != s {
+			subs = append(subs, v)
+		}
+	}
+	p.subs.Store(subs)
+}
+
+//------------------------------------------------------------------------------
+
+// Taps is a registry of tap points across all streams of a service, and
+// provides an HTTP handler for subscribing to them.
+type Taps struct {
+	mut    sync.Mutex
+	points map[string]*tapPointState
+}
+
+// NewTaps creates an empty registry of tap points.
+func NewTaps() *Taps {
+	return &Taps{
+		points: map[string]*tapPointState{},
+	}
+}
+
+// Point returns a tap point of a labelled component within a stream. Points of
+// the same component share their subscribers, and remain registered for as
+// long as at least one of them is open. Therefore subscribers remain attached
+// when a component is replaced by a reload.
+func (t *Taps) Point(stream, label string) *TapPoint {
+	key := stream + "\x00" + label
+
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	state, exists := t.points[key]
+	if !exists {
+		state = &tapPointState{stream: stream, label: label}
+		t.points[key] = state
+	}
+	state.refs++
+	return &TapPoint{taps: t, key: key, state: state}
+}
+
+func (t *Taps) release(key string) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	state, exists := t.points[key]
+	if !exists {
+		return
+	}
+	if state.refs--; state.refs <= 0 {
+		delete(t.points, key)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type tapOptions struct {
+	rate           float64
+	filter         *mapping.Executor
+	duration       time.Duration
+	maxMessages    int
+	includeContent bool
+	redactAll      bool
+	redactMetadata map[string]struct{}
+}
+
+func parseTapOptions(env *bloblang.Environment, r *http.Request) (opts tapOptions, err error) {
+	query := r.URL.Query()
+
+	opts = tapOptions{
+		rate:           1,
+		duration:       defaultTapDuration,
+		includeContent: true,
+		redactMetadata: map[string]struct{}{},
+	}
+	if v := query.Get("rate"); v != "" {
+		if opts.rate, err = strconv.ParseFloat(v, 64); err != nil {
+			return opts, fmt.Errorf("failed to parse rate: %w", err)
+		}
+		if opts.rate <= 0 || opts.rate > 1 {
+			return opts, fmt.Errorf("rate must be greater than 0 and at most 1, got %v", opts.rate)
+		}
+	}
+	if v := query.Get("filter"); v != "" {
+		if opts.filter, err = env.NewMapping(v); err != nil {
+			return opts, fmt.Errorf("failed to parse filter: %w", err)
+		}
+	}
+	if v := query.Get("duration"); v != "" {
+		if opts.duration, err = time.ParseDuration(v); err != nil {
+			return opts, fmt.Errorf("failed to parse duration: %w", err)
+		}
+		if opts.duration <= 0 || opts.duration > maxTapDuration {
+			return opts, fmt.Errorf("duration must be greater than 0 and at most %v, got %v", maxTapDuration, opts.duration)
+		}
+	}
+	if v := query.Get("count"); v != "" {
+		if opts.maxMessages, err = strconv.Atoi(v); err != nil {
+			return opts, fmt.Errorf("failed to parse count: %w", err)
+		}
+		if opts.maxMessages < 0 {
+			return opts, fmt.Errorf("count must not be negative, got %v", opts.maxMessages)
+		}
+	}
+	if v := query.Get("content"); v != "" {
+		if opts.includeContent, err = strconv.ParseBool(v); err != nil {
+			return opts, fmt.Errorf("failed to parse content: %w", err)
+		}
+	}
+	for _, v := range query["redact"] {
+		for _, k := range strings.Split(v, ",") {
+			if k = strings.TrimSpace(k); k == "*" {
+				opts.redactAll = true
+			} else if k != "" {
+				opts.redactMetadata[k] = struct{}{}
+			}
+		}
+	}
+	return opts, nil
+}
+
+type tapEvent struct {
+	Stream   string            `json:"stream,omitempty"`
+	Label    string            `json:"label"`
+	Time     string            `json:"time"`
+	Content  *string           `json:"content,omitempty"`
+	Metadata map[string]string `json:"metadata"`
+	Error    string            `json:"error,omitempty"`
+}
+
+type tapSubscriber struct {
+	opts tapOptions
+
+	mut      sync.Mutex
+	sampled  float64
+	sent     int
+	finished bool
+
+	events  chan []byte
+	done    chan struct{}
+	dropped int64
+}
+
+func newTapSubscriber(opts tapOptions) *tapSubscriber {
+	return &tapSubscriber{
+		opts:   opts,
+		events: make(chan []byte, tapBufferSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// take decides whether the next message that passed the filter should be
+// sampled, and whether it is the last message to be sent. Sampling is
+// deterministic, where a rate of 0.25 takes every fourth message.
+func (s *tapSubscriber) take() (ok, last bool) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.finished {
+		return false, false
+	}
+	if s.sampled += s.opts.rate; s.sampled < 1 {
+		return false, false
+	}
+	s.sampled--
+	if s.sent++; s.opts.maxMessages > 0 && s.sent >= s.opts.maxMessages {
+		s.finished = true
+		return true, true
+	}
+	return true, false
+}
+
+func (s *tapSubscriber) offer(p *tapPointState, batch *message.Batch) {
+	_ = batch.Iter(func(i int, part *message.Part) error {
+		if s.opts.filter != nil {
+			if pass, err := s.opts.filter.QueryPart(i, batch); err != nil || !pass {
+				return nil
+			}
+		}
+		ok, last := s.take()
+		if !ok {
+			return nil
+		}
+		if last {
+			defer close(s.done)
+		}
+
+		event := tapEvent{
+			Stream:   p.stream,
+			Label:    p.label,
+			Time:     time.Now().Format(time.RFC3339Nano),
+			Metadata: map[string]string{},
+		}
+		if s.opts.includeContent {
+			content := string(part.Get())
+			event.Content = &content
+		}
+		_ = part.MetaIter(func(k, v string) error {
+			if _, redact := s.opts.redactMetadata[k]; redact || s.opts.redactAll {
+				v = docs.SecretScrubbed
+			}
+			event.Metadata[k] = v
+			return nil
+		})
+		if err := part.ErrorGet(); err != nil {
+			event.Error = err.Error()
+		}
+
+		eventBytes, err := json.Marshal(event)
+		if err != nil {
+			return nil
+		}
+		select {
+		case s.events <- eventBytes:
+		default:
+			atomic.AddInt64(&s.dropped, 1)
+		}
+		return nil
+	})
+}
+
+//------------------------------------------------------------------------------
+
+// Websocket upgrades are only accepted from the same origin, which is the
+// default behaviour of an upgrader without a CheckOrigin func.
+var tapUpgrader = websocket.Upgrader{}
+
+// HandlerFunc returns an http.HandlerFunc that streams a sample of the messages
+// passing through a labelled component to the client, either as Server-Sent
+// Events or, when the request is a websocket upgrade, as websocket messages.
+// Bloblang filters are parsed using the provided environment.
+func (t *Taps) HandlerFunc(env *bloblang.Environment) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		label := r.URL.Query().Get("label")
+		if label == "" {
+			http.Error(w, "A label must be specified", http.StatusBadRequest)
+			return
+		}
+		opts, err := parseTapOptions(env, r)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid tap options: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		var send func(data []byte) error
+		var finish func(reason string)
+
+		closed := r.Context().Done()
+		if websocket.IsWebSocketUpgrade(r) {
+			ws, err := tapUpgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer ws.Close()
+
+			// Reads are required in order to detect the client closing the
+			// connection, any messages received are ignored.
+			wsClosed := make(chan struct{})
+			go func() {
+				defer close(wsClosed)
+				for {
+					if _, _, err := ws.NextReader(); err != nil {
+						return
+					}
+				}
+			}()
+			closed = wsClosed
+
+			send = func(data []byte) error {
+				return ws.WriteMessage(websocket.TextMessage, data)
+			}
+			finish = func(reason string) {
+				_ = ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, reason))
+			}
+		} else {
+			flusher, ok := w.(http.Flusher)
+			if !ok {
+				http.Error(w, "Streaming is not supported by the connection", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("Connection", "keep-alive")
+			w.WriteHeader(http.StatusOK)
+			flusher.Flush()
+
+			send = func(data []byte) error {
+				if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+					return err
+				}
+				flusher.Flush()
+				return nil
+			}
+			finish = func(reason string) {
+				_, _ = fmt.Fprintf(w, "event: end\ndata: %s\n\n", reason)
+				flusher.Flush()
+			}
+		}
+
+		point := t.Point(r.URL.Query().Get("stream"), label)
+		defer point.Close()
+
+		sub := newTapSubscriber(opts)
+		point.state.attach(sub)
+		defer point.state.detach(sub)
+
+		timer := time.NewTimer(opts.duration)
+		defer timer.Stop()
+
+		drain := func() error {
+			for {
+				select {
+				case data := <-sub.events:
+					if err := send(data); err != nil {
+						return err
+					}
+				default:
+					return nil
+				}
+			}
+		}
+
+		end := func(reason string) {
+			if err := drain(); err != nil {
+				return
+			}
+			endBytes, _ := json.Marshal(map[string]interface{}{
+				"reason":  reason,
+				"dropped": atomic.LoadInt64(&sub.dropped),
+			})
+			finish(string(endBytes))
+		}
+
+		for {
+			select {
+			case data := <-sub.events:
+				if err := send(data); err != nil {
+					return
+				}
+			case <-sub.done:
+				end("count")
+				return
+			case <-timer.C:
+				end("duration")
+				return
+			case <-closed:
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTapPointsRemovedOnClose(t *testing.T) {
+	taps := NewTaps()
+
+	a := taps.Point("", "foo")
+	b := taps.Point("", "foo")
+	assert.Len(t, taps.points, 1)
+
+	a.Close()
+	a.Close()
+	assert.Len(t, taps.points, 1)
+
+	b.Close()
+	assert.Empty(t, taps.points)
+
+	var nilPoint *TapPoint
+	nilPoint.Close()
+}
@@ -0,0 +1,170 @@
+package api_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/api"
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func tapTestBatch(contents ...string) *message.Batch {
+	batch := message.QuickBatch(nil)
+	for i, c := range contents {
+		part := message.NewPart([]byte(c))
+		part.MetaSet("index", string(rune('0'+i)))
+		part.MetaSet("secret", "hunter2")
+		batch.Append(part)
+	}
+	return batch
+}
+
+func waitForTap(t *testing.T, point *api.TapPoint) {
+	t.Helper()
+	require.Eventually(t, point.Active, time.Second*5, time.Millisecond*10)
+}
+
+func readSSE(t *testing.T, scanner *bufio.Scanner) (event, data string) {
+	t.Helper()
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if data != "" {
+				return event, data
+			}
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	require.NoError(t, scanner.Err())
+	t.Fatal("stream ended unexpectedly")
+	return
+}
+
+func TestTapServerSentEvents(t *testing.T) {
+	taps := api.NewTaps()
+	point := taps.Point("", "foo")
+	assert.False(t, point.Active())
+
+	server := httptest.NewServer(taps.HandlerFunc(bloblang.GlobalEnvironment()))
+	defer server.Close()
+
+	query := url.Values{}
+	query.Set("label", "foo")
+	query.Set("rate", "0.5")
+	query.Set("filter", `!content().has_prefix("skip")`)
+	query.Set("count", "2")
+	query.Set("redact", "secret")
+
+	res, err := http.Get(server.URL + "?" + query.Encode())
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	waitForTap(t, point)
+
+	// Messages offered to other tap points are not sent.
+	taps.Point("", "bar").Offer(tapTestBatch("nope"))
+	point.Offer(tapTestBatch("a", "skip b", "c", "d", "e", "f"))
+
+	scanner := bufio.NewScanner(res.Body)
+	for _, exp := range []string{"c", "e"} {
+		_, data := readSSE(t, scanner)
+
+		var event map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(data), &event))
+		assert.Equal(t, "foo", event["label"])
+		assert.Equal(t, exp, event["content"])
+		assert.Equal(t, "!!!SECRET_SCRUBBED!!!", event["metadata"].(map[string]interface{})["secret"])
+	}
+
+	event, data := readSSE(t, scanner)
+	assert.Equal(t, "end", event)
+	assert.JSONEq(t, `{"reason":"count","dropped":0}`, data)
+
+	require.Eventually(t, func() bool {
+		return !point.Active()
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func TestTapWebsocket(t *testing.T) {
+	taps := api.NewTaps()
+	point := taps.Point("baz", "foo")
+
+	server := httptest.NewServer(taps.HandlerFunc(bloblang.GlobalEnvironment()))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "?stream=baz&label=foo&content=false&redact=*"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	waitForTap(t, point)
+	point.Offer(tapTestBatch("a"))
+
+	_, data, err := conn.ReadMessage()
+	require.NoError(t, err)
+
+	var event map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &event))
+	assert.Equal(t, "baz", event["stream"])
+	assert.NotContains(t, event, "content")
+	assert.Equal(t, map[string]interface{}{
+		"index":  "!!!SECRET_SCRUBBED!!!",
+		"secret": "!!!SECRET_SCRUBBED!!!",
+	}, event["metadata"])
+
+	require.NoError(t, conn.Close())
+	require.Eventually(t, func() bool {
+		return !point.Active()
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func TestTapBadOptions(t *testing.T) {
+	handler := api.NewTaps().HandlerFunc(bloblang.GlobalEnvironment())
+
+	for _, query := range []string{
+		"",
+		"label=foo&rate=0",
+		"label=foo&rate=2",
+		"label=foo&filter=nope(",
+		"label=foo&duration=1h",
+		"label=foo&count=-1",
+		"label=foo&content=nah",
+	} {
+		res := httptest.NewRecorder()
+		handler(res, httptest.NewRequest(http.MethodGet, "/tap?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, res.Code, query)
+	}
+}
+
+func TestTapWebsocketCrossOrigin(t *testing.T) {
+	taps := api.NewTaps()
+
+	server := httptest.NewServer(taps.HandlerFunc(bloblang.GlobalEnvironment()))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "?label=foo"
+	_, res, err := websocket.DefaultDialer.Dial(wsURL, http.Header{
+		"Origin": []string{"http://example.com"},
+	})
+	require.Error(t, err)
+	require.NotNil(t, res)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+}
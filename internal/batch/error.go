@@ -42,6 +42,17 @@ func (e *Error) Failed(i int, err error) *Error {
 	return e
 }
 
+// WithSource returns a copy of the error that refers to a different batch of
+// messages, which is useful when the error was created by a component that
+// only has access to a copy of the batch, such as a plugin output.
+func (e *Error) WithSource(msg *message.Batch) *Error {
+	return &Error{
+		err:        e.err,
+		source:     msg,
+		partErrors: e.partErrors,
+	}
+}
+
 // IndexedErrors returns the number of indexed errors that have been registered
 // for the batch.
 func (e *Error) IndexedErrors() int {
@@ -16,6 +16,7 @@ type Environment struct {
 	rateLimits *RateLimitSet
 	metrics    *MetricsSet
 	tracers    *TracerSet
+	secrets    *SecretsSet
 }
 
 // NewEnvironment creates an empty environment.
@@ -29,6 +30,7 @@ func NewEnvironment() *Environment {
 		rateLimits: &RateLimitSet{},
 		metrics:    &MetricsSet{},
 		tracers:    &TracerSet{},
+		secrets:    &SecretsSet{},
 	}
 }
 
@@ -60,6 +62,7 @@ func (e *Environment) Clone() *Environment {
 	for _, v := range e.tracers.specs {
 		_ = newEnv.tracers.Add(v.constructor, v.spec)
 	}
+	newEnv.secrets = e.secrets.clone()
 	return newEnv
 }
 
@@ -102,4 +105,5 @@ var GlobalEnvironment = &Environment{
 	rateLimits: AllRateLimits,
 	metrics:    AllMetrics,
 	tracers:    AllTracers,
+	secrets:    AllSecrets,
 }
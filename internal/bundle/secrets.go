@@ -0,0 +1,96 @@
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// AllSecrets is a set containing every single secrets provider that has been
+// imported.
+var AllSecrets = &SecretsSet{
+	providers: map[string]SecretLookupFunc{},
+}
+
+//------------------------------------------------------------------------------
+
+// SecretsAdd adds a new secrets provider to this environment.
+func (e *Environment) SecretsAdd(name string, fn SecretLookupFunc) error {
+	return e.secrets.Add(name, fn)
+}
+
+// SecretsLookup attempts to obtain the value of a secret from a provider.
+func (e *Environment) SecretsLookup(ctx context.Context, provider, path string) (string, error) {
+	return e.secrets.Lookup(ctx, provider, path)
+}
+
+// SecretsProviders returns the names of all secrets providers.
+func (e *Environment) SecretsProviders() []string {
+	return e.secrets.Providers()
+}
+
+//------------------------------------------------------------------------------
+
+// SecretLookupFunc obtains the value of a secret identified by a path, where
+// the format of the path is specific to the provider.
+type SecretLookupFunc func(ctx context.Context, path string) (string, error)
+
+// SecretsSet contains an explicit set of secrets providers available to a
+// Benthos service.
+type SecretsSet struct {
+	mut       sync.RWMutex
+	providers map[string]SecretLookupFunc
+}
+
+// Add a new secrets provider to this set.
+func (s *SecretsSet) Add(name string, fn SecretLookupFunc) error {
+	if !nameRegexp.MatchString(name) {
+		return fmt.Errorf("secrets provider name '%v' does not match the required regular expression /%v/", name, nameRegexpRaw)
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.providers == nil {
+		s.providers = map[string]SecretLookupFunc{}
+	}
+	s.providers[name] = fn
+	return nil
+}
+
+// Lookup attempts to obtain the value of a secret from a provider.
+func (s *SecretsSet) Lookup(ctx context.Context, provider, path string) (string, error) {
+	s.mut.RLock()
+	fn, exists := s.providers[provider]
+	s.mut.RUnlock()
+
+	if !exists {
+		return "", fmt.Errorf("secrets provider '%v' was not recognised", provider)
+	}
+	return fn(ctx, path)
+}
+
+// Providers returns the names of all secrets providers within the set.
+func (s *SecretsSet) Providers() []string {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	names := make([]string, 0, len(s.providers))
+	for k := range s.providers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *SecretsSet) clone() *SecretsSet {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	newSet := &SecretsSet{providers: make(map[string]SecretLookupFunc, len(s.providers))}
+	for k, v := range s.providers {
+		newSet.providers[k] = v
+	}
+	return newSet
+}
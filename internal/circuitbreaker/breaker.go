@@ -0,0 +1,300 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned when a request is rejected because the circuit breaker
+// is open, or because it is half open and already has the maximum number of
+// probe requests in flight.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State describes the current state of a circuit breaker.
+type State int
+
+// Circuit breaker states.
+const (
+	// StateClosed means requests are allowed and their results are tracked.
+	StateClosed State = iota
+	// StateHalfOpen means a limited number of probe requests are allowed in
+	// order to determine whether the circuit breaker can be closed again.
+	StateHalfOpen
+	// StateOpen means all requests are rejected until the open period has
+	// elapsed.
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half_open"
+	case StateOpen:
+		return "open"
+	}
+	return "unknown"
+}
+
+// Config describes the conditions under which a circuit breaker opens and
+// closes.
+type Config struct {
+	// ErrorThreshold is the ratio of failed requests, between 0 and 1, within
+	// the window at or above which the circuit breaker opens.
+	ErrorThreshold float64
+
+	// MinRequests is the minimum number of requests within the window before
+	// the error threshold is considered.
+	MinRequests int
+
+	// Window is the period of time over which the error rate is measured.
+	Window time.Duration
+
+	// OpenPeriod is the period of time to reject requests for once opened,
+	// after which the circuit breaker becomes half open.
+	OpenPeriod time.Duration
+
+	// HalfOpenRequests is the number of probe requests allowed while half
+	// open, all of which must succeed in order for the breaker to close.
+	HalfOpenRequests int
+}
+
+// Status is a snapshot of the state of a circuit breaker.
+type Status struct {
+	State    string     `json:"state"`
+	Requests int64      `json:"requests"`
+	Failures int64      `json:"failures"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+}
+
+const windowBuckets = 10
+
+type bucket struct {
+	epoch    int64
+	requests int64
+	failures int64
+}
+
+// Breaker is a circuit breaker that opens once the ratio of failed requests
+// within a sliding window exceeds a threshold, rejects requests while open,
+// and then probes the downstream service with a limited number of requests
+// before closing again.
+type Breaker struct {
+	conf        Config
+	bucketWidth int64
+
+	mut        sync.Mutex
+	state      State
+	generation int64
+	openedAt   time.Time
+	buckets    [windowBuckets]bucket
+
+	probesInFlight int
+	probeSuccesses int
+	openTimer      *time.Timer
+
+	onStateChange func(from, to State)
+	nowFn         func() time.Time
+}
+
+// New creates a new circuit breaker, or returns an error if the provided config
+// is invalid.
+func New(conf Config) (*Breaker, error) {
+	if conf.ErrorThreshold <= 0 || conf.ErrorThreshold > 1 {
+		return nil, errors.New("error threshold must be greater than 0 and no greater than 1")
+	}
+	if conf.Window <= 0 {
+		return nil, errors.New("window must be greater than zero")
+	}
+	if conf.OpenPeriod <= 0 {
+		return nil, errors.New("open period must be greater than zero")
+	}
+	if conf.MinRequests < 1 {
+		conf.MinRequests = 1
+	}
+	if conf.HalfOpenRequests < 1 {
+		conf.HalfOpenRequests = 1
+	}
+
+	bucketWidth := int64(conf.Window) / windowBuckets
+	if bucketWidth < 1 {
+		bucketWidth = 1
+	}
+	return &Breaker{
+		conf:          conf,
+		bucketWidth:   bucketWidth,
+		onStateChange: func(from, to State) {},
+		nowFn:         time.Now,
+	}, nil
+}
+
+// OnStateChange sets a closure to be called each time the circuit breaker
+// changes state. The closure is called whilst a lock is held and therefore
+// must not call methods of the breaker.
+func (b *Breaker) OnStateChange(fn func(from, to State)) {
+	b.mut.Lock()
+	b.onStateChange = fn
+	b.mut.Unlock()
+}
+
+func (b *Breaker) setState(to State, now time.Time) {
+	from := b.state
+	b.state = to
+	b.generation++
+	b.probesInFlight = 0
+	b.probeSuccesses = 0
+
+	if b.openTimer != nil {
+		b.openTimer.Stop()
+		b.openTimer = nil
+	}
+
+	switch to {
+	case StateOpen:
+		b.openedAt = now
+
+		// Move into half open once the open period has elapsed even if no
+		// requests arrive, so that observers of state changes are up to date.
+		generation := b.generation
+		b.openTimer = time.AfterFunc(b.conf.OpenPeriod, func() {
+			b.mut.Lock()
+			defer b.mut.Unlock()
+			if b.generation == generation {
+				b.setState(StateHalfOpen, b.nowFn())
+			}
+		})
+	case StateClosed:
+		b.buckets = [windowBuckets]bucket{}
+	}
+	b.onStateChange(from, to)
+}
+
+func (b *Breaker) currentBucket(now time.Time) *bucket {
+	epoch := now.UnixNano() / b.bucketWidth
+	bk := &b.buckets[epoch%windowBuckets]
+	if bk.epoch != epoch {
+		*bk = bucket{epoch: epoch}
+	}
+	return bk
+}
+
+func (b *Breaker) windowTotals(now time.Time) (requests, failures int64) {
+	epoch := now.UnixNano() / b.bucketWidth
+	for _, bk := range b.buckets {
+		if epoch-bk.epoch < windowBuckets {
+			requests += bk.requests
+			failures += bk.failures
+		}
+	}
+	return
+}
+
+// Allow determines whether a request should be attempted. If the request is
+// allowed then a closure is returned that must be called with the result of
+// the request, otherwise ErrOpen is returned.
+func (b *Breaker) Allow() (done func(err error), err error) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	now := b.nowFn()
+	if b.state == StateOpen {
+		if now.Sub(b.openedAt) < b.conf.OpenPeriod {
+			return nil, ErrOpen
+		}
+		b.setState(StateHalfOpen, now)
+	}
+
+	if b.state == StateHalfOpen {
+		if b.probesInFlight >= b.conf.HalfOpenRequests {
+			return nil, ErrOpen
+		}
+		b.probesInFlight++
+	}
+
+	generation := b.generation
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() {
+			b.resolve(generation, err)
+		})
+	}, nil
+}
+
+func (b *Breaker) resolve(generation int64, err error) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	// Results of requests that were allowed before the last state change are
+	// no longer relevant.
+	if generation != b.generation {
+		return
+	}
+
+	now := b.nowFn()
+	switch b.state {
+	case StateClosed:
+		bk := b.currentBucket(now)
+		bk.requests++
+		if err != nil {
+			bk.failures++
+		}
+		requests, failures := b.windowTotals(now)
+		if requests >= int64(b.conf.MinRequests) && float64(failures)/float64(requests) >= b.conf.ErrorThreshold {
+			b.setState(StateOpen, now)
+		}
+	case StateHalfOpen:
+		if err != nil {
+			b.setState(StateOpen, now)
+			return
+		}
+		b.probeSuccesses++
+		if b.probeSuccesses >= b.conf.HalfOpenRequests {
+			b.setState(StateClosed, now)
+		}
+	}
+}
+
+// State returns the current state of the circuit breaker.
+func (b *Breaker) State() State {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	if b.state == StateOpen && b.nowFn().Sub(b.openedAt) >= b.conf.OpenPeriod {
+		// The transition into half open may not have happened yet, but from
+		// the perspective of an observer we're already there.
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+// Close stops any pending transition of the circuit breaker into half open.
+func (b *Breaker) Close() {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	if b.openTimer != nil {
+		b.openTimer.Stop()
+		b.openTimer = nil
+	}
+}
+
+// Status returns a snapshot of the circuit breaker state along with the number
+// of requests and failures within the current window.
+func (b *Breaker) Status() Status {
+	state := b.State()
+
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	requests, failures := b.windowTotals(b.nowFn())
+	s := Status{
+		State:    state.String(),
+		Requests: requests,
+		Failures: failures,
+	}
+	if state != StateClosed {
+		openedAt := b.openedAt
+		s.OpenedAt = &openedAt
+	}
+	return s
+}
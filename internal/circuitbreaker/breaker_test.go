@@ -0,0 +1,172 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBreaker(t *testing.T, conf Config) (*Breaker, *time.Time) {
+	t.Helper()
+
+	b, err := New(conf)
+	require.NoError(t, err)
+
+	now := time.Unix(1000, 0)
+	b.nowFn = func() time.Time {
+		return now
+	}
+	return b, &now
+}
+
+func doRequest(t *testing.T, b *Breaker, err error) {
+	t.Helper()
+
+	done, allowErr := b.Allow()
+	require.NoError(t, allowErr)
+	done(err)
+}
+
+func TestBreakerBadConfig(t *testing.T) {
+	for _, conf := range []Config{
+		{ErrorThreshold: 0, Window: time.Second, OpenPeriod: time.Second},
+		{ErrorThreshold: 1.5, Window: time.Second, OpenPeriod: time.Second},
+		{ErrorThreshold: 0.5, Window: 0, OpenPeriod: time.Second},
+		{ErrorThreshold: 0.5, Window: time.Second, OpenPeriod: 0},
+	} {
+		_, err := New(conf)
+		assert.Error(t, err, "%+v", conf)
+	}
+}
+
+func TestBreakerLifecycle(t *testing.T) {
+	b, now := newTestBreaker(t, Config{
+		ErrorThreshold:   0.5,
+		MinRequests:      4,
+		Window:           time.Second * 10,
+		OpenPeriod:       time.Second * 5,
+		HalfOpenRequests: 2,
+	})
+
+	var transitions []string
+	b.OnStateChange(func(from, to State) {
+		transitions = append(transitions, from.String()+"->"+to.String())
+	})
+
+	errFailed := errors.New("nope")
+
+	doRequest(t, b, errFailed)
+	doRequest(t, b, errFailed)
+	doRequest(t, b, nil)
+	assert.Equal(t, StateClosed, b.State(), "below min requests")
+
+	doRequest(t, b, errFailed)
+	assert.Equal(t, StateOpen, b.State())
+
+	_, err := b.Allow()
+	assert.Equal(t, ErrOpen, err)
+
+	*now = now.Add(time.Second * 5)
+	assert.Equal(t, StateHalfOpen, b.State())
+
+	doneA, err := b.Allow()
+	require.NoError(t, err)
+	doneB, err := b.Allow()
+	require.NoError(t, err)
+
+	_, err = b.Allow()
+	assert.Equal(t, ErrOpen, err, "probe limit reached")
+
+	doneA(nil)
+	assert.Equal(t, StateHalfOpen, b.State())
+	doneB(errFailed)
+	assert.Equal(t, StateOpen, b.State())
+
+	*now = now.Add(time.Second * 5)
+	doRequest(t, b, nil)
+	doRequest(t, b, nil)
+	assert.Equal(t, StateClosed, b.State())
+
+	status := b.Status()
+	assert.Equal(t, "closed", status.State)
+	assert.Equal(t, int64(0), status.Requests)
+	assert.Nil(t, status.OpenedAt)
+
+	assert.Equal(t, []string{
+		"closed->open",
+		"open->half_open",
+		"half_open->open",
+		"open->half_open",
+		"half_open->closed",
+	}, transitions)
+}
+
+func TestBreakerWindowExpiry(t *testing.T) {
+	b, now := newTestBreaker(t, Config{
+		ErrorThreshold: 0.5,
+		MinRequests:    2,
+		Window:         time.Second * 10,
+		OpenPeriod:     time.Second,
+	})
+
+	errFailed := errors.New("nope")
+
+	doRequest(t, b, errFailed)
+	assert.Equal(t, int64(1), b.Status().Failures)
+
+	*now = now.Add(time.Second * 11)
+	assert.Equal(t, int64(0), b.Status().Failures)
+
+	doRequest(t, b, nil)
+	doRequest(t, b, errFailed)
+	assert.Equal(t, StateOpen, b.State())
+}
+
+func TestBreakerStaleResults(t *testing.T) {
+	b, _ := newTestBreaker(t, Config{
+		ErrorThreshold: 0.5,
+		MinRequests:    1,
+		Window:         time.Second * 10,
+		OpenPeriod:     time.Second,
+	})
+
+	doneStale, err := b.Allow()
+	require.NoError(t, err)
+
+	doRequest(t, b, errors.New("nope"))
+	assert.Equal(t, StateOpen, b.State())
+
+	// A result from a request made before opening must not count.
+	doneStale(nil)
+	assert.Equal(t, StateOpen, b.State())
+}
+
+func TestBreakerOpenPeriodElapses(t *testing.T) {
+	b, err := New(Config{
+		ErrorThreshold: 0.5,
+		MinRequests:    1,
+		Window:         time.Second * 10,
+		OpenPeriod:     time.Millisecond * 10,
+	})
+	require.NoError(t, err)
+	defer b.Close()
+
+	transitions := make(chan string, 10)
+	b.OnStateChange(func(from, to State) {
+		transitions <- from.String() + "->" + to.String()
+	})
+
+	doRequest(t, b, errors.New("nope"))
+
+	for _, exp := range []string{"closed->open", "open->half_open"} {
+		select {
+		case actual := <-transitions:
+			assert.Equal(t, exp, actual)
+		case <-time.After(time.Second * 5):
+			t.Fatalf("timed out waiting for transition %v", exp)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+// Package circuitbreaker implements a circuit breaker that tracks the error
+// rate of requests made to a downstream service over a sliding window, and
+// fails requests fast while that rate is exceeded.
+package circuitbreaker
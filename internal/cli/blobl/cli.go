@@ -106,6 +106,7 @@ Find out more about Bloblang at: https://benthos.dev/docs/guides/bloblang/about`
 					},
 				},
 			},
+			testCommand(),
 		},
 	}
 }
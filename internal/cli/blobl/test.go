@@ -0,0 +1,318 @@
+package blobl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/nsf/jsondiff"
+	"github.com/urfave/cli/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
+	ifilepath "github.com/benthosdev/benthos/v4/internal/filepath"
+)
+
+var green = color.New(color.FgGreen).SprintFunc()
+var yellow = color.New(color.FgYellow).SprintFunc()
+
+const (
+	inputSuffix    = ".input.json"
+	expectedSuffix = ".expected.json"
+)
+
+func testCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "test",
+		Usage: "Execute Bloblang mappings against golden files",
+		Description: `
+Executes any number of Bloblang mapping files (with the extension .blobl)
+against input documents, and compares the results against expected documents.
+If one or more tests fail the process will report the errors and exit with a
+status code 1.
+
+  benthos blobl test ./path/to/mappings/...
+  benthos blobl test ./foo.blobl
+  benthos blobl test --update ./path/to/mappings/...
+
+The input and expected documents of a mapping foo.blobl are files within the
+same directory named foo.input.json and foo.expected.json, and multiple cases
+can be specified with files named foo.<case>.input.json and
+foo.<case>.expected.json. When a case name matches another mapping, e.g.
+foo.bar.input.json alongside foo.bar.blobl, the files belong to that mapping.
+
+When the --update flag is set the expected documents are written with the
+results of the mappings rather than compared.`[1:],
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "update",
+				Aliases: []string{"u"},
+				Value:   false,
+				Usage:   "write the results of mappings to the expected files rather than comparing them.",
+			},
+			&cli.IntFlag{
+				Name:    "threads",
+				Aliases: []string{"t"},
+				Value:   runtime.NumCPU(),
+				Usage:   "the number of test cases to execute in parallel.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			paths := c.Args().Slice()
+			if len(paths) == 0 {
+				paths = []string{"./..."}
+			}
+			if runTests(os.Stdout, paths, c.Bool("update"), c.Int("threads")) {
+				os.Exit(0)
+			}
+			os.Exit(1)
+			return nil
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type testCase struct {
+	mappingPath  string
+	inputPath    string
+	expectedPath string
+}
+
+func (t testCase) name() string {
+	return filepath.Base(t.inputPath)
+}
+
+type testResult struct {
+	testCase
+	err error
+}
+
+// getTestCases returns the test cases of a mapping file, which are the input
+// files that share the name of the mapping. Input files that share the name of
+// another mapping with a longer name, e.g. foo.bar.input.json when both
+// foo.blobl and foo.bar.blobl exist, belong to that mapping instead.
+func getTestCases(mappingPath string) ([]testCase, error) {
+	base := strings.TrimSuffix(mappingPath, ".blobl")
+
+	var inputPaths []string
+	if _, err := os.Stat(base + inputSuffix); err == nil {
+		inputPaths = append(inputPaths, base+inputSuffix)
+	}
+	named, err := filepath.Glob(escapeGlob(base) + ".*" + inputSuffix)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range named {
+		if !ownedBySibling(base, p) {
+			inputPaths = append(inputPaths, p)
+		}
+	}
+
+	cases := make([]testCase, 0, len(inputPaths))
+	for _, p := range inputPaths {
+		cases = append(cases, testCase{
+			mappingPath:  mappingPath,
+			inputPath:    p,
+			expectedPath: strings.TrimSuffix(p, inputSuffix) + expectedSuffix,
+		})
+	}
+	return cases, nil
+}
+
+// ownedBySibling returns true if an input file that matches the name of a
+// mapping also matches the name of another mapping in the same directory with a
+// longer name.
+func ownedBySibling(base, inputPath string) bool {
+	segments := strings.Split(strings.TrimSuffix(strings.TrimPrefix(inputPath, base+"."), inputSuffix), ".")
+	for i := 1; i <= len(segments); i++ {
+		if _, err := os.Stat(base + "." + strings.Join(segments[:i], ".") + ".blobl"); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func escapeGlob(p string) string {
+	var buf strings.Builder
+	for _, r := range p {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			buf.WriteRune('\\')
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
+
+func readMapping(path string) (*mapping.Executor, error) {
+	mappingBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mapping file: %w", err)
+	}
+	exec, err := bloblang.NewEnvironment().WithImporterRelativeToFile(path).NewMapping(string(mappingBytes))
+	if err != nil {
+		if perr, ok := err.(*parser.Error); ok {
+			return nil, fmt.Errorf("failed to parse mapping: %v", perr.ErrorAtPositionStructured("", []rune(string(mappingBytes))))
+		}
+		return nil, err
+	}
+	return exec, nil
+}
+
+// runCase executes a mapping against the input of a test case and either
+// compares the result against the expected file or, when updating, writes the
+// result to it.
+func runCase(eCache *execCache, exec *mapping.Executor, tCase testCase, update bool) testResult {
+	res := testResult{testCase: tCase}
+
+	input, err := os.ReadFile(tCase.inputPath)
+	if err != nil {
+		res.err = fmt.Errorf("failed to read input file: %w", err)
+		return res
+	}
+
+	result, err := eCache.executeMapping(exec, false, true, input)
+	if err != nil {
+		res.err = fmt.Errorf("failed to execute mapping: %w", err)
+		return res
+	}
+
+	if update {
+		if err := os.WriteFile(tCase.expectedPath, []byte(result+"\n"), 0o644); err != nil {
+			res.err = fmt.Errorf("failed to write expected file: %w", err)
+		}
+		return res
+	}
+
+	expected, err := os.ReadFile(tCase.expectedPath)
+	if err != nil {
+		res.err = fmt.Errorf("failed to read expected file: %w", err)
+		return res
+	}
+
+	if json.Valid([]byte(result)) && json.Valid(expected) {
+		jdopts := jsondiff.DefaultConsoleOptions()
+		diff, explanation := jsondiff.Compare([]byte(result), expected, &jdopts)
+		if diff != jsondiff.FullMatch {
+			res.err = fmt.Errorf("JSON content mismatch\n%v", explanation)
+		}
+	} else if exp := strings.TrimSuffix(string(expected), "\n"); exp != result {
+		res.err = fmt.Errorf("content mismatch\n  expected: %v\n  received: %v", exp, result)
+	}
+	return res
+}
+
+// runTests executes the tests of mapping files for a slice of paths, which can
+// be either mapping files, glob patterns, or super paths such as './...'.
+// Returns true if all tests succeeded.
+func runTests(w io.Writer, paths []string, update bool, threads int) bool {
+	mappingPaths, err := ifilepath.GlobsAndSuperPaths(paths, "blobl")
+	if err != nil {
+		fmt.Fprintf(w, "Failed to obtain test targets: %v\n", err)
+		return false
+	}
+	sort.Strings(mappingPaths)
+
+	type target struct {
+		exec *mapping.Executor
+		err  error
+	}
+	targets := map[string]*target{}
+	var cases []testCase
+	for _, p := range mappingPaths {
+		tCases, err := getTestCases(p)
+		if err != nil {
+			fmt.Fprintf(w, "Failed to obtain test cases of '%v': %v\n", p, err)
+			return false
+		}
+		if len(tCases) == 0 {
+			continue
+		}
+		t := &target{}
+		t.exec, t.err = readMapping(p)
+		targets[p] = t
+		cases = append(cases, tCases...)
+	}
+	if len(targets) == 0 {
+		fmt.Fprintf(w, "%v\n", yellow("No tests were found"))
+		return false
+	}
+
+	if threads < 1 {
+		threads = 1
+	}
+
+	casesChan := make(chan int)
+	results := make([]testResult, len(cases))
+
+	var wg sync.WaitGroup
+	wg.Add(threads)
+	for i := 0; i < threads; i++ {
+		go func() {
+			defer wg.Done()
+			eCache := newExecCache()
+			for i := range casesChan {
+				t := targets[cases[i].mappingPath]
+				if t.err != nil {
+					results[i] = testResult{testCase: cases[i], err: t.err}
+					continue
+				}
+				results[i] = runCase(eCache, t.exec, cases[i], update)
+			}
+		}()
+	}
+	for i := range cases {
+		casesChan <- i
+	}
+	close(casesChan)
+	wg.Wait()
+
+	failures := map[string][]testResult{}
+	for _, r := range results {
+		if r.err != nil {
+			failures[r.mappingPath] = append(failures[r.mappingPath], r)
+		}
+	}
+
+	var failedPaths []string
+	for _, p := range mappingPaths {
+		if _, exists := targets[p]; !exists {
+			continue
+		}
+		if _, failed := failures[p]; failed {
+			failedPaths = append(failedPaths, p)
+			fmt.Fprintf(w, "Test '%v' %v\n", p, red("failed"))
+		} else if update {
+			fmt.Fprintf(w, "Test '%v' %v\n", p, green("updated"))
+		} else {
+			fmt.Fprintf(w, "Test '%v' %v\n", p, green("succeeded"))
+		}
+	}
+
+	if len(failedPaths) > 0 {
+		fmt.Fprintf(w, "\nFailures:\n\n")
+		for i, p := range failedPaths {
+			if i > 0 {
+				fmt.Fprintln(w, "")
+			}
+			fmt.Fprintf(w, "--- %v ---\n\n", p)
+			for j, r := range failures[p] {
+				if j > 0 {
+					fmt.Fprintln(w, "")
+				}
+				fmt.Fprintf(w, "%v:\n%v\n", r.name(), r.err)
+			}
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+package blobl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for k, v := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, k), []byte(v), 0o644))
+	}
+}
+
+func TestRunTests(t *testing.T) {
+	color.NoColor = true
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"upper.blobl":         `root.name = this.name.uppercase()`,
+		"upper.input.json":    `{"name":"foo"}`,
+		"upper.expected.json": `{ "name": "FOO" }`,
+
+		"count.blobl":               `root = this.items.length()`,
+		"count.one.input.json":      `{"items":[1]}`,
+		"count.one.expected.json":   `1`,
+		"count.three.input.json":    `{"items":[1,2,3]}`,
+		"count.three.expected.json": `3`,
+
+		"nocases.blobl": `root = this`,
+	})
+
+	var buf bytes.Buffer
+	assert.True(t, runTests(&buf, []string{dir + "/..."}, false, 2), buf.String())
+	assert.Equal(t, "Test '"+filepath.Join(dir, "count.blobl")+"' succeeded\n"+
+		"Test '"+filepath.Join(dir, "upper.blobl")+"' succeeded\n", buf.String())
+}
+
+func TestRunTestsFailures(t *testing.T) {
+	color.NoColor = true
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"upper.blobl":         `root.name = this.name.uppercase()`,
+		"upper.input.json":    `{"name":"foo"}`,
+		"upper.expected.json": `{"name":"BAR"}`,
+
+		"broken.blobl":      `root = this.`,
+		"broken.input.json": `{}`,
+	})
+
+	var buf bytes.Buffer
+	assert.False(t, runTests(&buf, []string{dir + "/..."}, false, 1))
+
+	out := buf.String()
+	assert.Contains(t, out, "Test '"+filepath.Join(dir, "broken.blobl")+"' failed\n")
+	assert.Contains(t, out, "Test '"+filepath.Join(dir, "upper.blobl")+"' failed\n")
+	assert.Contains(t, out, "broken.input.json:\nfailed to parse mapping:")
+	assert.Contains(t, out, "upper.input.json:\nJSON content mismatch\n")
+}
+
+func TestRunTestsUpdate(t *testing.T) {
+	color.NoColor = true
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"upper.blobl":         `root.name = this.name.uppercase()`,
+		"upper.input.json":    `{"name":"foo"}`,
+		"upper.expected.json": `{"name":"BAR"}`,
+
+		"text.blobl":            `root = this.name`,
+		"text.first.input.json": `{"name":"foo"}`,
+	})
+
+	var buf bytes.Buffer
+	assert.True(t, runTests(&buf, []string{dir + "/..."}, true, 1), buf.String())
+
+	b, err := os.ReadFile(filepath.Join(dir, "upper.expected.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"name\": \"FOO\"\n}\n", string(b))
+
+	b, err = os.ReadFile(filepath.Join(dir, "text.first.expected.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo\n", string(b))
+
+	buf.Reset()
+	assert.True(t, runTests(&buf, []string{dir + "/..."}, false, 1), buf.String())
+}
+
+func TestGetTestCasesSiblingMappings(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"foo.blobl":                 `root = this`,
+		"foo.input.json":            `{}`,
+		"foo.baz.input.json":        `{}`,
+		"foo.bar.blobl":             `root = this`,
+		"foo.bar.input.json":        `{}`,
+		"foo.bar.buz.input.json":    `{}`,
+		"foo.bar.buz.expected.json": `{}`,
+	})
+
+	caseNames := func(mappingPath string) []string {
+		cases, err := getTestCases(filepath.Join(dir, mappingPath))
+		require.NoError(t, err)
+
+		var names []string
+		for _, c := range cases {
+			names = append(names, c.name())
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"foo.input.json", "foo.baz.input.json"}, caseNames("foo.blobl"))
+	assert.Equal(t, []string{"foo.bar.input.json", "foo.bar.buz.input.json"}, caseNames("foo.bar.blobl"))
+}
@@ -0,0 +1,202 @@
+package graph
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+//go:embed resources/graph_page.html
+var graphPage string
+
+// CliCommand is a cli.Command definition for rendering the topology of a
+// config.
+func CliCommand(testSuffix string) *cli.Command {
+	return &cli.Command{
+		Name:  "graph",
+		Usage: "Render the topology of a config as a graph",
+		Description: `
+Parses a config, along with any resources and templates, and renders the
+topology of its components as a graph in either the Graphviz DOT language, as a
+Mermaid flowchart, as an SVG image, or as an HTML page served locally:
+
+  benthos -c ./config.yaml graph | dot -Tsvg > ./config.svg
+  benthos -r "./resources/*.yaml" graph --format mermaid ./config.yaml
+  benthos graph --format svg ./config.yaml > ./config.svg
+  benthos graph --format html --metrics-url http://localhost:4195 ./config.yaml
+
+When a metrics URL is provided the components are annotated with metrics
+obtained from the /metrics endpoint of a running instance, which must be using
+the prometheus metrics exporter. Components are matched to metrics by their
+label where they have one, and therefore labelling components is recommended.
+
+EXPERIMENTAL: This subcommand is experimental and therefore is subject to
+change outside of major version releases.`[1:],
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Value:   "dot",
+				Usage:   "the format to render the graph in, options are: dot, mermaid, svg, html",
+			},
+			&cli.StringFlag{
+				Name:    "metrics-url",
+				Aliases: []string{"m"},
+				Value:   "",
+				Usage:   "an optional URL of the HTTP server of a running instance to obtain metrics from, e.g. http://localhost:4195",
+			},
+			&cli.StringFlag{
+				Name:  "host",
+				Value: "localhost",
+				Usage: "the host to bind to when serving the html format.",
+			},
+			&cli.StringFlag{
+				Name:    "port",
+				Value:   "4196",
+				Aliases: []string{"p"},
+				Usage:   "the port to bind to when serving the html format.",
+			},
+			&cli.DurationFlag{
+				Name:  "refresh",
+				Value: 5 * time.Second,
+				Usage: "the interval at which the html format is refreshed when a metrics URL is provided.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			path := c.String("config")
+			if c.Args().Len() > 0 {
+				path = c.Args().First()
+			}
+			if path == "" {
+				fmt.Fprintln(os.Stderr, "A config must be specified either with --config or as an argument")
+				os.Exit(1)
+			}
+
+			confReader := config.NewReader(path, c.StringSlice("resources"),
+				config.OptAddOverrides(c.StringSlice("set")...),
+				config.OptTestSuffix(testSuffix),
+			)
+			readGraph := func() (*Graph, error) {
+				return graphFromReader(c.Context, confReader, c.String("metrics-url"))
+			}
+
+			switch format := c.String("format"); format {
+			case "dot", "mermaid", "svg":
+				g, err := readGraph()
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Graph error: %v\n", err)
+					os.Exit(1)
+				}
+				switch format {
+				case "dot":
+					fmt.Print(g.DOT())
+				case "mermaid":
+					fmt.Print(g.Mermaid())
+				default:
+					fmt.Print(g.SVG())
+				}
+			case "html":
+				var refresh time.Duration
+				if c.String("metrics-url") != "" {
+					refresh = c.Duration("refresh")
+				}
+				if err := serve(c.String("host")+":"+c.String("port"), refresh, readGraph); err != nil {
+					fmt.Fprintf(os.Stderr, "Graph error: %v\n", err)
+					os.Exit(1)
+				}
+			default:
+				fmt.Fprintf(os.Stderr, "Unrecognised format: %v\n", format)
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+}
+
+func graphFromReader(ctx context.Context, confReader *config.Reader, metricsURL string) (*Graph, error) {
+	conf := config.New()
+	if _, err := confReader.Read(&conf); err != nil {
+		return nil, fmt.Errorf("configuration file read error: %w", err)
+	}
+
+	var node yaml.Node
+	if err := node.Encode(conf); err != nil {
+		return nil, err
+	}
+	if err := config.Spec().SanitiseYAML(&node, docs.NewSanitiseConfig()); err != nil {
+		return nil, err
+	}
+
+	g := FromYAML(docs.DeprecatedProvider, &node)
+	if metricsURL != "" {
+		m, err := FetchMetrics(ctx, metricsURL)
+		if err != nil {
+			return g, fmt.Errorf("failed to obtain metrics: %w", err)
+		}
+		g.Annotate(m)
+	}
+	return g, nil
+}
+
+// serve the graph as an HTML page, where the config (and metrics) are read
+// each time the page is loaded.
+func serve(bindAddress string, refresh time.Duration, readGraph func() (*Graph, error)) error {
+	pageTemplate := template.Must(template.New("graph").Parse(graphPage))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		page := struct {
+			RefreshSeconds int
+			SVG            template.HTML
+			Error          string
+		}{
+			RefreshSeconds: int(refresh.Seconds()),
+		}
+
+		g, err := readGraph()
+		if err != nil {
+			page.Error = err.Error()
+		}
+		if g != nil {
+			page.SVG = template.HTML(g.SVG())
+		}
+		if err := pageTemplate.Execute(w, page); err != nil {
+			http.Error(w, "Template error", http.StatusBadGateway)
+		}
+	})
+
+	log.Printf("Serving at: http://%v\n", bindAddress)
+
+	server := http.Server{
+		Addr:    bindAddress,
+		Handler: mux,
+	}
+
+	go func() {
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+		// Wait for termination signal
+		<-sigChan
+		_ = server.Shutdown(context.Background())
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to listen and serve: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,371 @@
+package graph
+
+import (
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// Node is a component within the topology of a config.
+type Node struct {
+	ID    string
+	Type  docs.Type
+	Name  string
+	Label string
+	Path  []string
+
+	// Resource is true when the node is either a resource or a child of one.
+	Resource bool
+
+	// Metrics is a map of metric names to values that the node has been
+	// annotated with.
+	Metrics map[string]float64
+}
+
+// PathStr returns the component path of the node in the form used by the
+// path label of metrics and logs.
+func (n *Node) PathStr() string {
+	return "root." + query.SliceToDotPath(n.Path...)
+}
+
+// EdgeKind describes the relationship between two nodes.
+type EdgeKind string
+
+// Edge kinds.
+const (
+	// EdgeFlow indicates that messages flow from one node to the other.
+	EdgeFlow EdgeKind = "flow"
+
+	// EdgeChild indicates that a node is configured as a child of another.
+	EdgeChild EdgeKind = "child"
+
+	// EdgeReference indicates that a node refers to a resource.
+	EdgeReference EdgeKind = "reference"
+)
+
+// Edge is a connection between two nodes.
+type Edge struct {
+	From  string
+	To    string
+	Kind  EdgeKind
+	Label string
+}
+
+// Graph is the topology of the components within a config.
+type Graph struct {
+	Nodes []*Node
+	Edges []Edge
+}
+
+// Node returns a node by its ID, or nil if it does not exist.
+func (g *Graph) Node(id string) *Node {
+	for _, n := range g.Nodes {
+		if n.ID == id {
+			return n
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+var resourceFields = []struct {
+	field string
+	cType docs.Type
+}{
+	{"input_resources", docs.TypeInput},
+	{"processor_resources", docs.TypeProcessor},
+	{"output_resources", docs.TypeOutput},
+	{"cache_resources", docs.TypeCache},
+	{"rate_limit_resources", docs.TypeRateLimit},
+}
+
+// Fields containing names of resources, and the types of resource they can
+// refer to.
+var referenceFields = map[string][]docs.Type{
+	"resource":         {docs.TypeCache, docs.TypeRateLimit},
+	"cache":            {docs.TypeCache},
+	"rate_limit":       {docs.TypeRateLimit},
+	"branch_resources": {docs.TypeProcessor},
+	"order":            {docs.TypeProcessor},
+}
+
+type reference struct {
+	from   string
+	cTypes []docs.Type
+	name   string
+}
+
+type builder struct {
+	docsProv  docs.Provider
+	graph     *Graph
+	refs      []reference
+	resources map[docs.Type]map[string]*Node
+}
+
+func getNode(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// FromYAML walks a config and returns the topology of its components, which
+// includes the input, buffer, pipeline and output of the stream, and any
+// resources.
+func FromYAML(docsProv docs.Provider, node *yaml.Node) *Graph {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	b := &builder{
+		docsProv:  docsProv,
+		graph:     &Graph{},
+		resources: map[docs.Type]map[string]*Node{},
+	}
+
+	var tail []*Node
+	if n := b.component(docs.TypeInput, getNode(node, "input"), []string{"input"}, false); n != nil {
+		tail = []*Node{n}
+	}
+	if bufNode := getNode(node, "buffer"); bufNode != nil {
+		if name, _, _ := docs.GetInferenceCandidateFromYAML(b.docsProv, docs.TypeBuffer, bufNode); name != "none" {
+			if n := b.component(docs.TypeBuffer, bufNode, []string{"buffer"}, false); n != nil {
+				b.flowFrom(tail, n)
+				tail = []*Node{n}
+			}
+		}
+	}
+	if procs := getNode(getNode(node, "pipeline"), "processors"); procs != nil && procs.Kind == yaml.SequenceNode {
+		for i, pNode := range procs.Content {
+			if n := b.component(docs.TypeProcessor, pNode, []string{"pipeline", "processors", strconv.Itoa(i)}, false); n != nil {
+				b.flowFrom(tail, n)
+				tail = []*Node{n}
+			}
+		}
+	}
+	if n := b.component(docs.TypeOutput, getNode(node, "output"), []string{"output"}, false); n != nil {
+		b.flowFrom(tail, n)
+	}
+
+	for _, rField := range resourceFields {
+		resources := getNode(node, rField.field)
+		if resources == nil || resources.Kind != yaml.SequenceNode {
+			continue
+		}
+		b.resources[rField.cType] = map[string]*Node{}
+		for _, rNode := range resources.Content {
+			if n := b.component(rField.cType, rNode, []string{rField.field}, true); n != nil {
+				b.resources[rField.cType][n.Label] = n
+			}
+		}
+	}
+
+	b.resolveReferences()
+	return b.graph
+}
+
+func (b *builder) addNode(n *Node) {
+	n.ID = "n" + strconv.Itoa(len(b.graph.Nodes))
+	b.graph.Nodes = append(b.graph.Nodes, n)
+}
+
+func (b *builder) flowFrom(from []*Node, to *Node) {
+	for _, f := range from {
+		b.graph.Edges = append(b.graph.Edges, Edge{
+			From: f.ID,
+			To:   to.ID,
+			Kind: EdgeFlow,
+		})
+	}
+}
+
+// component adds a node for a component config and walks its fields for any
+// child components. Returns nil if the component type could not be inferred.
+func (b *builder) component(cType docs.Type, node *yaml.Node, path []string, resource bool) *Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	name, spec, err := docs.GetInferenceCandidateFromYAML(b.docsProv, cType, node)
+	if err != nil {
+		return nil
+	}
+
+	n := &Node{
+		Type:     cType,
+		Name:     name,
+		Path:     path,
+		Resource: resource,
+	}
+	if labelNode := getNode(node, "label"); labelNode != nil {
+		n.Label = labelNode.Value
+	}
+	b.addNode(n)
+
+	confNode, err := docs.GetPluginConfigYAML(name, node)
+	if err != nil {
+		return n
+	}
+
+	childPath := append(append([]string{}, path...), name)
+	if name == "resource" && confNode.Kind == yaml.ScalarNode {
+		b.refs = append(b.refs, reference{
+			from:   n.ID,
+			cTypes: []docs.Type{cType},
+			name:   confNode.Value,
+		})
+	} else if len(spec.Config.Children) > 0 {
+		b.fields(n, spec.Config.Children, &confNode, childPath, nil)
+	} else {
+		b.field(n, spec.Config, &confNode, childPath, nil)
+	}
+
+	if cType == docs.TypeInput || cType == docs.TypeOutput {
+		if procs := getNode(node, "processors"); procs != nil {
+			b.processorChain(n, procs, append(append([]string{}, path...), "processors"), "processors")
+		}
+	}
+	return n
+}
+
+// processorChain adds an array of processors as a chain of nodes, where the
+// first is a child of the parent.
+func (b *builder) processorChain(parent *Node, node *yaml.Node, path []string, label string) {
+	if node.Kind != yaml.SequenceNode {
+		return
+	}
+	var prev *Node
+	for i, pNode := range node.Content {
+		n := b.component(docs.TypeProcessor, pNode, append(append([]string{}, path...), strconv.Itoa(i)), parent.Resource)
+		if n == nil {
+			continue
+		}
+		if prev == nil {
+			b.graph.Edges = append(b.graph.Edges, Edge{
+				From:  parent.ID,
+				To:    n.ID,
+				Kind:  EdgeChild,
+				Label: label,
+			})
+		} else {
+			b.flowFrom([]*Node{prev}, n)
+		}
+		prev = n
+	}
+}
+
+func (b *builder) fields(parent *Node, specs docs.FieldSpecs, node *yaml.Node, path, fieldPath []string) {
+	for _, spec := range specs {
+		if child := getNode(node, spec.Name); child != nil {
+			b.field(parent, spec, child, append(append([]string{}, path...), spec.Name), append(append([]string{}, fieldPath...), spec.Name))
+		}
+	}
+}
+
+func (b *builder) field(parent *Node, spec docs.FieldSpec, node *yaml.Node, path, fieldPath []string) {
+	if cType, isCore := spec.Type.IsCoreComponent(); isCore {
+		if cType == docs.TypeProcessor && spec.Kind == docs.KindArray {
+			b.processorChain(parent, node, path, strings.Join(fieldPath, "."))
+			return
+		}
+		b.eachElement(spec.Kind, node, path, fieldPath, func(n *yaml.Node, p, fp []string) {
+			if child := b.component(cType, n, p, parent.Resource); child != nil {
+				b.graph.Edges = append(b.graph.Edges, Edge{
+					From:  parent.ID,
+					To:    child.ID,
+					Kind:  EdgeChild,
+					Label: strings.Join(fp, "."),
+				})
+			}
+		})
+		return
+	}
+
+	if len(spec.Children) > 0 {
+		b.eachElement(spec.Kind, node, path, fieldPath, func(n *yaml.Node, p, fp []string) {
+			b.fields(parent, spec.Children, n, p, fp)
+		})
+		return
+	}
+
+	if cTypes, exists := referenceFields[spec.Name]; exists {
+		b.eachElement(spec.Kind, node, path, fieldPath, func(n *yaml.Node, _, _ []string) {
+			if n.Kind == yaml.ScalarNode && n.Value != "" {
+				b.refs = append(b.refs, reference{
+					from:   parent.ID,
+					cTypes: cTypes,
+					name:   n.Value,
+				})
+			}
+		})
+	}
+}
+
+func (b *builder) eachElement(kind docs.FieldKind, node *yaml.Node, path, fieldPath []string, fn func(n *yaml.Node, path, fieldPath []string)) {
+	appendPath := func(p []string, s ...string) []string {
+		return append(append([]string{}, p...), s...)
+	}
+	switch kind {
+	case docs.KindArray:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, n := range node.Content {
+			fn(n, appendPath(path, strconv.Itoa(i)), appendPath(fieldPath, strconv.Itoa(i)))
+		}
+	case docs.Kind2DArray:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, arr := range node.Content {
+			if arr.Kind != yaml.SequenceNode {
+				continue
+			}
+			for j, n := range arr.Content {
+				fn(n, appendPath(path, strconv.Itoa(i), strconv.Itoa(j)), appendPath(fieldPath, strconv.Itoa(i), strconv.Itoa(j)))
+			}
+		}
+	case docs.KindMap:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			k := node.Content[i].Value
+			fn(node.Content[i+1], appendPath(path, k), appendPath(fieldPath, k))
+		}
+	default:
+		fn(node, path, fieldPath)
+	}
+}
+
+func (b *builder) resolveReferences() {
+	seen := map[[2]string]struct{}{}
+	for _, ref := range b.refs {
+		for _, cType := range ref.cTypes {
+			n, exists := b.resources[cType][ref.name]
+			if !exists {
+				continue
+			}
+			key := [2]string{ref.from, n.ID}
+			if _, exists := seen[key]; !exists {
+				seen[key] = struct{}{}
+				b.graph.Edges = append(b.graph.Edges, Edge{
+					From: ref.from,
+					To:   n.ID,
+					Kind: EdgeReference,
+				})
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package graph_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/cli/graph"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+
+	_ "github.com/benthosdev/benthos/v4/internal/impl/pure"
+)
+
+const testConfig = `
+input:
+  label: in
+  broker:
+    inputs:
+      - generate:
+          mapping: 'root = "a"'
+      - generate:
+          mapping: 'root = "b"'
+  processors:
+    - bloblang: 'root = this'
+
+buffer:
+  none: {}
+
+pipeline:
+  processors:
+    - label: enrich
+      branch:
+        request_map: 'root = this'
+        processors:
+          - cache:
+              resource: foo
+              operator: get
+              key: bar
+          - bloblang: 'root = this'
+
+output:
+  switch:
+    cases:
+      - check: 'this.a == "b"'
+        output:
+          resource: out
+      - output:
+          drop: {}
+
+cache_resources:
+  - label: foo
+    memory: {}
+
+output_resources:
+  - label: out
+    drop: {}
+`
+
+func testGraph(t *testing.T) *graph.Graph {
+	t.Helper()
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(testConfig), &node))
+	return graph.FromYAML(docs.DeprecatedProvider, &node)
+}
+
+func TestGraphFromYAML(t *testing.T) {
+	g := testGraph(t)
+
+	var nodes []string
+	for _, n := range g.Nodes {
+		nodes = append(nodes, n.ID+" "+n.PathStr()+" "+strings.Join(n.Lines(), ","))
+	}
+	assert.Equal(t, []string{
+		"n0 root.input in,input: broker",
+		"n1 root.input.broker.inputs.0 input: generate",
+		"n2 root.input.broker.inputs.1 input: generate",
+		"n3 root.input.processors.0 processor: bloblang",
+		"n4 root.pipeline.processors.0 enrich,processor: branch",
+		"n5 root.pipeline.processors.0.branch.processors.0 processor: cache",
+		"n6 root.pipeline.processors.0.branch.processors.1 processor: bloblang",
+		"n7 root.output output: switch",
+		"n8 root.output.switch.cases.0.output output: resource",
+		"n9 root.output.switch.cases.1.output output: drop",
+		"n10 root.output_resources out,output: drop",
+		"n11 root.cache_resources foo,cache: memory",
+	}, nodes)
+
+	assert.ElementsMatch(t, []graph.Edge{
+		{From: "n0", To: "n1", Kind: graph.EdgeChild, Label: "inputs.0"},
+		{From: "n0", To: "n2", Kind: graph.EdgeChild, Label: "inputs.1"},
+		{From: "n0", To: "n3", Kind: graph.EdgeChild, Label: "processors"},
+		{From: "n0", To: "n4", Kind: graph.EdgeFlow},
+		{From: "n4", To: "n5", Kind: graph.EdgeChild, Label: "processors"},
+		{From: "n5", To: "n6", Kind: graph.EdgeFlow},
+		{From: "n4", To: "n7", Kind: graph.EdgeFlow},
+		{From: "n7", To: "n8", Kind: graph.EdgeChild, Label: "cases.0.output"},
+		{From: "n7", To: "n9", Kind: graph.EdgeChild, Label: "cases.1.output"},
+		{From: "n5", To: "n11", Kind: graph.EdgeReference},
+		{From: "n8", To: "n10", Kind: graph.EdgeReference},
+	}, g.Edges)
+
+	assert.True(t, g.Node("n10").Resource)
+	assert.False(t, g.Node("n9").Resource)
+}
+
+func TestGraphRender(t *testing.T) {
+	g := testGraph(t)
+
+	dot := g.DOT()
+	assert.True(t, strings.HasPrefix(dot, "digraph benthos {\n"), dot)
+	assert.Contains(t, dot, `  n0 [label="in\ninput: broker", shape=box];`)
+	assert.Contains(t, dot, `    n11 [label="foo\ncache: memory", shape=cylinder];`)
+	assert.Contains(t, dot, `  n0 -> n1 [label="inputs.0", arrowhead=empty];`)
+	assert.Contains(t, dot, `  n4 -> n7;`)
+	assert.Contains(t, dot, `  n8 -> n10 [style=dashed];`)
+
+	mermaid := g.Mermaid()
+	assert.True(t, strings.HasPrefix(mermaid, "flowchart LR\n"), mermaid)
+	assert.Contains(t, mermaid, `  n0(["in<br/>input: broker"])`)
+	assert.Contains(t, mermaid, `    n11[("foo<br/>cache: memory")]`)
+	assert.Contains(t, mermaid, `  n0 ---|"inputs.0"| n1`)
+	assert.Contains(t, mermaid, `  n4 --> n7`)
+	assert.Contains(t, mermaid, `  n8 -.-> n10`)
+
+	svg := g.SVG()
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg"`), svg)
+	assert.NotContains(t, svg, "<script")
+	assert.Contains(t, svg, `<g id="n0"><rect class="node io"`)
+	assert.Contains(t, svg, `<tspan x="`)
+	assert.Contains(t, svg, `>cache: memory</tspan>`)
+	assert.Contains(t, svg, `<text x="20" y="`)
+	assert.Contains(t, svg, `marker-end="url(#arrow-empty)"/>`)
+	assert.Contains(t, svg, `class="edge reference"`)
+	assert.Contains(t, svg, `>inputs.0</text>`)
+	assert.Equal(t, len(g.Nodes), strings.Count(svg, "<g id="))
+}
+
+func TestGraphRenderSVGEscapes(t *testing.T) {
+	g := &graph.Graph{
+		Nodes: []*graph.Node{
+			{ID: "n0", Type: docs.TypeInput, Name: "generate", Label: "<b>&"},
+			{ID: "n1", Type: docs.TypeOutput, Name: "drop"},
+		},
+		Edges: []graph.Edge{
+			{From: "n0", To: "n1", Kind: graph.EdgeFlow, Label: `"x"`},
+		},
+	}
+
+	svg := g.SVG()
+	assert.Contains(t, svg, "&lt;b&gt;&amp;")
+	assert.Contains(t, svg, "&#34;x&#34;")
+	assert.NotContains(t, svg, "<b>")
+	assert.Contains(t, svg, `<line class="edge" x1="`)
+}
+
+func TestGraphAnnotateMetrics(t *testing.T) {
+	g := testGraph(t)
+
+	m, err := graph.ParseMetrics(strings.NewReader(`# TYPE input_received counter
+input_received{label="",path="root.input.broker.inputs.0"} 5
+input_received{label="",path="root.input.broker.inputs.1"} 7
+# TYPE processor_sent counter
+processor_sent{label="enrich",path="root.pipeline.processors.0"} 12
+processor_sent{label="",path="root.pipeline.processors.0.branch.processors.1"} 3
+# TYPE processor_batch_sent counter
+processor_batch_sent{label="enrich",path="root.pipeline.processors.0"} 2
+# TYPE output_error counter
+output_error{label="out",path="root.output_resources"} 1
+`))
+	require.NoError(t, err)
+
+	g.Annotate(m)
+
+	assert.Equal(t, map[string]float64{"received": 5}, g.Node("n1").Metrics)
+	assert.Equal(t, map[string]float64{"received": 7}, g.Node("n2").Metrics)
+	assert.Equal(t, map[string]float64{"sent": 12}, g.Node("n4").Metrics)
+	assert.Nil(t, g.Node("n5").Metrics)
+	assert.Equal(t, map[string]float64{"sent": 3}, g.Node("n6").Metrics)
+	assert.Equal(t, map[string]float64{"error": 1}, g.Node("n10").Metrics)
+
+	assert.Equal(t, []string{"enrich", "processor: branch", "sent: 12"}, g.Node("n4").Lines())
+}
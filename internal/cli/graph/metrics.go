@@ -0,0 +1,128 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+)
+
+// Only these metrics are used to annotate nodes, as the full set would be too
+// noisy. The names are without the component type prefix, i.e. the metric
+// input_received is annotated as received.
+var annotatedMetrics = map[string]struct{}{
+	"received": {},
+	"sent":     {},
+	"error":    {},
+	"success":  {},
+}
+
+type metricKey struct {
+	byLabel bool
+	value   string
+}
+
+// Metrics contains the values of component metrics obtained from a running
+// instance of Benthos, keyed by either the label or path of the component.
+type Metrics map[metricKey]map[string]float64
+
+// ParseMetrics parses metrics in the Prometheus text exposition format.
+func ParseMetrics(r io.Reader) (Metrics, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(r)
+	if err != nil {
+		return nil, err
+	}
+
+	m := Metrics{}
+	add := func(k metricKey, name string, v float64) {
+		if _, exists := m[k]; !exists {
+			m[k] = map[string]float64{}
+		}
+		m[k][name] += v
+	}
+
+	for name, family := range families {
+		for _, metric := range family.GetMetric() {
+			var v float64
+			if c := metric.GetCounter(); c != nil {
+				v = c.GetValue()
+			} else if g := metric.GetGauge(); g != nil {
+				v = g.GetValue()
+			} else {
+				continue
+			}
+			for _, l := range metric.GetLabel() {
+				switch l.GetName() {
+				case "label":
+					if l.GetValue() != "" {
+						add(metricKey{byLabel: true, value: l.GetValue()}, name, v)
+					}
+				case "path":
+					add(metricKey{value: l.GetValue()}, name, v)
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+// FetchMetrics obtains the metrics of a running instance of Benthos from the
+// /metrics endpoint of its HTTP server, which requires the instance to be
+// configured with the prometheus metrics exporter.
+func FetchMetrics(ctx context.Context, baseURL string) (Metrics, error) {
+	ctx, done := context.WithTimeout(ctx, 5*time.Second)
+	defer done()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(baseURL, "/")+"/metrics", nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %v", res.StatusCode)
+	}
+	return ParseMetrics(res.Body)
+}
+
+// Annotate the nodes of the graph with metrics. Nodes are matched by their
+// label where they have one, and by their path otherwise.
+func (g *Graph) Annotate(m Metrics) {
+	for _, n := range g.Nodes {
+		key := metricKey{value: n.PathStr()}
+		if n.Label != "" {
+			key = metricKey{byLabel: true, value: n.Label}
+		}
+		values, exists := m[key]
+		if !exists {
+			continue
+		}
+
+		// Components can share a label or path with components of another
+		// type, e.g. the batch policy of an output, so only metrics prefixed
+		// with the node type are used.
+		prefix := string(n.Type) + "_"
+		for k, v := range values {
+			if !strings.HasPrefix(k, prefix) {
+				continue
+			}
+			k = strings.TrimPrefix(k, prefix)
+			if _, exists := annotatedMetrics[k]; !exists {
+				continue
+			}
+			if n.Metrics == nil {
+				n.Metrics = map[string]float64{}
+			}
+			n.Metrics[k] = v
+		}
+	}
+}
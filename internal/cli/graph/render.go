@@ -0,0 +1,366 @@
+package graph
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// Lines returns the lines of text that describe a node.
+func (n *Node) Lines() []string {
+	var lines []string
+	if n.Label != "" {
+		lines = append(lines, n.Label)
+	}
+	lines = append(lines, fmt.Sprintf("%v: %v", n.Type, n.Name))
+
+	keys := make([]string, 0, len(n.Metrics))
+	for k := range n.Metrics {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("%v: %v", k, strconv.FormatFloat(n.Metrics[k], 'f', -1, 64)))
+	}
+	return lines
+}
+
+func (g *Graph) streamAndResources() (stream, resources []*Node) {
+	for _, n := range g.Nodes {
+		if n.Resource {
+			resources = append(resources, n)
+		} else {
+			stream = append(stream, n)
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+}
+
+func dotShape(t docs.Type) string {
+	switch t {
+	case docs.TypeInput, docs.TypeOutput:
+		return "box"
+	case docs.TypeCache, docs.TypeRateLimit:
+		return "cylinder"
+	}
+	return "box, style=rounded"
+}
+
+// DOT renders the graph in the Graphviz DOT language.
+func (g *Graph) DOT() string {
+	var buf bytes.Buffer
+	buf.WriteString("digraph benthos {\n")
+	buf.WriteString("  rankdir=LR;\n")
+	buf.WriteString("  node [fontname=\"Helvetica\"];\n")
+
+	writeNode := func(indent string, n *Node) {
+		fmt.Fprintf(&buf, "%v%v [label=%v, shape=%v];\n", indent, n.ID, dotQuote(strings.Join(n.Lines(), "\n")), dotShape(n.Type))
+	}
+
+	stream, resources := g.streamAndResources()
+	for _, n := range stream {
+		writeNode("  ", n)
+	}
+	if len(resources) > 0 {
+		buf.WriteString("  subgraph cluster_resources {\n")
+		buf.WriteString("    label=\"resources\";\n")
+		buf.WriteString("    style=dashed;\n")
+		for _, n := range resources {
+			writeNode("    ", n)
+		}
+		buf.WriteString("  }\n")
+	}
+
+	for _, e := range g.Edges {
+		var attrs []string
+		if e.Label != "" {
+			attrs = append(attrs, "label="+dotQuote(e.Label))
+		}
+		switch e.Kind {
+		case EdgeChild:
+			attrs = append(attrs, "arrowhead=empty")
+		case EdgeReference:
+			attrs = append(attrs, "style=dashed")
+		}
+		fmt.Fprintf(&buf, "  %v -> %v", e.From, e.To)
+		if len(attrs) > 0 {
+			fmt.Fprintf(&buf, " [%v]", strings.Join(attrs, ", "))
+		}
+		buf.WriteString(";\n")
+	}
+
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+//------------------------------------------------------------------------------
+
+func mermaidQuote(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	return `"` + strings.ReplaceAll(s, "\n", "<br/>") + `"`
+}
+
+func mermaidShape(t docs.Type, text string) string {
+	switch t {
+	case docs.TypeInput, docs.TypeOutput:
+		return "([" + text + "])"
+	case docs.TypeCache, docs.TypeRateLimit:
+		return "[(" + text + ")]"
+	}
+	return "[" + text + "]"
+}
+
+// Mermaid renders the graph as a Mermaid flowchart.
+func (g *Graph) Mermaid() string {
+	var buf bytes.Buffer
+	buf.WriteString("flowchart LR\n")
+
+	writeNode := func(indent string, n *Node) {
+		fmt.Fprintf(&buf, "%v%v%v\n", indent, n.ID, mermaidShape(n.Type, mermaidQuote(strings.Join(n.Lines(), "\n"))))
+	}
+
+	stream, resources := g.streamAndResources()
+	for _, n := range stream {
+		writeNode("  ", n)
+	}
+	if len(resources) > 0 {
+		buf.WriteString("  subgraph resources\n")
+		for _, n := range resources {
+			writeNode("    ", n)
+		}
+		buf.WriteString("  end\n")
+	}
+
+	for _, e := range g.Edges {
+		arrow := "-->"
+		switch e.Kind {
+		case EdgeChild:
+			arrow = "---"
+		case EdgeReference:
+			arrow = "-.->"
+		}
+		if e.Label != "" {
+			fmt.Fprintf(&buf, "  %v %v|%v| %v\n", e.From, arrow, mermaidQuote(e.Label), e.To)
+		} else {
+			fmt.Fprintf(&buf, "  %v %v %v\n", e.From, arrow, e.To)
+		}
+	}
+	return buf.String()
+}
+
+//------------------------------------------------------------------------------
+
+const (
+	svgCharWidth   = 7
+	svgLineHeight  = 16
+	svgNodePadding = 10
+	svgColumnGap   = 110
+	svgRowGap      = 24
+	svgMargin      = 20
+)
+
+type svgBox struct {
+	x, y, w, h int
+	lines      []string
+}
+
+func (b svgBox) right() int  { return b.x + b.w }
+func (b svgBox) bottom() int { return b.y + b.h }
+func (b svgBox) midX() int   { return b.x + b.w/2 }
+func (b svgBox) midY() int   { return b.y + b.h/2 }
+
+func newSVGBox(n *Node) *svgBox {
+	lines := n.Lines()
+	width := 0
+	for _, l := range lines {
+		if w := len([]rune(l)) * svgCharWidth; w > width {
+			width = w
+		}
+	}
+	return &svgBox{
+		w:     width + svgNodePadding*2,
+		h:     len(lines)*svgLineHeight + svgNodePadding,
+		lines: lines,
+	}
+}
+
+// svgLayout positions a set of nodes in columns, where each node is placed in
+// the column after the furthest of the nodes that lead to it. Returns the width
+// and height of the area occupied.
+func (g *Graph) svgLayout(nodes []*Node, boxes map[string]*svgBox, offsetY int) (width, height int) {
+	inSet := map[string]bool{}
+	for _, n := range nodes {
+		inSet[n.ID] = true
+	}
+
+	// Ranks are bounded by the number of nodes so that cycles terminate.
+	ranks := map[string]int{}
+	for i := 0; i < len(nodes); i++ {
+		changed := false
+		for _, e := range g.Edges {
+			if e.Kind == EdgeReference || !inSet[e.From] || !inSet[e.To] {
+				continue
+			}
+			if r := ranks[e.From] + 1; r > ranks[e.To] && r < len(nodes) {
+				ranks[e.To] = r
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	var columns [][]*Node
+	for _, n := range nodes {
+		r := ranks[n.ID]
+		for len(columns) <= r {
+			columns = append(columns, nil)
+		}
+		columns[r] = append(columns[r], n)
+	}
+
+	x := svgMargin
+	for _, col := range columns {
+		if len(col) == 0 {
+			continue
+		}
+		y, colWidth := offsetY, 0
+		for _, n := range col {
+			b := boxes[n.ID]
+			b.x, b.y = x, y
+			y += b.h + svgRowGap
+			if b.w > colWidth {
+				colWidth = b.w
+			}
+		}
+		if h := y - svgRowGap - offsetY; h > height {
+			height = h
+		}
+		x += colWidth + svgColumnGap
+	}
+	width = x - svgColumnGap - svgMargin
+	return
+}
+
+func svgNodeClass(t docs.Type) string {
+	switch t {
+	case docs.TypeInput, docs.TypeOutput:
+		return "node io"
+	case docs.TypeCache, docs.TypeRateLimit:
+		return "node store"
+	}
+	return "node"
+}
+
+// SVG renders the graph as an SVG image.
+func (g *Graph) SVG() string {
+	boxes := make(map[string]*svgBox, len(g.Nodes))
+	for _, n := range g.Nodes {
+		boxes[n.ID] = newSVGBox(n)
+	}
+
+	stream, resources := g.streamAndResources()
+	width, height := g.svgLayout(stream, boxes, svgMargin)
+
+	var resourcesY, resourcesW, resourcesH int
+	if len(resources) > 0 {
+		resourcesY = svgMargin + height + svgColumnGap/2
+		resourcesW, resourcesH = g.svgLayout(resources, boxes, resourcesY+svgLineHeight*2)
+		resourcesH += svgLineHeight * 2
+		if resourcesW > width {
+			width = resourcesW
+		}
+		height = resourcesY + resourcesH
+	} else {
+		height += svgMargin
+	}
+
+	// Leave room for the border drawn around resources.
+	totalW, totalH := width+svgMargin*2, height+svgMargin*2
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%v" height="%v" viewBox="0 0 %v %v">`+"\n", totalW, totalH, totalW, totalH)
+	buf.WriteString(`<style>
+  text { font-family: Helvetica, Arial, sans-serif; font-size: 12px; fill: #333; }
+  .node { fill: #ececff; stroke: #9370db; }
+  .node.io { fill: #e8f4e8; stroke: #4a9a4a; }
+  .node.store { fill: #fff4dd; stroke: #c8a040; }
+  .edge { fill: none; stroke: #555; }
+  .edge.reference { stroke-dasharray: 5 4; }
+  .edge-label { font-size: 10px; fill: #555; paint-order: stroke; stroke: #fafafa; stroke-width: 3px; }
+  .resources { fill: none; stroke: #aaa; stroke-dasharray: 6 4; }
+</style>
+<defs>
+  <marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto"><path d="M0,0 L10,5 L0,10 z" fill="#555"/></marker>
+  <marker id="arrow-empty" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto"><path d="M0,0 L10,5 L0,10 z" fill="#fafafa" stroke="#555"/></marker>
+</defs>
+`)
+
+	if len(resources) > 0 {
+		fmt.Fprintf(&buf, `<rect class="resources" x="%v" y="%v" width="%v" height="%v" rx="6"/>`+"\n", svgMargin/2, resourcesY-svgMargin/2, resourcesW+svgMargin, resourcesH+svgMargin)
+		fmt.Fprintf(&buf, `<text x="%v" y="%v">resources</text>`+"\n", svgMargin, resourcesY+svgLineHeight)
+	}
+
+	for _, e := range g.Edges {
+		from, to := boxes[e.From], boxes[e.To]
+		if from == nil || to == nil {
+			continue
+		}
+
+		var x1, y1, x2, y2 int
+		switch {
+		case to.x > from.right():
+			x1, y1, x2, y2 = from.right(), from.midY(), to.x, to.midY()
+		case to.y >= from.bottom():
+			x1, y1, x2, y2 = from.midX(), from.bottom(), to.midX(), to.y
+		default:
+			x1, y1, x2, y2 = from.midX(), from.y, to.midX(), to.bottom()
+		}
+
+		class, marker := "edge", "arrow"
+		switch e.Kind {
+		case EdgeChild:
+			marker = "arrow-empty"
+		case EdgeReference:
+			class = "edge reference"
+		}
+		fmt.Fprintf(&buf, `<line class="%v" x1="%v" y1="%v" x2="%v" y2="%v" marker-end="url(#%v)"/>`+"\n", class, x1, y1, x2, y2, marker)
+		if e.Label != "" {
+			fmt.Fprintf(&buf, `<text class="edge-label" x="%v" y="%v" text-anchor="middle">%v</text>`+"\n", (x1+x2)/2, (y1+y2)/2-4, html.EscapeString(e.Label))
+		}
+	}
+
+	for _, n := range g.Nodes {
+		b := boxes[n.ID]
+		rx := 4
+		if n.Type == docs.TypeInput || n.Type == docs.TypeOutput {
+			rx = b.h / 2
+			if rx > 16 {
+				rx = 16
+			}
+		}
+		fmt.Fprintf(&buf, `<g id="%v"><rect class="%v" x="%v" y="%v" width="%v" height="%v" rx="%v"/>`, html.EscapeString(n.ID), svgNodeClass(n.Type), b.x, b.y, b.w, b.h, rx)
+		fmt.Fprintf(&buf, `<text x="%v" y="%v" text-anchor="middle">`, b.midX(), b.y+svgNodePadding/2)
+		for _, l := range b.lines {
+			fmt.Fprintf(&buf, `<tspan x="%v" dy="%v">%v</tspan>`, b.midX(), svgLineHeight, html.EscapeString(l))
+		}
+		buf.WriteString("</text></g>\n")
+	}
+
+	buf.WriteString("</svg>\n")
+	return buf.String()
+}
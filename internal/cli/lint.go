@@ -21,10 +21,11 @@ var red = color.New(color.FgRed).SprintFunc()
 var yellow = color.New(color.FgYellow).SprintFunc()
 
 type pathLint struct {
-	source string
-	line   int
-	lint   string
-	err    string
+	source  string
+	line    int
+	lint    string
+	err     string
+	warning bool
 }
 
 func lintFile(path string, rejectDeprecated bool) (pathLints []pathLint) {
@@ -43,6 +44,24 @@ func lintFile(path string, rejectDeprecated bool) (pathLints []pathLint) {
 			lint:   l,
 		})
 	}
+
+	// Secrets are linted from the raw config as interpolations have already
+	// been replaced by the time the config is linted above.
+	rawBytes, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	secretLints, err := config.LintInlinedSecrets(docs.NewLintContext(), rawBytes)
+	if err != nil {
+		return
+	}
+	for _, l := range secretLints {
+		pathLints = append(pathLints, pathLint{
+			source:  path,
+			lint:    fmt.Sprintf("line %v: %v", l.Line, l.What),
+			warning: true,
+		})
+	}
 	return
 }
 
@@ -177,17 +196,26 @@ files with the .yaml or .yml extension.`[1:],
 			if len(pathLints) == 0 {
 				os.Exit(0)
 			}
+			onlyWarnings := true
 			for _, lint := range pathLints {
 				message := yellow(lint.lint)
 				if len(lint.err) > 0 {
 					message = red(lint.err)
 				}
+				if lint.warning {
+					message = yellow("warning: " + lint.lint)
+				} else {
+					onlyWarnings = false
+				}
 				if lint.line > 0 {
 					fmt.Fprintf(os.Stderr, "%v: from snippet at line %v: %v\n", lint.source, lint.line, message)
 				} else {
 					fmt.Fprintf(os.Stderr, "%v: %v\n", lint.source, message)
 				}
 			}
+			if onlyWarnings {
+				os.Exit(0)
+			}
 			os.Exit(1)
 			return nil
 		},
@@ -11,6 +11,7 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
 	"github.com/benthosdev/benthos/v4/internal/cli/blobl"
+	"github.com/benthosdev/benthos/v4/internal/cli/graph"
 	"github.com/benthosdev/benthos/v4/internal/cli/studio"
 	clitemplate "github.com/benthosdev/benthos/v4/internal/cli/template"
 	"github.com/benthosdev/benthos/v4/internal/cli/test"
@@ -68,6 +69,16 @@ func OptAddStringFlag(name, usage string, aliases []string, value string, destin
 	}
 }
 
+var customCommands []*cli.Command
+
+// OptAddCommand registers a custom CLI subcommand, which is useful for
+// subcommands that depend on packages that can't be imported by the CLI.
+func OptAddCommand(cmd *cli.Command) func() {
+	return func() {
+		customCommands = append(customCommands, cmd)
+	}
+}
+
 //------------------------------------------------------------------------------
 
 var optContext = context.Background()
@@ -245,7 +256,8 @@ Either run Benthos as a stream processor or choose a command:
 				Description: `
 This simple command is useful for sanity checking a config if it isn't
 behaving as expected, as it shows you a normalised version after environment
-variables have been resolved:
+variables have been resolved. The values of fields that hold secrets, such as
+passwords and tokens, are scrubbed from the output:
 
   benthos -c ./config.yaml echo | less`[1:],
 				Action: func(c *cli.Context) error {
@@ -260,6 +272,7 @@ variables have been resolved:
 					if err == nil {
 						sanitConf := docs.NewSanitiseConfig()
 						sanitConf.RemoveTypeField = true
+						sanitConf.ScrubSecrets = true
 						err = config.Spec().SanitiseYAML(&node, sanitConf)
 					}
 					if err == nil {
@@ -322,9 +335,11 @@ https://benthos.dev/docs/guides/streams_mode/about`[1:],
 			test.CliCommand(testSuffix),
 			clitemplate.CliCommand(),
 			blobl.CliCommand(),
+			graph.CliCommand(testSuffix),
 			studio.CliCommand(Version, DateBuilt),
 		},
 	}
+	app.Commands = append(app.Commands, customCommands...)
 
 	app.OnUsageError = func(context *cli.Context, err error, isSubcommand bool) error {
 		fmt.Printf("Usage error: %v\n", err)
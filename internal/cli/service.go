@@ -74,7 +74,12 @@ func initStreamsMode(
 	logger log.Modular,
 	stats *metrics.Namespaced,
 ) stoppable {
-	streamMgr := strmmgr.New(manager, strmmgr.OptAPIEnabled(enableAPI))
+	streamMgrOpts := []func(*strmmgr.Type){strmmgr.OptAPIEnabled(enableAPI)}
+	if watching {
+		// Failed config reloads are reported via the ready endpoint.
+		streamMgrOpts = append(streamMgrOpts, strmmgr.OptAddReadyCheck(confReader.ReloadError))
+	}
+	streamMgr := strmmgr.New(manager, streamMgrOpts...)
 
 	streamConfs := map[string]stream.Config{}
 	lints, err := confReader.ReadStreams(streamConfs)
@@ -118,6 +123,18 @@ func initStreamsMode(
 		os.Exit(1)
 	}
 
+	if err := confReader.SubscribeStreamRemovals(func(id string) bool {
+		if err := streamMgr.Delete(id, time.Second*30); err != nil && !errors.Is(err, strmmgr.ErrStreamDoesNotExist) {
+			logger.Errorf("Failed to remove stream %v: %v", id, err)
+			return false
+		}
+		logger.Infof("Removed stream %v as its config file was removed", id)
+		return true
+	}); err != nil {
+		logger.Errorf("Failed to create stream config watcher: %v", err)
+		os.Exit(1)
+	}
+
 	if watching {
 		if err := confReader.BeginFileWatching(manager, strict); err != nil {
 			logger.Errorf("Failed to create stream config watcher: %v", err)
@@ -129,15 +146,27 @@ func initStreamsMode(
 
 type swappableStopper struct {
 	stopped bool
-	current stoppable
+	current *stream.Type
 	mut     sync.Mutex
+
+	swapCtx     context.Context
+	cancelSwaps func()
+}
+
+func newSwappableStopper() *swappableStopper {
+	s := &swappableStopper{}
+	s.swapCtx, s.cancelSwaps = context.WithCancel(context.Background())
+	return s
 }
 
 func (s *swappableStopper) Stop(timeout time.Duration) error {
+	// Abandon any swap in progress rather than waiting for it.
+	s.cancelSwaps()
+
 	s.mut.Lock()
 	defer s.mut.Unlock()
 
-	if s.stopped {
+	if s.stopped || s.current == nil {
 		return nil
 	}
 
@@ -145,7 +174,10 @@ func (s *swappableStopper) Stop(timeout time.Duration) error {
 	return s.current.Stop(timeout)
 }
 
-func (s *swappableStopper) Replace(fn func() (stoppable, error)) error {
+// Swap replaces the active stream with a new stream of the provided config,
+// where the new stream is brought up alongside the active stream and the
+// active stream is only drained once the new stream is connected.
+func (s *swappableStopper) Swap(conf stream.Config, mgr bundle.NewManagement, opts ...func(*stream.Type)) error {
 	s.mut.Lock()
 	defer s.mut.Unlock()
 
@@ -154,16 +186,20 @@ func (s *swappableStopper) Replace(fn func() (stoppable, error)) error {
 		return nil
 	}
 
-	if err := s.current.Stop(time.Second * 30); err != nil {
-		return fmt.Errorf("failed to stop active stream: %w", err)
+	if s.current == nil {
+		newStream, err := stream.New(conf, mgr, opts...)
+		if err != nil {
+			return fmt.Errorf("failed to init updated stream: %w", err)
+		}
+		s.current = newStream
+		return nil
 	}
 
-	newStoppable, err := fn()
+	newStream, err := stream.Swap(s.swapCtx, s.current, conf, mgr, time.Second*30, opts...)
+	s.current = newStream
 	if err != nil {
-		return fmt.Errorf("failed to init updated stream: %w", err)
+		return fmt.Errorf("failed to swap updated stream: %w", err)
 	}
-
-	s.current = newStoppable
 	return nil
 }
 
@@ -177,31 +213,23 @@ func initNormalMode(
 ) (newStream stoppable, stoppedChan chan struct{}) {
 	stoppedChan = make(chan struct{})
 
-	streamInit := func() (stoppable, error) {
-		return stream.New(
-			conf.Config, manager,
-			stream.OptOnClose(func() {
-				if !watching {
-					close(stoppedChan)
-				}
-			}),
-		)
-	}
+	onClose := stream.OptOnClose(func() {
+		if !watching {
+			close(stoppedChan)
+		}
+	})
 
-	var stoppableStream swappableStopper
+	stoppableStream := newSwappableStopper()
 
 	var err error
-	if stoppableStream.current, err = streamInit(); err != nil {
+	if stoppableStream.current, err = stream.New(conf.Config, manager, onClose); err != nil {
 		logger.Errorf("Service closing due to: %v\n", err)
 		os.Exit(1)
 	}
 	logger.Infoln("Launching a benthos instance, use CTRL+C to close")
 
 	if err := confReader.SubscribeConfigChanges(func(newStreamConf stream.Config) bool {
-		if err := stoppableStream.Replace(func() (stoppable, error) {
-			conf.Config = newStreamConf
-			return streamInit()
-		}); err != nil {
+		if err := stoppableStream.Swap(newStreamConf, manager, onClose); err != nil {
 			logger.Errorf("Failed to update stream: %v", err)
 			return false
 		}
@@ -220,7 +248,7 @@ func initNormalMode(
 		}
 	}
 
-	newStream = &stoppableStream
+	newStream = stoppableStream
 	return
 }
 
@@ -339,6 +367,7 @@ func cmdService(
 	if err == nil {
 		sanitConf := docs.NewSanitiseConfig()
 		sanitConf.RemoveTypeField = true
+		sanitConf.ScrubSecrets = true
 		err = config.Spec().SanitiseYAML(&sanitNode, sanitConf)
 	}
 	if err != nil {
@@ -349,11 +378,27 @@ func cmdService(
 		logger.Errorf("Failed to initialise API: %v\n", err)
 		return 1
 	}
+	if levelsHandler := log.LevelsHandlerFunc(logger); levelsHandler != nil {
+		httpServer.RegisterEndpoint(
+			"/log/level",
+			"Returns the current log levels of the service, a POST request changes the level and the overrides of components.",
+			levelsHandler,
+		)
+	}
+
+	taps := api.NewTaps()
+	health, err := api.NewHealth(conf.HTTP.Health)
+	if err != nil {
+		logger.Errorf("Failed to initialise health checks: %v\n", err)
+		return 1
+	}
 
 	// Create resource manager.
 	manager, err := manager.New(
 		conf.ResourceConfig,
 		manager.OptSetAPIReg(httpServer),
+		manager.OptSetTaps(taps),
+		manager.OptSetHealth(health),
 		manager.OptSetLogger(logger),
 		manager.OptSetMetrics(stats),
 		manager.OptSetTracer(trac),
@@ -363,6 +408,16 @@ func cmdService(
 		logger.Errorf("Failed to create resource: %v\n", err)
 		return 1
 	}
+	httpServer.RegisterEndpoint(
+		"/tap",
+		"Streams a sample of the messages passing through a labelled component as Server-Sent Events or over a websocket.",
+		taps.HandlerFunc(manager.BloblEnvironment()),
+	)
+	httpServer.RegisterEndpoint(
+		"/health",
+		"Returns the health of all components as JSON, the status code is 503 if the readiness check fails, or the liveness check when the query parameter check=liveness.",
+		health.HandlerFunc(),
+	)
 
 	var stoppableStream stoppable
 	var dataStreamClosedChan chan struct{}
@@ -393,8 +448,19 @@ func cmdService(
 		}
 	}
 
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
 	// Defer clean up.
 	defer func() {
+		// A further termination signal during shut down skips the remaining
+		// shutdown phases of streams.
+		go func() {
+			<-sigChan
+			logger.Warnln("Received a second termination signal, exiting immediately")
+			os.Exit(1)
+		}()
+
 		go func() {
 			_ = httpServer.Shutdown(context.Background())
 			select {
@@ -434,9 +500,6 @@ func cmdService(
 		}
 	}()
 
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-
 	// Wait for termination signal
 	select {
 	case <-sigChan:
@@ -243,7 +243,7 @@ func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]str
 		remainingMocks[k] = v
 	}
 
-	configBytes, _, err := config.ReadFileEnvSwap(targetPath)
+	configBytes, _, err := config.ReadFileEnvSwapSkipSecrets(targetPath)
 	if err != nil {
 		return confs, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
 	}
@@ -254,7 +254,7 @@ func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]str
 	}
 
 	for _, path := range p.resourcesPaths {
-		resourceBytes, _, err := config.ReadFileEnvSwap(path)
+		resourceBytes, _, err := config.ReadFileEnvSwapSkipSecrets(path)
 		if err != nil {
 			return confs, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
 		}
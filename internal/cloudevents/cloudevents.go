@@ -0,0 +1,284 @@
+// Package cloudevents provides utilities for decoding and encoding messages as
+// CloudEvents (https://cloudevents.io) in both structured and binary content
+// modes, where the attributes of events are carried as message metadata.
+package cloudevents
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MetaPrefix is the prefix given to metadata keys that carry the
+	// attributes of an event.
+	MetaPrefix = "ce_"
+
+	// SpecVersion is the version of the CloudEvents specification supported.
+	SpecVersion = "1.0"
+
+	// StructuredContentType is the media type of a structured mode event.
+	StructuredContentType = "application/cloudevents+json"
+
+	// BatchContentType is the media type of a batch of structured mode events.
+	BatchContentType = "application/cloudevents-batch+json"
+)
+
+// Binary and structured content modes, used as the values of the output
+// cloudevents field.
+const (
+	ModeNone       = "none"
+	ModeBinary     = "binary"
+	ModeStructured = "structured"
+)
+
+var attributeNameRegex = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// Attributes defined by the specification, which are always strings when
+// serialised in structured mode.
+var stringAttributes = map[string]struct{}{
+	"id":              {},
+	"source":          {},
+	"specversion":     {},
+	"type":            {},
+	"datacontenttype": {},
+	"dataschema":      {},
+	"subject":         {},
+	"time":            {},
+}
+
+// Event is a CloudEvent decoded into its attributes and data. The attribute
+// datacontenttype, when present, is included within the attributes.
+type Event struct {
+	Attributes map[string]string
+	Data       []byte
+}
+
+// FromMetadata creates an event from the metadata of a message, where
+// attributes are those keys with the prefix ce_, and the contents of the
+// message are the data of the event.
+func FromMetadata(walkFn func(fn func(k, v string) error) error, data []byte) Event {
+	attrs := map[string]string{}
+	_ = walkFn(func(k, v string) error {
+		if strings.HasPrefix(k, MetaPrefix) {
+			attrs[strings.TrimPrefix(k, MetaPrefix)] = v
+		}
+		return nil
+	})
+	return Event{Attributes: attrs, Data: data}
+}
+
+// WalkMetadata calls a closure for each attribute of the event with the key it
+// should be given as metadata.
+func (e Event) WalkMetadata(fn func(k, v string)) {
+	for k, v := range e.Attributes {
+		fn(MetaPrefix+k, v)
+	}
+}
+
+// Validate returns an error if the event is missing required attributes or
+// contains attributes that are invalid.
+func (e Event) Validate() error {
+	for k := range e.Attributes {
+		if !attributeNameRegex.MatchString(k) {
+			return fmt.Errorf("attribute name '%v' must consist of lower-case letters and digits", k)
+		}
+	}
+	if v := e.Attributes["specversion"]; v != SpecVersion {
+		if v == "" {
+			return errors.New("event is missing the attribute specversion")
+		}
+		return fmt.Errorf("specversion '%v' is not supported", v)
+	}
+	for _, k := range []string{"id", "source", "type"} {
+		if e.Attributes[k] == "" {
+			return fmt.Errorf("event is missing the attribute %v", k)
+		}
+	}
+	if v, exists := e.Attributes["time"]; exists {
+		if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+			return fmt.Errorf("attribute time must be an RFC 3339 timestamp: %w", err)
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// IsStructuredContentType returns true if the provided content type is that
+// of a structured mode event.
+func IsStructuredContentType(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == StructuredContentType
+}
+
+// IsBatchContentType returns true if the provided content type is that of a
+// batch of structured mode events.
+func IsBatchContentType(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == BatchContentType
+}
+
+// ParseBinary creates a validated event from the attributes and data of a
+// binary mode message, where the content type of the message, if not empty,
+// becomes the attribute datacontenttype.
+func ParseBinary(attrs map[string]string, contentType string, data []byte) (Event, error) {
+	e := Event{Attributes: make(map[string]string, len(attrs)+1), Data: data}
+	for k, v := range attrs {
+		e.Attributes[strings.ToLower(k)] = v
+	}
+	if contentType != "" {
+		e.Attributes["datacontenttype"] = contentType
+	}
+	if err := e.Validate(); err != nil {
+		return Event{}, err
+	}
+	return e, nil
+}
+
+// ParseStructured creates a validated event from a structured mode event.
+// Attributes that are not strings within the event are kept as their raw JSON
+// value.
+func ParseStructured(b []byte) (Event, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return Event{}, fmt.Errorf("failed to parse structured event: %w", err)
+	}
+	return parseStructured(raw)
+}
+
+// ParseStructuredBatch creates validated events from a batch of structured
+// mode events.
+func ParseStructuredBatch(b []byte) ([]Event, error) {
+	var raws []map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raws); err != nil {
+		return nil, fmt.Errorf("failed to parse batch of events: %w", err)
+	}
+	events := make([]Event, 0, len(raws))
+	for i, raw := range raws {
+		e, err := parseStructured(raw)
+		if err != nil {
+			return nil, fmt.Errorf("event %v: %w", i, err)
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+func parseStructured(raw map[string]json.RawMessage) (Event, error) {
+	e := Event{Attributes: map[string]string{}}
+	for k, v := range raw {
+		switch k {
+		case "data", "data_base64":
+			continue
+		}
+		var str string
+		if err := json.Unmarshal(v, &str); err != nil {
+			str = string(v)
+		}
+		e.Attributes[k] = str
+	}
+	if err := e.Validate(); err != nil {
+		return Event{}, err
+	}
+
+	if dataB64, exists := raw["data_base64"]; exists {
+		var dataStr string
+		if err := json.Unmarshal(dataB64, &dataStr); err != nil {
+			return Event{}, fmt.Errorf("failed to parse data_base64: %w", err)
+		}
+		data, err := base64.StdEncoding.DecodeString(dataStr)
+		if err != nil {
+			return Event{}, fmt.Errorf("failed to decode data_base64: %w", err)
+		}
+		e.Data = data
+		return e, nil
+	}
+
+	data, exists := raw["data"]
+	if !exists {
+		return e, nil
+	}
+
+	// When the data is a JSON string and the content type is not JSON then
+	// the string is the data itself.
+	var dataStr string
+	if !isJSONContentType(e.Attributes["datacontenttype"]) && json.Unmarshal(data, &dataStr) == nil {
+		e.Data = []byte(dataStr)
+		return e, nil
+	}
+	e.Data = data
+	return e, nil
+}
+
+//------------------------------------------------------------------------------
+
+// BinaryAttributes validates the event and returns the attributes to be set on
+// a binary mode message, excluding datacontenttype, which is returned as the
+// content type of the message instead.
+func (e Event) BinaryAttributes() (attrs map[string]string, contentType string, err error) {
+	if err = e.Validate(); err != nil {
+		return nil, "", err
+	}
+	attrs = make(map[string]string, len(e.Attributes))
+	for k, v := range e.Attributes {
+		if k == "datacontenttype" {
+			contentType = v
+			continue
+		}
+		attrs[k] = v
+	}
+	return attrs, contentType, nil
+}
+
+// MarshalStructured validates the event and serialises it as a structured mode
+// event. Extension attributes containing integers or booleans are serialised
+// as JSON numbers and booleans respectively.
+func (e Event) MarshalStructured() ([]byte, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+
+	obj := make(map[string]interface{}, len(e.Attributes)+1)
+	for k, v := range e.Attributes {
+		obj[k] = v
+		if _, isString := stringAttributes[k]; isString {
+			continue
+		}
+		if i, err := strconv.ParseInt(v, 10, 32); err == nil {
+			obj[k] = i
+		} else if v == "true" || v == "false" {
+			obj[k] = v == "true"
+		}
+	}
+
+	if len(e.Data) > 0 {
+		switch {
+		case isJSONContentType(e.Attributes["datacontenttype"]) && json.Valid(e.Data):
+			obj["data"] = json.RawMessage(e.Data)
+		case utf8.Valid(e.Data):
+			obj["data"] = string(e.Data)
+		default:
+			obj["data_base64"] = base64.StdEncoding.EncodeToString(e.Data)
+		}
+	}
+	return json.Marshal(obj)
+}
+
+func isJSONContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
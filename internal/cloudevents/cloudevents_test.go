@@ -0,0 +1,233 @@
+package cloudevents_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/cloudevents"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		attrs       map[string]string
+		errContains string
+	}{
+		{
+			name: "valid",
+			attrs: map[string]string{
+				"specversion": "1.0", "id": "a", "source": "/b", "type": "c",
+				"time": "2022-01-02T15:04:05Z",
+			},
+		},
+		{
+			name:        "missing specversion",
+			attrs:       map[string]string{"id": "a", "source": "/b", "type": "c"},
+			errContains: "missing the attribute specversion",
+		},
+		{
+			name:        "wrong specversion",
+			attrs:       map[string]string{"specversion": "0.3", "id": "a", "source": "/b", "type": "c"},
+			errContains: "not supported",
+		},
+		{
+			name:        "missing id",
+			attrs:       map[string]string{"specversion": "1.0", "source": "/b", "type": "c"},
+			errContains: "missing the attribute id",
+		},
+		{
+			name:        "missing type",
+			attrs:       map[string]string{"specversion": "1.0", "id": "a", "source": "/b"},
+			errContains: "missing the attribute type",
+		},
+		{
+			name: "bad time",
+			attrs: map[string]string{
+				"specversion": "1.0", "id": "a", "source": "/b", "type": "c",
+				"time": "yesterday",
+			},
+			errContains: "RFC 3339",
+		},
+		{
+			name: "bad attribute name",
+			attrs: map[string]string{
+				"specversion": "1.0", "id": "a", "source": "/b", "type": "c",
+				"Foo_Bar": "baz",
+			},
+			errContains: "lower-case",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			err := cloudevents.Event{Attributes: test.attrs}.Validate()
+			if test.errContains == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+			}
+		})
+	}
+}
+
+func TestStructuredRoundTrip(t *testing.T) {
+	e, err := cloudevents.ParseStructured([]byte(`{
+  "specversion": "1.0",
+  "id": "abc",
+  "type": "com.example.foo",
+  "source": "/example",
+  "datacontenttype": "application/json",
+  "count": 10,
+  "enabled": true,
+  "data": {"foo":"bar"}
+}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"specversion":     "1.0",
+		"id":              "abc",
+		"type":            "com.example.foo",
+		"source":          "/example",
+		"datacontenttype": "application/json",
+		"count":           "10",
+		"enabled":         "true",
+	}, e.Attributes)
+	assert.Equal(t, `{"foo":"bar"}`, string(e.Data))
+
+	b, err := e.MarshalStructured()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+  "specversion": "1.0",
+  "id": "abc",
+  "type": "com.example.foo",
+  "source": "/example",
+  "datacontenttype": "application/json",
+  "count": 10,
+  "enabled": true,
+  "data": {"foo":"bar"}
+}`, string(b))
+}
+
+func TestStructuredData(t *testing.T) {
+	attrs := func(contentType string) map[string]string {
+		m := map[string]string{"specversion": "1.0", "id": "1", "source": "/a", "type": "b"}
+		if contentType != "" {
+			m["datacontenttype"] = contentType
+		}
+		return m
+	}
+
+	b, err := cloudevents.Event{Attributes: attrs("text/plain"), Data: []byte(`hello world`)}.MarshalStructured()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"specversion":"1.0","id":"1","source":"/a","type":"b","datacontenttype":"text/plain","data":"hello world"}`, string(b))
+
+	e, err := cloudevents.ParseStructured(b)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(e.Data))
+
+	b, err = cloudevents.Event{Attributes: attrs("application/octet-stream"), Data: []byte{0xff, 0x00}}.MarshalStructured()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"specversion":"1.0","id":"1","source":"/a","type":"b","datacontenttype":"application/octet-stream","data_base64":"/wA="}`, string(b))
+
+	e, err = cloudevents.ParseStructured(b)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xff, 0x00}, e.Data)
+
+	_, err = cloudevents.Event{Attributes: map[string]string{"id": "1"}}.MarshalStructured()
+	require.Error(t, err)
+}
+
+func TestStructuredBatch(t *testing.T) {
+	events, err := cloudevents.ParseStructuredBatch([]byte(`[
+  {"specversion":"1.0","id":"a","type":"foo","source":"/bar","datacontenttype":"text/plain","data":"first"},
+  {"specversion":"1.0","id":"b","type":"foo","source":"/bar","data_base64":"c2Vjb25k"}
+]`))
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "first", string(events[0].Data))
+	assert.Equal(t, "a", events[0].Attributes["id"])
+	assert.Equal(t, "second", string(events[1].Data))
+	assert.Equal(t, "b", events[1].Attributes["id"])
+
+	_, err = cloudevents.ParseStructuredBatch([]byte(`[{"specversion":"1.0","id":"a","type":"foo","source":"/bar"},{"id":"b"}]`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "event 1")
+}
+
+func TestBinary(t *testing.T) {
+	e, err := cloudevents.ParseBinary(map[string]string{
+		"Specversion": "1.0",
+		"Id":          "1",
+		"Source":      "/a",
+		"Type":        "b",
+	}, "text/plain", []byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", e.Attributes["datacontenttype"])
+	assert.Equal(t, "1", e.Attributes["id"])
+
+	meta := map[string]string{}
+	e.WalkMetadata(func(k, v string) {
+		meta[k] = v
+	})
+	assert.Equal(t, map[string]string{
+		"ce_specversion":     "1.0",
+		"ce_id":              "1",
+		"ce_source":          "/a",
+		"ce_type":            "b",
+		"ce_datacontenttype": "text/plain",
+	}, meta)
+
+	meta["other"] = "ignored"
+	e = cloudevents.FromMetadata(func(fn func(k, v string) error) error {
+		for k, v := range meta {
+			if err := fn(k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, []byte("hello"))
+
+	attrs, contentType, err := e.BinaryAttributes()
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", contentType)
+	assert.Equal(t, map[string]string{
+		"specversion": "1.0",
+		"id":          "1",
+		"source":      "/a",
+		"type":        "b",
+	}, attrs)
+
+	_, err = cloudevents.ParseBinary(map[string]string{"id": "1"}, "", nil)
+	require.Error(t, err)
+}
+
+func TestHTTPHeaderEncoding(t *testing.T) {
+	e, err := cloudevents.ParseBinary(map[string]string{
+		"Specversion": "1.0",
+		"Id":          "1",
+		"Source":      "/a",
+		"Type":        "b",
+		"Subject":     `hello "wörld" 100%`,
+	}, "text/plain", []byte("hello"))
+	require.NoError(t, err)
+
+	header, body, err := cloudevents.EncodeHTTP(e, cloudevents.ModeBinary)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+	assert.Equal(t, "hello%20%22w%C3%B6rld%22%20100%25", header.Get("Ce-Subject"))
+	assert.Equal(t, "/a", header.Get("Ce-Source"))
+
+	events, err := cloudevents.ParseHTTP(header, body)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, `hello "wörld" 100%`, events[0].Attributes["subject"])
+
+	header.Set("Ce-Subject", "100%")
+	events, err = cloudevents.ParseHTTP(header, body)
+	require.NoError(t, err)
+	assert.Equal(t, "100%", events[0].Attributes["subject"])
+}
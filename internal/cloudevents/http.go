@@ -0,0 +1,104 @@
+package cloudevents
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// HTTPHeaderPrefix is the prefix of HTTP headers that carry the attributes of
+// a binary mode event.
+const HTTPHeaderPrefix = "Ce-"
+
+// ErrNotEvent is returned when a message does not contain an event in either
+// structured or binary content mode.
+var ErrNotEvent = errors.New("message is not a CloudEvent")
+
+// encodeHeaderValue percent-encodes the characters of an attribute value that
+// the HTTP protocol binding requires to be encoded within headers, which are
+// spaces, double quotes, percent signs and anything outside of printable ASCII.
+func encodeHeaderValue(v string) string {
+	var buf strings.Builder
+	for i := 0; i < len(v); i++ {
+		b := v[i]
+		if b <= ' ' || b > '~' || b == '"' || b == '%' {
+			fmt.Fprintf(&buf, "%%%02X", b)
+			continue
+		}
+		buf.WriteByte(b)
+	}
+	return buf.String()
+}
+
+// decodeHeaderValue decodes a percent-encoded attribute value from a header,
+// where values that are not validly encoded are returned unchanged.
+func decodeHeaderValue(v string) string {
+	if !strings.Contains(v, "%") {
+		return v
+	}
+	decoded, err := url.PathUnescape(v)
+	if err != nil {
+		return v
+	}
+	return decoded
+}
+
+// ParseHTTP creates validated events from the headers and body of an HTTP
+// request or response, which may contain an event in either structured or
+// binary content mode, or a batch of structured mode events. If the message
+// does not resemble an event at all then ErrNotEvent is returned.
+func ParseHTTP(header http.Header, body []byte) ([]Event, error) {
+	contentType := header.Get("Content-Type")
+	switch {
+	case IsStructuredContentType(contentType):
+		event, err := ParseStructured(body)
+		if err != nil {
+			return nil, err
+		}
+		return []Event{event}, nil
+	case IsBatchContentType(contentType):
+		return ParseStructuredBatch(body)
+	case header.Get(HTTPHeaderPrefix+"Specversion") == "":
+		return nil, ErrNotEvent
+	}
+
+	attrs := map[string]string{}
+	for k, v := range header {
+		if len(v) > 0 && strings.HasPrefix(k, HTTPHeaderPrefix) {
+			attrs[strings.TrimPrefix(k, HTTPHeaderPrefix)] = decodeHeaderValue(v[0])
+		}
+	}
+	event, err := ParseBinary(attrs, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	return []Event{event}, nil
+}
+
+// EncodeHTTP validates an event and returns the headers and body of an HTTP
+// message carrying it in either binary or structured content mode.
+func EncodeHTTP(e Event, mode string) (http.Header, []byte, error) {
+	header := http.Header{}
+	if mode == ModeStructured {
+		body, err := e.MarshalStructured()
+		if err != nil {
+			return nil, nil, err
+		}
+		header.Set("Content-Type", StructuredContentType)
+		return header, body, nil
+	}
+
+	attrs, contentType, err := e.BinaryAttributes()
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range attrs {
+		header.Set(HTTPHeaderPrefix+k, encodeHeaderValue(v))
+	}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return header, e.Data, nil
+}
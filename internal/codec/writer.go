@@ -2,24 +2,35 @@ package codec
 
 import (
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"strings"
 
+	"github.com/golang/snappy"
+	"github.com/pierrec/lz4/v4"
+
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
 
 // WriterDocs is a static field documentation for output codecs.
 var WriterDocs = docs.FieldString(
-	"codec", "The way in which the bytes of messages should be written out into the output data stream. It's possible to write lines using a custom delimiter with the `delim:x` codec, where x is the character sequence custom delimiter.", "lines", "delim:\t", "delim:foobar",
+	"codec", "The way in which the bytes of messages should be written out into the output data stream. It's possible to write lines using a custom delimiter with the `delim:x` codec, where x is the character sequence custom delimiter. The output data stream can be compressed by prefixing a codec with a compression algorithm followed by `/`, for example lines can be written to a gzip compressed stream with the codec `gzip/lines`.", "lines", "delim:\t", "delim:foobar", "gzip/lines",
 ).HasAnnotatedOptions(
 	"all-bytes", "Only applicable to file based outputs. Writes each message to a file in full, if the file already exists the old content is deleted.",
 	"append", "Append each message to the output stream without any delimiter or special encoding.",
 	"lines", "Append each message to the output stream followed by a line break.",
 	"delim:x", "Append each message to the output stream followed by a custom delimiter.",
+	"flate", "Compress the output data stream with flate, this codec should precede another codec, e.g. `flate/lines`.",
+	"gzip", "Compress the output data stream with gzip, this codec should precede another codec, e.g. `gzip/lines`.",
+	"lz4", "Compress the output data stream with lz4, this codec should precede another codec, e.g. `lz4/lines`.",
+	"snappy", "Compress the output data stream with the snappy framing format, this codec should precede another codec, e.g. `snappy/lines`.",
+	"zlib", "Compress the output data stream with zlib, this codec should precede another codec, e.g. `zlib/lines`.",
 ).LinterFunc(nil) // Disable default option linter as it doesn't include foo:bar formats.
 
 //------------------------------------------------------------------------------
@@ -43,6 +54,13 @@ type WriterConstructor func(io.WriteCloser) (Writer, error)
 
 // GetWriter returns a constructor that creates write codecs.
 func GetWriter(codec string) (WriterConstructor, WriterConfig, error) {
+	if strings.Contains(codec, "/") && !strings.HasPrefix(codec, "delim:") {
+		return chainedWriter(codec)
+	}
+	return partWriter(codec)
+}
+
+func partWriter(codec string) (WriterConstructor, WriterConfig, error) {
 	switch codec {
 	case "all-bytes":
 		return func(w io.WriteCloser) (Writer, error) {
@@ -67,6 +85,93 @@ func GetWriter(codec string) (WriterConstructor, WriterConfig, error) {
 	return nil, WriterConfig{}, fmt.Errorf("codec was not recognised: %v", codec)
 }
 
+type ioWriterConstructor func(io.WriteCloser) (io.WriteCloser, error)
+
+func chainedWriter(codec string) (WriterConstructor, WriterConfig, error) {
+	codecs := strings.Split(codec, "/")
+
+	var ioCtors []ioWriterConstructor
+	for i, c := range codecs {
+		if strings.HasPrefix(c, "delim:") {
+			// A custom delimiter may itself contain forward slashes.
+			codecs[i] = strings.Join(codecs[i:], "/")
+			codecs = codecs[:i+1]
+			break
+		}
+		if i == len(codecs)-1 {
+			break
+		}
+		ctor, err := ioWriter(c)
+		if err != nil {
+			return nil, WriterConfig{}, err
+		}
+		ioCtors = append(ioCtors, ctor)
+	}
+
+	partCtor, conf, err := partWriter(codecs[len(codecs)-1])
+	if err != nil {
+		return nil, WriterConfig{}, err
+	}
+	return func(w io.WriteCloser) (Writer, error) {
+		for _, ctor := range ioCtors {
+			var err error
+			if w, err = ctor(w); err != nil {
+				return nil, err
+			}
+		}
+		return partCtor(w)
+	}, conf, nil
+}
+
+func ioWriter(codec string) (ioWriterConstructor, error) {
+	switch codec {
+	case "flate":
+		return func(w io.WriteCloser) (io.WriteCloser, error) {
+			fw, err := flate.NewWriter(w, flate.DefaultCompression)
+			if err != nil {
+				return nil, err
+			}
+			return &compressedWriter{c: fw, w: w}, nil
+		}, nil
+	case "gzip":
+		return func(w io.WriteCloser) (io.WriteCloser, error) {
+			return &compressedWriter{c: gzip.NewWriter(w), w: w}, nil
+		}, nil
+	case "lz4":
+		return func(w io.WriteCloser) (io.WriteCloser, error) {
+			return &compressedWriter{c: lz4.NewWriter(w), w: w}, nil
+		}, nil
+	case "snappy":
+		return func(w io.WriteCloser) (io.WriteCloser, error) {
+			return &compressedWriter{c: snappy.NewBufferedWriter(w), w: w}, nil
+		}, nil
+	case "zlib":
+		return func(w io.WriteCloser) (io.WriteCloser, error) {
+			return &compressedWriter{c: zlib.NewWriter(w), w: w}, nil
+		}, nil
+	}
+	return nil, fmt.Errorf("compression codec was not recognised: %v", codec)
+}
+
+// compressedWriter writes to a compression stream, and on close flushes the
+// stream before closing the underlying writer.
+type compressedWriter struct {
+	c io.WriteCloser
+	w io.WriteCloser
+}
+
+func (c *compressedWriter) Write(p []byte) (int, error) {
+	return c.c.Write(p)
+}
+
+func (c *compressedWriter) Close() error {
+	err := c.c.Close()
+	if cErr := c.w.Close(); err == nil {
+		err = cErr
+	}
+	return err
+}
+
 //------------------------------------------------------------------------------
 
 var allBytesConfig = WriterConfig{
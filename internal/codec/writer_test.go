@@ -0,0 +1,130 @@
+package codec
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/pierrec/lz4/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+type closeTracker struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+func testWriterSuite(t *testing.T, codec string, parts []string, expected string) {
+	t.Helper()
+
+	ctor, _, err := GetWriter(codec)
+	require.NoError(t, err)
+
+	buf := &closeTracker{}
+	w, err := ctor(buf)
+	require.NoError(t, err)
+
+	for _, p := range parts {
+		require.NoError(t, w.Write(context.Background(), message.NewPart([]byte(p))))
+	}
+	require.NoError(t, w.Close(context.Background()))
+
+	assert.True(t, buf.closed)
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestLinesWriter(t *testing.T) {
+	testWriterSuite(t, "lines", []string{"foo", "bar\n", "baz"}, "foo\nbar\nbaz\n")
+}
+
+func TestDelimWriter(t *testing.T) {
+	testWriterSuite(t, "delim:/", []string{"foo", "bar"}, "foo/bar/")
+}
+
+func TestGzipLinesWriter(t *testing.T) {
+	ctor, _, err := GetWriter("gzip/lines")
+	require.NoError(t, err)
+
+	buf := &closeTracker{}
+	w, err := ctor(buf)
+	require.NoError(t, err)
+
+	for _, p := range []string{"foo", "bar", "baz"} {
+		require.NoError(t, w.Write(context.Background(), message.NewPart([]byte(p))))
+	}
+	require.NoError(t, w.Close(context.Background()))
+	assert.True(t, buf.closed)
+
+	gr, err := gzip.NewReader(&buf.Buffer)
+	require.NoError(t, err)
+
+	decompressed, err := io.ReadAll(gr)
+	require.NoError(t, err)
+	assert.Equal(t, "foo\nbar\nbaz\n", string(decompressed))
+}
+
+func TestChainedWriterRoundTrip(t *testing.T) {
+	decompressors := map[string]func(io.Reader) (io.Reader, error){
+		"flate": func(r io.Reader) (io.Reader, error) {
+			return flate.NewReader(r), nil
+		},
+		"gzip": func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		},
+		"lz4": func(r io.Reader) (io.Reader, error) {
+			return lz4.NewReader(r), nil
+		},
+		"snappy": func(r io.Reader) (io.Reader, error) {
+			return snappy.NewReader(r), nil
+		},
+		"zlib": func(r io.Reader) (io.Reader, error) {
+			return zlib.NewReader(r)
+		},
+	}
+
+	for algorithm, decompressor := range decompressors {
+		algorithm, decompressor := algorithm, decompressor
+		t.Run(algorithm, func(t *testing.T) {
+			ctor, _, err := GetWriter(algorithm + "/delim:a/b")
+			require.NoError(t, err)
+
+			buf := &closeTracker{}
+			w, err := ctor(buf)
+			require.NoError(t, err)
+
+			for _, p := range []string{"foo", "bar"} {
+				require.NoError(t, w.Write(context.Background(), message.NewPart([]byte(p))))
+			}
+			require.NoError(t, w.Close(context.Background()))
+			assert.True(t, buf.closed)
+
+			r, err := decompressor(&buf.Buffer)
+			require.NoError(t, err)
+
+			decompressed, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, "fooa/bbara/b", string(decompressed))
+		})
+	}
+}
+
+func TestChainedWriterErrors(t *testing.T) {
+	_, _, err := GetWriter("nope/lines")
+	require.Error(t, err)
+
+	_, _, err = GetWriter("gzip/nope")
+	require.Error(t, err)
+}
@@ -5,14 +5,16 @@ import (
 	"errors"
 	"time"
 
+	"github.com/benthosdev/benthos/v4/internal/api"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
 )
 
 type metricsCache struct {
-	c   V1
-	sig *shutdown.Signaller
+	c      V1
+	sig    *shutdown.Signaller
+	health *api.HealthTracker
 
 	mGetNotFound metrics.StatCounter
 	mGetError    metrics.StatCounter
@@ -63,10 +65,29 @@ func MetricsForCache(c V1, stats metrics.Type) V1 {
 	}
 }
 
+// MetricsAndHealthForCache wraps a cache with a struct that adds standard
+// metrics over each method, and reports the health of the cache when the
+// manager supports health tracking. Missing and duplicate keys are not
+// considered failures.
+func MetricsAndHealthForCache(c V1, mgr component.Observability) V1 {
+	mc := MetricsForCache(c, mgr.Metrics()).(*metricsCache)
+	mc.health = api.HealthTrackerFor(mgr, api.HealthKindCache)
+	return mc
+}
+
+func (a *metricsCache) recordHealth(err error) {
+	if err == nil || errors.Is(err, component.ErrKeyNotFound) || errors.Is(err, component.ErrKeyAlreadyExists) {
+		a.health.Success()
+	} else {
+		a.health.Error(err)
+	}
+}
+
 func (a *metricsCache) Get(ctx context.Context, key string) ([]byte, error) {
 	started := time.Now()
 	b, err := a.c.Get(ctx, key)
 	a.mGetLatency.Timing(int64(time.Since(started)))
+	a.recordHealth(err)
 	if err != nil {
 		if errors.Is(err, component.ErrKeyNotFound) {
 			a.mGetNotFound.Incr(1)
@@ -83,6 +104,7 @@ func (a *metricsCache) Set(ctx context.Context, key string, value []byte, ttl *t
 	started := time.Now()
 	err := a.c.Set(ctx, key, value, ttl)
 	a.mSetLatency.Timing(int64(time.Since(started)))
+	a.recordHealth(err)
 	if err != nil {
 		a.mSetError.Incr(1)
 	} else {
@@ -95,6 +117,7 @@ func (a *metricsCache) SetMulti(ctx context.Context, items map[string]TTLItem) e
 	started := time.Now()
 	err := a.c.SetMulti(ctx, items)
 	a.mSetLatency.Timing(int64(time.Since(started)))
+	a.recordHealth(err)
 	if err != nil {
 		a.mSetError.Incr(int64(len(items)))
 	} else {
@@ -107,6 +130,7 @@ func (a *metricsCache) Add(ctx context.Context, key string, value []byte, ttl *t
 	started := time.Now()
 	err := a.c.Add(ctx, key, value, ttl)
 	a.mAddLatency.Timing(int64(time.Since(started)))
+	a.recordHealth(err)
 	if err != nil {
 		if errors.Is(err, component.ErrKeyAlreadyExists) {
 			a.mAddDupe.Incr(1)
@@ -123,6 +147,7 @@ func (a *metricsCache) Delete(ctx context.Context, key string) error {
 	started := time.Now()
 	err := a.c.Delete(ctx, key)
 	a.mDelLatency.Timing(int64(time.Since(started)))
+	a.recordHealth(err)
 	if err != nil {
 		a.mDelError.Incr(1)
 	} else {
@@ -132,5 +157,6 @@ func (a *metricsCache) Delete(ctx context.Context, key string) error {
 }
 
 func (a *metricsCache) Close(ctx context.Context) error {
+	a.health.Close()
 	return a.c.Close(ctx)
 }
@@ -0,0 +1,21 @@
+package component
+
+// InFlightReporter is optionally implemented by components that are able to
+// report the number of messages they currently hold that are yet to be
+// resolved, which is used in order to report on messages that are still in
+// flight during shut down.
+type InFlightReporter interface {
+	// InFlight returns the number of messages held, and false if the number is
+	// not known, which is the case for components that wrap others that are
+	// unable to report them.
+	InFlight() (int, bool)
+}
+
+// InFlightOf returns the number of messages in flight of a component, and
+// whether the component is able to report it.
+func InFlightOf(c interface{}) (int, bool) {
+	if r, ok := c.(InFlightReporter); ok {
+		return r.InFlight()
+	}
+	return 0, false
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/cenkalti/backoff/v4"
 
+	"github.com/benthosdev/benthos/v4/internal/api"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
@@ -18,6 +19,7 @@ import (
 // input.Async component.
 type AsyncReader struct {
 	connected   int32
+	inFlight    int64
 	connBackoff backoff.BackOff
 
 	allowSkipAcks bool
@@ -25,10 +27,13 @@ type AsyncReader struct {
 	typeStr string
 	reader  Async
 
-	mgr component.Observability
+	mgr    component.Observability
+	tap    *api.TapPoint
+	health *api.HealthTracker
 
-	transactions chan message.Transaction
-	shutSig      *shutdown.Signaller
+	transactions     chan message.Transaction
+	stoppedConsuming chan struct{}
+	shutSig          *shutdown.Signaller
 }
 
 // NewAsyncReader creates a new AsyncReader input type.
@@ -44,13 +49,16 @@ func NewAsyncReader(
 	boff.MaxElapsedTime = 0
 
 	rdr := &AsyncReader{
-		connBackoff:   boff,
-		allowSkipAcks: allowSkipAcks,
-		typeStr:       typeStr,
-		reader:        r,
-		mgr:           mgr,
-		transactions:  make(chan message.Transaction),
-		shutSig:       shutdown.NewSignaller(),
+		connBackoff:      boff,
+		allowSkipAcks:    allowSkipAcks,
+		typeStr:          typeStr,
+		reader:           r,
+		mgr:              mgr,
+		tap:              api.TapPointFor(mgr),
+		health:           api.HealthTrackerFor(mgr, api.HealthKindInput),
+		transactions:     make(chan message.Transaction),
+		stoppedConsuming: make(chan struct{}),
+		shutSig:          shutdown.NewSignaller(),
 	}
 
 	go rdr.loop()
@@ -81,8 +89,8 @@ func (r *AsyncReader) loop() {
 		_ = r.reader.WaitForClose(shutdown.MaximumShutdownWait())
 
 		atomic.StoreInt32(&r.connected, 0)
-
-		close(r.transactions)
+		r.health.Close()
+		r.tap.Close()
 		r.shutSig.ShutdownComplete()
 	}()
 
@@ -93,6 +101,14 @@ func (r *AsyncReader) loop() {
 		r.mgr.Logger().Debugln("Pending acks resolved.")
 	}()
 
+	// The transactions channel is closed before waiting for pending acks so
+	// that downstream components, such as batchers, are able to flush the
+	// messages they hold and resolve them.
+	defer func() {
+		close(r.transactions)
+		close(r.stoppedConsuming)
+	}()
+
 	initConnection := func() bool {
 		initConnCtx, initConnDone := r.shutSig.CloseAtLeisureCtx(context.Background())
 		defer initConnDone()
@@ -103,6 +119,7 @@ func (r *AsyncReader) loop() {
 				}
 				r.mgr.Logger().Errorf("Failed to connect to %v: %v\n", r.typeStr, err)
 				mFailedConn.Incr(1)
+				r.health.Error(err)
 				select {
 				case <-time.After(r.connBackoff.NextBackOff()):
 				case <-initConnCtx.Done():
@@ -119,6 +136,7 @@ func (r *AsyncReader) loop() {
 	}
 	mConn.Incr(1)
 	atomic.StoreInt32(&r.connected, 1)
+	r.health.SetConnected(true)
 
 	for {
 		readCtx, readDone := r.shutSig.CloseAtLeisureCtx(context.Background())
@@ -129,6 +147,7 @@ func (r *AsyncReader) loop() {
 		if err == component.ErrNotConnected {
 			mLostConn.Incr(1)
 			atomic.StoreInt32(&r.connected, 0)
+			r.health.SetConnected(false)
 
 			// Continue to try to reconnect while still active.
 			if !initConnection() {
@@ -136,6 +155,7 @@ func (r *AsyncReader) loop() {
 			}
 			mConn.Incr(1)
 			atomic.StoreInt32(&r.connected, 1)
+			r.health.SetConnected(true)
 		}
 
 		// Close immediately if our reader is closed.
@@ -146,6 +166,7 @@ func (r *AsyncReader) loop() {
 		if err != nil || msg == nil {
 			if err != nil && err != component.ErrTimeout && err != component.ErrNotConnected {
 				r.mgr.Logger().Errorf("Failed to read message: %v\n", err)
+				r.health.Error(err)
 			}
 			select {
 			case <-time.After(r.connBackoff.NextBackOff()):
@@ -156,6 +177,7 @@ func (r *AsyncReader) loop() {
 		} else {
 			r.connBackoff.Reset()
 			mRcvd.Incr(int64(msg.Len()))
+			r.health.Success()
 			r.mgr.Logger().Tracef("Consumed %v messages from '%v'.\n", msg.Len(), r.typeStr)
 		}
 
@@ -163,6 +185,9 @@ func (r *AsyncReader) loop() {
 
 		resChan := make(chan error)
 		tracing.InitSpans(r.mgr.Tracer(), "input_"+r.typeStr, msg)
+		if r.tap.Active() {
+			r.tap.Offer(msg)
+		}
 		select {
 		case r.transactions <- message.NewTransaction(msg, resChan):
 		case <-r.shutSig.CloseAtLeisureChan():
@@ -170,12 +195,17 @@ func (r *AsyncReader) loop() {
 		}
 
 		pendingAcks.Add(1)
+		inFlight := int64(msg.Len())
+		atomic.AddInt64(&r.inFlight, inFlight)
 		go func(
 			m *message.Batch,
 			aFn AsyncAckFn,
 			rChan chan error,
 		) {
-			defer pendingAcks.Done()
+			defer func() {
+				atomic.AddInt64(&r.inFlight, -inFlight)
+				pendingAcks.Done()
+			}()
 
 			var res error
 			var open bool
@@ -213,11 +243,29 @@ func (r *AsyncReader) Connected() bool {
 	return atomic.LoadInt32(&r.connected) == 1
 }
 
+// InFlight returns the number of messages consumed by the input that are yet to
+// be acknowledged.
+func (r *AsyncReader) InFlight() (int, bool) {
+	return int(atomic.LoadInt64(&r.inFlight)), true
+}
+
 // CloseAsync shuts down the AsyncReader input and stops processing requests.
 func (r *AsyncReader) CloseAsync() {
 	r.shutSig.CloseAtLeisure()
 }
 
+// WaitForStopConsuming blocks until the AsyncReader input has stopped consuming
+// new messages, after which it may still be waiting for pending messages to be
+// acknowledged.
+func (r *AsyncReader) WaitForStopConsuming(timeout time.Duration) error {
+	select {
+	case <-r.stoppedConsuming:
+	case <-time.After(timeout):
+		return component.ErrTimeout
+	}
+	return nil
+}
+
 // WaitForClose blocks until the AsyncReader input has closed down.
 func (r *AsyncReader) WaitForClose(timeout time.Duration) error {
 	go func() {
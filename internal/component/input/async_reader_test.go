@@ -552,6 +552,73 @@ func TestAsyncReaderCloseWithPendingAcks(t *testing.T) {
 	}
 }
 
+func TestAsyncReaderStopConsumingBeforeAcks(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	readerImpl := newMockAsyncReader()
+	readerImpl.msgsToSnd = []*message.Batch{message.QuickBatch([][]byte{[]byte("foo"), []byte("bar")})}
+
+	r, err := input.NewAsyncReader("foo", true, readerImpl, mock.NewManager())
+	require.NoError(t, err)
+
+	select {
+	case readerImpl.connChan <- nil:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	go func() {
+		select {
+		case readerImpl.readChan <- nil:
+		case <-time.After(time.Second):
+		}
+	}()
+
+	var ts message.Transaction
+	select {
+	case ts = <-r.TransactionChan():
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	n, ok := component.InFlightOf(r)
+	assert.True(t, ok)
+	assert.Equal(t, 2, n)
+
+	r.CloseAsync()
+
+	// The input stops consuming, and closes its transaction channel, whilst
+	// the message remains unacknowledged.
+	require.NoError(t, input.WaitForStopConsuming(r, time.Second))
+	_, open := <-r.TransactionChan()
+	assert.False(t, open)
+
+	select {
+	case <-readerImpl.closeAsyncChan:
+		t.Fatal("reader closed early")
+	case <-time.After(time.Millisecond * 100):
+	}
+	n, ok = component.InFlightOf(r)
+	assert.True(t, ok)
+	assert.Equal(t, 2, n)
+
+	require.NoError(t, ts.Ack(tCtx, nil))
+	select {
+	case readerImpl.ackChan <- nil:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	require.NoError(t, r.WaitForClose(time.Second*5))
+	n, ok = component.InFlightOf(r)
+	assert.True(t, ok)
+	assert.Equal(t, 0, n)
+
+	readerImpl.ackMut.Lock()
+	assert.NoError(t, readerImpl.ackRcvd[0])
+	readerImpl.ackMut.Unlock()
+}
+
 func TestAsyncReaderSadPath(t *testing.T) {
 	tCtx, done := context.WithTimeout(context.Background(), time.Second*5)
 	defer done()
@@ -176,11 +176,23 @@ func (m *Impl) TransactionChan() <-chan message.Transaction {
 	return m.messagesOut
 }
 
+// InFlight returns the number of messages consumed by the child input that are
+// yet to be acknowledged.
+func (m *Impl) InFlight() (int, bool) {
+	return component.InFlightOf(m.child)
+}
+
 // CloseAsync shuts down the Batcher and stops processing messages.
 func (m *Impl) CloseAsync() {
 	m.shutSig.CloseAtLeisure()
 }
 
+// WaitForStopConsuming blocks until the child input has stopped consuming new
+// messages.
+func (m *Impl) WaitForStopConsuming(timeout time.Duration) error {
+	return input.WaitForStopConsuming(m.child, timeout)
+}
+
 // WaitForClose blocks until the Batcher output has closed down.
 func (m *Impl) WaitForClose(timeout time.Duration) error {
 	go func() {
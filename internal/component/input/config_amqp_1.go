@@ -10,6 +10,7 @@ type AMQP1Config struct {
 	URL            string            `json:"url" yaml:"url"`
 	SourceAddress  string            `json:"source_address" yaml:"source_address"`
 	AzureRenewLock bool              `json:"azure_renew_lock" yaml:"azure_renew_lock"`
+	CloudEvents    bool              `json:"cloudevents" yaml:"cloudevents"`
 	TLS            btls.Config       `json:"tls" yaml:"tls"`
 	SASL           shared.SASLConfig `json:"sasl" yaml:"sasl"`
 }
@@ -37,6 +37,7 @@ type HTTPServerConfig struct {
 	CertFile           string                   `json:"cert_file" yaml:"cert_file"`
 	KeyFile            string                   `json:"key_file" yaml:"key_file"`
 	CORS               httpdocs.ServerCORS      `json:"cors" yaml:"cors"`
+	CloudEvents        bool                     `json:"cloudevents" yaml:"cloudevents"`
 	Response           HTTPServerResponseConfig `json:"sync_response" yaml:"sync_response"`
 }
 
@@ -51,11 +52,12 @@ func NewHTTPServerConfig() HTTPServerConfig {
 		AllowedVerbs: []string{
 			"POST",
 		},
-		Timeout:   "5s",
-		RateLimit: "",
-		CertFile:  "",
-		KeyFile:   "",
-		CORS:      httpdocs.NewServerCORS(),
-		Response:  NewHTTPServerResponseConfig(),
+		Timeout:     "5s",
+		RateLimit:   "",
+		CertFile:    "",
+		KeyFile:     "",
+		CORS:        httpdocs.NewServerCORS(),
+		CloudEvents: false,
+		Response:    NewHTTPServerResponseConfig(),
 	}
 }
@@ -7,30 +7,38 @@ import (
 // RedisStreamsConfig contains configuration fields for the RedisStreams input
 // type.
 type RedisStreamsConfig struct {
-	bredis.Config   `json:",inline" yaml:",inline"`
-	BodyKey         string   `json:"body_key" yaml:"body_key"`
-	Streams         []string `json:"streams" yaml:"streams"`
-	CreateStreams   bool     `json:"create_streams" yaml:"create_streams"`
-	ConsumerGroup   string   `json:"consumer_group" yaml:"consumer_group"`
-	ClientID        string   `json:"client_id" yaml:"client_id"`
-	Limit           int64    `json:"limit" yaml:"limit"`
-	StartFromOldest bool     `json:"start_from_oldest" yaml:"start_from_oldest"`
-	CommitPeriod    string   `json:"commit_period" yaml:"commit_period"`
-	Timeout         string   `json:"timeout" yaml:"timeout"`
+	bredis.Config    `json:",inline" yaml:",inline"`
+	BodyKey          string   `json:"body_key" yaml:"body_key"`
+	Streams          []string `json:"streams" yaml:"streams"`
+	CreateStreams    bool     `json:"create_streams" yaml:"create_streams"`
+	ConsumerGroup    string   `json:"consumer_group" yaml:"consumer_group"`
+	ClientID         string   `json:"client_id" yaml:"client_id"`
+	Limit            int64    `json:"limit" yaml:"limit"`
+	StartFromOldest  bool     `json:"start_from_oldest" yaml:"start_from_oldest"`
+	CommitPeriod     string   `json:"commit_period" yaml:"commit_period"`
+	Timeout          string   `json:"timeout" yaml:"timeout"`
+	ClaimPeriod      string   `json:"claim_period" yaml:"claim_period"`
+	ClaimMinIdle     string   `json:"claim_min_idle" yaml:"claim_min_idle"`
+	MaxDeliveries    int64    `json:"max_deliveries" yaml:"max_deliveries"`
+	DeadLetterStream string   `json:"dead_letter_stream" yaml:"dead_letter_stream"`
 }
 
 // NewRedisStreamsConfig creates a new RedisStreamsConfig with default values.
 func NewRedisStreamsConfig() RedisStreamsConfig {
 	return RedisStreamsConfig{
-		Config:          bredis.NewConfig(),
-		BodyKey:         "body",
-		Streams:         []string{},
-		CreateStreams:   true,
-		ConsumerGroup:   "",
-		ClientID:        "",
-		Limit:           10,
-		StartFromOldest: true,
-		CommitPeriod:    "1s",
-		Timeout:         "1s",
+		Config:           bredis.NewConfig(),
+		BodyKey:          "body",
+		Streams:          []string{},
+		CreateStreams:    true,
+		ConsumerGroup:    "",
+		ClientID:         "",
+		Limit:            10,
+		StartFromOldest:  true,
+		CommitPeriod:     "1s",
+		Timeout:          "1s",
+		ClaimPeriod:      "",
+		ClaimMinIdle:     "5m",
+		MaxDeliveries:    0,
+		DeadLetterStream: "",
 	}
 }
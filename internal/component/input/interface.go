@@ -28,6 +28,26 @@ type Streamed interface {
 	WaitForClose(timeout time.Duration) error
 }
 
+// StopConsumingWaiter is optionally implemented by inputs that are able to
+// signal when they have stopped consuming new messages after being closed. An
+// input that has stopped consuming may still be waiting for pending messages to
+// be acknowledged before it finishes closing.
+type StopConsumingWaiter interface {
+	// WaitForStopConsuming is a blocking call to wait until the input has
+	// stopped consuming new messages.
+	WaitForStopConsuming(timeout time.Duration) error
+}
+
+// WaitForStopConsuming blocks until an input has stopped consuming new
+// messages. Inputs that are unable to signal this are assumed to have stopped
+// consuming immediately.
+func WaitForStopConsuming(in Streamed, timeout time.Duration) error {
+	if w, ok := in.(StopConsumingWaiter); ok {
+		return w.WaitForStopConsuming(timeout)
+	}
+	return nil
+}
+
 // AsyncAckFn is a function used to acknowledge receipt of a message batch. The
 // provided response indicates whether the message batch was successfully
 // delivered. Returns an error if the acknowledge was not propagated.
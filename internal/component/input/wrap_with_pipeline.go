@@ -3,6 +3,7 @@ package input
 import (
 	"time"
 
+	"github.com/benthosdev/benthos/v4/internal/component"
 	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
@@ -57,6 +58,12 @@ func (i *WithPipeline) Connected() bool {
 	return i.in.Connected()
 }
 
+// InFlight returns the number of messages consumed by the wrapped input that are
+// yet to be acknowledged.
+func (i *WithPipeline) InFlight() (int, bool) {
+	return component.InFlightOf(i.in)
+}
+
 //------------------------------------------------------------------------------
 
 // CloseAsync triggers a closure of this object but does not block.
@@ -65,6 +72,12 @@ func (i *WithPipeline) CloseAsync() {
 	i.pipe.CloseAsync()
 }
 
+// WaitForStopConsuming blocks until the wrapped input has stopped consuming new
+// messages.
+func (i *WithPipeline) WaitForStopConsuming(timeout time.Duration) error {
+	return WaitForStopConsuming(i.in, timeout)
+}
+
 // WaitForClose is a blocking call to wait until the object has finished closing
 // down and cleaning up resources.
 func (i *WithPipeline) WaitForClose(timeout time.Duration) error {
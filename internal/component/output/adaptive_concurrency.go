@@ -0,0 +1,156 @@
+package output
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// AdaptiveConcurrencyConfig contains configuration fields for adjusting the
+// number of messages an output has in flight based on observed latency and
+// errors.
+type AdaptiveConcurrencyConfig struct {
+	Enabled          bool    `json:"enabled" yaml:"enabled"`
+	MinInFlight      int     `json:"min_in_flight" yaml:"min_in_flight"`
+	LatencyTolerance float64 `json:"latency_tolerance" yaml:"latency_tolerance"`
+	BackoffRatio     float64 `json:"backoff_ratio" yaml:"backoff_ratio"`
+}
+
+// NewAdaptiveConcurrencyConfig creates an AdaptiveConcurrencyConfig with
+// default values.
+func NewAdaptiveConcurrencyConfig() AdaptiveConcurrencyConfig {
+	return AdaptiveConcurrencyConfig{
+		Enabled:          false,
+		MinInFlight:      1,
+		LatencyTolerance: 2,
+		BackoffRatio:     0.9,
+	}
+}
+
+// AdaptiveConcurrencyFieldSpec returns a field spec for an adaptive
+// concurrency config.
+func AdaptiveConcurrencyFieldSpec() docs.FieldSpec {
+	return docs.FieldObject(
+		"adaptive_concurrency",
+		"Allows the number of messages in flight to be adjusted automatically based on the latency and errors of writes. When enabled the number of messages in flight starts at `min_in_flight` and is increased gradually up to `max_in_flight` while writes remain healthy, and is reduced multiplicatively when writes fail or their latency exceeds the `latency_tolerance`. The current limit is exposed as the gauge `output_in_flight_limit`.",
+	).WithChildren(
+		docs.FieldBool("enabled", "Whether to adjust the number of messages in flight automatically.").HasDefault(false),
+		docs.FieldInt("min_in_flight", "The minimum number of messages to have in flight at a given time.").HasDefault(1),
+		docs.FieldFloat("latency_tolerance", "The ratio of the latency of a write to the long term average latency of writes above which the number of messages in flight is reduced.").HasDefault(2.0),
+		docs.FieldFloat("backoff_ratio", "The ratio, between 0 and 1, that the number of messages in flight is multiplied by when it is reduced.").HasDefault(0.9),
+	).Advanced().AtVersion("4.4.0")
+}
+
+//------------------------------------------------------------------------------
+
+// The weight given to each observed latency when updating the long term
+// average latency of writes.
+const adaptiveLatencySmoothing = 0.05
+
+// adaptiveLimiter implements additive increase multiplicative decrease (AIMD)
+// of a concurrency limit. Writers are identified by an index, and a writer may
+// only proceed while its index is below the current limit.
+type adaptiveLimiter struct {
+	mut       sync.Mutex
+	limit     float64
+	baseline  float64
+	changedCh chan struct{}
+
+	min, max     float64
+	tolerance    float64
+	backoffRatio float64
+
+	mLimit metrics.StatGauge
+}
+
+func newAdaptiveLimiter(conf AdaptiveConcurrencyConfig, maxInFlight int, stats metrics.Type) (*adaptiveLimiter, error) {
+	if conf.LatencyTolerance <= 1 {
+		return nil, errors.New("adaptive concurrency latency_tolerance must be greater than one")
+	}
+	if conf.BackoffRatio <= 0 || conf.BackoffRatio >= 1 {
+		return nil, errors.New("adaptive concurrency backoff_ratio must be between zero and one")
+	}
+	if conf.MinInFlight < 1 {
+		return nil, errors.New("adaptive concurrency min_in_flight must be at least one")
+	}
+
+	minInFlight := conf.MinInFlight
+	if minInFlight > maxInFlight {
+		minInFlight = maxInFlight
+	}
+
+	a := &adaptiveLimiter{
+		limit:        float64(minInFlight),
+		changedCh:    make(chan struct{}),
+		min:          float64(minInFlight),
+		max:          float64(maxInFlight),
+		tolerance:    conf.LatencyTolerance,
+		backoffRatio: conf.BackoffRatio,
+		mLimit:       stats.GetGauge("output_in_flight_limit"),
+	}
+	a.mLimit.Set(int64(minInFlight))
+	return a, nil
+}
+
+// Limit returns the current concurrency limit.
+func (a *adaptiveLimiter) Limit() int {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	return int(a.limit)
+}
+
+// Wait blocks until the writer of the given index is within the current limit,
+// returning false if the done channel is closed first.
+func (a *adaptiveLimiter) Wait(index int, done <-chan struct{}) bool {
+	for {
+		a.mut.Lock()
+		if index < int(a.limit) {
+			a.mut.Unlock()
+			return true
+		}
+		changedCh := a.changedCh
+		a.mut.Unlock()
+
+		select {
+		case <-changedCh:
+		case <-done:
+			return false
+		}
+	}
+}
+
+// Record adjusts the limit based on the outcome of a write.
+func (a *adaptiveLimiter) Record(latencyNs int64, err error) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	prevLimit := int(a.limit)
+	if err != nil {
+		a.limit *= a.backoffRatio
+	} else {
+		latency := float64(latencyNs)
+		if a.baseline == 0 {
+			a.baseline = latency
+		}
+		if latency > a.baseline*a.tolerance {
+			a.limit *= a.backoffRatio
+		} else {
+			a.limit += 1 / a.limit
+		}
+		a.baseline += (latency - a.baseline) * adaptiveLatencySmoothing
+	}
+
+	if a.limit < a.min {
+		a.limit = a.min
+	} else if a.limit > a.max {
+		a.limit = a.max
+	}
+
+	if newLimit := int(a.limit); newLimit != prevLimit {
+		a.mLimit.Set(int64(newLimit))
+		close(a.changedCh)
+		a.changedCh = make(chan struct{})
+	}
+}
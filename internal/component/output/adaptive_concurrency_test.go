@@ -0,0 +1,91 @@
+package output
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+)
+
+func TestAdaptiveLimiterBadConfig(t *testing.T) {
+	for name, fn := range map[string]func(c *AdaptiveConcurrencyConfig){
+		"min in flight":     func(c *AdaptiveConcurrencyConfig) { c.MinInFlight = 0 },
+		"latency tolerance": func(c *AdaptiveConcurrencyConfig) { c.LatencyTolerance = 1 },
+		"backoff ratio":     func(c *AdaptiveConcurrencyConfig) { c.BackoffRatio = 1 },
+	} {
+		conf := NewAdaptiveConcurrencyConfig()
+		fn(&conf)
+		_, err := newAdaptiveLimiter(conf, 10, metrics.Noop())
+		assert.Error(t, err, name)
+	}
+}
+
+func TestAdaptiveLimiterAIMD(t *testing.T) {
+	conf := NewAdaptiveConcurrencyConfig()
+	conf.BackoffRatio = 0.5
+
+	a, err := newAdaptiveLimiter(conf, 4, metrics.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, 1, a.Limit())
+
+	for i := 0; i < 100; i++ {
+		a.Record(int64(time.Millisecond), nil)
+	}
+	assert.Equal(t, 4, a.Limit())
+
+	a.Record(int64(time.Millisecond), errors.New("nope"))
+	assert.Equal(t, 2, a.Limit())
+
+	// A write far slower than the average reduces the limit.
+	a.Record(int64(time.Second), nil)
+	assert.Equal(t, 1, a.Limit())
+
+	a.Record(int64(time.Millisecond), errors.New("nope"))
+	assert.Equal(t, 1, a.Limit())
+}
+
+func TestAdaptiveLimiterWait(t *testing.T) {
+	a, err := newAdaptiveLimiter(NewAdaptiveConcurrencyConfig(), 2, metrics.Noop())
+	require.NoError(t, err)
+
+	doneChan := make(chan struct{})
+	assert.True(t, a.Wait(0, doneChan))
+
+	waitResChan := make(chan bool)
+	go func() {
+		waitResChan <- a.Wait(1, doneChan)
+	}()
+
+	select {
+	case <-waitResChan:
+		t.Fatal("expected writer to wait")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	for a.Limit() < 2 {
+		a.Record(int64(time.Millisecond), nil)
+	}
+
+	select {
+	case res := <-waitResChan:
+		assert.True(t, res)
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	go func() {
+		waitResChan <- a.Wait(2, doneChan)
+	}()
+	close(doneChan)
+
+	select {
+	case res := <-waitResChan:
+		assert.False(t, res)
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+}
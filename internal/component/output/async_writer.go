@@ -9,6 +9,7 @@ import (
 	"github.com/cenkalti/backoff/v4"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/benthosdev/benthos/v4/internal/api"
 	"github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/component"
@@ -45,6 +46,7 @@ type AsyncSink interface {
 // AsyncWriter is an output type that writes messages to a writer.Type.
 type AsyncWriter struct {
 	isConnected int32
+	inFlight    int64
 
 	typeStr     string
 	maxInflight int
@@ -52,10 +54,13 @@ type AsyncWriter struct {
 	writer      AsyncSink
 
 	injectTracingMap *mapping.Executor
+	adaptiveConf     *AdaptiveConcurrencyConfig
 
 	log    log.Modular
 	stats  metrics.Type
 	tracer trace.TracerProvider
+	tap    *api.TapPoint
+	health *api.HealthTracker
 
 	transactions <-chan message.Transaction
 
@@ -71,6 +76,8 @@ func NewAsyncWriter(typeStr string, maxInflight int, w AsyncSink, mgr component.
 		log:          mgr.Logger(),
 		stats:        mgr.Metrics(),
 		tracer:       mgr.Tracer(),
+		tap:          api.TapPointFor(mgr),
+		health:       api.HealthTrackerFor(mgr, api.HealthKindOutput),
 		transactions: nil,
 		shutSig:      shutdown.NewSignaller(),
 	}
@@ -92,6 +99,22 @@ func (w *AsyncWriter) SetNoCancel() {
 	w.noCancel = true
 }
 
+// SetAdaptiveConcurrency configures the async writer to adjust the number of
+// messages in flight based on the latency and errors of writes, where the
+// maximum in flight given to the writer is used as an upper bound.
+func (w *AsyncWriter) SetAdaptiveConcurrency(conf AdaptiveConcurrencyConfig) error {
+	if !conf.Enabled {
+		w.adaptiveConf = nil
+		return nil
+	}
+	// Validate the config early so that errors surface at construction.
+	if _, err := newAdaptiveLimiter(conf, w.maxInflight, metrics.Noop()); err != nil {
+		return err
+	}
+	w.adaptiveConf = &conf
+	return nil
+}
+
 //------------------------------------------------------------------------------
 
 func (w *AsyncWriter) latencyMeasuringWrite(msg *message.Batch) (latencyNs int64, err error) {
@@ -160,6 +183,8 @@ func (w *AsyncWriter) loop() {
 		_ = w.writer.WaitForClose(shutdown.MaximumShutdownWait())
 
 		atomic.StoreInt32(&w.isConnected, 0)
+		w.health.Close()
+		w.tap.Close()
 		w.shutSig.ShutdownComplete()
 	}()
 
@@ -181,6 +206,7 @@ func (w *AsyncWriter) loop() {
 				}
 				w.log.Errorf("Failed to connect to %v: %v\n", w.typeStr, err)
 				mFailedConn.Incr(1)
+				w.health.Error(err)
 				select {
 				case <-time.After(connBackoff.NextBackOff()):
 				case <-initConnCtx.Done():
@@ -197,6 +223,16 @@ func (w *AsyncWriter) loop() {
 	}
 	mConn.Incr(1)
 	atomic.StoreInt32(&w.isConnected, 1)
+	w.health.SetConnected(true)
+
+	var limiter *adaptiveLimiter
+	if w.adaptiveConf != nil {
+		var err error
+		if limiter, err = newAdaptiveLimiter(*w.adaptiveConf, w.maxInflight, w.stats); err != nil {
+			w.log.Errorf("Failed to create adaptive concurrency limiter: %v\n", err)
+			return
+		}
+	}
 
 	wg := sync.WaitGroup{}
 	wg.Add(w.maxInflight)
@@ -218,6 +254,7 @@ func (w *AsyncWriter) loop() {
 			}
 		}
 		mLostConn.Incr(1)
+		w.health.SetConnected(false)
 
 		// Continue to try to reconnect while still active.
 		for {
@@ -228,6 +265,7 @@ func (w *AsyncWriter) loop() {
 			if latency, err = w.latencyMeasuringWrite(msg); err != component.ErrNotConnected {
 				atomic.StoreInt32(&w.isConnected, 1)
 				mConn.Incr(1)
+				w.health.SetConnected(true)
 				return
 			} else if err != nil {
 				mError.Incr(1)
@@ -235,10 +273,14 @@ func (w *AsyncWriter) loop() {
 		}
 	}
 
-	writerLoop := func() {
+	writerLoop := func(index int) {
 		defer wg.Done()
 
 		for {
+			if limiter != nil && !limiter.Wait(index, w.shutSig.CloseAtLeisureChan()) {
+				return
+			}
+
 			var ts message.Transaction
 			var open bool
 			select {
@@ -250,9 +292,15 @@ func (w *AsyncWriter) loop() {
 				return
 			}
 
+			inFlight := int64(ts.Payload.Len())
+			atomic.AddInt64(&w.inFlight, inFlight)
+
 			w.log.Tracef("Attempting to write %v messages to '%v'.\n", ts.Payload.Len(), w.typeStr)
 			spans := tracing.CreateChildSpans(w.tracer, "output_"+w.typeStr, ts.Payload)
 			ts.Payload = w.injectSpans(ts.Payload, spans)
+			if w.tap.Active() {
+				w.tap.Offer(ts.Payload)
+			}
 
 			latency, err := w.latencyMeasuringWrite(ts.Payload)
 
@@ -265,14 +313,20 @@ func (w *AsyncWriter) loop() {
 
 			// Close immediately if our writer is closed.
 			if err == component.ErrTypeClosed {
+				atomic.AddInt64(&w.inFlight, -inFlight)
 				return
 			}
 
+			if limiter != nil {
+				limiter.Record(latency, err)
+			}
+
 			if err != nil {
 				if w.typeStr != "reject" {
 					// TODO: Maybe reintroduce a sleep here if we encounter a
 					// busy retry loop.
 					w.log.Errorf("Failed to send message to %v: %v\n", w.typeStr, err)
+					w.health.Error(err)
 				} else {
 					w.log.Debugf("Rejecting message: %v\n", err)
 				}
@@ -280,6 +334,7 @@ func (w *AsyncWriter) loop() {
 				mBatchSent.Incr(1)
 				mSent.Incr(int64(batch.MessageCollapsedCount(ts.Payload)))
 				mLatency.Timing(latency)
+				w.health.Success()
 				w.log.Tracef("Successfully wrote %v messages to '%v'.\n", ts.Payload.Len(), w.typeStr)
 			}
 
@@ -288,11 +343,12 @@ func (w *AsyncWriter) loop() {
 			}
 
 			_ = ts.Ack(closeLeisureCtx, err)
+			atomic.AddInt64(&w.inFlight, -inFlight)
 		}
 	}
 
 	for i := 0; i < w.maxInflight; i++ {
-		go writerLoop()
+		go writerLoop(i)
 	}
 	wg.Wait()
 }
@@ -313,6 +369,11 @@ func (w *AsyncWriter) Connected() bool {
 	return atomic.LoadInt32(&w.isConnected) == 1
 }
 
+// InFlight returns the number of messages currently being written.
+func (w *AsyncWriter) InFlight() (int, bool) {
+	return int(atomic.LoadInt64(&w.inFlight)), true
+}
+
 // CloseAsync shuts down the File output and stops processing messages.
 func (w *AsyncWriter) CloseAsync() {
 	w.shutSig.CloseAtLeisure()
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/component"
@@ -629,3 +630,59 @@ func TestAsyncWriterSadPath(t *testing.T) {
 		t.Errorf("Wrong message sent: %v != %v", act, exp)
 	}
 }
+
+func TestAsyncWriterAdaptiveConcurrency(t *testing.T) {
+	t.Parallel()
+
+	writerImpl := newAsyncMockWriter()
+
+	w, err := NewAsyncWriter("foo", 4, writerImpl, component.NoopObservability())
+	require.NoError(t, err)
+
+	conf := NewAdaptiveConcurrencyConfig()
+	conf.Enabled = true
+	require.NoError(t, w.(*AsyncWriter).SetAdaptiveConcurrency(conf))
+
+	msgChan := make(chan message.Transaction)
+	resChan := make(chan error)
+	require.NoError(t, w.Consume(msgChan))
+
+	select {
+	case writerImpl.connChan <- nil:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	go func() {
+		for i := 0; i < 2; i++ {
+			select {
+			case msgChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("foo")}), resChan):
+			case <-time.After(time.Second):
+				t.Error("Timed out")
+			}
+		}
+	}()
+
+	// With a limit of one the second transaction must not be written until
+	// the first has completed.
+	<-time.After(time.Millisecond * 50)
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&writerImpl.msgsTotal))
+
+	for i := 0; i < 2; i++ {
+		select {
+		case writerImpl.writeChan <- nil:
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+		select {
+		case res := <-resChan:
+			require.NoError(t, res)
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+	assert.Equal(t, uint64(2), atomic.LoadUint64(&writerImpl.msgsTotal))
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second))
+}
@@ -3,6 +3,8 @@ package batcher
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/benthosdev/benthos/v4/internal/batch/policy"
@@ -19,6 +21,8 @@ import (
 
 // Impl wraps an output with a batching policy.
 type Impl struct {
+	pending int64
+
 	stats metrics.Type
 	log   log.Modular
 
@@ -28,6 +32,9 @@ type Impl struct {
 	messagesIn  <-chan message.Transaction
 	messagesOut chan message.Transaction
 
+	flushChan chan struct{}
+	flushOnce sync.Once
+
 	shutSig *shutdown.Signaller
 }
 
@@ -53,6 +60,7 @@ func New(batcher *policy.Batcher, child output.Streamed, mgr bundle.NewManagemen
 		child:       child,
 		batcher:     batcher,
 		messagesOut: make(chan message.Transaction),
+		flushChan:   make(chan struct{}),
 		shutSig:     shutdown.NewSignaller(),
 	}
 	return &m
@@ -61,8 +69,16 @@ func New(batcher *policy.Batcher, child output.Streamed, mgr bundle.NewManagemen
 //------------------------------------------------------------------------------
 
 func (m *Impl) loop() {
+	pendingAcks := sync.WaitGroup{}
 	defer func() {
 		close(m.messagesOut)
+
+		// Wait for the child output to resolve flushed batches before closing
+		// it, otherwise the final acknowledgements would be lost.
+		m.log.Debugln("Waiting for pending acks to resolve before shutting down.")
+		pendingAcks.Wait()
+		m.log.Debugln("Pending acks resolved.")
+
 		m.child.CloseAsync()
 		_ = m.child.WaitForClose(shutdown.MaximumShutdownWait())
 
@@ -77,6 +93,11 @@ func (m *Impl) loop() {
 		nextTimedBatchChan = time.After(tNext)
 	}
 
+	// Once a flush is triggered every message is flushed as soon as it
+	// arrives.
+	flushChan := m.flushChan
+	var flushing bool
+
 	var pendingTrans []*transaction.Tracked
 	for !m.shutSig.ShouldCloseAtLeisure() {
 		if nextTimedBatchChan == nil {
@@ -97,6 +118,7 @@ func (m *Impl) loop() {
 				if nextTimedBatchChan != nil {
 					select {
 					case <-nextTimedBatchChan:
+					case <-flushChan:
 					case <-m.shutSig.CloseAtLeisureChan():
 					}
 				}
@@ -109,10 +131,17 @@ func (m *Impl) loop() {
 					return nil
 				})
 				pendingTrans = append(pendingTrans, trackedTran)
+				atomic.AddInt64(&m.pending, int64(trackedTran.Message().Len()))
+				if flushing {
+					flushBatch = true
+				}
 			}
 		case <-nextTimedBatchChan:
 			flushBatch = true
 			nextTimedBatchChan = nil
+		case <-flushChan:
+			flushBatch, flushing = true, true
+			flushChan = nil
 		case <-m.shutSig.CloseAtLeisureChan():
 			flushBatch = true
 		}
@@ -121,8 +150,12 @@ func (m *Impl) loop() {
 			continue
 		}
 
+		// Flushed messages remain pending until they're acknowledged, as the
+		// child output might not yet have received them.
+		flushedCount := int64(m.batcher.Count())
 		sendMsg := m.batcher.Flush()
 		if sendMsg == nil {
+			atomic.AddInt64(&m.pending, -flushedCount)
 			continue
 		}
 
@@ -133,7 +166,10 @@ func (m *Impl) loop() {
 			return
 		}
 
-		go func(rChan chan error, upstreamTrans []*transaction.Tracked) {
+		pendingAcks.Add(1)
+		go func(rChan chan error, upstreamTrans []*transaction.Tracked, count int64) {
+			defer pendingAcks.Done()
+			defer atomic.AddInt64(&m.pending, -count)
 			select {
 			case <-m.shutSig.CloseAtLeisureChan():
 				return
@@ -150,7 +186,7 @@ func (m *Impl) loop() {
 				}
 				done()
 			}
-		}(resChan, pendingTrans)
+		}(resChan, pendingTrans, flushedCount)
 		pendingTrans = nil
 	}
 }
@@ -174,6 +210,21 @@ func (m *Impl) Consume(msgs <-chan message.Transaction) error {
 	return nil
 }
 
+// InFlight returns the number of messages pending within the batch as well as
+// those flushed to the child output that are yet to be acknowledged.
+func (m *Impl) InFlight() (int, bool) {
+	return int(atomic.LoadInt64(&m.pending)), true
+}
+
+// TriggerFlush signals the batcher to flush its pending batch immediately, and
+// to flush each message as it arrives from then on.
+func (m *Impl) TriggerFlush() {
+	m.flushOnce.Do(func() {
+		close(m.flushChan)
+	})
+	output.TriggerFlush(m.child)
+}
+
 // CloseAsync shuts down the Batcher and stops processing messages.
 func (m *Impl) CloseAsync() {
 	m.shutSig.CloseAtLeisure()
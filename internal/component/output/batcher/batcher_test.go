@@ -388,3 +388,119 @@ func TestBatcherTimed(t *testing.T) {
 
 	close(resChan)
 }
+
+func TestBatcherTriggerFlush(t *testing.T) {
+	tInChan := make(chan message.Transaction)
+	resChan := make(chan error)
+
+	policyConf := batchconfig.NewConfig()
+	policyConf.Count = 10
+	policyConf.Period = "1h"
+	batchPol, err := policy.New(policyConf, mock.NewManager())
+	require.NoError(t, err)
+
+	out := &mock.OutputChanneled{}
+
+	b := batcher.New(batchPol, out, mock.NewManager())
+	require.NoError(t, b.Consume(tInChan))
+
+	tOutChan := out.TChan
+
+	select {
+	case tInChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("foo1")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for message send")
+	}
+
+	select {
+	case <-tOutChan:
+		t.Fatal("Batch flushed before trigger")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	b.(*batcher.Impl).TriggerFlush()
+
+	// The pending batch is flushed, and messages that arrive afterwards are
+	// flushed individually.
+	for _, exp := range []string{"foo1", "foo2"} {
+		var outTr message.Transaction
+		select {
+		case outTr = <-tOutChan:
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for message read")
+		}
+		assert.Equal(t, [][]byte{[]byte(exp)}, message.GetAllBytes(outTr.Payload))
+		require.NoError(t, outTr.Ack(context.Background(), nil))
+
+		if exp == "foo1" {
+			select {
+			case tInChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("foo2")}), resChan):
+			case <-time.After(time.Second):
+				t.Fatal("Timed out waiting for message send")
+			}
+		}
+	}
+
+	close(tInChan)
+	b.CloseAsync()
+	require.NoError(t, b.WaitForClose(time.Second))
+}
+
+func TestBatcherInFlightSlowChild(t *testing.T) {
+	tInChan := make(chan message.Transaction)
+	resChan := make(chan error)
+
+	policyConf := batchconfig.NewConfig()
+	policyConf.Count = 2
+	batchPol, err := policy.New(policyConf, mock.NewManager())
+	require.NoError(t, err)
+
+	out := &mock.OutputChanneled{}
+
+	b := batcher.New(batchPol, out, mock.NewManager())
+	require.NoError(t, b.Consume(tInChan))
+
+	inFlight := func() int {
+		n, ok := b.(*batcher.Impl).InFlight()
+		require.True(t, ok)
+		return n
+	}
+
+	for _, content := range []string{"foo1", "foo2"} {
+		select {
+		case tInChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte(content)}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for message send")
+		}
+	}
+
+	// The batch has been flushed but the child output is yet to receive it,
+	// and so it remains in flight.
+	<-time.After(time.Millisecond * 50)
+	assert.Equal(t, 2, inFlight())
+
+	var outTr message.Transaction
+	select {
+	case outTr = <-out.TChan:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for message read")
+	}
+	assert.Equal(t, 2, inFlight())
+
+	require.NoError(t, outTr.Ack(context.Background(), nil))
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-resChan:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for ack")
+		}
+	}
+	assert.Eventually(t, func() bool {
+		return inFlight() == 0
+	}, time.Second, time.Millisecond*10)
+
+	close(tInChan)
+	b.CloseAsync()
+	require.NoError(t, b.WaitForClose(time.Second))
+}
@@ -14,6 +14,7 @@ type AMQP1Config struct {
 	TLS           btls.Config                  `json:"tls" yaml:"tls"`
 	SASL          shared.SASLConfig            `json:"sasl" yaml:"sasl"`
 	Metadata      metadata.ExcludeFilterConfig `json:"metadata" yaml:"metadata"`
+	CloudEvents   string                       `json:"cloudevents" yaml:"cloudevents"`
 }
 
 // NewAMQP1Config creates a new AMQP1Config with default values.
@@ -25,5 +26,6 @@ func NewAMQP1Config() AMQP1Config {
 		TLS:           btls.NewConfig(),
 		SASL:          shared.NewSASLConfig(),
 		Metadata:      metadata.NewExcludeFilterConfig(),
+		CloudEvents:   "none",
 	}
 }
@@ -13,15 +13,16 @@ type SessionConfig struct {
 
 // DynamoDBConfig contains config fields for the DynamoDB output type.
 type DynamoDBConfig struct {
-	SessionConfig  `json:",inline" yaml:",inline"`
-	Table          string            `json:"table" yaml:"table"`
-	StringColumns  map[string]string `json:"string_columns" yaml:"string_columns"`
-	JSONMapColumns map[string]string `json:"json_map_columns" yaml:"json_map_columns"`
-	TTL            string            `json:"ttl" yaml:"ttl"`
-	TTLKey         string            `json:"ttl_key" yaml:"ttl_key"`
-	MaxInFlight    int               `json:"max_in_flight" yaml:"max_in_flight"`
-	retries.Config `json:",inline" yaml:",inline"`
-	Batching       batchconfig.Config `json:"batching" yaml:"batching"`
+	SessionConfig       `json:",inline" yaml:",inline"`
+	Table               string                    `json:"table" yaml:"table"`
+	StringColumns       map[string]string         `json:"string_columns" yaml:"string_columns"`
+	JSONMapColumns      map[string]string         `json:"json_map_columns" yaml:"json_map_columns"`
+	TTL                 string                    `json:"ttl" yaml:"ttl"`
+	TTLKey              string                    `json:"ttl_key" yaml:"ttl_key"`
+	MaxInFlight         int                       `json:"max_in_flight" yaml:"max_in_flight"`
+	AdaptiveConcurrency AdaptiveConcurrencyConfig `json:"adaptive_concurrency" yaml:"adaptive_concurrency"`
+	retries.Config      `json:",inline" yaml:",inline"`
+	Batching            batchconfig.Config `json:"batching" yaml:"batching"`
 }
 
 // NewDynamoDBConfig creates a DynamoDBConfig populated with default values.
@@ -35,13 +36,14 @@ func NewDynamoDBConfig() DynamoDBConfig {
 		SessionConfig: SessionConfig{
 			Config: sess.NewConfig(),
 		},
-		Table:          "",
-		StringColumns:  map[string]string{},
-		JSONMapColumns: map[string]string{},
-		TTL:            "",
-		TTLKey:         "",
-		MaxInFlight:    64,
-		Config:         rConf,
-		Batching:       batchconfig.NewConfig(),
+		Table:               "",
+		StringColumns:       map[string]string{},
+		JSONMapColumns:      map[string]string{},
+		TTL:                 "",
+		TTLKey:              "",
+		MaxInFlight:         64,
+		AdaptiveConcurrency: NewAdaptiveConcurrencyConfig(),
+		Config:              rConf,
+		Batching:            batchconfig.NewConfig(),
 	}
 }
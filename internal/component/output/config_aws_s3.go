@@ -4,6 +4,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/batch/policy/batchconfig"
 	sess "github.com/benthosdev/benthos/v4/internal/impl/aws/session"
 	"github.com/benthosdev/benthos/v4/internal/metadata"
+	"github.com/benthosdev/benthos/v4/internal/rolling/rollingconfig"
 )
 
 // AmazonS3Config contains configuration fields for the AmazonS3 output type.
@@ -26,6 +27,7 @@ type AmazonS3Config struct {
 	ServerSideEncryption    string                       `json:"server_side_encryption" yaml:"server_side_encryption"`
 	MaxInFlight             int                          `json:"max_in_flight" yaml:"max_in_flight"`
 	Batching                batchconfig.Config           `json:"batching" yaml:"batching"`
+	Rolling                 rollingconfig.Config         `json:"rolling" yaml:"rolling"`
 }
 
 // NewAmazonS3Config creates a new Config with default values.
@@ -49,5 +51,6 @@ func NewAmazonS3Config() AmazonS3Config {
 		ServerSideEncryption:    "",
 		MaxInFlight:             64,
 		Batching:                batchconfig.NewConfig(),
+		Rolling:                 rollingconfig.NewConfig(),
 	}
 }
@@ -1,15 +1,21 @@
 package output
 
+import "github.com/benthosdev/benthos/v4/internal/rolling/rollingconfig"
+
 // FileConfig contains configuration fields for the file based output type.
 type FileConfig struct {
-	Path  string `json:"path" yaml:"path"`
-	Codec string `json:"codec" yaml:"codec"`
+	Path        string               `json:"path" yaml:"path"`
+	Codec       string               `json:"codec" yaml:"codec"`
+	MaxInFlight int                  `json:"max_in_flight" yaml:"max_in_flight"`
+	Rolling     rollingconfig.Config `json:"rolling" yaml:"rolling"`
 }
 
 // NewFileConfig creates a new FileConfig with default values.
 func NewFileConfig() FileConfig {
 	return FileConfig{
-		Path:  "",
-		Codec: "lines",
+		Path:        "",
+		Codec:       "lines",
+		MaxInFlight: 64,
+		Rolling:     rollingconfig.NewConfig(),
 	}
 }
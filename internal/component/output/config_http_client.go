@@ -18,21 +18,25 @@ type HTTPClientMultipartExpression struct {
 // HTTPClientConfig contains configuration fields for the HTTPClient output
 // type.
 type HTTPClientConfig struct {
-	docs.Config       `json:",inline" yaml:",inline"`
-	BatchAsMultipart  bool                            `json:"batch_as_multipart" yaml:"batch_as_multipart"`
-	MaxInFlight       int                             `json:"max_in_flight" yaml:"max_in_flight"`
-	PropagateResponse bool                            `json:"propagate_response" yaml:"propagate_response"`
-	Batching          batchconfig.Config              `json:"batching" yaml:"batching"`
-	Multipart         []HTTPClientMultipartExpression `json:"multipart" yaml:"multipart"`
+	docs.Config         `json:",inline" yaml:",inline"`
+	BatchAsMultipart    bool                            `json:"batch_as_multipart" yaml:"batch_as_multipart"`
+	MaxInFlight         int                             `json:"max_in_flight" yaml:"max_in_flight"`
+	AdaptiveConcurrency AdaptiveConcurrencyConfig       `json:"adaptive_concurrency" yaml:"adaptive_concurrency"`
+	PropagateResponse   bool                            `json:"propagate_response" yaml:"propagate_response"`
+	Batching            batchconfig.Config              `json:"batching" yaml:"batching"`
+	Multipart           []HTTPClientMultipartExpression `json:"multipart" yaml:"multipart"`
+	CloudEvents         string                          `json:"cloudevents" yaml:"cloudevents"`
 }
 
 // NewHTTPClientConfig creates a new HTTPClientConfig with default values.
 func NewHTTPClientConfig() HTTPClientConfig {
 	return HTTPClientConfig{
-		Config:            docs.NewConfig(),
-		BatchAsMultipart:  false,
-		MaxInFlight:       64,
-		PropagateResponse: false,
-		Batching:          batchconfig.NewConfig(),
+		Config:              docs.NewConfig(),
+		BatchAsMultipart:    false,
+		MaxInFlight:         64,
+		AdaptiveConcurrency: NewAdaptiveConcurrencyConfig(),
+		PropagateResponse:   false,
+		Batching:            batchconfig.NewConfig(),
+		CloudEvents:         "none",
 	}
 }
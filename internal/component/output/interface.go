@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
 
@@ -44,3 +45,41 @@ type Streamed interface {
 	// shutting down and cleaning up resources.
 	WaitForClose(timeout time.Duration) error
 }
+
+// InFlightSum returns the total number of messages in flight across a slice of
+// outputs, and false if any of the outputs are unable to report them.
+func InFlightSum(outputs []Streamed) (int, bool) {
+	total, known := 0, true
+	for _, out := range outputs {
+		n, ok := component.InFlightOf(out)
+		total += n
+		known = known && ok
+	}
+	return total, known
+}
+
+// Flusher is implemented by outputs that hold messages in pending batches, and
+// by those that wrap other outputs, in order to support flushing those batches
+// ahead of time during a graceful shut down.
+type Flusher interface {
+	// TriggerFlush signals the output to flush any pending batches without
+	// waiting for their batching policies to be met, and to continue flushing
+	// messages as they arrive from then on. This call must not block.
+	TriggerFlush()
+}
+
+// TriggerFlush signals an output to flush any pending batches if it
+// implements Flusher, otherwise this is a no-op.
+func TriggerFlush(o interface{}) {
+	if f, ok := o.(Flusher); ok {
+		f.TriggerFlush()
+	}
+}
+
+// TriggerFlushAll signals each of a slice of outputs to flush any pending
+// batches.
+func TriggerFlushAll(outputs []Streamed) {
+	for _, out := range outputs {
+		TriggerFlush(out)
+	}
+}
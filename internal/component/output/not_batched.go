@@ -3,6 +3,7 @@ package output
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/benthosdev/benthos/v4/internal/batch"
@@ -12,6 +13,8 @@ import (
 )
 
 type notBatchedOutput struct {
+	inFlight int64
+
 	out Streamed
 
 	inChan  <-chan message.Transaction
@@ -93,8 +96,22 @@ func (n *notBatchedOutput) breakMessageOut(msg *message.Batch) error {
 }
 
 func (n *notBatchedOutput) loop() {
+	var pendingAcks sync.WaitGroup
 	defer func() {
 		close(n.outChan)
+
+		// Wait for the wrapped output to resolve the messages it holds before
+		// closing it, otherwise they would be aborted.
+		acksDone := make(chan struct{})
+		go func() {
+			pendingAcks.Wait()
+			close(acksDone)
+		}()
+		select {
+		case <-acksDone:
+		case <-n.shutSig.CloseAtLeisureChan():
+		}
+
 		n.out.CloseAsync()
 		_ = n.out.WaitForClose(shutdown.MaximumShutdownWait())
 		n.shutSig.ShutdownComplete()
@@ -115,10 +132,25 @@ func (n *notBatchedOutput) loop() {
 			return
 		}
 
+		// Messages remain in flight until they're acknowledged, as the
+		// wrapped output might not yet have received them.
+		count := int64(tran.Payload.Len())
+		atomic.AddInt64(&n.inFlight, count)
+
 		if tran.Payload.Len() == 1 {
+			upstreamTran := tran
+			tran = message.NewTransactionFunc(tran.Payload, func(ctx context.Context, err error) error {
+				defer func() {
+					atomic.AddInt64(&n.inFlight, -count)
+					pendingAcks.Done()
+				}()
+				return upstreamTran.Ack(ctx, err)
+			})
+			pendingAcks.Add(1)
 			select {
-			case n.outChan <- tran:
+			case n.outChan <- *tran.WithContext(upstreamTran.Context()):
 			case <-n.shutSig.CloseNowChan():
+				pendingAcks.Done()
 				return
 			}
 		} else {
@@ -130,6 +162,7 @@ func (n *notBatchedOutput) loop() {
 				res = err
 			}
 			_ = tran.Ack(ctx, res)
+			atomic.AddInt64(&n.inFlight, -count)
 		}
 	}
 }
@@ -152,6 +185,14 @@ func (n *notBatchedOutput) Connected() bool {
 	return n.out.Connected()
 }
 
+func (n *notBatchedOutput) InFlight() (int, bool) {
+	return int(atomic.LoadInt64(&n.inFlight)), true
+}
+
+func (n *notBatchedOutput) TriggerFlush() {
+	TriggerFlush(n.out)
+}
+
 func (n *notBatchedOutput) CloseAsync() {
 	n.shutSig.CloseAtLeisure()
 }
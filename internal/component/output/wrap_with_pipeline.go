@@ -3,6 +3,7 @@ package output
 import (
 	"time"
 
+	"github.com/benthosdev/benthos/v4/internal/component"
 	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
@@ -58,6 +59,17 @@ func (i *WithPipeline) Connected() bool {
 	return i.out.Connected()
 }
 
+// InFlight returns the number of messages held by the wrapped output that are
+// yet to be resolved.
+func (i *WithPipeline) InFlight() (int, bool) {
+	return component.InFlightOf(i.out)
+}
+
+// TriggerFlush signals the wrapped output to flush any pending batches.
+func (i *WithPipeline) TriggerFlush() {
+	TriggerFlush(i.out)
+}
+
 //------------------------------------------------------------------------------
 
 // CloseAsync triggers a closure of this object but does not block.
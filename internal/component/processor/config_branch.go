@@ -1,10 +1,24 @@
 package processor
 
+import (
+	"github.com/benthosdev/benthos/v4/internal/old/util/retries"
+)
+
 // BranchConfig contains configuration fields for the Branch processor.
 type BranchConfig struct {
-	RequestMap string   `json:"request_map" yaml:"request_map"`
-	Processors []Config `json:"processors" yaml:"processors"`
-	ResultMap  string   `json:"result_map" yaml:"result_map"`
+	RequestMap string            `json:"request_map" yaml:"request_map"`
+	Processors []Config          `json:"processors" yaml:"processors"`
+	ResultMap  string            `json:"result_map" yaml:"result_map"`
+	Check      string            `json:"check" yaml:"check"`
+	Timeout    string            `json:"timeout" yaml:"timeout"`
+	Retry      BranchRetryConfig `json:"retry" yaml:"retry"`
+}
+
+// BranchRetryConfig contains configuration fields for retrying the child
+// processors of a branch when they fail.
+type BranchRetryConfig struct {
+	MaxRetries int             `json:"max_retries" yaml:"max_retries"`
+	Backoff    retries.Backoff `json:"backoff" yaml:"backoff"`
 }
 
 // NewBranchConfig returns a BranchConfig with default values.
@@ -13,5 +27,15 @@ func NewBranchConfig() BranchConfig {
 		RequestMap: "",
 		Processors: []Config{},
 		ResultMap:  "",
+		Check:      "",
+		Timeout:    "",
+		Retry: BranchRetryConfig{
+			MaxRetries: 0,
+			Backoff: retries.Backoff{
+				InitialInterval: "500ms",
+				MaxInterval:     "3s",
+				MaxElapsedTime:  "0s",
+			},
+		},
 	}
 }
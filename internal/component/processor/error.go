@@ -1,6 +1,8 @@
 package processor
 
 import (
+	"errors"
+
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/tracing"
 )
@@ -24,3 +26,51 @@ func MarkErr(part *message.Part, span *tracing.Span, err error) {
 		)
 	}
 }
+
+// LabelledError is an error that a labelled processor has flagged a message
+// with, along with the label of that processor.
+type LabelledError struct {
+	Label string
+	Err   error
+}
+
+// Error implements the common error interface.
+func (e *LabelledError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *LabelledError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorLabel returns the label of the processor that flagged a message with an
+// error, and whether the error was flagged by a labelled processor.
+func ErrorLabel(err error) (string, bool) {
+	var lErr *LabelledError
+	if errors.As(err, &lErr) {
+		return lErr.Label, true
+	}
+	return "", false
+}
+
+// labelOf returns the label of the component a manager belongs to, or an empty
+// string if it has none.
+func labelOf(mgr interface{}) string {
+	if l, ok := mgr.(interface{ Label() string }); ok {
+		return l.Label()
+	}
+	return ""
+}
+
+// labelErr wraps an error with the label of the processor that caused it, or
+// returns it unchanged when the processor has no label.
+func labelErr(label string, err error) error {
+	if label == "" || err == nil {
+		return err
+	}
+	if _, isLabelled := ErrorLabel(err); isLabelled {
+		return err
+	}
+	return &LabelledError{Label: label, Err: err}
+}
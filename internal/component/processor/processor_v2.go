@@ -2,8 +2,10 @@ package processor
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/benthosdev/benthos/v4/internal/api"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/message"
@@ -46,6 +48,9 @@ type v2ToV1Processor struct {
 	p       V2
 	sig     *shutdown.Signaller
 	mgr     component.Observability
+	label   string
+	tap     *api.TapPoint
+	health  *api.HealthTracker
 
 	mReceived      metrics.StatCounter
 	mBatchReceived metrics.StatCounter
@@ -59,6 +64,9 @@ type v2ToV1Processor struct {
 func NewV2ToV1Processor(typeStr string, p V2, mgr component.Observability) V1 {
 	return &v2ToV1Processor{
 		typeStr: typeStr, p: p, sig: shutdown.NewSignaller(), mgr: mgr,
+		label:  labelOf(mgr),
+		tap:    api.TapPointFor(mgr),
+		health: api.HealthTrackerFor(mgr, api.HealthKindProcessor),
 
 		mReceived:      mgr.Metrics().GetCounter("processor_received"),
 		mBatchReceived: mgr.Metrics().GetCounter("processor_batch_received"),
@@ -77,6 +85,8 @@ func (a *v2ToV1Processor) ProcessMessage(msg *message.Batch) ([]*message.Batch,
 
 	newParts := make([]*message.Part, 0, msg.Len())
 
+	var firstErr error
+	var failed int
 	_ = msg.Iter(func(i int, part *message.Part) error {
 		span := tracing.CreateChildSpan(a.mgr.Tracer(), a.typeStr, part)
 
@@ -84,8 +94,11 @@ func (a *v2ToV1Processor) ProcessMessage(msg *message.Batch) ([]*message.Batch,
 		if err != nil {
 			newPart := part.Copy()
 			a.mError.Incr(1)
-			MarkErr(newPart, span, err)
+			MarkErr(newPart, span, labelErr(a.label, err))
 			nextParts = append(nextParts, newPart)
+			if failed++; firstErr == nil {
+				firstErr = err
+			}
 		}
 
 		span.Finish()
@@ -96,12 +109,23 @@ func (a *v2ToV1Processor) ProcessMessage(msg *message.Batch) ([]*message.Batch,
 	})
 
 	a.mLatency.Timing(time.Since(tStarted).Nanoseconds())
+
+	// The processor is considered failing only when it fails every message.
+	if failed > 0 && failed == msg.Len() {
+		a.health.Error(firstErr)
+	} else {
+		a.health.Success()
+	}
+
 	if len(newParts) == 0 {
 		return nil, nil
 	}
 
 	newMsg := message.QuickBatch(nil)
 	newMsg.SetAll(newParts)
+	if a.tap.Active() {
+		a.tap.Offer(newMsg)
+	}
 
 	a.mSent.Incr(int64(newMsg.Len()))
 	a.mBatchSent.Incr(1)
@@ -109,6 +133,8 @@ func (a *v2ToV1Processor) ProcessMessage(msg *message.Batch) ([]*message.Batch,
 }
 
 func (a *v2ToV1Processor) CloseAsync() {
+	a.health.Close()
+	a.tap.Close()
 	go func() {
 		if err := a.p.Close(context.Background()); err == nil {
 			a.sig.ShutdownComplete()
@@ -133,6 +159,9 @@ type v2BatchedToV1Processor struct {
 	p       V2Batched
 	sig     *shutdown.Signaller
 	mgr     component.Observability
+	label   string
+	tap     *api.TapPoint
+	health  *api.HealthTracker
 
 	mReceived      metrics.StatCounter
 	mBatchReceived metrics.StatCounter
@@ -147,6 +176,9 @@ type v2BatchedToV1Processor struct {
 func NewV2BatchedToV1Processor(typeStr string, p V2Batched, mgr component.Observability) V1 {
 	return &v2BatchedToV1Processor{
 		typeStr: typeStr, p: p, sig: shutdown.NewSignaller(), mgr: mgr,
+		label:  labelOf(mgr),
+		tap:    api.TapPointFor(mgr),
+		health: api.HealthTrackerFor(mgr, api.HealthKindProcessor),
 
 		mReceived:      mgr.Metrics().GetCounter("processor_received"),
 		mBatchReceived: mgr.Metrics().GetCounter("processor_batch_received"),
@@ -164,15 +196,29 @@ func (a *v2BatchedToV1Processor) ProcessMessage(msg *message.Batch) ([]*message.
 	tStarted := time.Now()
 	spans := tracing.CreateChildSpans(a.mgr.Tracer(), a.typeStr, msg)
 
+	priorFailed, _ := batchFailed(msg)
+	var priorErrs []error
+	if a.label != "" {
+		priorErrs = batchErrors(msg)
+	}
+
 	outputBatches, err := a.p.ProcessBatch(context.Background(), spans, msg)
 	if err != nil {
+		a.health.Error(err)
 		a.mError.Incr(1)
 		outputBatch := msg.Copy()
 		_ = outputBatch.Iter(func(i int, p *message.Part) error {
-			MarkErr(p, spans[i], err)
+			MarkErr(p, spans[i], labelErr(a.label, err))
 			return nil
 		})
 		outputBatches = append(outputBatches, outputBatch)
+	} else {
+		a.reportBatchedHealth(priorFailed, outputBatches)
+		if a.label != "" {
+			for _, m := range outputBatches {
+				labelNewErrors(a.label, priorErrs, m)
+			}
+		}
 	}
 
 	for _, s := range spans {
@@ -186,12 +232,83 @@ func (a *v2BatchedToV1Processor) ProcessMessage(msg *message.Batch) ([]*message.
 
 	for _, m := range outputBatches {
 		a.mSent.Incr(int64(m.Len()))
+		if a.tap.Active() {
+			a.tap.Offer(m)
+		}
 	}
 	a.mBatchSent.Incr(int64(len(outputBatches)))
 	return outputBatches, nil
 }
 
+// batchFailed returns whether all messages of a batch are flagged with errors,
+// along with the first error found.
+func batchFailed(msg *message.Batch) (all bool, first error) {
+	all = msg.Len() > 0
+	_ = msg.Iter(func(i int, p *message.Part) error {
+		if err := p.ErrorGet(); err == nil {
+			all = false
+		} else if first == nil {
+			first = err
+		}
+		return nil
+	})
+	return
+}
+
+// batchErrors returns the errors that messages of a batch are flagged with.
+func batchErrors(msg *message.Batch) (errs []error) {
+	_ = msg.Iter(func(i int, p *message.Part) error {
+		if err := p.ErrorGet(); err != nil {
+			errs = append(errs, err)
+		}
+		return nil
+	})
+	return
+}
+
+// labelNewErrors labels the errors of messages that were flagged by a
+// processor, which are those that messages were not flagged with before the
+// processor was executed.
+func labelNewErrors(label string, priorErrs []error, msg *message.Batch) {
+	_ = msg.Iter(func(i int, p *message.Part) error {
+		err := p.ErrorGet()
+		if err == nil {
+			return nil
+		}
+		for _, prior := range priorErrs {
+			if errors.Is(err, prior) {
+				return nil
+			}
+		}
+		p.ErrorSet(labelErr(label, err))
+		return nil
+	})
+}
+
+// reportBatchedHealth considers a batched processor failing when every message
+// it returns is flagged with an error, unless every message arrived flagged
+// with an error already.
+func (a *v2BatchedToV1Processor) reportBatchedHealth(priorFailed bool, outputBatches []*message.Batch) {
+	var firstErr error
+	allFailed := len(outputBatches) > 0
+	for _, m := range outputBatches {
+		all, mErr := batchFailed(m)
+		if !all {
+			allFailed = false
+		} else if firstErr == nil {
+			firstErr = mErr
+		}
+	}
+	if allFailed && !priorFailed {
+		a.health.Error(firstErr)
+	} else {
+		a.health.Success()
+	}
+}
+
 func (a *v2BatchedToV1Processor) CloseAsync() {
+	a.health.Close()
+	a.tap.Close()
 	go func() {
 		if err := a.p.Close(context.Background()); err == nil {
 			a.sig.ShutdownComplete()
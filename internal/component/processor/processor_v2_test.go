@@ -223,3 +223,58 @@ func TestBatchProcessorAirGapOneToMany(t *testing.T) {
 	assert.Equal(t, 1, msgs[1].Len())
 	assert.Equal(t, "changed 3", string(msgs[1].Get(0).Get()))
 }
+
+type labelledObservability struct {
+	component.Observability
+	label string
+}
+
+func (l labelledObservability) Label() string {
+	return l.label
+}
+
+func TestProcessorAirGapLabelledErrors(t *testing.T) {
+	obs := labelledObservability{Observability: component.NoopObservability(), label: "foo"}
+
+	agrp := NewV2ToV1Processor("foo", &fnProcessor{
+		fn: func(c context.Context, m *message.Part) ([]*message.Part, error) {
+			return nil, errors.New("nope")
+		},
+	}, obs)
+
+	msgs, res := agrp.ProcessMessage(message.QuickBatch([][]byte{[]byte("hello")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	err := msgs[0].Get(0).ErrorGet()
+	assert.EqualError(t, err, "nope")
+	label, ok := ErrorLabel(err)
+	assert.True(t, ok)
+	assert.Equal(t, "foo", label)
+}
+
+func TestBatchProcessorAirGapLabelledErrors(t *testing.T) {
+	obs := labelledObservability{Observability: component.NoopObservability(), label: "bar"}
+
+	agrp := NewV2BatchedToV1Processor("foo", &fnBatchProcessor{
+		fn: func(c context.Context, b *message.Batch) ([]*message.Batch, error) {
+			b = b.Copy()
+			b.Get(1).ErrorSet(errors.New("nope"))
+			return []*message.Batch{b}, nil
+		},
+	}, obs)
+
+	upstreamErr := errors.New("upstream failure")
+	msg := message.QuickBatch([][]byte{[]byte("first"), []byte("second")})
+	msg.Get(0).ErrorSet(upstreamErr)
+
+	msgs, res := agrp.ProcessMessage(msg)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, upstreamErr, msgs[0].Get(0).ErrorGet())
+
+	label, ok := ErrorLabel(msgs[0].Get(1).ErrorGet())
+	assert.True(t, ok)
+	assert.Equal(t, "bar", label)
+}
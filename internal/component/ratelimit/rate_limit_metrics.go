@@ -4,11 +4,14 @@ import (
 	"context"
 	"time"
 
+	"github.com/benthosdev/benthos/v4/internal/api"
+	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 )
 
 type metricsRateLimit struct {
-	r V1
+	r      V1
+	health *api.HealthTracker
 
 	mChecked metrics.StatCounter
 	mLimited metrics.StatCounter
@@ -27,17 +30,31 @@ func MetricsForRateLimit(r V1, stats metrics.Type) V1 {
 	}
 }
 
+// MetricsAndHealthForRateLimit wraps a rate limit with a struct that adds
+// standard metrics, and reports the health of the rate limit when the manager
+// supports health tracking.
+func MetricsAndHealthForRateLimit(r V1, mgr component.Observability) V1 {
+	mr := MetricsForRateLimit(r, mgr.Metrics()).(*metricsRateLimit)
+	mr.health = api.HealthTrackerFor(mgr, api.HealthKindRateLimit)
+	return mr
+}
+
 func (r *metricsRateLimit) Access(ctx context.Context) (time.Duration, error) {
 	r.mChecked.Incr(1)
 	tout, err := r.r.Access(ctx)
 	if err != nil {
 		r.mErr.Incr(1)
-	} else if tout > 0 {
-		r.mLimited.Incr(1)
+		r.health.Error(err)
+	} else {
+		if tout > 0 {
+			r.mLimited.Incr(1)
+		}
+		r.health.Success()
 	}
 	return tout, err
 }
 
 func (r *metricsRateLimit) Close(ctx context.Context) error {
+	r.health.Close()
 	return r.r.Close(ctx)
 }
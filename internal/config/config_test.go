@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
 
 	_ "github.com/benthosdev/benthos/v4/public/components/all"
 )
@@ -209,3 +210,31 @@ cache_resources:
 	assert.Equal(t, "bar", conf.ResourceCaches[1].Label)
 	assert.Equal(t, "memory", conf.ResourceCaches[1].Type)
 }
+
+func TestLintInlinedSecrets(t *testing.T) {
+	lints, err := config.LintInlinedSecrets(docs.NewLintContext(), []byte(`
+input:
+  mqtt:
+    urls: [ tcp://localhost:1883 ]
+    topics: [ foo ]
+    user: foo
+    password: bar
+output:
+  mqtt:
+    urls: [ tcp://localhost:1883 ]
+    topic: bar
+    password: ${MQTT_PASSWORD}
+`))
+	require.NoError(t, err)
+	require.Len(t, lints, 1)
+	assert.Equal(t, 7, lints[0].Line)
+	assert.Equal(t, docs.LintInlinedSecret, lints[0].Type)
+
+	lints, err = config.LintInlinedSecrets(docs.NewLintContext(), []byte(`# BENTHOS LINT DISABLE
+input:
+  mqtt:
+    password: bar
+`))
+	require.NoError(t, err)
+	assert.Empty(t, lints)
+}
@@ -2,16 +2,39 @@ package config
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
 )
 
 var (
 	envRegex        = regexp.MustCompile(`\${[0-9A-Za-z_.]+(:((\${[^}]+})|[^}])+)?}`)
 	escapedEnvRegex = regexp.MustCompile(`\${({[0-9A-Za-z_.]+(:((\${[^}]+})|[^}])+)?})}`)
+	secretRegex     = regexp.MustCompile(`^secret:([a-z0-9_]+):(.+)$`)
 )
 
+// The maximum period of time to wait for a single secret to be obtained from a
+// provider.
+const secretLookupTimeout = time.Second * 30
+
+// SecretsProvider obtains the values of secrets referenced by config
+// interpolations of the form `${secret:provider:path}`.
+type SecretsProvider interface {
+	SecretsLookup(ctx context.Context, provider, path string) (string, error)
+}
+
 // ReplaceEnvVariables will search a blob of data for the pattern `${FOO:bar}`,
 // where `FOO` is an environment variable name and `bar` is a default value. The
 // `bar` section (including the colon) can be left out if there is no
@@ -21,11 +44,39 @@ var (
 // respective environment variable will be read and will replace the pattern. If
 // the environment variable is empty or does not exist then either the default
 // value is used or the field will be left empty.
-func ReplaceEnvVariables(inBytes []byte) []byte {
+//
+// Patterns of the form `${secret:provider:path}` are instead resolved using
+// the secrets providers of the global environment, and an error is returned if
+// any of them fail.
+func ReplaceEnvVariables(inBytes []byte) ([]byte, error) {
+	return ReplaceEnvVariablesWithSecrets(inBytes, bundle.GlobalEnvironment)
+}
+
+// ReplaceEnvVariablesWithSecrets is the same as ReplaceEnvVariables but
+// resolves secrets with a provided SecretsProvider. When the provider is nil
+// secret interpolations are left in place.
+//
+// Unlike environment variables, secrets are resolved within the scalar values
+// of the parsed YAML document and are escaped according to the style of the
+// scalar, which means a secret cannot change the structure of a config. The
+// rest of the document is left as is. Secret interpolations within comments
+// are not resolved.
+func ReplaceEnvVariablesWithSecrets(inBytes []byte, secrets SecretsProvider) ([]byte, error) {
+	var refs []secretRef
 	replaced := envRegex.ReplaceAllFunc(inBytes, func(content []byte) []byte {
 		var value string
 		if len(content) > 3 {
-			if colonIndex := bytes.IndexByte(content, ':'); colonIndex == -1 {
+			if matches := secretRegex.FindSubmatch(content[2 : len(content)-1]); matches != nil {
+				if secrets == nil {
+					return content
+				}
+				refs = append(refs, secretRef{
+					provider: string(matches[1]),
+					path:     string(matches[2]),
+					raw:      string(content),
+				})
+				return []byte(fmt.Sprintf(secretPlaceholderFmt, len(refs)-1))
+			} else if colonIndex := bytes.IndexByte(content, ':'); colonIndex == -1 {
 				value = os.Getenv(string(content[2 : len(content)-1]))
 			} else {
 				targetVar := content[2:colonIndex]
@@ -43,5 +94,259 @@ func ReplaceEnvVariables(inBytes []byte) []byte {
 		return []byte(value)
 	})
 	replaced = escapedEnvRegex.ReplaceAll(replaced, []byte("$$$1"))
-	return replaced
+	if len(refs) == 0 {
+		return replaced, nil
+	}
+	return replaceSecrets(replaced, refs, secrets)
+}
+
+// Secret interpolations are swapped for placeholders before the config is
+// parsed, which are then replaced within scalar values only.
+const secretPlaceholderFmt = "__benthos_secret_%v__"
+
+var secretPlaceholderRegex = regexp.MustCompile(`__benthos_secret_([0-9]+)__`)
+
+type secretRef struct {
+	provider, path string
+
+	// The original interpolation, which is restored within comments.
+	raw string
+}
+
+func replaceSecrets(inBytes []byte, refs []secretRef, secrets SecretsProvider) ([]byte, error) {
+	lineOffsets := []int{0}
+	for i, b := range inBytes {
+		if b == '\n' {
+			lineOffsets = append(lineOffsets, i+1)
+		}
+	}
+
+	// Converts a node position, where the column is counted in characters,
+	// into an offset of the original bytes.
+	offsetOf := func(line, column int) int {
+		if line < 1 || line > len(lineOffsets) {
+			return -1
+		}
+		offset := lineOffsets[line-1]
+		for i := 1; i < column && offset < len(inBytes); i++ {
+			_, width := utf8.DecodeRune(inBytes[offset:])
+			offset += width
+		}
+		return offset
+	}
+
+	var edits []secretEdit
+	placeholderEdits := map[int]string{}
+
+	var walkErr error
+	var walk func(n *yaml.Node, inFlow bool)
+	walk = func(n *yaml.Node, inFlow bool) {
+		if walkErr != nil {
+			return
+		}
+		if n.Kind == yaml.ScalarNode && secretPlaceholderRegex.MatchString(n.Value) {
+			if walkErr = replaceSecretsInScalar(n, inFlow, inBytes, offsetOf, refs, secrets, &edits, placeholderEdits); walkErr != nil {
+				return
+			}
+		}
+		inFlow = inFlow || n.Style&yaml.FlowStyle != 0
+		for _, c := range n.Content {
+			walk(c, inFlow)
+		}
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(inBytes))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		if walk(&doc, false); walkErr != nil {
+			return nil, walkErr
+		}
+	}
+
+	// Placeholders that aren't within a scalar value, such as those within
+	// comments, are restored to the original interpolation.
+	for _, loc := range secretPlaceholderRegex.FindAllSubmatchIndex(inBytes, -1) {
+		i, _ := strconv.Atoi(string(inBytes[loc[2]:loc[3]]))
+		text, exists := placeholderEdits[i]
+		if !exists {
+			text = refs[i].raw
+		}
+		edits = append(edits, secretEdit{start: loc[0], end: loc[1], text: text})
+	}
+
+	// Edits that replace a whole scalar take precedence over the placeholders
+	// they contain.
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].start == edits[j].start {
+			return edits[i].end > edits[j].end
+		}
+		return edits[i].start < edits[j].start
+	})
+
+	var buf bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		if e.start < last {
+			continue
+		}
+		buf.Write(inBytes[last:e.start])
+		buf.WriteString(e.text)
+		last = e.end
+	}
+	buf.Write(inBytes[last:])
+	return buf.Bytes(), nil
+}
+
+type secretEdit struct {
+	start, end int
+	text       string
+}
+
+// Resolves the secrets within a scalar value and determines how they should be
+// written into the original bytes given the style of the scalar, so that the
+// rest of the document is left untouched.
+func replaceSecretsInScalar(
+	n *yaml.Node,
+	inFlow bool,
+	inBytes []byte,
+	offsetOf func(line, column int) int,
+	refs []secretRef,
+	secrets SecretsProvider,
+	edits *[]secretEdit,
+	placeholderEdits map[int]string,
+) error {
+	values := map[int]string{}
+	multiline := false
+	for _, match := range secretPlaceholderRegex.FindAllStringSubmatch(n.Value, -1) {
+		i, _ := strconv.Atoi(match[1])
+		v, err := lookupSecret(secrets, refs[i].provider, refs[i].path)
+		if err != nil {
+			return err
+		}
+		values[i] = v
+		multiline = multiline || strings.Contains(v, "\n")
+	}
+
+	resolved := secretPlaceholderRegex.ReplaceAllStringFunc(n.Value, func(placeholder string) string {
+		i, _ := strconv.Atoi(secretPlaceholderRegex.FindStringSubmatch(placeholder)[1])
+		return values[i]
+	})
+
+	eachValue := func(fn func(v string) string) {
+		for i, v := range values {
+			placeholderEdits[i] = fn(v)
+		}
+	}
+
+	// Replaces the entire scalar with a double quoted equivalent of the
+	// resolved value.
+	replaceScalar := func(end int) {
+		start := offsetOf(n.Line, n.Column)
+		*edits = append(*edits, secretEdit{start: start, end: end, text: strconv.Quote(resolved)})
+	}
+
+	switch {
+	case n.Style&yaml.DoubleQuotedStyle != 0:
+		eachValue(func(v string) string {
+			q := strconv.Quote(v)
+			return q[1 : len(q)-1]
+		})
+	case n.Style&yaml.SingleQuotedStyle != 0:
+		if !multiline {
+			eachValue(func(v string) string {
+				return strings.ReplaceAll(v, "'", "''")
+			})
+			return nil
+		}
+		start := offsetOf(n.Line, n.Column)
+		if start < 0 || start >= len(inBytes) || inBytes[start] != '\'' {
+			return fmt.Errorf("line %v: unable to locate quoted value containing secret", n.Line)
+		}
+		for i := start + 1; i < len(inBytes); i++ {
+			if inBytes[i] != '\'' {
+				continue
+			}
+			if i+1 < len(inBytes) && inBytes[i+1] == '\'' {
+				i++
+				continue
+			}
+			replaceScalar(i + 1)
+			return nil
+		}
+		return fmt.Errorf("line %v: unable to locate quoted value containing secret", n.Line)
+	case n.Style&yaml.LiteralStyle != 0:
+		indent := blockScalarIndent(inBytes, offsetOf(n.Line+1, 1))
+		eachValue(func(v string) string {
+			return strings.ReplaceAll(v, "\n", "\n"+indent)
+		})
+	case n.Style&yaml.FoldedStyle != 0:
+		if multiline {
+			return fmt.Errorf("line %v: secrets containing line breaks cannot be used within folded block scalars, use a literal block scalar instead", n.Line)
+		}
+		eachValue(func(v string) string { return v })
+	default:
+		if isPlainSafe(resolved, inFlow) {
+			eachValue(func(v string) string { return v })
+			return nil
+		}
+		start := offsetOf(n.Line, n.Column)
+		if start < 0 || !bytes.HasPrefix(inBytes[start:], []byte(n.Value)) {
+			return fmt.Errorf("line %v: secret cannot be used within this value unquoted, try quoting the value", n.Line)
+		}
+		replaceScalar(start + len(n.Value))
+	}
+	return nil
+}
+
+// Returns the indentation of the first non-empty line of a block scalar.
+func blockScalarIndent(inBytes []byte, offset int) string {
+	if offset < 0 {
+		return ""
+	}
+	for offset < len(inBytes) {
+		end := bytes.IndexByte(inBytes[offset:], '\n')
+		if end == -1 {
+			end = len(inBytes) - offset
+		}
+		line := inBytes[offset : offset+end]
+		if trimmed := bytes.TrimLeft(line, " "); len(trimmed) > 0 {
+			return string(line[:len(line)-len(trimmed)])
+		}
+		offset += end + 1
+	}
+	return ""
+}
+
+// Returns whether a value would be parsed back the same when written as a plain
+// scalar.
+func isPlainSafe(value string, inFlow bool) bool {
+	if value == "" || strings.Contains(value, "\n") {
+		return false
+	}
+	if inFlow && strings.ContainsAny(value, ",[]{}") {
+		return false
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &doc); err != nil || len(doc.Content) != 1 {
+		return false
+	}
+	n := doc.Content[0]
+	return n.Kind == yaml.ScalarNode && n.Style == 0 && n.Value == value && n.Anchor == ""
+}
+
+func lookupSecret(secrets SecretsProvider, provider, path string) (string, error) {
+	ctx, done := context.WithTimeout(context.Background(), secretLookupTimeout)
+	defer done()
+
+	value, err := secrets.SecretsLookup(ctx, provider, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to obtain secret '%v:%v': %w", provider, path, err)
+	}
+	return value, nil
 }
@@ -1,8 +1,17 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
 )
 
 func TestEnvSwapping(t *testing.T) {
@@ -36,9 +45,174 @@ func TestEnvSwapping(t *testing.T) {
 	}
 
 	for in, exp := range tests {
-		out := ReplaceEnvVariables([]byte(in))
+		out, err := ReplaceEnvVariables([]byte(in))
+		if err != nil {
+			t.Fatal(err)
+		}
 		if act := string(out); act != exp {
 			t.Errorf("Wrong result: %v != %v", act, exp)
 		}
 	}
 }
+
+func TestSecretSwapping(t *testing.T) {
+	env := bundle.NewEnvironment()
+	require.NoError(t, env.SecretsAdd("test", func(ctx context.Context, path string) (string, error) {
+		if path == "bad" {
+			return "", errors.New("nope")
+		}
+		return "secret(" + path + ")", nil
+	}))
+
+	os.Setenv("secret", "not a secret")
+	t.Cleanup(func() {
+		os.Unsetenv("secret")
+	})
+
+	tests := map[string]string{
+		"foo ${secret:test:bar} baz":                 "foo secret(bar) baz",
+		"foo ${secret:test:/a/b/c#d.e} baz":          "foo secret(/a/b/c#d.e) baz",
+		"foo ${secret:test:bar}${secret:test:baz}":   "foo secret(bar)secret(baz)",
+		"foo ${{secret:test:bar}} baz":               "foo ${secret:test:bar} baz",
+		"foo ${secret} baz":                          "foo not a secret baz",
+		"foo ${secret:bar} baz":                      "foo not a secret baz",
+		"foo ${secret:test:bar} ${BENTHOS_NOPE:buz}": "foo secret(bar) buz",
+	}
+
+	for in, exp := range tests {
+		out, err := ReplaceEnvVariablesWithSecrets([]byte(in), env)
+		require.NoError(t, err, in)
+		assert.Equal(t, exp, string(out), in)
+	}
+
+	_, err := ReplaceEnvVariablesWithSecrets([]byte("foo ${secret:test:bad} baz"), env)
+	require.EqualError(t, err, "failed to obtain secret 'test:bad': nope")
+
+	_, err = ReplaceEnvVariablesWithSecrets([]byte("foo ${secret:nope:bar} baz"), env)
+	require.EqualError(t, err, "failed to obtain secret 'nope:bar': secrets provider 'nope' was not recognised")
+}
+
+func TestSecretSwappingYAML(t *testing.T) {
+	env := bundle.NewEnvironment()
+	require.NoError(t, env.SecretsAdd("test", func(ctx context.Context, path string) (string, error) {
+		switch path {
+		case "structure":
+			return "bar\nbaz: \"buz\" # nope", nil
+		case "number":
+			return "10", nil
+		case "commented":
+			return "", errors.New("secrets within comments should not be obtained")
+		}
+		return "secret(" + path + ")", nil
+	}))
+
+	out, err := ReplaceEnvVariablesWithSecrets([]byte(`
+# Uses ${secret:test:commented}
+a: ${secret:test:structure}
+b: "quoted ${secret:test:structure}"
+c: ${secret:test:number}
+d: '${secret:test:number}'
+e: [ ${secret:test:foo}, { f: "${secret:test:bar}" } ] # ${secret:test:commented}
+`), env)
+	require.NoError(t, err)
+
+	var res map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(out, &res))
+	assert.Equal(t, map[string]interface{}{
+		"a": "bar\nbaz: \"buz\" # nope",
+		"b": "quoted bar\nbaz: \"buz\" # nope",
+		"c": 10,
+		"d": "10",
+		"e": []interface{}{"secret(foo)", map[string]interface{}{"f": "secret(bar)"}},
+	}, res)
+
+	assert.Contains(t, string(out), "# Uses ${secret:test:commented}")
+	assert.Contains(t, string(out), "# ${secret:test:commented}")
+}
+
+func TestSecretSwappingPreservesFormatting(t *testing.T) {
+	env := bundle.NewEnvironment()
+	require.NoError(t, env.SecretsAdd("test", func(ctx context.Context, path string) (string, error) {
+		switch path {
+		case "multiline":
+			return "foo\nbar", nil
+		case "quotes":
+			return `it's "quoted"`, nil
+		case "special":
+			return "a: b # c", nil
+		}
+		return path, nil
+	}))
+
+	out, err := ReplaceEnvVariablesWithSecrets([]byte(`input:
+    # A comment ${secret:test:foo}
+    a:    ${secret:test:foo}   # trailing
+    b: "${secret:test:quotes}"
+    c: '${secret:test:quotes}'
+    d: '${secret:test:multiline}'
+    e: prefix ${secret:test:special}
+    f: [ ${secret:test:foo}, ${secret:test:special} ]
+    g: |
+      first
+      ${secret:test:multiline}
+    h: ${secret:test:multiline}
+`), env)
+	require.NoError(t, err)
+
+	assert.Equal(t, `input:
+    # A comment ${secret:test:foo}
+    a:    foo   # trailing
+    b: "it's \"quoted\""
+    c: 'it''s "quoted"'
+    d: "foo\nbar"
+    e: "prefix a: b # c"
+    f: [ foo, "a: b # c" ]
+    g: |
+      first
+      foo
+      bar
+    h: "foo\nbar"
+`, string(out))
+
+	var res map[string]map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(out, &res))
+	assert.Equal(t, map[string]interface{}{
+		"a": "foo",
+		"b": `it's "quoted"`,
+		"c": `it's "quoted"`,
+		"d": "foo\nbar",
+		"e": "prefix a: b # c",
+		"f": []interface{}{"foo", "a: b # c"},
+		"g": "first\nfoo\nbar\n",
+		"h": "foo\nbar",
+	}, res["input"])
+}
+
+func TestSecretSwappingSkipped(t *testing.T) {
+	out, err := ReplaceEnvVariablesWithSecrets([]byte(`a: ${secret:test:foo}
+b: ${BENTHOS_NOPE:bar}
+`), nil)
+	require.NoError(t, err)
+	assert.Equal(t, `a: ${secret:test:foo}
+b: bar
+`, string(out))
+}
+
+func TestReadFileLintedSkipsSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+input:
+  generate:
+    mapping: 'root = "${secret:nope:foo}"'
+output:
+  drop: {}
+`), 0o644))
+
+	conf := New()
+	lints, err := ReadFileLinted(path, false, &conf)
+	require.NoError(t, err)
+	assert.Empty(t, lints)
+
+	_, err = ReadFile(path, &conf)
+	require.EqualError(t, err, "failed to obtain secret 'nope:foo': secrets provider 'nope' was not recognised")
+}
@@ -8,13 +8,29 @@ import (
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 )
 
+// ReadFile will attempt to read a configuration file path into a structure,
+// replacing any environment variable and secret interpolations. Returns an
+// array of lint messages for the higher level format of the file or an error.
+func ReadFile(path string, config *Type) ([]string, error) {
+	configBytes, lints, err := ReadFileEnvSwap(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(configBytes, config); err != nil {
+		return nil, err
+	}
+	return lints, nil
+}
+
 // ReadFileLinted will attempt to read a configuration file path into a
-// structure. Returns an array of lint messages or an error.
+// structure. Returns an array of lint messages or an error. Secret
+// interpolations are left in place rather than obtained from their providers.
 func ReadFileLinted(path string, rejectDeprecated bool, config *Type) ([]string, error) {
-	configBytes, lints, err := ReadFileEnvSwap(path)
+	configBytes, lints, err := ReadFileEnvSwapSkipSecrets(path)
 	if err != nil {
 		return nil, err
 	}
@@ -54,11 +70,46 @@ func LintBytes(ctx docs.LintContext, rawBytes []byte) ([]string, error) {
 	return lintStrs, nil
 }
 
-// ReadFileEnvSwap reads a file and replaces any environment variable
+// LintInlinedSecrets returns warnings for secret fields within a raw config,
+// i.e. prior to interpolations being replaced, that are set inline rather than
+// with environment variable or secret interpolations.
+func LintInlinedSecrets(ctx docs.LintContext, rawBytes []byte) ([]docs.Lint, error) {
+	if bytes.HasPrefix(rawBytes, []byte("# BENTHOS LINT DISABLE")) {
+		return nil, nil
+	}
+
+	var rawNode yaml.Node
+	if err := yaml.Unmarshal(rawBytes, &rawNode); err != nil {
+		return nil, err
+	}
+
+	ctx.WarnInlinedSecrets = true
+
+	var lints []docs.Lint
+	for _, lint := range Spec().LintYAML(ctx, &rawNode) {
+		if lint.Type == docs.LintInlinedSecret {
+			lints = append(lints, lint)
+		}
+	}
+	return lints, nil
+}
+
+// ReadFileEnvSwap reads a file and replaces any environment variable and secret
 // interpolations before returning the contents. Linting errors are returned if
 // the file has an unexpected higher level format, such as invalid utf-8
 // encoding.
 func ReadFileEnvSwap(path string) (configBytes []byte, lints []string, err error) {
+	return readFileEnvSwap(path, bundle.GlobalEnvironment)
+}
+
+// ReadFileEnvSwapSkipSecrets is the same as ReadFileEnvSwap but leaves secret
+// interpolations in place, which avoids obtaining secrets from providers when
+// a config is only being linted or tested.
+func ReadFileEnvSwapSkipSecrets(path string) (configBytes []byte, lints []string, err error) {
+	return readFileEnvSwap(path, nil)
+}
+
+func readFileEnvSwap(path string, secrets SecretsProvider) (configBytes []byte, lints []string, err error) {
 	configBytes, err = os.ReadFile(path)
 	if err != nil {
 		return nil, nil, err
@@ -68,6 +119,8 @@ func ReadFileEnvSwap(path string) (configBytes []byte, lints []string, err error
 		lints = append(lints, "Detected invalid utf-8 encoding in config, this may result in interpolation functions not working as expected")
 	}
 
-	configBytes = ReplaceEnvVariables(configBytes)
+	if configBytes, err = ReplaceEnvVariablesWithSecrets(configBytes, secrets); err != nil {
+		return nil, nil, err
+	}
 	return configBytes, lints, nil
 }
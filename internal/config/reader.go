@@ -5,6 +5,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -30,6 +32,10 @@ type streamFileInfo struct {
 	configFileInfo
 
 	id string
+
+	// Need to track the config that came from the previous read so that
+	// updates that don't change it do not restart the stream.
+	conf stream.Config
 }
 
 type fileWatcher interface {
@@ -66,8 +72,13 @@ type Reader struct {
 
 	mainUpdateFn   MainUpdateFunc
 	streamUpdateFn StreamUpdateFunc
+	streamRemoveFn StreamRemoveFunc
 	watcher        fileWatcher
 
+	// Tracks config files that failed to be reloaded during file watching.
+	reloadErrs    map[string]error
+	reloadErrsMut sync.Mutex
+
 	changeFlushPeriod time.Duration
 	changeDelayPeriod time.Duration
 }
@@ -80,6 +91,7 @@ func NewReader(mainPath string, resourcePaths []string, opts ...OptFunc) *Reader
 		resourcePaths:     resourcePaths,
 		streamFileInfo:    map[string]streamFileInfo{},
 		resourceFileInfo:  map[string]resourceFileInfo{},
+		reloadErrs:        map[string]error{},
 		changeFlushPeriod: defaultChangeFlushPeriod,
 		changeDelayPeriod: defaultChangeDelayPeriod,
 	}
@@ -182,6 +194,83 @@ func (r *Reader) SubscribeStreamChanges(fn StreamUpdateFunc) error {
 	return nil
 }
 
+// StreamRemoveFunc is a closure function called whenever a stream config has
+// been removed. A boolean should be returned indicating whether the stream was
+// successfully removed, if false then the attempt will be made again after a
+// grace period.
+type StreamRemoveFunc func(id string) bool
+
+// SubscribeStreamRemovals registers a closure to be called whenever the config
+// file of a stream is removed from a watched streams directory.
+//
+// The provided closure should return true if the stream was successfully
+// removed.
+func (r *Reader) SubscribeStreamRemovals(fn StreamRemoveFunc) error {
+	if r.watcher != nil {
+		return errors.New("a file watcher has already been started")
+	}
+
+	r.streamRemoveFn = fn
+	return nil
+}
+
+// ReloadError returns an error describing each config file that failed to be
+// reloaded during file watching and has not since been successfully reloaded,
+// or nil if all reloads have succeeded.
+func (r *Reader) ReloadError() error {
+	r.reloadErrsMut.Lock()
+	defer r.reloadErrsMut.Unlock()
+
+	if len(r.reloadErrs) == 0 {
+		return nil
+	}
+
+	paths := make([]string, 0, len(r.reloadErrs))
+	for k := range r.reloadErrs {
+		paths = append(paths, k)
+	}
+	sort.Strings(paths)
+
+	errStrs := make([]string, 0, len(paths))
+	for _, p := range paths {
+		errStrs = append(errStrs, fmt.Sprintf("%v: %v", p, r.reloadErrs[p]))
+	}
+	return fmt.Errorf("failed to reload configs: %v", strings.Join(errStrs, ", "))
+}
+
+var errLintRejected = errors.New("rejected due to linter errors")
+
+// forgetMissingReloads removes the reload errors of config files that no longer
+// exist, as they can no longer be reloaded.
+func (r *Reader) forgetMissingReloads() {
+	r.reloadErrsMut.Lock()
+	defer r.reloadErrsMut.Unlock()
+
+	for path := range r.reloadErrs {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			delete(r.reloadErrs, path)
+		}
+	}
+}
+
+// trackReload records the outcome of an attempt to reload a config file, which
+// is reflected in both metrics and the result of ReloadError.
+func (r *Reader) trackReload(mgr bundle.NewManagement, path string, err error) {
+	r.reloadErrsMut.Lock()
+	if err != nil {
+		r.reloadErrs[path] = err
+	} else {
+		delete(r.reloadErrs, path)
+	}
+	r.reloadErrsMut.Unlock()
+
+	if err != nil {
+		mgr.Metrics().GetCounter("config_reload_failure").Incr(1)
+	} else {
+		mgr.Metrics().GetCounter("config_reload_success").Incr(1)
+	}
+}
+
 // Close the reader, when this method exits all reloading will be stopped.
 func (r *Reader) Close(ctx context.Context) error {
 	if r.watcher != nil {
@@ -280,6 +369,7 @@ func (r *Reader) reactMainUpdate(mgr bundle.NewManagement, strict bool) bool {
 	lints, err := r.readMain(&conf)
 	if err != nil {
 		mgr.Logger().Errorf("Failed to read updated config: %v", err)
+		r.trackReload(mgr, r.mainPath, err)
 
 		// Rejecting due to invalid file means we do not want to try again.
 		return true
@@ -291,6 +381,7 @@ func (r *Reader) reactMainUpdate(mgr bundle.NewManagement, strict bool) bool {
 	}
 	if strict && len(lints) > 0 {
 		mgr.Logger().Errorln("Rejecting updated main config due to linter errors, to allow linting errors run Benthos with --chilled")
+		r.trackReload(mgr, r.mainPath, errLintRejected)
 
 		// Rejecting from linters means we do not want to try again.
 		return true
@@ -298,8 +389,14 @@ func (r *Reader) reactMainUpdate(mgr bundle.NewManagement, strict bool) bool {
 
 	// Update any resources within the file.
 	if newInfo := resInfoFromConfig(&conf.ResourceConfig); !newInfo.applyChanges(mgr) {
+		r.trackReload(mgr, r.mainPath, errors.New("failed to update resources"))
 		return false
 	}
 
-	return r.mainUpdateFn(conf.Config)
+	if !r.mainUpdateFn(conf.Config) {
+		r.trackReload(mgr, r.mainPath, errors.New("failed to update pipeline"))
+		return false
+	}
+	r.trackReload(mgr, r.mainPath, nil)
+	return true
 }
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -125,3 +126,202 @@ output:
 	assert.Equal(t, "kafka", updatedConf.Input.Type)
 	assert.Equal(t, "aws_s3", updatedConf.Output.Type)
 }
+
+func TestReaderStreamDirectoryWatching(t *testing.T) {
+	confDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(confDir, "first.yaml"), []byte(`
+input:
+  generate:
+    mapping: 'root = "first"'
+`), 0o644))
+
+	rdr := NewReader("", nil, OptSetStreamPaths(confDir))
+	rdr.changeDelayPeriod = 1 * time.Millisecond
+	rdr.changeFlushPeriod = 1 * time.Millisecond
+
+	streamConfs := map[string]stream.Config{}
+	lints, err := rdr.ReadStreams(streamConfs)
+	require.NoError(t, err)
+	require.Empty(t, lints)
+	require.Contains(t, streamConfs, "first")
+
+	updatedChan := make(chan string, 10)
+	require.NoError(t, rdr.SubscribeStreamChanges(func(id string, conf stream.Config) bool {
+		updatedChan <- id
+		return true
+	}))
+
+	removedChan := make(chan string, 10)
+	require.NoError(t, rdr.SubscribeStreamRemovals(func(id string) bool {
+		removedChan <- id
+		return true
+	}))
+
+	testMgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+	require.NoError(t, rdr.BeginFileWatching(testMgr, true))
+	t.Cleanup(func() {
+		_ = rdr.Close(context.Background())
+	})
+
+	// Add a new stream config
+	require.NoError(t, os.WriteFile(filepath.Join(confDir, "second.yaml"), []byte(`
+input:
+  generate:
+    mapping: 'root = "second"'
+`), 0o644))
+
+	select {
+	case id := <-updatedChan:
+		assert.Equal(t, "second", id)
+	case <-time.After(time.Second):
+		require.FailNow(t, "Expected a stream to be created")
+	}
+
+	// Remove the original stream config
+	require.NoError(t, os.Remove(filepath.Join(confDir, "first.yaml")))
+
+	select {
+	case id := <-removedChan:
+		assert.Equal(t, "first", id)
+	case <-time.After(time.Second):
+		require.FailNow(t, "Expected a stream to be removed")
+	}
+
+	require.NoError(t, rdr.ReloadError())
+
+	// Break the new stream config
+	require.NoError(t, os.WriteFile(filepath.Join(confDir, "second.yaml"), []byte(`
+input:
+  generate:
+    nope: 'root = "second"'
+`), 0o644))
+
+	assert.Eventually(t, func() bool {
+		return rdr.ReloadError() != nil
+	}, time.Second, 5*time.Millisecond)
+	assert.Contains(t, rdr.ReloadError().Error(), "second.yaml: rejected due to linter errors")
+}
+
+func watchStreamDir(t *testing.T, confDir string) (rdr *Reader, updatedChan, removedChan chan string) {
+	t.Helper()
+
+	rdr = NewReader("", nil, OptSetStreamPaths(confDir))
+	rdr.changeDelayPeriod = 1 * time.Millisecond
+	rdr.changeFlushPeriod = 1 * time.Millisecond
+
+	_, err := rdr.ReadStreams(map[string]stream.Config{})
+	require.NoError(t, err)
+
+	updatedChan = make(chan string, 10)
+	require.NoError(t, rdr.SubscribeStreamChanges(func(id string, conf stream.Config) bool {
+		updatedChan <- id
+		return true
+	}))
+
+	removedChan = make(chan string, 10)
+	require.NoError(t, rdr.SubscribeStreamRemovals(func(id string) bool {
+		removedChan <- id
+		return true
+	}))
+
+	testMgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+	require.NoError(t, rdr.BeginFileWatching(testMgr, true))
+	t.Cleanup(func() {
+		_ = rdr.Close(context.Background())
+	})
+	return
+}
+
+func TestReaderStreamDirectoryWatchingFixedConfig(t *testing.T) {
+	confDir := t.TempDir()
+	rdr, updatedChan, _ := watchStreamDir(t, confDir)
+
+	// Add a new stream config that cannot be read
+	confPath := filepath.Join(confDir, "broken.yaml")
+	require.NoError(t, os.WriteFile(confPath, []byte(`input: [`), 0o644))
+
+	assert.Eventually(t, func() bool {
+		return rdr.ReloadError() != nil
+	}, time.Second, 5*time.Millisecond)
+	assert.Contains(t, rdr.ReloadError().Error(), "broken.yaml")
+
+	// Fix the config by writing to the same file
+	f, err := os.OpenFile(confPath, os.O_WRONLY|os.O_TRUNC, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`
+input:
+  generate:
+    mapping: 'root = "fixed"'
+`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	select {
+	case id := <-updatedChan:
+		assert.Equal(t, "broken", id)
+	case <-time.After(time.Second):
+		require.FailNow(t, "Expected a stream to be created")
+	}
+	assert.NoError(t, rdr.ReloadError())
+}
+
+func TestReaderStreamDirectoryWatchingRemovedBrokenConfig(t *testing.T) {
+	confDir := t.TempDir()
+	rdr, _, _ := watchStreamDir(t, confDir)
+
+	confPath := filepath.Join(confDir, "broken.yaml")
+	require.NoError(t, os.WriteFile(confPath, []byte(`input: [`), 0o644))
+
+	assert.Eventually(t, func() bool {
+		return rdr.ReloadError() != nil
+	}, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, os.Remove(confPath))
+
+	assert.Eventually(t, func() bool {
+		return rdr.ReloadError() == nil
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestReaderStreamDirectoryWatchingUnchangedConfig(t *testing.T) {
+	confDir := t.TempDir()
+
+	confPath := filepath.Join(confDir, "first.yaml")
+	require.NoError(t, os.WriteFile(confPath, []byte(`
+input:
+  generate:
+    mapping: 'root = "first"'
+`), 0o644))
+
+	_, updatedChan, _ := watchStreamDir(t, confDir)
+
+	// Rewrite the config with only formatting changes
+	require.NoError(t, os.WriteFile(confPath, []byte(`
+# A new comment
+input:
+  generate:
+    mapping:    'root = "first"'
+`), 0o644))
+
+	select {
+	case id := <-updatedChan:
+		require.FailNowf(t, "Unexpected stream update", "stream: %v", id)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	require.NoError(t, os.WriteFile(confPath, []byte(`
+input:
+  generate:
+    mapping: 'root = "changed"'
+`), 0o644))
+
+	select {
+	case id := <-updatedChan:
+		assert.Equal(t, "first", id)
+	case <-time.After(time.Second):
+		require.FailNow(t, "Expected a stream to be updated")
+	}
+}
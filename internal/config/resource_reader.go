@@ -3,8 +3,10 @@ package config
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"reflect"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -47,18 +49,23 @@ func resInfoFromConfig(conf *manager.ResourceConfig) resourceFileInfo {
 
 	// New style
 	for _, c := range conf.ResourceInputs {
+		c := c
 		resInfo.inputs[c.Label] = &c
 	}
 	for _, c := range conf.ResourceProcessors {
+		c := c
 		resInfo.processors[c.Label] = &c
 	}
 	for _, c := range conf.ResourceOutputs {
+		c := c
 		resInfo.outputs[c.Label] = &c
 	}
 	for _, c := range conf.ResourceCaches {
+		c := c
 		resInfo.caches[c.Label] = &c
 	}
 	for _, c := range conf.ResourceRateLimits {
+		c := c
 		resInfo.rateLimits[c.Label] = &c
 	}
 
@@ -139,6 +146,7 @@ func (r *Reader) reactResourceUpdate(mgr bundle.NewManagement, strict bool, path
 	lints, err := readResource(path, &newResConf)
 	if err != nil {
 		mgr.Logger().Errorf("Failed to read updated resources config: %v", err)
+		r.trackReload(mgr, path, err)
 		return true
 	}
 
@@ -148,22 +156,65 @@ func (r *Reader) reactResourceUpdate(mgr bundle.NewManagement, strict bool, path
 	}
 	if strict && len(lints) > 0 {
 		mgr.Logger().Errorln("Rejecting updated resource config due to linter errors, to allow linting errors run Benthos with --chilled")
+		r.trackReload(mgr, path, errLintRejected)
 		return true
 	}
 
 	// TODO: Should we error out if the new config is missing some resources?
-	// (as they will continue to exist). Also, we could avoid restarting
-	// resources where the config hasn't changed.
+	// (as they will continue to exist).
 
 	newInfo := resInfoFromConfig(&newResConf)
-	if !newInfo.applyChanges(mgr) {
+	changedInfo := newInfo.changedFrom(r.resourceFileInfo[path])
+	if !changedInfo.applyChanges(mgr) {
+		r.trackReload(mgr, path, errors.New("failed to update resources"))
 		return false
 	}
 
 	r.resourceFileInfo[path] = newInfo
+	r.trackReload(mgr, path, nil)
 	return true
 }
 
+// changedFrom returns a copy of the resource file info containing only the
+// resources that are either absent from or configured differently in a
+// previous version, so that unchanged resources are not restarted.
+func (i *resourceFileInfo) changedFrom(prev resourceFileInfo) resourceFileInfo {
+	changed := resourceFileInfo{
+		configFileInfo: i.configFileInfo,
+		inputs:         map[string]*input.Config{},
+		processors:     map[string]*processor.Config{},
+		outputs:        map[string]*output.Config{},
+		caches:         map[string]*cache.Config{},
+		rateLimits:     map[string]*ratelimit.Config{},
+	}
+	for k, v := range i.inputs {
+		if p, exists := prev.inputs[k]; !exists || !reflect.DeepEqual(p, v) {
+			changed.inputs[k] = v
+		}
+	}
+	for k, v := range i.processors {
+		if p, exists := prev.processors[k]; !exists || !reflect.DeepEqual(p, v) {
+			changed.processors[k] = v
+		}
+	}
+	for k, v := range i.outputs {
+		if p, exists := prev.outputs[k]; !exists || !reflect.DeepEqual(p, v) {
+			changed.outputs[k] = v
+		}
+	}
+	for k, v := range i.caches {
+		if p, exists := prev.caches[k]; !exists || !reflect.DeepEqual(p, v) {
+			changed.caches[k] = v
+		}
+	}
+	for k, v := range i.rateLimits {
+		if p, exists := prev.rateLimits[k]; !exists || !reflect.DeepEqual(p, v) {
+			changed.rateLimits[k] = v
+		}
+	}
+	return changed
+}
+
 func (i *resourceFileInfo) applyChanges(mgr bundle.NewManagement) bool {
 	// Kind of arbitrary, but I feel better about having some sort of timeout.
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"time"
 
@@ -88,7 +89,7 @@ func (r *Reader) readStreamFile(dir, path string, confs map[string]stream.Config
 		return nil, err
 	}
 
-	strmInfo := streamFileInfo{id: id}
+	strmInfo := streamFileInfo{id: id, conf: conf}
 	// This is an unlikely race condition, see readMain for more info.
 	strmInfo.updatedAt = time.Now()
 
@@ -119,9 +120,17 @@ func (r *Reader) streamPathsExpanded() ([][2]string, error) {
 			if werr != nil {
 				return werr
 			}
-			if info.IsDir() ||
-				(!strings.HasSuffix(info.Name(), ".yaml") &&
-					!strings.HasSuffix(info.Name(), ".yml")) {
+			if info.IsDir() {
+				// Skip hidden directories, this includes the timestamped
+				// directories of Kubernetes ConfigMap volumes, which would
+				// otherwise result in duplicate streams.
+				if path != target && strings.HasPrefix(info.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(info.Name(), ".yaml") &&
+				!strings.HasSuffix(info.Name(), ".yml") {
 				return nil
 			}
 
@@ -150,6 +159,41 @@ func (r *Reader) readStreamFiles(streamMap map[string]stream.Config) (pathLints
 	return
 }
 
+func (r *Reader) streamDirsExpanded() ([]string, error) {
+	streamsPaths, err := ifilepath.Globs(r.streamsPaths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve stream glob pattern: %w", err)
+	}
+
+	var dirs []string
+	for _, target := range streamsPaths {
+		target = filepath.Clean(target)
+
+		if info, err := os.Stat(target); err != nil {
+			return nil, err
+		} else if !info.IsDir() {
+			continue
+		}
+
+		if err := filepath.Walk(target, func(path string, info os.FileInfo, werr error) error {
+			if werr != nil {
+				return werr
+			}
+			if !info.IsDir() {
+				return nil
+			}
+			if path != target && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			dirs = append(dirs, path)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return dirs, nil
+}
+
 func (r *Reader) reactStreamUpdate(mgr bundle.NewManagement, strict bool, path string) bool {
 	if r.streamUpdateFn == nil {
 		return true
@@ -166,6 +210,7 @@ func (r *Reader) reactStreamUpdate(mgr bundle.NewManagement, strict bool, path s
 	conf, lints, err := ReadStreamFile(path)
 	if err != nil {
 		mgr.Logger().Errorf("Failed to read updated stream config: %v", err)
+		r.trackReload(mgr, path, err)
 		return true
 	}
 
@@ -175,8 +220,112 @@ func (r *Reader) reactStreamUpdate(mgr bundle.NewManagement, strict bool, path s
 	}
 	if strict && len(lints) > 0 {
 		mgr.Logger().Errorf("Rejecting updated stream %v config due to linter errors, to allow linting errors run Benthos with --chilled", info.id)
+		r.trackReload(mgr, path, errLintRejected)
 		return true
 	}
 
-	return r.streamUpdateFn(info.id, conf)
+	if reflect.DeepEqual(info.conf, conf) {
+		mgr.Logger().Infof("Stream %v config is unchanged, skipping update.", info.id)
+		r.trackReload(mgr, path, nil)
+		return true
+	}
+
+	if !r.streamUpdateFn(info.id, conf) {
+		r.trackReload(mgr, path, fmt.Errorf("failed to update stream %v", info.id))
+		return false
+	}
+
+	info.conf = conf
+	r.streamFileInfo[path] = info
+	r.trackReload(mgr, path, nil)
+	return true
+}
+
+// reactStreamPathsUpdate walks the stream paths for stream config files that
+// have been added or removed since they were last read, and creates or removes
+// the respective streams. Returns the paths of any removed stream configs, and
+// a boolean indicating whether all changes were applied.
+func (r *Reader) reactStreamPathsUpdate(mgr bundle.NewManagement, strict bool) (removed []string, succeeded bool) {
+	streamsPaths, err := r.streamPathsExpanded()
+	if err != nil {
+		mgr.Logger().Errorf("Failed to walk stream config paths: %v", err)
+		return nil, false
+	}
+
+	existingIDs := map[string]stream.Config{}
+	for _, info := range r.streamFileInfo {
+		existingIDs[info.id] = stream.Config{}
+	}
+
+	succeeded = true
+	seenPaths := map[string]struct{}{}
+	for _, target := range streamsPaths {
+		seenPaths[target[1]] = struct{}{}
+		if _, exists := r.streamFileInfo[target[1]]; exists {
+			continue
+		}
+		if r.streamUpdateFn == nil {
+			continue
+		}
+
+		newConfs := map[string]stream.Config{}
+		for k, v := range existingIDs {
+			newConfs[k] = v
+		}
+
+		lints, err := r.readStreamFile(target[0], target[1], newConfs)
+		if err != nil {
+			mgr.Logger().Errorf("Failed to read new stream config: %v", err)
+			r.trackReload(mgr, target[1], err)
+			continue
+		}
+
+		info, exists := r.streamFileInfo[target[1]]
+		if !exists {
+			// Unit test definitions are not run as streams.
+			continue
+		}
+
+		mgr.Logger().Infof("Stream %v config added, attempting to create stream.", info.id)
+
+		lintlog := mgr.Logger()
+		for _, lint := range lints {
+			lintlog.Infoln(lint)
+		}
+		if strict && len(lints) > 0 {
+			mgr.Logger().Errorf("Rejecting new stream %v config due to linter errors, to allow linting errors run Benthos with --chilled", info.id)
+			r.trackReload(mgr, target[1], errLintRejected)
+			continue
+		}
+
+		if !r.streamUpdateFn(info.id, newConfs[info.id]) {
+			// Forget the file so that creating the stream is attempted again.
+			delete(r.streamFileInfo, target[1])
+			r.trackReload(mgr, target[1], fmt.Errorf("failed to create stream %v", info.id))
+			succeeded = false
+			continue
+		}
+		existingIDs[info.id] = stream.Config{}
+		r.trackReload(mgr, target[1], nil)
+	}
+
+	for path, info := range r.streamFileInfo {
+		if _, exists := seenPaths[path]; exists {
+			continue
+		}
+
+		mgr.Logger().Infof("Stream %v config removed, attempting to remove stream.", info.id)
+		if r.streamRemoveFn != nil && !r.streamRemoveFn(info.id) {
+			r.trackReload(mgr, path, fmt.Errorf("failed to remove stream %v", info.id))
+			succeeded = false
+			continue
+		}
+
+		delete(r.streamFileInfo, path)
+		r.trackReload(mgr, path, nil)
+		removed = append(removed, path)
+	}
+
+	r.forgetMissingReloads()
+	return
 }
@@ -19,6 +19,11 @@ import (
 // changes then the closures registered with either SubscribeConfigChanges or
 // SubscribeStreamChanges will be called.
 //
+// In streams mode the directories of stream configs are also watched, and
+// stream config files that are added or removed result in the closures
+// registered with SubscribeStreamChanges or SubscribeStreamRemovals being
+// called respectively.
+//
 // WARNING: Either SubscribeConfigChanges or SubscribeStreamChanges must be
 // called before this, as otherwise it is unsafe to register them during
 // watching.
@@ -36,28 +41,92 @@ func (r *Reader) BeginFileWatching(mgr bundle.NewManagement, strict bool) error
 	}
 	r.watcher = watcher
 
+	// Directories of stream configs are watched in order to detect stream
+	// config files being added or removed.
+	var initStreamDirs []string
+	if r.streamsMode {
+		if initStreamDirs, err = r.streamDirsExpanded(); err != nil {
+			_ = watcher.Close()
+			return err
+		}
+	}
+	streamDirs := map[string]struct{}{}
+	for _, d := range initStreamDirs {
+		streamDirs[d] = struct{}{}
+	}
+	inStreamDir := func(name string) bool {
+		_, exists := streamDirs[filepath.Dir(name)]
+		return exists
+	}
+
 	go func() {
 		ticker := time.NewTicker(r.changeFlushPeriod)
 		defer ticker.Stop()
 
 		collapsedChanges := map[string]time.Time{}
 		lostNames := map[string]struct{}{}
+
+		var streamDirsChangedAt time.Time
 		for {
 			select {
 			case event, ok := <-watcher.Events:
 				if !ok {
 					return
 				}
+				nameClean := filepath.Clean(event.Name)
+				if _, isDir := streamDirs[nameClean]; isDir {
+					if event.Op&fsnotify.Remove == fsnotify.Remove ||
+						event.Op&fsnotify.Rename == fsnotify.Rename {
+						delete(streamDirs, nameClean)
+						streamDirsChangedAt = time.Now()
+					}
+					continue
+				}
+				if inStreamDir(nameClean) {
+					if event.Op&fsnotify.Create == fsnotify.Create ||
+						event.Op&fsnotify.Remove == fsnotify.Remove ||
+						event.Op&fsnotify.Rename == fsnotify.Rename {
+						streamDirsChangedAt = time.Now()
+					}
+					if _, tracked := r.streamFileInfo[nameClean]; !tracked {
+						// Files that are not yet stream configs, including
+						// those that previously failed to be read, are picked
+						// up by rescanning the stream directories.
+						streamDirsChangedAt = time.Now()
+						continue
+					}
+				}
 				switch {
 				case event.Op&fsnotify.Write == fsnotify.Write:
-					collapsedChanges[filepath.Clean(event.Name)] = time.Now()
+					collapsedChanges[nameClean] = time.Now()
 
 				case event.Op&fsnotify.Remove == fsnotify.Remove ||
 					event.Op&fsnotify.Rename == fsnotify.Rename:
 					_ = watcher.Remove(event.Name)
-					lostNames[filepath.Clean(event.Name)] = struct{}{}
+					lostNames[nameClean] = struct{}{}
 				}
 			case <-ticker.C:
+				if !streamDirsChangedAt.IsZero() && time.Since(streamDirsChangedAt) >= r.changeDelayPeriod {
+					if dirs, err := r.streamDirsExpanded(); err == nil {
+						for _, d := range dirs {
+							if _, exists := streamDirs[d]; !exists {
+								if err := watcher.Add(d); err == nil {
+									streamDirs[d] = struct{}{}
+								}
+							}
+						}
+					}
+					removed, succeeded := r.reactStreamPathsUpdate(mgr, strict)
+					for _, p := range removed {
+						delete(collapsedChanges, p)
+						delete(lostNames, p)
+					}
+					if succeeded {
+						streamDirsChangedAt = time.Time{}
+					} else {
+						streamDirsChangedAt = time.Now()
+					}
+				}
 				for nameClean, changed := range collapsedChanges {
 					if time.Since(changed) < r.changeDelayPeriod {
 						continue
@@ -98,7 +167,13 @@ func (r *Reader) BeginFileWatching(mgr bundle.NewManagement, strict bool) error
 		}
 	}
 
-	// TODO: Refresh this occasionally?
+	for _, d := range initStreamDirs {
+		if err := watcher.Add(d); err != nil {
+			_ = watcher.Close()
+			return err
+		}
+	}
+
 	streamsPaths, err := r.streamPathsExpanded()
 	if err != nil {
 		return err
@@ -95,6 +95,10 @@ type FieldSpec struct {
 	// is missing.
 	IsOptional bool `json:"is_optional,omitempty"`
 
+	// IsSecret indicates that the field represents information that is
+	// generally considered sensitive such as passwords or access tokens.
+	IsSecret bool `json:"is_secret,omitempty"`
+
 	// Default value of the field.
 	Default *interface{} `json:"default,omitempty"`
 
@@ -153,6 +157,15 @@ func (f FieldSpec) Optional() FieldSpec {
 	return f
 }
 
+// Secret marks this field as being a secret, which means it represents
+// information that is generally considered sensitive such as passwords or
+// access tokens. Secret values are scrubbed from sanitised configs when
+// requested, and can be linted for when they're set inline.
+func (f FieldSpec) Secret() FieldSpec {
+	f.IsSecret = true
+	return f
+}
+
 // Advanced marks this field as being advanced, and therefore not commonly used.
 func (f FieldSpec) Advanced() FieldSpec {
 	f.IsAdvanced = true
@@ -563,6 +576,11 @@ type LintContext struct {
 
 	// Reject any deprecated components or fields as linting errors.
 	RejectDeprecated bool
+
+	// Emit warnings for secret fields that are set inline rather than with
+	// interpolations. This should only be enabled when linting a config prior
+	// to interpolations being replaced.
+	WarnInlinedSecrets bool
 }
 
 // NewLintContext creates a new linting context.
@@ -587,11 +605,22 @@ const (
 	LintWarning LintLevel = iota
 )
 
+// LintType describes the kind of issue a lint represents, which allows
+// specific kinds of lints to be filtered.
+type LintType int
+
+// Lint types
+const (
+	LintGeneric LintType = iota
+	LintInlinedSecret
+)
+
 // Lint describes a single linting issue found with a Benthos config.
 type Lint struct {
 	Line   int
 	Column int // Optional, omitted from lint report unless >= 1
 	Level  LintLevel
+	Type   LintType
 	What   string
 }
 
@@ -3,6 +3,7 @@ package http
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"mime"
@@ -17,6 +18,7 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/cloudevents"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
@@ -53,6 +55,7 @@ type Client struct {
 	host              *field.Expression
 	metaInsertFilter  *metadata.IncludeFilter
 	metaExtractFilter *metadata.IncludeFilter
+	cloudEventsMode   string
 
 	conf          docs.Config
 	retryThrottle *throttle.Type
@@ -215,6 +218,14 @@ func OptSetMultiPart(multipart []MultipartExpressions) func(*Client) {
 	}
 }
 
+// OptSetCloudEvents sets the content mode, either binary or structured, with
+// which messages are encoded as CloudEvents in requests.
+func OptSetCloudEvents(mode string) func(*Client) {
+	return func(t *Client) {
+		t.cloudEventsMode = mode
+	}
+}
+
 // OptSetStats sets the metrics aggregator to use.
 func OptSetStats(stats metrics.Type) func(*Client) {
 	return func(t *Client) {
@@ -294,6 +305,7 @@ func (h *Client) waitForAccess(ctx context.Context) bool {
 // and also a message used to form headers (they can be the same).
 func (h *Client) CreateRequest(sendMsg, refMsg *message.Batch) (req *http.Request, err error) {
 	var overrideContentType string
+	var overrideHeaders http.Header
 	var body io.Reader
 	if len(h.multipart) > 0 {
 		buf := &bytes.Buffer{}
@@ -313,6 +325,12 @@ func (h *Client) CreateRequest(sendMsg, refMsg *message.Batch) (req *http.Reques
 		writer.Close()
 		overrideContentType = writer.FormDataContentType()
 		body = buf
+	} else if sendMsg != nil && sendMsg.Len() > 0 && h.cloudEventsMode != "" && h.cloudEventsMode != cloudevents.ModeNone {
+		var msgBytes []byte
+		if overrideHeaders, msgBytes, err = h.cloudEventsRequest(sendMsg); err != nil {
+			return
+		}
+		body = bytes.NewBuffer(msgBytes)
 	} else if sendMsg != nil && sendMsg.Len() == 1 {
 		if msgBytes := sendMsg.Get(0).Get(); len(msgBytes) > 0 {
 			if _, exists := h.headers["Content-Type"]; !exists {
@@ -375,11 +393,48 @@ func (h *Client) CreateRequest(sendMsg, refMsg *message.Batch) (req *http.Reques
 		req.Header.Del("Content-Type")
 		req.Header.Add("Content-Type", overrideContentType)
 	}
+	for k, v := range overrideHeaders {
+		req.Header[k] = v
+	}
 
 	err = h.conf.Config.Sign(req)
 	return
 }
 
+// cloudEventsRequest encodes a message as CloudEvents, where a batch of
+// multiple messages is only supported in structured mode.
+func (h *Client) cloudEventsRequest(sendMsg *message.Batch) (http.Header, []byte, error) {
+	if sendMsg.Len() == 1 {
+		p := sendMsg.Get(0)
+		header, body, err := cloudevents.EncodeHTTP(cloudevents.FromMetadata(p.MetaIter, p.Get()), h.cloudEventsMode)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode message as a CloudEvent: %w", err)
+		}
+		return header, body, nil
+	}
+
+	if h.cloudEventsMode != cloudevents.ModeStructured {
+		return nil, nil, fmt.Errorf("unable to send a batch of %v CloudEvents in %v content mode", sendMsg.Len(), h.cloudEventsMode)
+	}
+	events := make([]json.RawMessage, sendMsg.Len())
+	if err := sendMsg.Iter(func(i int, p *message.Part) error {
+		var err error
+		if events[i], err = cloudevents.FromMetadata(p.MetaIter, p.Get()).MarshalStructured(); err != nil {
+			return fmt.Errorf("failed to encode message %v as a CloudEvent: %w", i, err)
+		}
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+	body, err := json.Marshal(events)
+	if err != nil {
+		return nil, nil, err
+	}
+	header := http.Header{}
+	header.Set("Content-Type", cloudevents.BatchContentType)
+	return header, body, nil
+}
+
 // ParseResponse attempts to parse an HTTP response into a 2D slice of bytes.
 func (h *Client) ParseResponse(res *http.Response) (resMsg *message.Batch, err error) {
 	resMsg = message.QuickBatch(nil)
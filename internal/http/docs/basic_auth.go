@@ -106,7 +106,7 @@ func BasicAuthFieldSpec() docs.FieldSpec {
 		docs.FieldBool("enabled", "Enable basic authentication").HasDefault(false),
 		docs.FieldString("realm", "Custom realm name").HasDefault("restricted"),
 		docs.FieldString("username", "Username required to authenticate.").HasDefault(""),
-		docs.FieldString("password_hash", "Hashed password required to authenticate. (base64 encoded)").HasDefault(""),
+		docs.FieldString("password_hash", "Hashed password required to authenticate. (base64 encoded)").HasDefault("").Secret(),
 		docs.FieldString("algorithm", "Encryption algorithm used to generate `password_hash`.", "md5", "sha256", "bcrypt", "scrypt").HasDefault("sha256"),
 		docs.FieldString("salt", "Salt for scrypt algorithm. (base64 encoded)").HasDefault(""),
 	).Advanced()
@@ -0,0 +1,90 @@
+package amqp1
+
+import (
+	"strings"
+
+	"github.com/Azure/go-amqp"
+
+	"github.com/benthosdev/benthos/v4/internal/cloudevents"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// Prefixes of application properties that carry the attributes of binary mode
+// events, where the latter is from earlier drafts of the AMQP protocol binding.
+const (
+	cloudEventsPropPrefix       = "cloudEvents_"
+	cloudEventsPropPrefixLegacy = "cloudEvents:"
+)
+
+// decodeCloudEvent replaces the contents of a message part with the data of the
+// CloudEvent within an AMQP message, and adds its attributes as metadata.
+// Messages that are not valid events result in the part being flagged with an
+// error.
+func decodeCloudEvent(amqpMsg *amqp.Message, part *message.Part) {
+	var contentType string
+	if amqpMsg.Properties != nil && amqpMsg.Properties.ContentType != nil {
+		contentType = *amqpMsg.Properties.ContentType
+	}
+
+	attrs := map[string]string{}
+	for k, v := range amqpMsg.ApplicationProperties {
+		var attr string
+		switch {
+		case strings.HasPrefix(k, cloudEventsPropPrefix):
+			attr = strings.TrimPrefix(k, cloudEventsPropPrefix)
+		case strings.HasPrefix(k, cloudEventsPropPrefixLegacy):
+			attr = strings.TrimPrefix(k, cloudEventsPropPrefixLegacy)
+		default:
+			continue
+		}
+		attrs[attr] = amqpValueToString(v)
+	}
+
+	var event cloudevents.Event
+	var err error
+	switch {
+	case cloudevents.IsStructuredContentType(contentType):
+		event, err = cloudevents.ParseStructured(amqpMsg.GetData())
+	case len(attrs) > 0:
+		event, err = cloudevents.ParseBinary(attrs, contentType, amqpMsg.GetData())
+	default:
+		err = cloudevents.ErrNotEvent
+	}
+	if err != nil {
+		part.ErrorSet(err)
+		return
+	}
+
+	part.Set(event.Data)
+	event.WalkMetadata(part.MetaSet)
+}
+
+// encodeCloudEvent creates an AMQP message from a message part and the
+// attributes within its metadata as a CloudEvent.
+func encodeCloudEvent(part *message.Part, mode string) (*amqp.Message, error) {
+	event := cloudevents.FromMetadata(part.MetaIter, part.Get())
+	if mode == cloudevents.ModeStructured {
+		data, err := event.MarshalStructured()
+		if err != nil {
+			return nil, err
+		}
+		m := amqp.NewMessage(data)
+		contentType := cloudevents.StructuredContentType
+		m.Properties = &amqp.MessageProperties{ContentType: &contentType}
+		return m, nil
+	}
+
+	attrs, contentType, err := event.BinaryAttributes()
+	if err != nil {
+		return nil, err
+	}
+	m := amqp.NewMessage(event.Data)
+	m.ApplicationProperties = make(map[string]interface{}, len(attrs))
+	for k, v := range attrs {
+		m.ApplicationProperties[cloudEventsPropPrefix+k] = v
+	}
+	if contentType != "" {
+		m.Properties = &amqp.MessageProperties{ContentType: &contentType}
+	}
+	return m, nil
+}
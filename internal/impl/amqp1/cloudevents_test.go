@@ -0,0 +1,79 @@
+package amqp1
+
+import (
+	"testing"
+
+	"github.com/Azure/go-amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestCloudEventsRoundTrip(t *testing.T) {
+	newPart := func() *message.Part {
+		p := message.NewPart([]byte(`hello world`))
+		p.MetaSet("ce_specversion", "1.0")
+		p.MetaSet("ce_id", "1")
+		p.MetaSet("ce_source", "/foo")
+		p.MetaSet("ce_type", "com.example.bar")
+		p.MetaSet("ce_datacontenttype", "text/plain")
+		return p
+	}
+
+	for _, mode := range []string{"binary", "structured"} {
+		mode := mode
+		t.Run(mode, func(t *testing.T) {
+			m, err := encodeCloudEvent(newPart(), mode)
+			require.NoError(t, err)
+
+			if mode == "binary" {
+				assert.Equal(t, "hello world", string(m.GetData()))
+				assert.Equal(t, "text/plain", *m.Properties.ContentType)
+				assert.Equal(t, "com.example.bar", m.ApplicationProperties["cloudEvents_type"])
+			} else {
+				assert.Equal(t, "application/cloudevents+json", *m.Properties.ContentType)
+			}
+
+			part := message.NewPart(nil)
+			decodeCloudEvent(m, part)
+			require.NoError(t, part.ErrorGet())
+
+			assert.Equal(t, "hello world", string(part.Get()))
+			assert.Equal(t, "1.0", part.MetaGet("ce_specversion"))
+			assert.Equal(t, "1", part.MetaGet("ce_id"))
+			assert.Equal(t, "/foo", part.MetaGet("ce_source"))
+			assert.Equal(t, "com.example.bar", part.MetaGet("ce_type"))
+			assert.Equal(t, "text/plain", part.MetaGet("ce_datacontenttype"))
+		})
+	}
+}
+
+func TestCloudEventsDecodeLegacyAndInvalid(t *testing.T) {
+	m := amqp.NewMessage([]byte(`hello world`))
+	m.ApplicationProperties = map[string]interface{}{
+		"cloudEvents:specversion": "1.0",
+		"cloudEvents:id":          "1",
+		"cloudEvents:source":      "/foo",
+		"cloudEvents:type":        "com.example.bar",
+		"cloudEvents:count":       int32(5),
+	}
+
+	part := message.NewPart(nil)
+	decodeCloudEvent(m, part)
+	require.NoError(t, part.ErrorGet())
+	assert.Equal(t, "1", part.MetaGet("ce_id"))
+	assert.Equal(t, "5", part.MetaGet("ce_count"))
+
+	delete(m.ApplicationProperties, "cloudEvents:type")
+	part = message.NewPart(nil)
+	decodeCloudEvent(m, part)
+	require.Error(t, part.ErrorGet())
+
+	part = message.NewPart(nil)
+	decodeCloudEvent(amqp.NewMessage([]byte(`not an event`)), part)
+	require.Error(t, part.ErrorGet())
+
+	_, err := encodeCloudEvent(message.NewPart([]byte(`nope`)), "binary")
+	require.Error(t, err)
+}
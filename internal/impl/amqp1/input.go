@@ -48,7 +48,11 @@ This input adds the following metadata fields to each message:
 ` + "```" + `
 
 You can access these metadata fields using
-[function interpolation](/docs/configuration/interpolation#metadata).`,
+[function interpolation](/docs/configuration/interpolation#metadata).
+
+### CloudEvents
+
+When the field ` + "`cloudevents`" + ` is set to ` + "`true`" + ` messages are decoded as [CloudEvents](https://cloudevents.io) in either binary content mode, where attributes are application properties with the prefix ` + "`cloudEvents_`" + ` (or ` + "`cloudEvents:`" + `), or structured content mode, where the message has the content type ` + "`application/cloudevents+json`" + `. The data of each event becomes the contents of the message and its attributes are added as metadata with the prefix ` + "`ce_`" + `. Messages that are not valid events, including those missing any of the required attributes ` + "`specversion`, `id`, `source` and `type`" + `, are flagged as having failed, which can be handled with [error handling patterns](/docs/configuration/error_handling).`,
 		Categories: []string{
 			"Services",
 		},
@@ -60,6 +64,7 @@ You can access these metadata fields using
 			).HasDefault(""),
 			docs.FieldString("source_address", "The source address to consume from.", "/foo", "queue:/bar", "topic:/baz").HasDefault(""),
 			docs.FieldBool("azure_renew_lock", "Experimental: Azure service bus specific option to renew lock if processing takes more then configured lock time").AtVersion("3.45.0").HasDefault(false).Advanced(),
+			docs.FieldBool("cloudevents", "Whether to decode messages as [CloudEvents](https://cloudevents.io) in either binary or structured content mode, adding the attributes of events as metadata with the prefix `ce_`.").HasDefault(false).Advanced(),
 			itls.FieldSpec(),
 			shared.SASLFieldSpec(),
 		),
@@ -257,6 +262,10 @@ func (a *amqp1Reader) ReadWithContext(ctx context.Context) (*message.Batch, inpu
 		}
 	}
 
+	if a.conf.CloudEvents {
+		decodeCloudEvent(amqpMsg, part)
+	}
+
 	msg.Append(part)
 
 	var done chan struct{}
@@ -413,37 +422,34 @@ func (a *amqp1Reader) renewWithContext(ctx context.Context, msg *amqp.Message) (
 }
 
 func amqpSetMetadata(p *message.Part, k string, v interface{}) {
-	var metaValue string
-	var metaKey = strings.ReplaceAll(k, "-", "_")
+	metaKey := strings.ReplaceAll(k, "-", "_")
+	if metaValue := amqpValueToString(v); metaValue != "" {
+		p.MetaSet(metaKey, metaValue)
+	}
+}
 
+func amqpValueToString(v interface{}) string {
 	switch v := v.(type) {
 	case bool:
-		metaValue = strconv.FormatBool(v)
+		return strconv.FormatBool(v)
 	case float32:
-		metaValue = strconv.FormatFloat(float64(v), 'f', -1, 32)
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
 	case float64:
-		metaValue = strconv.FormatFloat(v, 'f', -1, 64)
+		return strconv.FormatFloat(v, 'f', -1, 64)
 	case byte:
-		metaValue = strconv.Itoa(int(v))
+		return strconv.Itoa(int(v))
 	case int16:
-		metaValue = strconv.Itoa(int(v))
+		return strconv.Itoa(int(v))
 	case int32:
-		metaValue = strconv.Itoa(int(v))
+		return strconv.Itoa(int(v))
 	case int64:
-		metaValue = strconv.Itoa(int(v))
-	case nil:
-		metaValue = ""
+		return strconv.Itoa(int(v))
 	case string:
-		metaValue = v
+		return v
 	case []byte:
-		metaValue = string(v)
+		return string(v)
 	case time.Time:
-		metaValue = v.Format(time.RFC3339)
-	default:
-		metaValue = ""
-	}
-
-	if metaValue != "" {
-		p.MetaSet(metaKey, metaValue)
+		return v.Format(time.RFC3339)
 	}
+	return ""
 }
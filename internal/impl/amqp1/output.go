@@ -4,12 +4,14 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/Azure/go-amqp"
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/cloudevents"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/component/output/processors"
@@ -39,7 +41,11 @@ func init() {
 		Description: output.Description(true, false, `
 ### Metadata
 
-Message metadata is added to each AMQP message as string annotations. In order to control which metadata keys are added use the `+"`metadata`"+` config field.`),
+Message metadata is added to each AMQP message as string annotations. In order to control which metadata keys are added use the `+"`metadata`"+` config field.
+
+### CloudEvents
+
+Messages can be sent as [CloudEvents](https://cloudevents.io) by setting the field `+"`cloudevents`"+` to either `+"`binary` or `structured`"+`. The attributes of each event are taken from metadata with the prefix `+"`ce_`, e.g. `ce_type`"+`, and the contents of the message become the data of the event. In binary mode attributes are sent as application properties with the prefix `+"`cloudEvents_`"+` and the content type as the message content type, whereas in structured mode the message body is a JSON event. Messages missing any of the required attributes `+"`ce_specversion`, `ce_id`, `ce_source` and `ce_type`"+` are rejected.`),
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("url",
 				"A URL to connect to.",
@@ -52,6 +58,7 @@ Message metadata is added to each AMQP message as string annotations. In order t
 			shared.SASLFieldSpec(),
 			docs.FieldObject("metadata", "Specify criteria for which metadata values are attached to messages as headers.").
 				WithChildren(metadata.ExcludeFilterFields()...),
+			docs.FieldString("cloudevents", "Encode messages as [CloudEvents](https://cloudevents.io) in either binary or structured content mode, where the attributes of events are taken from metadata with the prefix `ce_`.").HasOptions("none", "binary", "structured").HasDefault("none").Advanced(),
 		),
 		Categories: []string{
 			"Services",
@@ -91,6 +98,11 @@ func newAMQP1Writer(conf output.AMQP1Config, log log.Modular) (*amqp1Writer, err
 	if a.metaFilter, err = conf.Metadata.Filter(); err != nil {
 		return nil, fmt.Errorf("failed to construct metadata filter: %w", err)
 	}
+	switch conf.CloudEvents {
+	case "", cloudevents.ModeNone, cloudevents.ModeBinary, cloudevents.ModeStructured:
+	default:
+		return nil, fmt.Errorf("cloudevents content mode not recognised: %v", conf.CloudEvents)
+	}
 	return &a, nil
 }
 
@@ -189,7 +201,16 @@ func (a *amqp1Writer) WriteWithContext(ctx context.Context, msg *message.Batch)
 
 	return output.IterateBatchedSend(msg, func(i int, p *message.Part) error {
 		m := amqp.NewMessage(p.Get())
+		if ceMode := a.conf.CloudEvents; ceMode != "" && ceMode != cloudevents.ModeNone {
+			var err error
+			if m, err = encodeCloudEvent(p, ceMode); err != nil {
+				return fmt.Errorf("failed to encode message as a CloudEvent: %w", err)
+			}
+		}
 		_ = a.metaFilter.Iter(p, func(k, v string) error {
+			if ceMode := a.conf.CloudEvents; ceMode != "" && ceMode != cloudevents.ModeNone && strings.HasPrefix(k, cloudevents.MetaPrefix) {
+				return nil
+			}
 			if m.Annotations == nil {
 				m.Annotations = amqp.Annotations{}
 			}
@@ -24,10 +24,10 @@ func SessionFields() []*service.ConfigField {
 				Default("").Advanced(),
 			service.NewStringField("secret").
 				Description("The secret for the credentials being used.").
-				Default("").Advanced(),
+				Default("").Advanced().Secret(),
 			service.NewStringField("token").
 				Description("The token for the credentials being used, required when using short term credentials.").
-				Default("").Advanced(),
+				Default("").Advanced().Secret(),
 			service.NewBoolField("from_ec2_role").
 				Description("Use the credentials of a host EC2 machine configured to assume [an IAM role associated with the instance](https://docs.aws.amazon.com/IAM/latest/UserGuide/id_roles_use_switch-role-ec2.html).").
 				Default(false).Version("4.2.0"),
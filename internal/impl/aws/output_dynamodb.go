@@ -41,6 +41,15 @@ func init() {
 		if err != nil {
 			return nil, err
 		}
+		if c.AWSDynamoDB.AdaptiveConcurrency.Enabled {
+			aw, ok := w.(*output.AsyncWriter)
+			if !ok {
+				return nil, fmt.Errorf("unable to set adaptive_concurrency due to wrong type: %T", w)
+			}
+			if err := aw.SetAdaptiveConcurrency(c.AWSDynamoDB.AdaptiveConcurrency); err != nil {
+				return nil, err
+			}
+		}
 		return batcher.NewFromConfig(c.AWSDynamoDB.Batching, w, nm)
 	}), docs.ComponentSpec{
 		Name:    "aws_dynamodb",
@@ -113,6 +122,7 @@ allowing you to transfer data across accounts. You can find out more
 			docs.FieldString("ttl", "An optional TTL to set for items, calculated from the moment the message is sent.").Advanced(),
 			docs.FieldString("ttl_key", "The column key to place the TTL value within.").Advanced(),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			output.AdaptiveConcurrencyFieldSpec(),
 			policy.FieldSpec(),
 		).WithChildren(session.FieldSpecs()...).WithChildren(retries.FieldSpecs()...).ChildDefaultAndTypesFromStruct(output.NewDynamoDBConfig()),
 		Categories: []string{
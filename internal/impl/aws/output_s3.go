@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net/url"
 	"sort"
 	"strings"
@@ -25,6 +26,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/metadata"
+	"github.com/benthosdev/benthos/v4/internal/rolling"
 )
 
 func init() {
@@ -114,7 +116,30 @@ output:
       processors:
         - archive:
             format: json_array
-`+"```"+``),
+`+"```"+`
+
+### Rolling
+
+Data lake layouts, where messages are written to files grouped by partition, can be produced by enabling the `+"`rolling`"+` field. For example, in order to write gzip compressed lines of messages to hourly partitions, where each file is rolled after 10,000 messages or five minutes, we could use the following config:
+
+`+"```yaml"+`
+output:
+  aws_s3:
+    bucket: TODO
+    max_in_flight: 64
+    batching:
+      count: 1000
+      period: 1s
+    rolling:
+      enabled: true
+      partition: 'dt=${! now().ts_format("2006-01-02") }/hour=${! now().ts_format("15") }'
+      file_suffix: .jsonl.gz
+      codec: gzip/lines
+      max_records: 10000
+      max_age: 5m
+`+"```"+`
+
+The fields of each object such as its content type and tags are derived from the first message written to it.`),
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("bucket", "The bucket to upload messages to."),
 			docs.FieldString(
@@ -146,6 +171,7 @@ output:
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			docs.FieldString("timeout", "The maximum period to wait on an upload before abandoning it and reattempting.").Advanced(),
 			policy.FieldSpec(),
+			rolling.FieldSpec().AtVersion("4.4.0"),
 		).WithChildren(sess.FieldSpecs()...).ChildDefaultAndTypesFromStruct(output.NewAmazonS3Config()),
 		Categories: []string{
 			"Services",
@@ -179,6 +205,7 @@ type amazonS3Writer struct {
 	session  *session.Session
 	uploader *s3manager.Uploader
 	timeout  time.Duration
+	rolling  *rolling.Writer
 
 	log log.Modular
 }
@@ -241,6 +268,20 @@ func newAmazonS3Writer(conf output.AmazonS3Config, mgr bundle.NewManagement) (*a
 		return a.tags[i].key < a.tags[j].key
 	})
 
+	if conf.Rolling.Enabled {
+		store := rolling.NewBufferedStore(func(ctx context.Context, path string, first *message.Part, contents []byte) error {
+			ctx, cancel := context.WithTimeout(ctx, a.timeout)
+			defer cancel()
+
+			msg := message.QuickBatch(nil)
+			msg.Append(first)
+			_, err := a.uploader.UploadWithContext(ctx, a.uploadInput(path, bytes.NewReader(contents), 0, msg))
+			return err
+		})
+		if a.rolling, err = rolling.NewWriter(conf.Rolling, conf.MaxInFlight, store, mgr); err != nil {
+			return nil, err
+		}
+	}
 	return a, nil
 }
 
@@ -268,84 +309,100 @@ func (a *amazonS3Writer) WriteWithContext(wctx context.Context, msg *message.Bat
 		return component.ErrNotConnected
 	}
 
+	if a.rolling != nil {
+		// Writes block until files are committed, and uploads are subject to
+		// the timeout individually.
+		return a.rolling.WriteWithContext(wctx, msg)
+	}
+
 	ctx, cancel := context.WithTimeout(
 		wctx, a.timeout,
 	)
 	defer cancel()
 
 	return output.IterateBatchedSend(msg, func(i int, p *message.Part) error {
-		metadata := map[string]*string{}
-		_ = a.metaFilter.Iter(p, func(k, v string) error {
-			metadata[k] = aws.String(v)
-			return nil
-		})
-
-		var contentEncoding *string
-		if ce := a.contentEncoding.String(i, msg); len(ce) > 0 {
-			contentEncoding = aws.String(ce)
-		}
-		var cacheControl *string
-		if ce := a.cacheControl.String(i, msg); len(ce) > 0 {
-			cacheControl = aws.String(ce)
-		}
-		var contentDisposition *string
-		if ce := a.contentDisposition.String(i, msg); len(ce) > 0 {
-			contentDisposition = aws.String(ce)
-		}
-		var contentLanguage *string
-		if ce := a.contentLanguage.String(i, msg); len(ce) > 0 {
-			contentLanguage = aws.String(ce)
-		}
-		var websiteRedirectLocation *string
-		if ce := a.websiteRedirectLocation.String(i, msg); len(ce) > 0 {
-			websiteRedirectLocation = aws.String(ce)
+		uploadInput := a.uploadInput(a.path.String(i, msg), bytes.NewReader(p.Get()), i, msg)
+		if _, err := a.uploader.UploadWithContext(ctx, uploadInput); err != nil {
+			return err
 		}
+		return nil
+	})
+}
 
-		uploadInput := &s3manager.UploadInput{
-			Bucket:                  &a.conf.Bucket,
-			Key:                     aws.String(a.path.String(i, msg)),
-			Body:                    bytes.NewReader(p.Get()),
-			ContentType:             aws.String(a.contentType.String(i, msg)),
-			ContentEncoding:         contentEncoding,
-			CacheControl:            cacheControl,
-			ContentDisposition:      contentDisposition,
-			ContentLanguage:         contentLanguage,
-			WebsiteRedirectLocation: websiteRedirectLocation,
-			StorageClass:            aws.String(a.storageClass.String(i, msg)),
-			Metadata:                metadata,
-		}
+func (a *amazonS3Writer) uploadInput(key string, body io.Reader, i int, msg *message.Batch) *s3manager.UploadInput {
+	metadata := map[string]*string{}
+	_ = a.metaFilter.Iter(msg.Get(i), func(k, v string) error {
+		metadata[k] = aws.String(v)
+		return nil
+	})
 
-		// Prepare tags, escaping keys and values to ensure they're valid query string parameters.
-		if len(a.tags) > 0 {
-			tags := make([]string, len(a.tags))
-			for j, pair := range a.tags {
-				tags[j] = url.QueryEscape(pair.key) + "=" + url.QueryEscape(pair.value.String(i, msg))
-			}
-			uploadInput.Tagging = aws.String(strings.Join(tags, "&"))
-		}
+	var contentEncoding *string
+	if ce := a.contentEncoding.String(i, msg); len(ce) > 0 {
+		contentEncoding = aws.String(ce)
+	}
+	var cacheControl *string
+	if ce := a.cacheControl.String(i, msg); len(ce) > 0 {
+		cacheControl = aws.String(ce)
+	}
+	var contentDisposition *string
+	if ce := a.contentDisposition.String(i, msg); len(ce) > 0 {
+		contentDisposition = aws.String(ce)
+	}
+	var contentLanguage *string
+	if ce := a.contentLanguage.String(i, msg); len(ce) > 0 {
+		contentLanguage = aws.String(ce)
+	}
+	var websiteRedirectLocation *string
+	if ce := a.websiteRedirectLocation.String(i, msg); len(ce) > 0 {
+		websiteRedirectLocation = aws.String(ce)
+	}
 
-		if a.conf.KMSKeyID != "" {
-			uploadInput.ServerSideEncryption = aws.String("aws:kms")
-			uploadInput.SSEKMSKeyId = &a.conf.KMSKeyID
-		}
+	uploadInput := &s3manager.UploadInput{
+		Bucket:                  &a.conf.Bucket,
+		Key:                     aws.String(key),
+		Body:                    body,
+		ContentType:             aws.String(a.contentType.String(i, msg)),
+		ContentEncoding:         contentEncoding,
+		CacheControl:            cacheControl,
+		ContentDisposition:      contentDisposition,
+		ContentLanguage:         contentLanguage,
+		WebsiteRedirectLocation: websiteRedirectLocation,
+		StorageClass:            aws.String(a.storageClass.String(i, msg)),
+		Metadata:                metadata,
+	}
 
-		// NOTE: This overrides the ServerSideEncryption set above. We need this to preserve
-		// backwards compatibility, where it is allowed to only set kms_key_id in the config and
-		// the ServerSideEncryption value of "aws:kms" is implied.
-		if a.conf.ServerSideEncryption != "" {
-			uploadInput.ServerSideEncryption = &a.conf.ServerSideEncryption
+	// Prepare tags, escaping keys and values to ensure they're valid query string parameters.
+	if len(a.tags) > 0 {
+		tags := make([]string, len(a.tags))
+		for j, pair := range a.tags {
+			tags[j] = url.QueryEscape(pair.key) + "=" + url.QueryEscape(pair.value.String(i, msg))
 		}
+		uploadInput.Tagging = aws.String(strings.Join(tags, "&"))
+	}
 
-		if _, err := a.uploader.UploadWithContext(ctx, uploadInput); err != nil {
-			return err
-		}
-		return nil
-	})
+	if a.conf.KMSKeyID != "" {
+		uploadInput.ServerSideEncryption = aws.String("aws:kms")
+		uploadInput.SSEKMSKeyId = &a.conf.KMSKeyID
+	}
+
+	// NOTE: This overrides the ServerSideEncryption set above. We need this to preserve
+	// backwards compatibility, where it is allowed to only set kms_key_id in the config and
+	// the ServerSideEncryption value of "aws:kms" is implied.
+	if a.conf.ServerSideEncryption != "" {
+		uploadInput.ServerSideEncryption = &a.conf.ServerSideEncryption
+	}
+	return uploadInput
 }
 
 func (a *amazonS3Writer) CloseAsync() {
+	if a.rolling != nil {
+		a.rolling.CloseAsync()
+	}
 }
 
-func (a *amazonS3Writer) WaitForClose(time.Duration) error {
+func (a *amazonS3Writer) WaitForClose(timeout time.Duration) error {
+	if a.rolling != nil {
+		return a.rolling.WaitForClose(timeout)
+	}
 	return nil
 }
@@ -0,0 +1,107 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func init() {
+	lookup := &secretsManagerLookup{
+		newClient: func() (secretsmanageriface.SecretsManagerAPI, error) {
+			sess, err := session.NewSessionWithOptions(session.Options{
+				SharedConfigState: session.SharedConfigEnable,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return secretsmanager.New(sess), nil
+		},
+	}
+	err := service.RegisterSecretsProvider("aws_sm", lookup.Lookup)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// secretsManagerLookup obtains secrets from AWS Secrets Manager, where the
+// path is the name or ARN of a secret, optionally followed by a hash and the
+// key of a field within a secret stored as a JSON object (name#key).
+//
+// The client is created lazily using the default credentials chain, as most
+// configs will never reference a secret from this provider.
+type secretsManagerLookup struct {
+	mut       sync.Mutex
+	client    secretsmanageriface.SecretsManagerAPI
+	newClient func() (secretsmanageriface.SecretsManagerAPI, error)
+}
+
+func (s *secretsManagerLookup) getClient() (secretsmanageriface.SecretsManagerAPI, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.client == nil {
+		client, err := s.newClient()
+		if err != nil {
+			return nil, err
+		}
+		s.client = client
+	}
+	return s.client, nil
+}
+
+func (s *secretsManagerLookup) Lookup(ctx context.Context, path string) (string, error) {
+	name, key := path, ""
+	if i := strings.LastIndexByte(path, '#'); i != -1 {
+		name, key = path[:i], path[i+1:]
+	}
+
+	client, err := s.getClient()
+	if err != nil {
+		return "", err
+	}
+
+	out, err := client.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(name),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var value string
+	if out.SecretString != nil {
+		value = *out.SecretString
+	} else {
+		value = string(out.SecretBinary)
+	}
+	if key == "" {
+		return value, nil
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &obj); err != nil {
+		return "", errors.New("secret is not a JSON object and therefore a key cannot be selected")
+	}
+	v, exists := obj[key]
+	if !exists {
+		return "", fmt.Errorf("key '%v' does not exist within secret", key)
+	}
+	if str, ok := v.(string); ok {
+		return str, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
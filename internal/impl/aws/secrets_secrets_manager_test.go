@@ -0,0 +1,67 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSecretsManager struct {
+	secretsmanageriface.SecretsManagerAPI
+	secrets map[string]*secretsmanager.GetSecretValueOutput
+}
+
+func (m *mockSecretsManager) GetSecretValueWithContext(ctx aws.Context, input *secretsmanager.GetSecretValueInput, opts ...request.Option) (*secretsmanager.GetSecretValueOutput, error) {
+	out, exists := m.secrets[*input.SecretId]
+	if !exists {
+		return nil, errors.New("secret not found")
+	}
+	return out, nil
+}
+
+func TestSecretsManagerLookup(t *testing.T) {
+	var clientsCreated int
+	lookup := &secretsManagerLookup{
+		newClient: func() (secretsmanageriface.SecretsManagerAPI, error) {
+			clientsCreated++
+			return &mockSecretsManager{
+				secrets: map[string]*secretsmanager.GetSecretValueOutput{
+					"plain": {SecretString: aws.String("hunter2")},
+					"json":  {SecretString: aws.String(`{"user":"foo","pass":"bar","port":5432}`)},
+					"bin":   {SecretBinary: []byte("binary")},
+				},
+			}, nil
+		},
+	}
+
+	tests := map[string]string{
+		"plain":     "hunter2",
+		"json#user": "foo",
+		"json#pass": "bar",
+		"json#port": "5432",
+		"bin":       "binary",
+	}
+	for path, exp := range tests {
+		v, err := lookup.Lookup(context.Background(), path)
+		require.NoError(t, err, path)
+		assert.Equal(t, exp, v, path)
+	}
+	assert.Equal(t, 1, clientsCreated)
+
+	for path, errContains := range map[string]string{
+		"nope":       "secret not found",
+		"plain#user": "not a JSON object",
+		"json#nope":  "key 'nope' does not exist",
+	} {
+		_, err := lookup.Lookup(context.Background(), path)
+		require.Error(t, err, path)
+		assert.Contains(t, err.Error(), errContains, path)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/rolling"
 )
 
 func init() {
@@ -38,7 +39,28 @@ Only one authentication method is required, `+"`storage_connection_string`"+` or
 
 In order to have a different path for each object you should use function
 interpolations described [here](/docs/configuration/interpolation#bloblang-queries), which are
-calculated per message of a batch.`),
+calculated per message of a batch.
+
+### Rolling
+
+Data lake layouts, where messages are written to files grouped by partition, can be produced by enabling the `+"`rolling`"+` field. For example, in order to write gzip compressed lines of messages to hourly partitions, where each file is rolled after 10,000 messages or five minutes, we could use the following config:
+
+`+"```yaml"+`
+output:
+  azure_blob_storage:
+    storage_connection_string: TODO
+    container: TODO
+    max_in_flight: 64
+    rolling:
+      enabled: true
+      partition: 'dt=${! now().ts_format("2006-01-02") }/hour=${! now().ts_format("15") }'
+      file_suffix: .jsonl.gz
+      codec: gzip/lines
+      max_records: 10000
+      max_age: 5m
+`+"```"+`
+
+The container of each file is derived from the first message written to it, and files are always uploaded as block blobs.`),
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString(
 				"storage_account",
@@ -47,15 +69,15 @@ calculated per message of a batch.`),
 			docs.FieldString(
 				"storage_access_key",
 				"The storage account access key. This field is ignored if `storage_connection_string` is set.",
-			),
+			).Secret(),
 			docs.FieldString(
 				"storage_sas_token",
 				"The storage account SAS token. This field is ignored if `storage_connection_string` or `storage_access_key` / `storage_sas_token` are set.",
-			).AtVersion("3.38.0"),
+			).AtVersion("3.38.0").Secret(),
 			docs.FieldString(
 				"storage_connection_string",
 				"A storage account connection string. This field is required if `storage_account` and `storage_access_key` are not set.",
-			),
+			).Secret(),
 			docs.FieldString("public_access_level", `The container's public access level. The default value is `+"`PRIVATE`"+`.`).HasOptions(
 				"PRIVATE", "BLOB", "CONTAINER",
 			).Advanced(),
@@ -73,6 +95,7 @@ calculated per message of a batch.`),
 				"BLOCK", "APPEND",
 			).IsInterpolated().Advanced(),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			rolling.FieldSpec().AtVersion("4.4.0"),
 		).ChildDefaultAndTypesFromStruct(output.NewAzureBlobStorageConfig()),
 		Categories: []string{
 			"Services",
@@ -93,6 +116,9 @@ func newAzureBlobStorageOutput(conf output.Config, mgr bundle.NewManagement, log
 	if err != nil {
 		return nil, err
 	}
+	if conf.AzureBlobStorage.Rolling.Enabled {
+		return a, nil
+	}
 	return output.OnlySinglePayloads(a), nil
 }
 
@@ -103,6 +129,7 @@ type azureBlobStorageWriter struct {
 	blobType    *field.Expression
 	accessLevel *field.Expression
 	client      storage.BlobStorageClient
+	rolling     *rolling.Writer
 	log         log.Modular
 }
 
@@ -149,6 +176,11 @@ func newAzureBlobStorageWriter(mgr bundle.NewManagement, conf output.AzureBlobSt
 	if a.accessLevel, err = mgr.BloblEnvironment().NewField(conf.PublicAccessLevel); err != nil {
 		return nil, fmt.Errorf("failed to parse public access level expression: %v", err)
 	}
+	if conf.Rolling.Enabled {
+		if a.rolling, err = rolling.NewWriter(conf.Rolling, conf.MaxInFlight, rolling.NewBufferedStore(a.putFile), mgr); err != nil {
+			return nil, err
+		}
+	}
 	return a, nil
 }
 
@@ -183,24 +215,39 @@ func (a *azureBlobStorageWriter) createContainer(c *storage.Container, accessLev
 	return c.Create(&opts)
 }
 
-func (a *azureBlobStorageWriter) WriteWithContext(_ context.Context, msg *message.Batch) error {
-	return output.IterateBatchedSend(msg, func(i int, p *message.Part) error {
-		c := a.client.GetContainerReference(a.container.String(i, msg))
-		b := c.GetBlobReference(a.path.String(i, msg))
-		if err := a.uploadBlob(b, a.blobType.String(i, msg), p.Get()); err != nil {
-			if containerNotFound(err) {
-				if cerr := a.createContainer(c, a.accessLevel.String(i, msg)); cerr != nil {
-					a.log.Debugf("error creating container: %v.", cerr)
-					return cerr
-				}
-				err = a.uploadBlob(b, a.blobType.String(i, msg), p.Get())
-				if err != nil {
-					a.log.Debugf("error retrying to upload  blob: %v.", err)
-				}
+func (a *azureBlobStorageWriter) putBlob(containerName, path, blobType, accessLevel string, data []byte) error {
+	c := a.client.GetContainerReference(containerName)
+	b := c.GetBlobReference(path)
+	if err := a.uploadBlob(b, blobType, data); err != nil {
+		if containerNotFound(err) {
+			if cerr := a.createContainer(c, accessLevel); cerr != nil {
+				a.log.Debugf("error creating container: %v.", cerr)
+				return cerr
+			}
+			err = a.uploadBlob(b, blobType, data)
+			if err != nil {
+				a.log.Debugf("error retrying to upload  blob: %v.", err)
 			}
-			return err
 		}
-		return nil
+		return err
+	}
+	return nil
+}
+
+// putFile uploads the contents of a rolled file as a block blob, which is
+// committed atomically.
+func (a *azureBlobStorageWriter) putFile(_ context.Context, path string, first *message.Part, contents []byte) error {
+	msg := message.QuickBatch(nil)
+	msg.Append(first)
+	return a.putBlob(a.container.String(0, msg), path, "BLOCK", a.accessLevel.String(0, msg), contents)
+}
+
+func (a *azureBlobStorageWriter) WriteWithContext(ctx context.Context, msg *message.Batch) error {
+	if a.rolling != nil {
+		return a.rolling.WriteWithContext(ctx, msg)
+	}
+	return output.IterateBatchedSend(msg, func(i int, p *message.Part) error {
+		return a.putBlob(a.container.String(i, msg), a.path.String(i, msg), a.blobType.String(i, msg), a.accessLevel.String(i, msg), p.Get())
 	})
 }
 
@@ -212,8 +259,14 @@ func containerNotFound(err error) bool {
 }
 
 func (a *azureBlobStorageWriter) CloseAsync() {
+	if a.rolling != nil {
+		a.rolling.CloseAsync()
+	}
 }
 
-func (a *azureBlobStorageWriter) WaitForClose(time.Duration) error {
+func (a *azureBlobStorageWriter) WaitForClose(timeout time.Duration) error {
+	if a.rolling != nil {
+		return a.rolling.WaitForClose(timeout)
+	}
 	return nil
 }
@@ -32,8 +32,8 @@ Only one authentication method is required, `+"`storage_connection_string`"+` or
 In order to set the `+"`queue_name`"+` you can use function interpolations described [here](/docs/configuration/interpolation#bloblang-queries), which are calculated per message of a batch.`),
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("storage_account", "The storage account to upload messages to. This field is ignored if `storage_connection_string` is set."),
-			docs.FieldString("storage_access_key", "The storage account access key. This field is ignored if `storage_connection_string` is set."),
-			docs.FieldString("storage_connection_string", "A storage account connection string. This field is required if `storage_account` and `storage_access_key` are not set."),
+			docs.FieldString("storage_access_key", "The storage account access key. This field is ignored if `storage_connection_string` is set.").Secret(),
+			docs.FieldString("storage_connection_string", "A storage account connection string. This field is required if `storage_account` and `storage_access_key` are not set.").Secret(),
 			docs.FieldString("queue_name", "The name of the target Queue Storage queue.").IsInterpolated(),
 			docs.FieldString(
 				"ttl", "The TTL of each individual message as a duration string. Defaults to 0, meaning no retention period is set",
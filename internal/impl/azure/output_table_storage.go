@@ -72,11 +72,11 @@ properties:
 			docs.FieldString(
 				"storage_access_key",
 				"The storage account access key. This field is ignored if `storage_connection_string` is set.",
-			),
+			).Secret(),
 			docs.FieldString(
 				"storage_connection_string",
 				"A storage account connection string. This field is required if `storage_account` and `storage_access_key` are not set.",
-			),
+			).Secret(),
 			docs.FieldString("table_name", "The table to store messages into.",
 				`${!meta("kafka_topic")}`,
 			).IsInterpolated(),
@@ -68,6 +68,7 @@ false for connections to succeed.`),
 			docs.FieldString("timeout", "The maximum time to wait before abandoning a request (and trying again).").Advanced(),
 			itls.FieldSpec(),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			output.AdaptiveConcurrencyFieldSpec(),
 		).WithChildren(retries.FieldSpecs()...).WithChildren(
 			auth.BasicAuthFieldSpec(),
 			policy.FieldSpec(),
@@ -97,6 +98,16 @@ func NewElasticsearch(conf output.Config, mgr bundle.NewManagement) (output.Stre
 	if err != nil {
 		return w, err
 	}
+
+	if conf.Elasticsearch.AdaptiveConcurrency.Enabled {
+		aw, ok := w.(*output.AsyncWriter)
+		if !ok {
+			return nil, fmt.Errorf("unable to set adaptive_concurrency due to wrong type: %T", w)
+		}
+		if err := aw.SetAdaptiveConcurrency(conf.Elasticsearch.AdaptiveConcurrency); err != nil {
+			return nil, err
+		}
+	}
 	return batcher.NewFromConfig(conf.Elasticsearch.Batching, w, mgr)
 }
 
@@ -21,6 +21,8 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/rolling"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
 )
 
 func init() {
@@ -33,7 +35,9 @@ func init() {
 		if err != nil {
 			return nil, err
 		}
-		w = output.OnlySinglePayloads(w)
+		if !c.GCPCloudStorage.Rolling.Enabled {
+			w = output.OnlySinglePayloads(w)
+		}
 		return batcher.NewFromConfig(c.GCPCloudStorage.Batching, w, nm)
 	}), docs.ComponentSpec{
 		Name:       "gcp_cloud_storage",
@@ -97,7 +101,30 @@ output:
       processors:
         - archive:
             format: json_array
-`+"```"+``),
+`+"```"+`
+
+### Rolling
+
+Data lake layouts, where messages are written to files grouped by partition, can be produced by enabling the `+"`rolling`"+` field. For example, in order to write gzip compressed lines of messages to hourly partitions, where each file is rolled after 10,000 messages or five minutes, we could use the following config:
+
+`+"```yaml"+`
+output:
+  gcp_cloud_storage:
+    bucket: TODO
+    max_in_flight: 64
+    batching:
+      count: 1000
+      period: 1s
+    rolling:
+      enabled: true
+      partition: 'dt=${! now().ts_format("2006-01-02") }/hour=${! now().ts_format("15") }'
+      file_suffix: .jsonl.gz
+      codec: gzip/lines
+      max_records: 10000
+      max_age: 5m
+`+"```"+`
+
+The fields of each object such as its content type and metadata are derived from the first message written to it, and the field `+"`collision_mode`"+` is ignored as the name of each file is unique.`),
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("bucket", "The bucket to upload messages to."),
 			docs.FieldString(
@@ -119,6 +146,7 @@ output:
 			docs.FieldInt("chunk_size", "An optional chunk size which controls the maximum number of bytes of the object that the Writer will attempt to send to the server in a single request. If ChunkSize is set to zero, chunking will be disabled.").Advanced(),
 			docs.FieldInt("max_in_flight", "The maximum number of message batches to have in flight at a given time. Increase this to improve throughput."),
 			policy.FieldSpec(),
+			rolling.FieldSpec().AtVersion("4.4.0"),
 		).ChildDefaultAndTypesFromStruct(output.NewGCPCloudStorageConfig()),
 	})
 	if err != nil {
@@ -137,6 +165,7 @@ type gcpCloudStorageOutput struct {
 
 	client  *storage.Client
 	connMut sync.RWMutex
+	rolling *rolling.Writer
 
 	log   log.Modular
 	stats metrics.Type
@@ -165,6 +194,11 @@ func newGCPCloudStorageOutput(
 	if g.contentEncoding, err = bEnv.NewField(conf.ContentEncoding); err != nil {
 		return nil, fmt.Errorf("failed to parse content encoding expression: %v", err)
 	}
+	if conf.Rolling.Enabled {
+		if g.rolling, err = rolling.NewWriter(conf.Rolling, conf.MaxInFlight, rolling.NewBufferedStore(g.putFile), mgr); err != nil {
+			return nil, err
+		}
+	}
 
 	return g, nil
 }
@@ -196,6 +230,10 @@ func (g *gcpCloudStorageOutput) WriteWithContext(ctx context.Context, msg *messa
 		return component.ErrNotConnected
 	}
 
+	if g.rolling != nil {
+		return g.rolling.WriteWithContext(ctx, msg)
+	}
+
 	return output.IterateBatchedSend(msg, func(i int, p *message.Part) error {
 		metadata := map[string]string{}
 		_ = p.MetaIter(func(k, v string) error {
@@ -259,9 +297,47 @@ func (g *gcpCloudStorageOutput) WriteWithContext(ctx context.Context, msg *messa
 	})
 }
 
+// putFile writes the contents of a rolled file to the target GCP Cloud Storage
+// bucket as an object.
+func (g *gcpCloudStorageOutput) putFile(ctx context.Context, path string, first *message.Part, contents []byte) error {
+	g.connMut.RLock()
+	client := g.client
+	g.connMut.RUnlock()
+
+	if client == nil {
+		return component.ErrNotConnected
+	}
+
+	metadata := map[string]string{}
+	_ = first.MetaIter(func(k, v string) error {
+		metadata[k] = v
+		return nil
+	})
+
+	msg := message.QuickBatch(nil)
+	msg.Append(first)
+
+	w := client.Bucket(g.conf.Bucket).Object(path).NewWriter(ctx)
+
+	w.ChunkSize = g.conf.ChunkSize
+	w.ContentType = g.contentType.String(0, msg)
+	w.ContentEncoding = g.contentEncoding.String(0, msg)
+	w.Metadata = metadata
+	if _, err := w.Write(contents); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
 func (g *gcpCloudStorageOutput) CloseAsync() {
 	go func() {
+		if g.rolling != nil {
+			// Open files must be committed before the client is closed.
+			g.rolling.CloseAsync()
+			_ = g.rolling.WaitForClose(shutdown.MaximumShutdownWait())
+		}
+
 		g.connMut.Lock()
 		if g.client != nil {
 			g.client.Close()
@@ -273,7 +349,10 @@ func (g *gcpCloudStorageOutput) CloseAsync() {
 
 // WaitForClose will block until either the reader is closed or a specified
 // timeout occurs.
-func (g *gcpCloudStorageOutput) WaitForClose(time.Duration) error {
+func (g *gcpCloudStorageOutput) WaitForClose(timeout time.Duration) error {
+	if g.rolling != nil {
+		return g.rolling.WaitForClose(timeout)
+	}
 	return nil
 }
 
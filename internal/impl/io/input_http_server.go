@@ -22,6 +22,7 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/cloudevents"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/input/processors"
@@ -74,6 +75,10 @@ You may specify an optional ` + "`ws_welcome_message`" + `, which is a static pa
 
 It's also possible to specify a ` + "`ws_rate_limit_message`" + `, which is a static payload to be sent to clients that have triggered the servers rate limit.
 
+### CloudEvents
+
+When the field ` + "`cloudevents`" + ` is set to ` + "`true`" + ` requests to the ` + "`path`" + ` endpoint must contain a [CloudEvent](https://cloudevents.io) in either binary or structured content mode, or a batch of structured events with the content type ` + "`application/cloudevents-batch+json`" + `. The data of each event becomes the contents of a message and the attributes of the event are added as metadata with the prefix ` + "`ce_`" + `, e.g. ` + "`ce_type`" + `. Requests that are not valid events, including those missing any of the required attributes ` + "`specversion`, `id`, `source` and `type`" + `, are rejected with a 400 response.
+
 ### Metadata
 
 This input adds the following metadata fields to each message:
@@ -107,6 +112,7 @@ You can access these metadata fields using [function interpolation](/docs/config
 			docs.FieldString("cert_file", "Enable TLS by specifying a certificate and key file. Only valid with a custom `address`.").Advanced(),
 			docs.FieldString("key_file", "Enable TLS by specifying a certificate and key file. Only valid with a custom `address`.").Advanced(),
 			corsSpec,
+			docs.FieldBool("cloudevents", "Whether to decode requests to the `path` endpoint as [CloudEvents](https://cloudevents.io) in either binary or structured content mode, adding the attributes of events as metadata with the prefix `ce_`.").Advanced(),
 			docs.FieldObject("sync_response", "Customise messages returned via [synchronous responses](/docs/guides/sync_responses).").WithChildren(
 				docs.FieldString(
 					"status",
@@ -264,7 +270,21 @@ func (h *httpServerInput) extractMessageFromRequest(r *http.Request) (*message.B
 		return nil, err
 	}
 
-	if strings.HasPrefix(mediaType, "multipart/") {
+	if h.conf.CloudEvents {
+		var msgBytes []byte
+		if msgBytes, err = io.ReadAll(r.Body); err != nil {
+			return nil, err
+		}
+		var events []cloudevents.Event
+		if events, err = cloudevents.ParseHTTP(r.Header, msgBytes); err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			part := message.NewPart(e.Data)
+			e.WalkMetadata(part.MetaSet)
+			msg.Append(part)
+		}
+	} else if strings.HasPrefix(mediaType, "multipart/") {
 		mr := multipart.NewReader(r.Body, params["boundary"])
 		for {
 			var p *multipart.Part
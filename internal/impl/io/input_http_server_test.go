@@ -419,6 +419,99 @@ func TestHTTPtServerPathParameters(t *testing.T) {
 	assert.Equal(t, "will go on", part.MetaGet("mylove"))
 }
 
+func TestHTTPServerCloudEvents(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Minute)
+	defer done()
+
+	reg := apiRegGorillaMutWrapper{mut: mux.NewRouter()}
+	mgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetAPIReg(reg))
+	require.NoError(t, err)
+
+	conf := input.NewConfig()
+	conf.Type = "http_server"
+	conf.HTTPServer.Path = "/events"
+	conf.HTTPServer.CloudEvents = true
+
+	server, err := mgr.NewInput(conf)
+	require.NoError(t, err)
+
+	defer func() {
+		server.CloseAsync()
+		assert.NoError(t, server.WaitForClose(time.Second))
+	}()
+
+	testServer := httptest.NewServer(reg.mut)
+	defer testServer.Close()
+
+	post := func(contentType string, headers map[string]string, body string) int {
+		req, cerr := http.NewRequest("POST", testServer.URL+"/events", bytes.NewReader([]byte(body)))
+		require.NoError(t, cerr)
+		req.Header.Set("Content-Type", contentType)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, cerr := http.DefaultClient.Do(req)
+		require.NoError(t, cerr)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	readNextMsg := func() *message.Batch {
+		var tran message.Transaction
+		select {
+		case tran = <-server.TransactionChan():
+			require.NoError(t, tran.Ack(tCtx, nil))
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+		return tran.Payload
+	}
+
+	resChan := make(chan int, 1)
+	go func() {
+		resChan <- post("text/plain", map[string]string{
+			"Ce-Specversion": "1.0",
+			"Ce-Id":          "1",
+			"Ce-Source":      "/foo",
+			"Ce-Type":        "com.example.bar",
+		}, "hello world")
+	}()
+
+	msg := readNextMsg()
+	require.Equal(t, 1, msg.Len())
+	part := msg.Get(0)
+	assert.Equal(t, "hello world", string(part.Get()))
+	assert.Equal(t, "1.0", part.MetaGet("ce_specversion"))
+	assert.Equal(t, "1", part.MetaGet("ce_id"))
+	assert.Equal(t, "/foo", part.MetaGet("ce_source"))
+	assert.Equal(t, "com.example.bar", part.MetaGet("ce_type"))
+	assert.Equal(t, "text/plain", part.MetaGet("ce_datacontenttype"))
+	assert.Equal(t, http.StatusOK, <-resChan)
+
+	go func() {
+		resChan <- post("application/cloudevents+json", nil, `{
+  "specversion": "1.0",
+  "id": "2",
+  "source": "/foo",
+  "type": "com.example.baz",
+  "count": 5,
+  "data": {"hello":"world"}
+}`)
+	}()
+
+	msg = readNextMsg()
+	require.Equal(t, 1, msg.Len())
+	part = msg.Get(0)
+	assert.Equal(t, `{"hello":"world"}`, string(part.Get()))
+	assert.Equal(t, "2", part.MetaGet("ce_id"))
+	assert.Equal(t, "com.example.baz", part.MetaGet("ce_type"))
+	assert.Equal(t, "5", part.MetaGet("ce_count"))
+	assert.Equal(t, http.StatusOK, <-resChan)
+
+	assert.Equal(t, http.StatusBadRequest, post("text/plain", nil, "not an event"))
+	assert.Equal(t, http.StatusBadRequest, post("application/cloudevents+json", nil, `{"specversion":"1.0","id":"3"}`))
+}
+
 func TestHTTPBadRequests(t *testing.T) {
 	t.Parallel()
 
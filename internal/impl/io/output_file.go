@@ -1,6 +1,7 @@
 package io
 
 import (
+	"bufio"
 	"context"
 	"fmt"
 	"os"
@@ -17,11 +18,19 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/rolling"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
 )
 
 func init() {
 	err := bundle.AllOutputs.Add(processors.WrapConstructor(func(conf output.Config, nm bundle.NewManagement) (output.Streamed, error) {
+		if conf.File.Rolling.Enabled {
+			f, err := newRollingFileWriter(conf.File, nm)
+			if err != nil {
+				return nil, err
+			}
+			return output.NewAsyncWriter("file", conf.File.MaxInFlight, f, nm)
+		}
 		f, err := newFileWriter(conf.File.Path, conf.File.Codec, nm)
 		if err != nil {
 			return nil, err
@@ -38,7 +47,26 @@ func init() {
 		Name: "file",
 		Summary: `
 Writes messages to files on disk based on a chosen codec.`,
-		Description: `Messages can be written to different files by using [interpolation functions](/docs/configuration/interpolation#bloblang-queries) in the path field. However, only one file is ever open at a given time, and therefore when the path changes the previously open file is closed.`,
+		Description: `Messages can be written to different files by using [interpolation functions](/docs/configuration/interpolation#bloblang-queries) in the path field. However, only one file is ever open at a given time, and therefore when the path changes the previously open file is closed.
+
+### Rolling
+
+Data lake layouts, where messages are written to files grouped by partition, can be produced by enabling the ` + "`rolling`" + ` field, in which case a file is open for each partition. For example, in order to write gzip compressed lines of messages to hourly partitions, where each file is rolled after 10,000 messages or five minutes, we could use the following config:
+
+` + "```yaml" + `
+output:
+  file:
+    max_in_flight: 64
+    rolling:
+      enabled: true
+      partition: '/tmp/data/dt=${! now().ts_format("2006-01-02") }/hour=${! now().ts_format("15") }'
+      file_suffix: .jsonl.gz
+      codec: gzip/lines
+      max_records: 10000
+      max_age: 5m
+` + "```" + `
+
+Files are written to a hidden temporary file within the partition directory, which is renamed once the file is rolled.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString(
 				"path", "The file to write to, if the file does not yet exist it will be created.",
@@ -47,6 +75,8 @@ Writes messages to files on disk based on a chosen codec.`,
 				`/tmp/${! json("document.id") }.json`,
 			).IsInterpolated().AtVersion("3.33.0"),
 			codec.WriterDocs.AtVersion("3.33.0"),
+			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time when `rolling` is enabled, otherwise messages are written one at a time.").Advanced().AtVersion("4.4.0"),
+			rolling.FieldSpec().AtVersion("4.4.0"),
 		).ChildDefaultAndTypesFromStruct(output.NewFileConfig()),
 		Categories: []string{
 			"Local",
@@ -174,3 +204,64 @@ func (w *fileWriter) WaitForClose(timeout time.Duration) error {
 	}
 	return nil
 }
+
+//------------------------------------------------------------------------------
+
+type rollingFileWriter struct {
+	*rolling.Writer
+}
+
+func newRollingFileWriter(conf output.FileConfig, mgr bundle.NewManagement) (*rollingFileWriter, error) {
+	w, err := rolling.NewWriter(conf.Rolling, conf.MaxInFlight, localStore{}, mgr)
+	if err != nil {
+		return nil, err
+	}
+	return &rollingFileWriter{Writer: w}, nil
+}
+
+func (w *rollingFileWriter) ConnectWithContext(ctx context.Context) error {
+	return nil
+}
+
+// localStore creates rolled files on the local disk, where each file is
+// written to a hidden temporary file that is renamed once committed.
+type localStore struct{}
+
+func (localStore) Create(ctx context.Context, path string, first *message.Part) (rolling.File, error) {
+	path = filepath.FromSlash(path)
+
+	dir, name := filepath.Split(path)
+	if dir != "" {
+		if err := os.MkdirAll(dir, os.FileMode(0o777)); err != nil {
+			return nil, err
+		}
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+name+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &localFile{Writer: bufio.NewWriter(tmp), file: tmp, path: path}, nil
+}
+
+type localFile struct {
+	*bufio.Writer
+	file *os.File
+	path string
+}
+
+func (l *localFile) Commit(ctx context.Context) error {
+	if err := l.Writer.Flush(); err != nil {
+		_ = l.file.Close()
+		return err
+	}
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(l.file.Name(), l.path)
+}
+
+func (l *localFile) Abort(ctx context.Context) error {
+	_ = l.file.Close()
+	return os.Remove(l.file.Name())
+}
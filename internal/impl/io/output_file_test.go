@@ -0,0 +1,92 @@
+package io
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestRollingFileWriter(t *testing.T) {
+	dir := t.TempDir()
+
+	conf := output.NewFileConfig()
+	conf.MaxInFlight = 10
+	conf.Rolling.Enabled = true
+	conf.Rolling.Partition = filepath.ToSlash(dir) + `/dt=${! meta("dt") }`
+	conf.Rolling.FileSuffix = ".txt"
+	conf.Rolling.MaxRecords = 2
+	conf.Rolling.MaxAge = ""
+
+	w, err := newRollingFileWriter(conf, mock.NewManager())
+	require.NoError(t, err)
+	require.NoError(t, w.ConnectWithContext(context.Background()))
+
+	msg := message.QuickBatch([][]byte{[]byte("foo"), []byte("bar"), []byte("baz")})
+	for i := 0; i < msg.Len(); i++ {
+		msg.Get(i).MetaSet("dt", "2022-01-01")
+	}
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- w.WriteWithContext(context.Background(), msg)
+	}()
+
+	partitionDir := filepath.Join(dir, "dt=2022-01-01")
+
+	// The first file is rolled once it reaches two records, and the second is
+	// left open until the writer is closed.
+	var names []string
+	require.Eventually(t, func() bool {
+		entries, err := os.ReadDir(partitionDir)
+		if err != nil || len(entries) != 2 {
+			return false
+		}
+		names = nil
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return true
+	}, time.Second, time.Millisecond*10)
+
+	var committed, pending string
+	for _, n := range names {
+		if strings.HasPrefix(n, ".") {
+			pending = n
+		} else {
+			committed = n
+		}
+	}
+	assert.True(t, strings.HasPrefix(committed, "part-00000-"), committed)
+	assert.True(t, strings.HasSuffix(committed, ".txt"), committed)
+	assert.True(t, strings.HasSuffix(pending, ".tmp"), pending)
+
+	contents, err := os.ReadFile(filepath.Join(partitionDir, committed))
+	require.NoError(t, err)
+	assert.Equal(t, "foo\nbar\n", string(contents))
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second))
+	require.NoError(t, <-errChan)
+
+	entries, err := os.ReadDir(partitionDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	for _, e := range entries {
+		assert.False(t, strings.HasPrefix(e.Name(), "."), e.Name())
+		if e.Name() != committed {
+			contents, err := os.ReadFile(filepath.Join(partitionDir, e.Name()))
+			require.NoError(t, err)
+			assert.Equal(t, "baz\n", string(contents))
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/batch/policy"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/cloudevents"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/component/output/batcher"
 	"github.com/benthosdev/benthos/v4/internal/component/output/processors"
@@ -31,11 +32,16 @@ The body of the HTTP request is the raw contents of the message payload. If the
 
 ### Propagating Responses
 
-It's possible to propagate the response from each HTTP request back to the input source by setting `+"`propagate_response` to `true`"+`. Only inputs that support [synchronous responses](/docs/guides/sync_responses) are able to make use of these propagated responses.`),
+It's possible to propagate the response from each HTTP request back to the input source by setting `+"`propagate_response` to `true`"+`. Only inputs that support [synchronous responses](/docs/guides/sync_responses) are able to make use of these propagated responses.
+
+### CloudEvents
+
+Messages can be sent as [CloudEvents](https://cloudevents.io) by setting the field `+"`cloudevents`"+` to either `+"`binary` or `structured`"+`. The attributes of each event are taken from metadata with the prefix `+"`ce_`, e.g. `ce_type`"+`, and the contents of the message become the data of the event. Messages missing any of the required attributes `+"`ce_specversion`, `ce_id`, `ce_source` and `ce_type`"+` are rejected. In structured mode batches sent as a single request are encoded as a JSON array with the content type `+"`application/cloudevents-batch+json`"+`, whereas in binary mode each message of a batch is sent as an individual request.`),
 		Config: ihttpdocs.ClientFieldSpec(true,
 			docs.FieldBool("batch_as_multipart", "Send message batches as a single request using [RFC1341](https://www.w3.org/Protocols/rfc1341/7_2_Multipart.html). If disabled messages in batches will be sent as individual requests.").Advanced(),
 			docs.FieldBool("propagate_response", "Whether responses from the server should be [propagated back](/docs/guides/sync_responses) to the input.").Advanced(),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			output.AdaptiveConcurrencyFieldSpec(),
 			policy.FieldSpec(),
 			docs.FieldObject(
 				"multipart", "EXPERIMENTAL: Create explicit multipart HTTP requests by specifying an array of parts to add to the request, each part specified consists of content headers and a data field that can be populated dynamically. If this field is populated it will override the default request creation behaviour.",
@@ -44,6 +50,7 @@ It's possible to propagate the response from each HTTP request back to the input
 				docs.FieldInterpolatedString("content_disposition", "The content disposition of the individual message part.", `form-data; name="bin"; filename='${! meta("AttachmentName") }`).HasDefault(""),
 				docs.FieldInterpolatedString("body", "The body of the individual message part.", `${! json("data.part1") }`).HasDefault(""),
 			).AtVersion("3.63.0"),
+			docs.FieldString("cloudevents", "Encode messages as [CloudEvents](https://cloudevents.io) in either binary or structured content mode, where the attributes of events are taken from metadata with the prefix `ce_`.").HasOptions("none", "binary", "structured").Advanced(),
 		).ChildDefaultAndTypesFromStruct(output.NewHTTPClientConfig()),
 		Categories: []string{
 			"Network",
@@ -63,7 +70,17 @@ func newHTTPClientOutput(conf output.Config, mgr bundle.NewManagement) (output.S
 	if err != nil {
 		return w, err
 	}
-	if !conf.HTTPClient.BatchAsMultipart {
+
+	if conf.HTTPClient.AdaptiveConcurrency.Enabled {
+		aw, ok := w.(*output.AsyncWriter)
+		if !ok {
+			return nil, fmt.Errorf("unable to set adaptive_concurrency due to wrong type: %T", w)
+		}
+		if err := aw.SetAdaptiveConcurrency(conf.HTTPClient.AdaptiveConcurrency); err != nil {
+			return nil, err
+		}
+	}
+	if !conf.HTTPClient.BatchAsMultipart || conf.HTTPClient.CloudEvents == cloudevents.ModeBinary {
 		w = output.OnlySinglePayloads(w)
 	}
 	return batcher.NewFromConfig(conf.HTTPClient.Batching, w, mgr)
@@ -110,6 +127,14 @@ func newHTTPClientWriter(conf output.HTTPClientConfig, mgr bundle.NewManagement)
 		opts = append(opts, http.OptSetMultiPart(parts))
 	}
 
+	switch conf.CloudEvents {
+	case "", cloudevents.ModeNone:
+	case cloudevents.ModeBinary, cloudevents.ModeStructured:
+		opts = append(opts, http.OptSetCloudEvents(conf.CloudEvents))
+	default:
+		return nil, fmt.Errorf("cloudevents content mode not recognised: %v", conf.CloudEvents)
+	}
+
 	var err error
 	if h.client, err = http.NewClient(conf.Config, opts...); err != nil {
 		return nil, err
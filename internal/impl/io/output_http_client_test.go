@@ -237,6 +237,90 @@ func TestHTTPClientBasic(t *testing.T) {
 	}
 }
 
+func TestHTTPClientCloudEvents(t *testing.T) {
+	reqChan := make(chan *http.Request, 1)
+	bodyChan := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		reqChan <- r
+		bodyChan <- string(b)
+	}))
+	defer ts.Close()
+
+	newEvent := func(id, data string) *message.Part {
+		p := message.NewPart([]byte(data))
+		p.MetaSet("ce_specversion", "1.0")
+		p.MetaSet("ce_id", id)
+		p.MetaSet("ce_source", "/foo")
+		p.MetaSet("ce_type", "com.example.bar")
+		p.MetaSet("ce_datacontenttype", "text/plain")
+		p.MetaSet("other", "ignored")
+		return p
+	}
+
+	newBatch := func(parts ...*message.Part) *message.Batch {
+		b := message.QuickBatch(nil)
+		b.SetAll(parts)
+		return b
+	}
+
+	conf := output.NewHTTPClientConfig()
+	conf.URL = ts.URL
+	conf.CloudEvents = "binary"
+
+	h, err := newHTTPClientWriter(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	require.NoError(t, h.WriteWithContext(context.Background(), newBatch(newEvent("1", "hello world"))))
+
+	req := <-reqChan
+	assert.Equal(t, "hello world", <-bodyChan)
+	assert.Equal(t, "text/plain", req.Header.Get("Content-Type"))
+	assert.Equal(t, "1.0", req.Header.Get("Ce-Specversion"))
+	assert.Equal(t, "1", req.Header.Get("Ce-Id"))
+	assert.Equal(t, "/foo", req.Header.Get("Ce-Source"))
+	assert.Equal(t, "com.example.bar", req.Header.Get("Ce-Type"))
+	assert.Empty(t, req.Header.Get("Ce-Datacontenttype"))
+	assert.Empty(t, req.Header.Get("Other"))
+
+	invalid := newEvent("2", "nope")
+	invalid.MetaDelete("ce_type")
+	require.Error(t, h.WriteWithContext(context.Background(), newBatch(invalid)))
+
+	h.CloseAsync()
+	require.NoError(t, h.WaitForClose(time.Second))
+
+	conf.CloudEvents = "structured"
+	h, err = newHTTPClientWriter(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	require.NoError(t, h.WriteWithContext(context.Background(), newBatch(newEvent("3", "hello world"))))
+
+	req = <-reqChan
+	assert.Equal(t, "application/cloudevents+json", req.Header.Get("Content-Type"))
+	assert.JSONEq(t, `{
+  "specversion": "1.0",
+  "id": "3",
+  "source": "/foo",
+  "type": "com.example.bar",
+  "datacontenttype": "text/plain",
+  "data": "hello world"
+}`, <-bodyChan)
+
+	require.NoError(t, h.WriteWithContext(context.Background(), newBatch(newEvent("4", "first"), newEvent("5", "second"))))
+
+	req = <-reqChan
+	assert.Equal(t, "application/cloudevents-batch+json", req.Header.Get("Content-Type"))
+	assert.JSONEq(t, `[
+  {"specversion":"1.0","id":"4","source":"/foo","type":"com.example.bar","datacontenttype":"text/plain","data":"first"},
+  {"specversion":"1.0","id":"5","source":"/foo","type":"com.example.bar","datacontenttype":"text/plain","data":"second"}
+]`, <-bodyChan)
+
+	h.CloseAsync()
+	require.NoError(t, h.WaitForClose(time.Second))
+}
+
 func TestHTTPClientSyncResponse(t *testing.T) {
 	nTestLoops := 1000
 
@@ -0,0 +1,27 @@
+package io
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func init() {
+	err := service.RegisterSecretsProvider("file", fileSecretLookup)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// fileSecretLookup obtains a secret from the contents of a file, such as those
+// mounted by Docker or Kubernetes secrets. Trailing line breaks are removed as
+// they're rarely intended to be part of the secret.
+func fileSecretLookup(ctx context.Context, path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
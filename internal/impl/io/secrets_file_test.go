@@ -0,0 +1,23 @@
+package io
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSecretLookup(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "db"), []byte("hunter2\n"), 0o600))
+
+	v, err := fileSecretLookup(context.Background(), filepath.Join(dir, "db"))
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", v)
+
+	_, err = fileSecretLookup(context.Background(), filepath.Join(dir, "nope"))
+	require.Error(t, err)
+}
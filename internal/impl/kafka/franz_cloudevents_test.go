@@ -0,0 +1,116 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	ibatch "github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestFranzCloudEventsRoundTrip(t *testing.T) {
+	newMsg := func() *service.Message {
+		msg := service.NewMessage([]byte(`hello world`))
+		msg.MetaSet("ce_specversion", "1.0")
+		msg.MetaSet("ce_id", "1")
+		msg.MetaSet("ce_source", "/foo")
+		msg.MetaSet("ce_type", "com.example.bar")
+		msg.MetaSet("ce_datacontenttype", "text/plain")
+		return msg
+	}
+
+	for _, mode := range []string{"binary", "structured"} {
+		mode := mode
+		t.Run(mode, func(t *testing.T) {
+			record := &kgo.Record{Value: []byte(`hello world`)}
+			require.NoError(t, encodeCloudEvent(record, newMsg(), mode))
+
+			if mode == "binary" {
+				assert.Equal(t, "hello world", string(record.Value))
+			} else {
+				assert.JSONEq(t, `{
+  "specversion": "1.0",
+  "id": "1",
+  "source": "/foo",
+  "type": "com.example.bar",
+  "datacontenttype": "text/plain",
+  "data": "hello world"
+}`, string(record.Value))
+			}
+
+			msg := recordToMessage(record)
+			decodeCloudEvent(record, msg)
+			require.NoError(t, msg.GetError())
+
+			b, err := msg.AsBytes()
+			require.NoError(t, err)
+			assert.Equal(t, "hello world", string(b))
+
+			for k, v := range map[string]string{
+				"ce_specversion":     "1.0",
+				"ce_id":              "1",
+				"ce_source":          "/foo",
+				"ce_type":            "com.example.bar",
+				"ce_datacontenttype": "text/plain",
+			} {
+				act, _ := msg.MetaGet(k)
+				assert.Equal(t, v, act, k)
+			}
+		})
+	}
+}
+
+func TestFranzCloudEventsInvalid(t *testing.T) {
+	msg := service.NewMessage([]byte(`hello world`))
+	msg.MetaSet("ce_specversion", "1.0")
+	msg.MetaSet("ce_id", "1")
+
+	record := &kgo.Record{Value: []byte(`hello world`)}
+	require.Error(t, encodeCloudEvent(record, msg, "binary"))
+
+	record = &kgo.Record{
+		Value: []byte(`hello world`),
+		Headers: []kgo.RecordHeader{
+			{Key: "ce_specversion", Value: []byte("1.0")},
+			{Key: "ce_id", Value: []byte("1")},
+		},
+	}
+	msg = recordToMessage(record)
+	decodeCloudEvent(record, msg)
+	require.Error(t, msg.GetError())
+
+	record = &kgo.Record{Value: []byte(`not an event`)}
+	msg = recordToMessage(record)
+	decodeCloudEvent(record, msg)
+	require.Error(t, msg.GetError())
+}
+
+func TestFranzCloudEventsInvalidBatch(t *testing.T) {
+	conf, err := franzKafkaOutputConfig().ParseYAML(`
+seed_brokers: [ 127.0.0.1:1 ]
+topic: foo
+cloudevents: binary
+`, nil)
+	require.NoError(t, err)
+
+	w, err := newFranzKafkaWriterFromConfig(conf, nil)
+	require.NoError(t, err)
+
+	w.client, err = kgo.NewClient(kgo.SeedBrokers("127.0.0.1:1"))
+	require.NoError(t, err)
+	defer w.client.Close()
+
+	invalid := service.NewMessage([]byte(`hello world`))
+	invalid.MetaSet("ce_specversion", "1.0")
+
+	err = w.WriteBatch(context.Background(), service.MessageBatch{invalid, invalid})
+	var bErr *ibatch.Error
+	require.True(t, errors.As(err, &bErr), err)
+	assert.Equal(t, 2, bErr.IndexedErrors())
+	assert.Contains(t, err.Error(), "failed to encode message 0 as a CloudEvent")
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/twmb/franz-go/pkg/sasl"
 
 	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+	"github.com/benthosdev/benthos/v4/internal/cloudevents"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
@@ -46,6 +47,24 @@ This input adds the following metadata fields to each message:
 - kafka_timestamp_unix
 - All record headers
 ` + "```" + `
+
+### Metrics
+
+This input emits the following gauges labelled by ` + "`topic`" + ` and ` + "`partition`" + `:
+
+` + "``` text" + `
+- input_kafka_lag
+- input_kafka_high_water_mark
+- input_kafka_committed_offset
+` + "```" + `
+
+The lag and high water mark are updated with each fetch from a partition, and the committed offset is updated as offsets are marked for commit, which happens once all messages of prior offsets have been delivered. Marked offsets are then committed at the next ` + "[`commit_period`](#commit_period)" + `.
+
+The counters ` + "`input_kafka_partitions_assigned`" + ` and ` + "`input_kafka_partitions_revoked`" + `, labelled by ` + "`topic`" + `, are incremented each time partitions are assigned to or revoked from this consumer during a rebalance. Partitions that are lost, for example due to a session timeout, are counted as revoked. The gauges of partitions that are revoked are reset to zero.
+
+### CloudEvents
+
+When the field ` + "`cloudevents`" + ` is set to ` + "`true`" + ` records are decoded as [CloudEvents](https://cloudevents.io) in either binary content mode, where attributes are record headers with the prefix ` + "`ce_`" + `, or structured content mode, where the record has a ` + "`content-type`" + ` header of ` + "`application/cloudevents+json`" + `. The data of each event becomes the contents of the message and its attributes are added as metadata with the prefix ` + "`ce_`" + `. Records that are not valid events, including those missing any of the required attributes ` + "`specversion`, `id`, `source` and `type`" + `, are flagged as having failed, which can be handled with [error handling patterns](/docs/configuration/error_handling).
 `).
 		Field(service.NewStringListField("seed_brokers").
 			Description("A list of broker addresses to connect to in order to establish connections. If an item of the list contains commas it will be expanded into multiple addresses.").
@@ -71,6 +90,10 @@ This input adds the following metadata fields to each message:
 			Description("If an offset is not found for a topic partition, determines whether to consume from the oldest available offset, otherwise messages are consumed from the latest offset.").
 			Default(true).
 			Advanced()).
+		Field(service.NewBoolField("cloudevents").
+			Description("Whether to decode records as [CloudEvents](https://cloudevents.io) in either binary or structured content mode, adding the attributes of events as metadata with the prefix `ce_`.").
+			Default(false).
+			Advanced()).
 		Field(service.NewTLSToggledField("tls")).
 		Field(saslField())
 }
@@ -78,7 +101,7 @@ This input adds the following metadata fields to each message:
 func init() {
 	err := service.RegisterInput("kafka_franz", franzKafkaInputConfig(),
 		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
-			rdr, err := newFranzKafkaReaderFromConfig(conf, mgr.Logger())
+			rdr, err := newFranzKafkaReaderFromConfig(conf, mgr.Logger(), mgr.Metrics())
 			if err != nil {
 				return nil, err
 			}
@@ -107,10 +130,17 @@ type franzKafkaReader struct {
 	startFromOldest bool
 	commitPeriod    time.Duration
 	regexPattern    bool
+	cloudEvents     bool
 
 	msgChan atomic.Value
 	log     *service.Logger
 	shutSig *shutdown.Signaller
+
+	mLag             *service.MetricGauge
+	mHighWaterMark   *service.MetricGauge
+	mCommittedOffset *service.MetricGauge
+	mPartsAssigned   *service.MetricCounter
+	mPartsRevoked    *service.MetricCounter
 }
 
 func (f *franzKafkaReader) getMsgChan() chan msgWithAckFn {
@@ -122,10 +152,16 @@ func (f *franzKafkaReader) storeMsgChan(c chan msgWithAckFn) {
 	f.msgChan.Store(c)
 }
 
-func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, log *service.Logger) (*franzKafkaReader, error) {
+func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, log *service.Logger, metrics *service.Metrics) (*franzKafkaReader, error) {
 	f := franzKafkaReader{
 		log:     log,
 		shutSig: shutdown.NewSignaller(),
+
+		mLag:             metrics.NewGauge("input_kafka_lag", "topic", "partition"),
+		mHighWaterMark:   metrics.NewGauge("input_kafka_high_water_mark", "topic", "partition"),
+		mCommittedOffset: metrics.NewGauge("input_kafka_committed_offset", "topic", "partition"),
+		mPartsAssigned:   metrics.NewCounter("input_kafka_partitions_assigned", "topic"),
+		mPartsRevoked:    metrics.NewCounter("input_kafka_partitions_revoked", "topic"),
 	}
 
 	brokerList, err := conf.FieldStringList("seed_brokers")
@@ -164,6 +200,10 @@ func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, log *service.Logg
 		return nil, err
 	}
 
+	if f.cloudEvents, err = conf.FieldBool("cloudevents"); err != nil {
+		return nil, err
+	}
+
 	tlsConf, tlsEnabled, err := conf.FieldTLSToggled("tls")
 	if err != nil {
 		return nil, err
@@ -269,6 +309,56 @@ func (c *checkpointTracker) removeTopicPartitions(m map[string][]int32) {
 
 //------------------------------------------------------------------------------
 
+func (f *franzKafkaReader) recordAssigned(m map[string][]int32) {
+	for topic, partitions := range m {
+		f.log.Infof("Assigned topic '%v' partitions %v by consumer group rebalance", topic, partitions)
+		f.mPartsAssigned.Incr(int64(len(partitions)), topic)
+	}
+}
+
+func (f *franzKafkaReader) recordRevoked(m map[string][]int32, lost bool) {
+	for topic, partitions := range m {
+		if lost {
+			f.log.Warnf("Lost topic '%v' partitions %v", topic, partitions)
+		} else {
+			f.log.Infof("Revoked topic '%v' partitions %v by consumer group rebalance", topic, partitions)
+		}
+		f.mPartsRevoked.Incr(int64(len(partitions)), topic)
+	}
+	f.resetPartitionGauges(m)
+}
+
+// resetPartitionGauges zeroes the gauges of partitions that are no longer
+// consumed, otherwise they would continue to report the values last seen.
+func (f *franzKafkaReader) resetPartitionGauges(m map[string][]int32) {
+	for topic, partitions := range m {
+		for _, partition := range partitions {
+			partitionStr := strconv.Itoa(int(partition))
+			f.mLag.Set(0, topic, partitionStr)
+			f.mHighWaterMark.Set(0, topic, partitionStr)
+			f.mCommittedOffset.Set(0, topic, partitionStr)
+		}
+	}
+}
+
+// recordFetchOffsets updates the lag and high water mark gauges of each
+// partition included within a fetch.
+func (f *franzKafkaReader) recordFetchOffsets(fetches kgo.Fetches) {
+	fetches.EachPartition(func(p kgo.FetchTopicPartition) {
+		if p.Err != nil || len(p.Records) == 0 {
+			return
+		}
+		partitionStr := strconv.Itoa(int(p.Partition))
+		f.mHighWaterMark.Set(p.HighWatermark, p.Topic, partitionStr)
+
+		lag := p.HighWatermark - p.Records[len(p.Records)-1].Offset - 1
+		if lag < 0 {
+			lag = 0
+		}
+		f.mLag.Set(lag, p.Topic, partitionStr)
+	})
+}
+
 func (f *franzKafkaReader) Connect(ctx context.Context) error {
 	if f.getMsgChan() != nil {
 		return nil
@@ -294,7 +384,12 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 		kgo.ConsumeTopics(f.topics...),
 		kgo.ConsumeResetOffset(initialOffset),
 		kgo.SASL(f.saslConfs...),
+		kgo.OnPartitionsAssigned(func(_ context.Context, _ *kgo.Client, m map[string][]int32) {
+			f.recordAssigned(m)
+		}),
 		kgo.OnPartitionsRevoked(func(rctx context.Context, c *kgo.Client, m map[string][]int32) {
+			f.recordRevoked(m, false)
+
 			// Note: this is a best attempt, there's a chance of duplicates if
 			// the checkpoint limit is borked with slow moving pending messages,
 			// but we can't block here, so work with that we have.
@@ -321,6 +416,8 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 			checkpoints.removeTopicPartitions(m)
 		}),
 		kgo.OnPartitionsLost(func(_ context.Context, _ *kgo.Client, m map[string][]int32) {
+			f.recordRevoked(m, true)
+
 			// No point trying to commit our offsets, just clean up our topic map
 			checkpoints.removeTopicPartitions(m)
 		}),
@@ -384,11 +481,16 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 				return
 			}
 
+			f.recordFetchOffsets(fetches)
+
 			pauseTopicPartitions := map[string][]int32{}
 			iter := fetches.RecordIter()
 			for !iter.Done() {
 				record := iter.Next()
 				msg := recordToMessage(record)
+				if f.cloudEvents {
+					decodeCloudEvent(record, msg)
+				}
 
 				// The record lives on for checkpointing, but we don't need the
 				// contents going forward so discard these. This looked fine to
@@ -416,6 +518,7 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 					onAck: func() {
 						if maxRec := releaseFn(); maxRec != nil {
 							cl.MarkCommitRecords(maxRec)
+							f.mCommittedOffset.Set(maxRec.Offset+1, maxRec.Topic, strconv.Itoa(int(maxRec.Partition)))
 						}
 					},
 				}:
@@ -460,6 +563,39 @@ func recordToMessage(record *kgo.Record) *service.Message {
 	return msg
 }
 
+// decodeCloudEvent replaces the contents of a message with the data of the
+// CloudEvent within a record, and adds its attributes as metadata. Records that
+// are not valid events result in the message being flagged with an error.
+func decodeCloudEvent(record *kgo.Record, msg *service.Message) {
+	var contentType string
+	attrs := map[string]string{}
+	for _, hdr := range record.Headers {
+		if strings.EqualFold(hdr.Key, "content-type") {
+			contentType = string(hdr.Value)
+		} else if strings.HasPrefix(hdr.Key, cloudevents.MetaPrefix) {
+			attrs[strings.TrimPrefix(hdr.Key, cloudevents.MetaPrefix)] = string(hdr.Value)
+		}
+	}
+
+	var event cloudevents.Event
+	var err error
+	switch {
+	case cloudevents.IsStructuredContentType(contentType):
+		event, err = cloudevents.ParseStructured(record.Value)
+	case len(attrs) > 0:
+		event, err = cloudevents.ParseBinary(attrs, contentType, record.Value)
+	default:
+		err = cloudevents.ErrNotEvent
+	}
+	if err != nil {
+		msg.SetError(err)
+		return
+	}
+
+	msg.SetBytes(event.Data)
+	event.WalkMetadata(msg.MetaSet)
+}
+
 func (f *franzKafkaReader) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
 	msgChan := f.getMsgChan()
 	if msgChan == nil {
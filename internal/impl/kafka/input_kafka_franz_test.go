@@ -0,0 +1,84 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestFranzPartitionMetrics(t *testing.T) {
+	stats := metrics.NewLocal()
+	res := service.MockResources(func(m *mock.Manager) {
+		m.M = stats
+	})
+
+	f, err := newFranzKafkaReaderFromConfig(franzTestConfig(t), res.Logger(), res.Metrics())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.recordAssigned(map[string][]int32{"foo": {0, 1}})
+	f.recordFetchOffsets(kgo.Fetches{{
+		Topics: []kgo.FetchTopic{{
+			Topic: "foo",
+			Partitions: []kgo.FetchPartition{
+				{
+					Partition:     0,
+					HighWatermark: 10,
+					Records:       []*kgo.Record{{Offset: 5}, {Offset: 6}},
+				},
+				{
+					Partition:     1,
+					HighWatermark: 3,
+					Records:       []*kgo.Record{{Offset: 2}},
+				},
+			},
+		}},
+	}})
+	f.mCommittedOffset.Set(5, "foo", "0")
+
+	assert.Equal(t, map[string]int64{
+		`input_kafka_partitions_assigned{topic="foo"}`:            2,
+		`input_kafka_lag{partition="0",topic="foo"}`:              3,
+		`input_kafka_high_water_mark{partition="0",topic="foo"}`:  10,
+		`input_kafka_committed_offset{partition="0",topic="foo"}`: 5,
+		`input_kafka_lag{partition="1",topic="foo"}`:              0,
+		`input_kafka_high_water_mark{partition="1",topic="foo"}`:  3,
+	}, stats.GetCounters())
+
+	f.recordRevoked(map[string][]int32{"foo": {0}}, false)
+
+	assert.Equal(t, map[string]int64{
+		`input_kafka_partitions_assigned{topic="foo"}`:            2,
+		`input_kafka_partitions_revoked{topic="foo"}`:             1,
+		`input_kafka_lag{partition="0",topic="foo"}`:              0,
+		`input_kafka_high_water_mark{partition="0",topic="foo"}`:  0,
+		`input_kafka_committed_offset{partition="0",topic="foo"}`: 0,
+		`input_kafka_lag{partition="1",topic="foo"}`:              0,
+		`input_kafka_high_water_mark{partition="1",topic="foo"}`:  3,
+	}, stats.GetCounters())
+
+	f.recordRevoked(map[string][]int32{"foo": {1}}, true)
+
+	assert.Equal(t, int64(2), stats.GetCounters()[`input_kafka_partitions_revoked{topic="foo"}`])
+	assert.Equal(t, int64(0), stats.GetCounters()[`input_kafka_high_water_mark{partition="1",topic="foo"}`])
+}
+
+func franzTestConfig(t *testing.T) *service.ParsedConfig {
+	t.Helper()
+
+	conf, err := franzKafkaInputConfig().ParseYAML(`
+seed_brokers: [ localhost:9092 ]
+topics: [ foo ]
+consumer_group: bar
+`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conf
+}
@@ -58,6 +58,20 @@ The field ` + "`kafka_lag`" + ` is the calculated difference between the high wa
 
 You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).
 
+### Metrics
+
+This input emits the following gauges labelled by ` + "`topic`" + ` and ` + "`partition`" + `:
+
+` + "``` text" + `
+- input_kafka_lag
+- input_kafka_high_water_mark
+- input_kafka_committed_offset
+` + "```" + `
+
+The lag and high water mark are updated as messages are consumed from a partition, and the committed offset is updated as offsets are marked for commit, which happens once all messages of prior offsets have been delivered. Marked offsets are then committed at the next ` + "[`commit_period`](#commit_period)" + `.
+
+When consuming balanced topics the counters ` + "`input_kafka_partitions_assigned`" + ` and ` + "`input_kafka_partitions_revoked`" + `, labelled by ` + "`topic`" + `, are incremented each time partitions are assigned to or revoked from this consumer during a rebalance. The gauges of partitions that are revoked are reset to zero.
+
 ### Ordering
 
 By default messages of a topic partition can be processed in parallel, up to a limit determined by the field ` + "`checkpoint_limit`" + `. However, if strict ordered processing is required then this value must be set to 1 in order to process shard messages in lock-step. When doing so it is recommended that you perform batching at this component for performance as it will not be possible to batch lock-stepped messages at the output level.
@@ -167,6 +181,12 @@ type kafkaReader struct {
 	log  log.Modular
 	mgr  bundle.NewManagement
 
+	mLag             metrics.StatGaugeVec
+	mHighWaterMark   metrics.StatGaugeVec
+	mCommittedOffset metrics.StatGaugeVec
+	mPartsAssigned   metrics.StatCounterVec
+	mPartsRevoked    metrics.StatCounterVec
+
 	closeOnce  sync.Once
 	closedChan chan struct{}
 }
@@ -214,6 +234,12 @@ func newKafkaReader(conf input.KafkaConfig, mgr bundle.NewManagement, log log.Mo
 		mgr:             mgr,
 		closedChan:      make(chan struct{}),
 		topicPartitions: map[string][]int32{},
+
+		mLag:             mgr.Metrics().GetGaugeVec("input_kafka_lag", "topic", "partition"),
+		mHighWaterMark:   mgr.Metrics().GetGaugeVec("input_kafka_high_water_mark", "topic", "partition"),
+		mCommittedOffset: mgr.Metrics().GetGaugeVec("input_kafka_committed_offset", "topic", "partition"),
+		mPartsAssigned:   mgr.Metrics().GetCounterVec("input_kafka_partitions_assigned", "topic"),
+		mPartsRevoked:    mgr.Metrics().GetCounterVec("input_kafka_partitions_revoked", "topic"),
 	}
 	if conf.TLS.Enabled {
 		var err error
@@ -302,6 +328,7 @@ func newKafkaReader(conf input.KafkaConfig, mgr bundle.NewManagement, log log.Mo
 
 func (k *kafkaReader) asyncCheckpointer(topic string, partition int32) func(context.Context, chan<- asyncMessage, *message.Batch, int64) bool {
 	cp := checkpoint.NewCapped(int64(k.conf.CheckpointLimit))
+	partitionStr := strconv.Itoa(int(partition))
 	return func(ctx context.Context, c chan<- asyncMessage, msg *message.Batch, offset int64) bool {
 		if msg == nil {
 			return true
@@ -325,6 +352,7 @@ func (k *kafkaReader) asyncCheckpointer(topic string, partition int32) func(cont
 				if k.session != nil {
 					k.log.Debugf("Marking offset for topic '%v' partition '%v'.\n", topic, partition)
 					k.session.MarkOffset(topic, partition, maxOffset.(int64), "")
+					k.mCommittedOffset.With(topic, partitionStr).Set(maxOffset.(int64))
 				} else {
 					k.log.Debugf("Unable to mark offset for topic '%v' partition '%v'.\n", topic, partition)
 				}
@@ -341,6 +369,7 @@ func (k *kafkaReader) asyncCheckpointer(topic string, partition int32) func(cont
 
 func (k *kafkaReader) syncCheckpointer(topic string, partition int32) func(context.Context, chan<- asyncMessage, *message.Batch, int64) bool {
 	ackedChan := make(chan error)
+	partitionStr := strconv.Itoa(int(partition))
 	return func(ctx context.Context, c chan<- asyncMessage, msg *message.Batch, offset int64) bool {
 		if msg == nil {
 			return true
@@ -355,6 +384,7 @@ func (k *kafkaReader) syncCheckpointer(topic string, partition int32) func(conte
 					if k.session != nil {
 						k.log.Debugf("Marking offset for topic '%v' partition '%v'.\n", topic, partition)
 						k.session.MarkOffset(topic, partition, offset, "")
+						k.mCommittedOffset.With(topic, partitionStr).Set(offset)
 					} else {
 						k.log.Debugf("Unable to mark offset for topic '%v' partition '%v'.\n", topic, partition)
 					}
@@ -383,6 +413,31 @@ func (k *kafkaReader) syncCheckpointer(topic string, partition int32) func(conte
 	}
 }
 
+func messageLag(highestOffset int64, data *sarama.ConsumerMessage) int64 {
+	lag := highestOffset - data.Offset - 1
+	if lag < 0 {
+		lag = 0
+	}
+	return lag
+}
+
+// recordPartitionOffsets updates the lag and high water mark gauges of a
+// partition following the consumption of a message.
+func (k *kafkaReader) recordPartitionOffsets(highestOffset int64, data *sarama.ConsumerMessage) {
+	partitionStr := strconv.Itoa(int(data.Partition))
+	k.mHighWaterMark.With(data.Topic, partitionStr).Set(highestOffset)
+	k.mLag.With(data.Topic, partitionStr).Set(messageLag(highestOffset, data))
+}
+
+// resetPartitionGauges zeroes the gauges of a partition that is no longer
+// consumed, otherwise they would continue to report the values last seen.
+func (k *kafkaReader) resetPartitionGauges(topic string, partition int32) {
+	partitionStr := strconv.Itoa(int(partition))
+	k.mLag.With(topic, partitionStr).Set(0)
+	k.mHighWaterMark.With(topic, partitionStr).Set(0)
+	k.mCommittedOffset.With(topic, partitionStr).Set(0)
+}
+
 func dataToPart(highestOffset int64, data *sarama.ConsumerMessage) *message.Part {
 	part := message.NewPart(data.Value)
 
@@ -390,10 +445,7 @@ func dataToPart(highestOffset int64, data *sarama.ConsumerMessage) *message.Part
 		part.MetaSet(string(hdr.Key), string(hdr.Value))
 	}
 
-	lag := highestOffset - data.Offset - 1
-	if lag < 0 {
-		lag = 0
-	}
+	lag := messageLag(highestOffset, data)
 
 	part.MetaSet("kafka_key", string(data.Key))
 	part.MetaSet("kafka_partition", strconv.Itoa(int(data.Partition)))
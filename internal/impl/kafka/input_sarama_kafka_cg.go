@@ -16,6 +16,11 @@ func (k *kafkaReader) Setup(sesh sarama.ConsumerGroupSession) error {
 	k.cMut.Lock()
 	k.session = sesh
 	k.cMut.Unlock()
+
+	for topic, partitions := range sesh.Claims() {
+		k.log.Infof("Assigned topic '%v' partitions %v by consumer group rebalance\n", topic, partitions)
+		k.mPartsAssigned.With(topic).Incr(int64(len(partitions)))
+	}
 	return nil
 }
 
@@ -25,6 +30,14 @@ func (k *kafkaReader) Cleanup(sesh sarama.ConsumerGroupSession) error {
 	k.cMut.Lock()
 	k.session = nil
 	k.cMut.Unlock()
+
+	for topic, partitions := range sesh.Claims() {
+		k.log.Infof("Revoked topic '%v' partitions %v by consumer group rebalance\n", topic, partitions)
+		k.mPartsRevoked.With(topic).Incr(int64(len(partitions)))
+		for _, partition := range partitions {
+			k.resetPartitionGauges(topic, partition)
+		}
+	}
 	return nil
 }
 
@@ -73,7 +86,9 @@ func (k *kafkaReader) ConsumeClaim(sess sarama.ConsumerGroupSession, claim saram
 			}
 
 			latestOffset = data.Offset
-			part := dataToPart(claim.HighWaterMarkOffset(), data)
+			highestOffset := claim.HighWaterMarkOffset()
+			k.recordPartitionOffsets(highestOffset, data)
+			part := dataToPart(highestOffset, data)
 
 			if batchPolicy.Add(part) {
 				nextTimedBatchChan = nil
@@ -0,0 +1,68 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+)
+
+type mockConsumerGroupSession struct {
+	sarama.ConsumerGroupSession
+	claims map[string][]int32
+}
+
+func (m *mockConsumerGroupSession) Claims() map[string][]int32 {
+	return m.claims
+}
+
+func TestKafkaPartitionMetrics(t *testing.T) {
+	stats := metrics.NewLocal()
+	mgr := mock.NewManager()
+	mgr.M = stats
+
+	conf := input.NewKafkaConfig()
+	conf.Addresses = []string{"localhost:9092"}
+	conf.Topics = []string{"foo"}
+	conf.ConsumerGroup = "bar"
+
+	k, err := newKafkaReader(conf, mgr, log.Noop())
+	require.NoError(t, err)
+
+	sesh := &mockConsumerGroupSession{
+		claims: map[string][]int32{"foo": {0, 1}},
+	}
+	require.NoError(t, k.Setup(sesh))
+
+	k.recordPartitionOffsets(10, &sarama.ConsumerMessage{Topic: "foo", Partition: 0, Offset: 6})
+	k.recordPartitionOffsets(3, &sarama.ConsumerMessage{Topic: "foo", Partition: 1, Offset: 2})
+	k.mCommittedOffset.With("foo", "0").Set(5)
+
+	assert.Equal(t, map[string]int64{
+		`input_kafka_partitions_assigned{topic="foo"}`:            2,
+		`input_kafka_lag{partition="0",topic="foo"}`:              3,
+		`input_kafka_high_water_mark{partition="0",topic="foo"}`:  10,
+		`input_kafka_committed_offset{partition="0",topic="foo"}`: 5,
+		`input_kafka_lag{partition="1",topic="foo"}`:              0,
+		`input_kafka_high_water_mark{partition="1",topic="foo"}`:  3,
+	}, stats.GetCounters())
+
+	require.NoError(t, k.Cleanup(sesh))
+
+	assert.Equal(t, map[string]int64{
+		`input_kafka_partitions_assigned{topic="foo"}`:            2,
+		`input_kafka_partitions_revoked{topic="foo"}`:             2,
+		`input_kafka_lag{partition="0",topic="foo"}`:              0,
+		`input_kafka_high_water_mark{partition="0",topic="foo"}`:  0,
+		`input_kafka_committed_offset{partition="0",topic="foo"}`: 0,
+		`input_kafka_lag{partition="1",topic="foo"}`:              0,
+		`input_kafka_high_water_mark{partition="1",topic="foo"}`:  0,
+		`input_kafka_committed_offset{partition="1",topic="foo"}`: 0,
+	}, stats.GetCounters())
+}
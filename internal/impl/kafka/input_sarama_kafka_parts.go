@@ -76,7 +76,9 @@ partMsgLoop:
 			k.log.Tracef("Received message from topic %v partition %v\n", topic, partition)
 
 			latestOffset = data.Offset
-			part := dataToPart(consumer.HighWaterMarkOffset(), data)
+			highestOffset := consumer.HighWaterMarkOffset()
+			k.recordPartitionOffsets(highestOffset, data)
+			part := dataToPart(highestOffset, data)
 
 			if batchPolicy.Add(part) {
 				nextTimedBatchChan = nil
@@ -12,6 +12,8 @@ import (
 	"github.com/twmb/franz-go/pkg/kgo"
 	"github.com/twmb/franz-go/pkg/sasl"
 
+	ibatch "github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/internal/cloudevents"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
@@ -30,6 +32,10 @@ This output is new and experimental, and the existing ` + "`kafka`" + ` input is
 - You like shiny new stuff
 - You are experiencing issues with the existing ` + "`kafka`" + ` output
 - Someone told you to
+
+### CloudEvents
+
+Messages can be written as [CloudEvents](https://cloudevents.io) by setting the field ` + "`cloudevents`" + ` to either ` + "`binary` or `structured`" + `. The attributes of each event are taken from metadata with the prefix ` + "`ce_`, e.g. `ce_type`" + `, and the contents of the message become the data of the event. In binary mode attributes are written as record headers with the prefix ` + "`ce_`" + ` and the content type as the header ` + "`content-type`" + `, whereas in structured mode the record value is a JSON event. Messages missing any of the required attributes ` + "`ce_specversion`, `ce_id`, `ce_source` and `ce_type`" + ` are rejected individually, and the remaining messages of the batch are still written.
 `).
 		Field(service.NewStringListField("seed_brokers").
 			Description("A list of broker addresses to connect to in order to establish connections. If an item of the list contains commas it will be expanded into multiple addresses.").
@@ -68,6 +74,10 @@ This output is new and experimental, and the existing ` + "`kafka`" + ` input is
 			Description("Optionally set an explicit compression type. The default preference is to use snappy when the broker supports it, and fall back to none if not.").
 			Optional().
 			Advanced()).
+		Field(service.NewStringEnumField("cloudevents", cloudevents.ModeNone, cloudevents.ModeBinary, cloudevents.ModeStructured).
+			Description("Encode messages as [CloudEvents](https://cloudevents.io) in either binary or structured content mode, where the attributes of events are taken from metadata with the prefix `ce_`.").
+			Default(cloudevents.ModeNone).
+			Advanced()).
 		Field(service.NewTLSToggledField("tls")).
 		Field(saslField())
 }
@@ -109,6 +119,7 @@ type franzKafkaWriter struct {
 	timeout          time.Duration
 	produceMaxBytes  int32
 	compressionPrefs []kgo.CompressionCodec
+	cloudEventsMode  string
 
 	client *kgo.Client
 
@@ -200,6 +211,10 @@ func newFranzKafkaWriterFromConfig(conf *service.ParsedConfig, log *service.Logg
 		}
 	}
 
+	if f.cloudEventsMode, err = conf.FieldString("cloudevents"); err != nil {
+		return nil, err
+	}
+
 	if conf.Contains("metadata") {
 		if f.metaFilter, err = conf.FieldMetadataFilter("metadata"); err != nil {
 			return nil, err
@@ -260,7 +275,18 @@ func (f *franzKafkaWriter) WriteBatch(ctx context.Context, b service.MessageBatc
 		return service.ErrNotConnected
 	}
 
+	// The batch of the error is attached by the service API, which has access
+	// to the underlying messages.
+	var batchErr *ibatch.Error
+	failed := func(i int, merr error) {
+		if batchErr == nil {
+			batchErr = ibatch.NewError(nil, merr)
+		}
+		batchErr.Failed(i, merr)
+	}
+
 	records := make([]*kgo.Record, 0, len(b))
+	indexes := make(map[*kgo.Record]int, len(b))
 	for i, msg := range b {
 		record := &kgo.Record{Topic: b.InterpolatedString(i, f.topic)}
 		if record.Value, err = msg.AsBytes(); err != nil {
@@ -270,19 +296,75 @@ func (f *franzKafkaWriter) WriteBatch(ctx context.Context, b service.MessageBatc
 			record.Key = b.InterpolatedBytes(i, f.key)
 		}
 		_ = f.metaFilter.Walk(msg, func(key, value string) error {
+			if f.cloudEventsMode != cloudevents.ModeNone && strings.HasPrefix(key, cloudevents.MetaPrefix) {
+				return nil
+			}
 			record.Headers = append(record.Headers, kgo.RecordHeader{
 				Key:   key,
 				Value: []byte(value),
 			})
 			return nil
 		})
+		if f.cloudEventsMode != cloudevents.ModeNone {
+			// Messages that cannot be encoded are failed individually as
+			// reattempting them will not help.
+			if ceErr := encodeCloudEvent(record, msg, f.cloudEventsMode); ceErr != nil {
+				failed(i, fmt.Errorf("failed to encode message %v as a CloudEvent: %w", i, ceErr))
+				continue
+			}
+		}
 		records = append(records, record)
+		indexes[record] = i
 	}
 
-	// TODO: This is very cool and allows us to easily return granular errors,
-	// so we should honor travis by doing it.
-	err = f.client.ProduceSync(ctx, records...).FirstErr()
-	return
+	if len(records) > 0 {
+		// Results are not in the order of the records given.
+		for _, res := range f.client.ProduceSync(ctx, records...) {
+			if res.Err != nil {
+				failed(indexes[res.Record], res.Err)
+			}
+		}
+	}
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
+// encodeCloudEvent encodes the value of a record along with the attributes
+// within the metadata of a message as a CloudEvent.
+func encodeCloudEvent(record *kgo.Record, msg *service.Message, mode string) error {
+	event := cloudevents.FromMetadata(msg.MetaWalk, record.Value)
+	if mode == cloudevents.ModeStructured {
+		value, err := event.MarshalStructured()
+		if err != nil {
+			return err
+		}
+		record.Value = value
+		record.Headers = append(record.Headers, kgo.RecordHeader{
+			Key:   "content-type",
+			Value: []byte(cloudevents.StructuredContentType),
+		})
+		return nil
+	}
+
+	attrs, contentType, err := event.BinaryAttributes()
+	if err != nil {
+		return err
+	}
+	for k, v := range attrs {
+		record.Headers = append(record.Headers, kgo.RecordHeader{
+			Key:   cloudevents.MetaPrefix + k,
+			Value: []byte(v),
+		})
+	}
+	if contentType != "" {
+		record.Headers = append(record.Headers, kgo.RecordHeader{
+			Key:   "content-type",
+			Value: []byte(contentType),
+		})
+	}
+	return nil
 }
 
 func (f *franzKafkaWriter) disconnect() {
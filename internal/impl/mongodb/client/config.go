@@ -121,6 +121,6 @@ func ConfigDocs() docs.FieldSpecs {
 		),
 		docs.FieldString("database", "The name of the target MongoDB DB."),
 		docs.FieldString("username", "The username to connect to the database."),
-		docs.FieldString("password", "The password to connect to the database."),
+		docs.FieldString("password", "The password to connect to the database.").Secret(),
 	}
 }
@@ -22,12 +22,14 @@ func mongoConfigSpec() *service.ConfigSpec {
 		Version("3.64.0").
 		Categories("Services").
 		Summary("Executes a find query and creates a message for each row received.").
-		Description(`Once the rows from the query are exhausted this input shuts down, allowing the pipeline to gracefully terminate (or the next input in a [sequence](/docs/components/inputs/sequence) to execute).`).
+		Description(`Once the rows from the query are exhausted this input shuts down, allowing the pipeline to gracefully terminate (or the next input in a [sequence](/docs/components/inputs/sequence) to execute).
+
+In order to continuously consume changes made to a collection use the [` + "`mongodb_change_stream`" + ` input](/docs/components/inputs/mongodb_change_stream) instead.`).
 		Field(urlField).
 		Field(service.NewStringField("database").Description("The name of the target MongoDB database.")).
 		Field(service.NewStringField("collection").Description("The collection to select from.")).
 		Field(service.NewStringField("username").Description("The username to connect to the database.").Default("")).
-		Field(service.NewStringField("password").Description("The password to connect to the database.").Default("")).
+		Field(service.NewStringField("password").Description("The password to connect to the database.").Default("").Secret()).
 		Field(service.NewStringEnumField("operation", FindInputOperation, AggregateInputOperation).
 			Description("The mongodb operation to perform.").
 			Default(FindInputOperation).Advanced().
@@ -0,0 +1,419 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+	"github.com/benthosdev/benthos/v4/internal/impl/mongodb/client"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func mongoChangeStreamConfigSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Version("4.4.0").
+		Categories("Services").
+		Summary("Watches a MongoDB collection, database or deployment for changes and creates a message for each change event.").
+		Description(`
+A [change stream](https://www.mongodb.com/docs/manual/changeStreams/) is opened on the target collection when both ` + "`database`" + ` and ` + "`collection`" + ` are set, on the target database when only ` + "`database`" + ` is set, and on the entire deployment when neither are set. Change streams are only available for replica sets and sharded clusters.
+
+Each message is a change event serialised as [relaxed extended JSON](https://www.mongodb.com/docs/manual/reference/mongodb-extended-json/), where the changed document can be found at the field ` + "`fullDocument`" + ` for insert and replace events, and also for update events when ` + "`full_document`" + ` is set to ` + "`update_lookup`" + `.
+
+### Resuming
+
+The resume token of the latest change event is kept once that event, and all events that preceded it, have been acknowledged. When the input reconnects it resumes from this token, and therefore events are delivered at least once. When a ` + "`cache`" + ` is configured the token is also stored in the cache, which allows the input to resume from it after a restart.
+
+If the change stream is invalidated, for example because the watched collection is dropped or renamed, the input shuts down.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- mongodb_operation_type
+- mongodb_database
+- mongodb_collection
+` + "```" + `
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(urlField).
+		Field(service.NewStringField("database").
+			Description("The name of the database to watch. If empty the entire deployment is watched.").
+			Default("")).
+		Field(service.NewStringField("collection").
+			Description("The name of the collection to watch. If empty the entire database is watched.").
+			Default("")).
+		Field(service.NewStringField("username").Description("The username to connect to the database.").Default("")).
+		Field(service.NewStringField("password").Description("The password to connect to the database.").Default("").Secret()).
+		Field(service.NewStringListField("operation_types").
+			Description("A list of change event operation types to emit, events of any other type are ignored. If empty all events are emitted.").
+			Default([]interface{}{"insert", "update", "replace", "delete"})).
+		Field(service.NewBloblangField("pipeline").
+			Description("An optional Bloblang expression that produces an array of aggregation pipeline stages, which are applied to change events in order to filter or modify them.").
+			Example(`root = [ { "$match": { "fullDocument.status": "active" } } ]`).
+			Optional().
+			Advanced()).
+		Field(service.NewStringEnumField("full_document", "default", "update_lookup").
+			Description("Whether update events should include the current version of the changed document, which is looked up at the time the event is read.").
+			Default("default")).
+		Field(service.NewStringField("cache").
+			Description("An optional [cache resource](/docs/components/caches/about) used to store the resume token of the change stream.").
+			Default("")).
+		Field(service.NewStringField("cache_key").
+			Description("The key under which the resume token is stored within the `cache`.").
+			Default("mongodb_change_stream_resume_token").
+			Advanced()).
+		Field(service.NewIntField("checkpoint_limit").
+			Description("The maximum number of change events that can be pending acknowledgement at any given time.").
+			Default(1024).
+			Advanced())
+}
+
+func init() {
+	err := service.RegisterInput(
+		"mongodb_change_stream", mongoChangeStreamConfigSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			return newMongoChangeStreamInput(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newMongoChangeStreamInput(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+	m := &mongoChangeStreamInput{
+		mgr: mgr,
+		log: mgr.Logger(),
+	}
+
+	var err error
+	if m.config.URL, err = conf.FieldString("url"); err != nil {
+		return nil, err
+	}
+	if m.config.Database, err = conf.FieldString("database"); err != nil {
+		return nil, err
+	}
+	if m.config.Collection, err = conf.FieldString("collection"); err != nil {
+		return nil, err
+	}
+	if m.config.Collection != "" && m.config.Database == "" {
+		return nil, errors.New("a database must be specified in order to watch a collection")
+	}
+	if m.config.Username, err = conf.FieldString("username"); err != nil {
+		return nil, err
+	}
+	if m.config.Password, err = conf.FieldString("password"); err != nil {
+		return nil, err
+	}
+
+	opTypes, err := conf.FieldStringList("operation_types")
+	if err != nil {
+		return nil, err
+	}
+	var userStages []interface{}
+	if conf.Contains("pipeline") {
+		pipelineExec, err := conf.FieldBloblang("pipeline")
+		if err != nil {
+			return nil, err
+		}
+		res, err := pipelineExec.Query(struct{}{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute pipeline: %w", err)
+		}
+		var ok bool
+		if userStages, ok = res.([]interface{}); !ok {
+			return nil, fmt.Errorf("expected pipeline to produce an array, got %T", res)
+		}
+	}
+	m.pipeline = changeStreamPipeline(opTypes, userStages)
+
+	fullDocument, err := conf.FieldString("full_document")
+	if err != nil {
+		return nil, err
+	}
+	m.fullDocument = options.Default
+	if fullDocument == "update_lookup" {
+		m.fullDocument = options.UpdateLookup
+	}
+
+	if m.cache, err = conf.FieldString("cache"); err != nil {
+		return nil, err
+	}
+	if m.cacheKey, err = conf.FieldString("cache_key"); err != nil {
+		return nil, err
+	}
+	if m.cache != "" && !mgr.HasCache(m.cache) {
+		return nil, fmt.Errorf("cache resource '%v' was not found", m.cache)
+	}
+
+	checkpointLimit, err := conf.FieldInt("checkpoint_limit")
+	if err != nil {
+		return nil, err
+	}
+	if checkpointLimit < 1 {
+		return nil, errors.New("checkpoint_limit must be at least one")
+	}
+	m.checkpointLimit = int64(checkpointLimit)
+
+	return service.AutoRetryNacks(m), nil
+}
+
+// changeStreamPipeline returns the aggregation pipeline of a change stream,
+// made up of a filter on the operation types of events, if any, followed by
+// the stages provided by the user. Invalidate events are never filtered as they
+// signal that the stream has ended.
+func changeStreamPipeline(opTypes []string, userStages []interface{}) []interface{} {
+	pipeline := []interface{}{}
+	if len(opTypes) > 0 {
+		matchTypes := make([]string, 0, len(opTypes)+1)
+		matchTypes = append(matchTypes, opTypes...)
+		pipeline = append(pipeline, bson.M{
+			"$match": bson.M{
+				"operationType": bson.M{"$in": append(matchTypes, invalidateOperationType)},
+			},
+		})
+	}
+	return append(pipeline, userStages...)
+}
+
+//------------------------------------------------------------------------------
+
+const invalidateOperationType = "invalidate"
+
+// changeStream is the subset of a *mongo.ChangeStream used by the input.
+type changeStream interface {
+	Next(ctx context.Context) bool
+	Err() error
+	Decode(val interface{}) error
+	ResumeToken() bson.Raw
+	Close(ctx context.Context) error
+}
+
+type mongoChangeStreamInput struct {
+	mgr *service.Resources
+	log *service.Logger
+
+	config          client.Config
+	pipeline        []interface{}
+	fullDocument    options.FullDocument
+	cache           string
+	cacheKey        string
+	checkpointLimit int64
+
+	mut          sync.Mutex
+	client       *mongo.Client
+	stream       changeStream
+	checkpointer *checkpoint.Capped
+	resumeToken  bson.Raw
+}
+
+func (m *mongoChangeStreamInput) loadResumeToken(ctx context.Context) (bson.Raw, error) {
+	if m.cache == "" {
+		return nil, nil
+	}
+
+	var token []byte
+	var cErr error
+	if err := m.mgr.AccessCache(ctx, m.cache, func(c service.Cache) {
+		token, cErr = c.Get(ctx, m.cacheKey)
+	}); err != nil {
+		return nil, err
+	}
+	if cErr != nil {
+		if errors.Is(cErr, service.ErrKeyNotFound) {
+			return nil, nil
+		}
+		return nil, cErr
+	}
+	return bson.Raw(token), nil
+}
+
+func (m *mongoChangeStreamInput) storeResumeToken(ctx context.Context, token bson.Raw) error {
+	var cErr error
+	if err := m.mgr.AccessCache(ctx, m.cache, func(c service.Cache) {
+		cErr = c.Set(ctx, m.cacheKey, token, nil)
+	}); err != nil {
+		return err
+	}
+	return cErr
+}
+
+func (m *mongoChangeStreamInput) Connect(ctx context.Context) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	if m.stream != nil {
+		return nil
+	}
+
+	mClient, err := m.config.Client()
+	if err != nil {
+		return err
+	}
+	if err = mClient.Connect(ctx); err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	if err = mClient.Ping(ctx, nil); err != nil {
+		_ = mClient.Disconnect(ctx)
+		return fmt.Errorf("ping failed: %v", err)
+	}
+
+	opts := options.ChangeStream().SetFullDocument(m.fullDocument)
+
+	// The token of the last acknowledged event is preferred over the cache as
+	// it is never behind it.
+	token := m.resumeToken
+	if token == nil {
+		if token, err = m.loadResumeToken(ctx); err != nil {
+			_ = mClient.Disconnect(ctx)
+			return fmt.Errorf("failed to obtain resume token: %w", err)
+		}
+	}
+	if token != nil {
+		opts.SetResumeAfter(token)
+	}
+
+	var stream *mongo.ChangeStream
+	switch {
+	case m.config.Collection != "":
+		stream, err = mClient.Database(m.config.Database).Collection(m.config.Collection).Watch(ctx, m.pipeline, opts)
+	case m.config.Database != "":
+		stream, err = mClient.Database(m.config.Database).Watch(ctx, m.pipeline, opts)
+	default:
+		stream, err = mClient.Watch(ctx, m.pipeline, opts)
+	}
+	if err != nil {
+		_ = mClient.Disconnect(ctx)
+		return fmt.Errorf("failed to open change stream: %w", err)
+	}
+
+	m.client = mClient
+	m.stream = stream
+	m.checkpointer = checkpoint.NewCapped(m.checkpointLimit)
+	return nil
+}
+
+type changeEventMeta struct {
+	OperationType string `bson:"operationType"`
+	NS            struct {
+		DB   string `bson:"db"`
+		Coll string `bson:"coll"`
+	} `bson:"ns"`
+}
+
+// disconnectStream closes the connection of the input, unless the stream has
+// already been replaced.
+func (m *mongoChangeStreamInput) disconnectStream(ctx context.Context, stream changeStream) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	if m.stream == stream {
+		_ = m.disconnect(ctx)
+	}
+}
+
+func (m *mongoChangeStreamInput) disconnect(ctx context.Context) error {
+	var err error
+	if m.stream != nil {
+		err = m.stream.Close(ctx)
+		m.stream = nil
+	}
+	if m.client != nil {
+		if dErr := m.client.Disconnect(ctx); err == nil {
+			err = dErr
+		}
+		m.client = nil
+	}
+	return err
+}
+
+func (m *mongoChangeStreamInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	m.mut.Lock()
+	stream, checkpointer := m.stream, m.checkpointer
+	m.mut.Unlock()
+
+	if stream == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	if !stream.Next(ctx) {
+		// Once Next returns false the stream can no longer be used, either
+		// because its cursor has closed or because the driver has stored an
+		// error, including that of a cancelled context. Therefore the stream is
+		// always reopened, resuming from the stored token if there is one.
+		if err := stream.Err(); err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+			m.log.Errorf("Change stream failed: %v", err)
+		} else if err == nil {
+			m.log.Warn("Change stream was closed")
+		}
+		m.disconnectStream(context.Background(), stream)
+		return nil, nil, service.ErrNotConnected
+	}
+
+	var current bson.Raw
+	if err := stream.Decode(&current); err != nil {
+		return nil, nil, err
+	}
+	var meta changeEventMeta
+	if err := bson.Unmarshal(current, &meta); err != nil {
+		return nil, nil, err
+	}
+	if meta.OperationType == invalidateOperationType {
+		m.log.Warn("Change stream was invalidated, shutting down")
+		m.disconnectStream(context.Background(), stream)
+		return nil, nil, service.ErrEndOfInput
+	}
+
+	eventBytes, err := bson.MarshalExtJSON(current, false, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	msg := service.NewMessage(eventBytes)
+	msg.MetaSet("mongodb_operation_type", meta.OperationType)
+	msg.MetaSet("mongodb_database", meta.NS.DB)
+	msg.MetaSet("mongodb_collection", meta.NS.Coll)
+
+	// The token is copied as the underlying buffer may be reused by the stream.
+	token := make(bson.Raw, len(stream.ResumeToken()))
+	copy(token, stream.ResumeToken())
+
+	resolveFn, err := checkpointer.Track(ctx, token, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return msg, func(ctx context.Context, err error) error {
+		highest := resolveFn()
+		if highest == nil {
+			return nil
+		}
+
+		m.mut.Lock()
+		if m.checkpointer != checkpointer {
+			// The stream has since been reopened from the token of the last
+			// event acknowledged before it, and so acknowledgements of events
+			// from the old stream must not move the token.
+			m.mut.Unlock()
+			return nil
+		}
+		m.resumeToken = highest.(bson.Raw)
+		m.mut.Unlock()
+
+		if m.cache == "" {
+			return nil
+		}
+		return m.storeResumeToken(ctx, highest.(bson.Raw))
+	}, nil
+}
+
+func (m *mongoChangeStreamInput) Close(ctx context.Context) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	return m.disconnect(ctx)
+}
@@ -0,0 +1,195 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestChangeStreamPipeline(t *testing.T) {
+	assert.Equal(t, []interface{}{}, changeStreamPipeline(nil, nil))
+
+	userStage := map[string]interface{}{
+		"$project": map[string]interface{}{"fullDocument": 1},
+	}
+	assert.Equal(t, []interface{}{
+		bson.M{"$match": bson.M{"operationType": bson.M{"$in": []string{"insert", "delete", "invalidate"}}}},
+		userStage,
+	}, changeStreamPipeline([]string{"insert", "delete"}, []interface{}{userStage}))
+}
+
+func TestChangeStreamInputConfig(t *testing.T) {
+	spec := mongoChangeStreamConfigSpec()
+	env := service.NewEnvironment()
+
+	conf, err := spec.ParseYAML(`
+url: "mongodb://localhost:27017"
+database: foo
+collection: bar
+full_document: update_lookup
+pipeline: 'root = [ { "$match": { "fullDocument.status": "active" } } ]'
+`, env)
+	require.NoError(t, err)
+
+	in, err := newMongoChangeStreamInput(conf, service.MockResources())
+	require.NoError(t, err)
+	require.NoError(t, in.Close(context.Background()))
+
+	for name, confStr := range map[string]string{
+		"collection without database": `
+url: "mongodb://localhost:27017"
+collection: bar
+`,
+		"pipeline not an array": `
+url: "mongodb://localhost:27017"
+pipeline: 'root = { "$match": {} }'
+`,
+		"missing cache": `
+url: "mongodb://localhost:27017"
+cache: nope
+`,
+	} {
+		conf, err := spec.ParseYAML(confStr, env)
+		require.NoError(t, err, name)
+
+		_, err = newMongoChangeStreamInput(conf, service.MockResources())
+		assert.Error(t, err, name)
+	}
+}
+
+type fakeChangeStream struct {
+	events [][]byte
+	err    error
+	closed bool
+
+	current []byte
+}
+
+func (f *fakeChangeStream) Next(ctx context.Context) bool {
+	if len(f.events) == 0 {
+		if f.err == nil {
+			f.err = ctx.Err()
+		}
+		return false
+	}
+	f.current, f.events = f.events[0], f.events[1:]
+	return true
+}
+
+func (f *fakeChangeStream) Err() error {
+	return f.err
+}
+
+func (f *fakeChangeStream) Decode(val interface{}) error {
+	return bson.Unmarshal(f.current, val)
+}
+
+func (f *fakeChangeStream) ResumeToken() bson.Raw {
+	return f.current
+}
+
+func (f *fakeChangeStream) Close(ctx context.Context) error {
+	f.closed = true
+	return nil
+}
+
+func testChangeStreamInput(t *testing.T, events ...bson.M) (*mongoChangeStreamInput, *fakeChangeStream) {
+	t.Helper()
+
+	stream := &fakeChangeStream{}
+	for _, e := range events {
+		eBytes, err := bson.Marshal(e)
+		require.NoError(t, err)
+		stream.events = append(stream.events, eBytes)
+	}
+
+	mgr := service.MockResources()
+	return &mongoChangeStreamInput{
+		mgr:          mgr,
+		log:          mgr.Logger(),
+		stream:       stream,
+		checkpointer: checkpoint.NewCapped(10),
+	}, stream
+}
+
+func TestChangeStreamReadCursorClosed(t *testing.T) {
+	in, stream := testChangeStreamInput(t, bson.M{
+		"operationType": "insert",
+		"ns":            bson.M{"db": "foo", "coll": "bar"},
+	})
+
+	msg, ackFn, err := in.Read(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, ackFn(context.Background(), nil))
+
+	mBytes, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.Contains(t, string(mBytes), `"operationType":"insert"`)
+
+	opType, _ := msg.MetaGet("mongodb_operation_type")
+	assert.Equal(t, "insert", opType)
+
+	_, _, err = in.Read(context.Background())
+	assert.Equal(t, service.ErrNotConnected, err)
+	assert.True(t, stream.closed)
+	assert.Nil(t, in.stream)
+}
+
+func TestChangeStreamReadContextCancelled(t *testing.T) {
+	in, stream := testChangeStreamInput(t)
+
+	ctx, done := context.WithCancel(context.Background())
+	done()
+
+	_, _, err := in.Read(ctx)
+	assert.Equal(t, service.ErrNotConnected, err)
+	assert.True(t, stream.closed)
+	assert.Nil(t, in.stream)
+}
+
+func TestChangeStreamReadInvalidate(t *testing.T) {
+	in, stream := testChangeStreamInput(t, bson.M{
+		"operationType": "invalidate",
+	})
+
+	_, _, err := in.Read(context.Background())
+	assert.Equal(t, service.ErrEndOfInput, err)
+	assert.True(t, stream.closed)
+	assert.Nil(t, in.stream)
+}
+
+func TestChangeStreamResumeToken(t *testing.T) {
+	in, _ := testChangeStreamInput(t,
+		bson.M{"operationType": "insert", "n": 1},
+		bson.M{"operationType": "insert", "n": 2},
+		bson.M{"operationType": "insert", "n": 3},
+	)
+
+	tCtx := context.Background()
+
+	var acks []service.AckFunc
+	var tokens []bson.Raw
+	for i := 0; i < 3; i++ {
+		_, ackFn, err := in.Read(tCtx)
+		require.NoError(t, err)
+		acks = append(acks, ackFn)
+		tokens = append(tokens, in.stream.ResumeToken())
+	}
+
+	require.NoError(t, acks[1](tCtx, nil))
+	assert.Nil(t, in.resumeToken, "earlier event not yet acknowledged")
+
+	require.NoError(t, acks[0](tCtx, nil))
+	assert.Equal(t, tokens[1], in.resumeToken)
+
+	// Acknowledgements of events read before a reconnect are ignored.
+	in.checkpointer = checkpoint.NewCapped(10)
+	require.NoError(t, acks[2](tCtx, nil))
+	assert.Equal(t, tokens[1], in.resumeToken)
+}
@@ -0,0 +1,121 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+
+	"github.com/eclipse/paho.golang/paho"
+
+	mqttconf "github.com/benthosdev/benthos/v4/internal/impl/mqtt/shared"
+)
+
+const (
+	protocolVersion311 = "3.1.1"
+	protocolVersion5   = "5"
+)
+
+// The session expiry interval, in seconds, that indicates to an MQTT 5 broker
+// that a session should never expire, which matches the behaviour of a
+// non-clean session in MQTT 3.1.1.
+const sessionExpiryNever uint32 = math.MaxUint32
+
+// dialV5 opens a network connection to the first of the provided broker URLs
+// that can be reached. Unlike the MQTT 3.1.1 client the MQTT 5 client expects
+// an established connection.
+func dialV5(ctx context.Context, urls []string, tlsConf *tls.Config) (net.Conn, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("no broker urls were provided")
+	}
+
+	var errs []error
+	for _, u := range urls {
+		brokerURL, err := url.Parse(u)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse broker url '%v': %w", u, err)
+		}
+
+		var conn net.Conn
+		switch brokerURL.Scheme {
+		case "tcp", "mqtt":
+			if tlsConf != nil {
+				conn, err = (&tls.Dialer{Config: tlsConf}).DialContext(ctx, "tcp", brokerURL.Host)
+			} else {
+				conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", brokerURL.Host)
+			}
+		case "ssl", "tls", "tcps", "mqtts":
+			conn, err = (&tls.Dialer{Config: tlsConf}).DialContext(ctx, "tcp", brokerURL.Host)
+		default:
+			return nil, fmt.Errorf("broker url scheme '%v' is not supported with protocol version %v", brokerURL.Scheme, protocolVersion5)
+		}
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, fmt.Errorf("%v: %w", u, err))
+	}
+	if len(errs) == 1 {
+		return nil, errs[0]
+	}
+	return nil, fmt.Errorf("failed to connect to any broker: %v", errs)
+}
+
+type connectV5Config struct {
+	clientID     string
+	user         string
+	password     string
+	keepAlive    int64
+	cleanSession bool
+	will         mqttconf.Will
+}
+
+// connectPacketV5 creates the connect packet sent to an MQTT 5 broker.
+func connectPacketV5(conf connectV5Config) *paho.Connect {
+	cp := &paho.Connect{
+		ClientID:   conf.clientID,
+		KeepAlive:  uint16(conf.keepAlive),
+		CleanStart: conf.cleanSession,
+	}
+	if !conf.cleanSession {
+		expiry := sessionExpiryNever
+		cp.Properties = &paho.ConnectProperties{
+			SessionExpiryInterval: &expiry,
+		}
+	}
+	if conf.user != "" {
+		cp.UsernameFlag = true
+		cp.Username = conf.user
+	}
+	if conf.password != "" {
+		cp.PasswordFlag = true
+		cp.Password = []byte(conf.password)
+	}
+	if conf.will.Enabled {
+		cp.WillMessage = &paho.WillMessage{
+			Retain:  conf.will.Retained,
+			QoS:     conf.will.QoS,
+			Topic:   conf.will.Topic,
+			Payload: []byte(conf.will.Payload),
+		}
+	}
+	return cp
+}
+
+// validateProtocolVersion checks that a protocol version is supported and that
+// the provided keep alive is compatible with it.
+func validateProtocolVersion(version string, keepAlive int64) error {
+	switch version {
+	case protocolVersion311:
+	case protocolVersion5:
+		// The MQTT 5 client requires a keep alive in order to schedule pings.
+		if keepAlive < 1 || keepAlive > math.MaxUint16 {
+			return fmt.Errorf("keepalive must be between 1 and %v seconds when protocol_version is %v", math.MaxUint16, protocolVersion5)
+		}
+	default:
+		return fmt.Errorf("unsupported protocol_version: %v", version)
+	}
+	return nil
+}
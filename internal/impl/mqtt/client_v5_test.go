@@ -0,0 +1,94 @@
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mqttconf "github.com/benthosdev/benthos/v4/internal/impl/mqtt/shared"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestValidateProtocolVersion(t *testing.T) {
+	assert.NoError(t, validateProtocolVersion("3.1.1", 0))
+	assert.NoError(t, validateProtocolVersion("5", 30))
+	assert.Error(t, validateProtocolVersion("5", 0))
+	assert.Error(t, validateProtocolVersion("4", 30))
+}
+
+func TestConnectPacketV5(t *testing.T) {
+	cp := connectPacketV5(connectV5Config{
+		clientID:     "foo",
+		user:         "bar",
+		password:     "baz",
+		keepAlive:    30,
+		cleanSession: false,
+		will: mqttconf.Will{
+			Enabled: true,
+			QoS:     1,
+			Topic:   "wills",
+			Payload: "gone",
+		},
+	})
+
+	assert.Equal(t, "foo", cp.ClientID)
+	assert.Equal(t, uint16(30), cp.KeepAlive)
+	assert.False(t, cp.CleanStart)
+	require.NotNil(t, cp.Properties)
+	require.NotNil(t, cp.Properties.SessionExpiryInterval)
+	assert.Equal(t, sessionExpiryNever, *cp.Properties.SessionExpiryInterval)
+	assert.True(t, cp.UsernameFlag)
+	assert.Equal(t, "bar", cp.Username)
+	assert.True(t, cp.PasswordFlag)
+	assert.Equal(t, []byte("baz"), cp.Password)
+	require.NotNil(t, cp.WillMessage)
+	assert.Equal(t, "wills", cp.WillMessage.Topic)
+	assert.Equal(t, []byte("gone"), cp.WillMessage.Payload)
+
+	cp = connectPacketV5(connectV5Config{clientID: "foo", keepAlive: 30, cleanSession: true})
+	assert.True(t, cp.CleanStart)
+	assert.Nil(t, cp.Properties)
+	assert.False(t, cp.UsernameFlag)
+	assert.False(t, cp.PasswordFlag)
+	assert.Nil(t, cp.WillMessage)
+}
+
+func TestAddMetadataV5(t *testing.T) {
+	expiry := uint32(60)
+	pub := &paho.Publish{
+		PacketID: 5,
+		QoS:      1,
+		Retain:   true,
+		Topic:    "foo",
+		Properties: &paho.PublishProperties{
+			ContentType:     "application/json",
+			ResponseTopic:   "bar",
+			CorrelationData: []byte("baz"),
+			MessageExpiry:   &expiry,
+			User: paho.UserProperties{
+				{Key: "first", Value: "a"},
+				{Key: "second", Value: "b"},
+			},
+		},
+	}
+
+	p := message.NewPart(nil)
+	addMetadataV5(p, pub)
+
+	for k, v := range map[string]string{
+		"first":                 "a",
+		"second":                "b",
+		"mqtt_content_type":     "application/json",
+		"mqtt_response_topic":   "bar",
+		"mqtt_correlation_data": "YmF6",
+		"mqtt_message_expiry":   "60",
+		"mqtt_qos":              "1",
+		"mqtt_retained":         "true",
+		"mqtt_topic":            "foo",
+		"mqtt_message_id":       "5",
+	} {
+		assert.Equal(t, v, p.MetaGet(k), k)
+	}
+}
@@ -24,7 +24,16 @@ import (
 
 func init() {
 	err := bundle.AllInputs.Add(processors.WrapConstructor(func(conf input.Config, nm bundle.NewManagement) (input.Streamed, error) {
-		m, err := newMQTTReader(conf.MQTT, nm.Logger())
+		if err := validateProtocolVersion(conf.MQTT.ProtocolVersion, conf.MQTT.KeepAlive); err != nil {
+			return nil, err
+		}
+		var m input.Async
+		var err error
+		if conf.MQTT.ProtocolVersion == protocolVersion5 {
+			m, err = newMQTTReaderV5(conf.MQTT, nm.Logger())
+		} else {
+			m, err = newMQTTReader(conf.MQTT, nm.Logger())
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -47,10 +56,27 @@ This input adds the following metadata fields to each message:
 ` + "```" + `
 
 You can access these metadata fields using
-[function interpolation](/docs/configuration/interpolation#metadata).`,
+[function interpolation](/docs/configuration/interpolation#metadata).
+
+### MQTT 5
+
+When the field ` + "`protocol_version`" + ` is set to ` + "`5`" + ` the input connects using MQTT 5, in which case the user properties of each message are added as metadata fields, and the following metadata fields are also added when the corresponding properties are present:
+
+` + "``` text" + `
+- mqtt_content_type
+- mqtt_response_topic
+- mqtt_correlation_data
+- mqtt_message_expiry
+` + "```" + `
+
+The field ` + "`mqtt_correlation_data`" + ` is base64 encoded as correlation data is binary, and ` + "`mqtt_message_expiry`" + ` is the number of seconds remaining before the message expires. The field ` + "`mqtt_duplicate`" + ` is not added in MQTT 5 mode.
+
+Setting the field ` + "`shared_subscription_group`" + ` subscribes to each topic as a shared subscription of that group, where the broker distributes messages between all clients of the group rather than delivering each message to every client.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("urls", "A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs.").Array(),
+			docs.FieldString("protocol_version", "The version of the MQTT protocol to connect with.").HasOptions("3.1.1", "5").AtVersion("4.4.0"),
 			docs.FieldString("topics", "A list of topics to consume from.").Array(),
+			docs.FieldString("shared_subscription_group", "An optional group name with which to subscribe to each topic as a [shared subscription](#mqtt-5). This field is only supported when `protocol_version` is `5`.").Advanced().AtVersion("4.4.0"),
 			docs.FieldString("client_id", "An identifier for the client connection."),
 			docs.FieldString("dynamic_client_id_suffix", "Append a dynamically generated suffix to the specified `client_id` on each run of the pipeline. This can be useful when clustering Benthos producers.").Optional().Advanced().HasAnnotatedOptions(
 				"nanoid", "append a nanoid of length 21 characters",
@@ -60,7 +86,7 @@ You can access these metadata fields using
 			mqttconf.WillFieldSpec(),
 			docs.FieldString("connect_timeout", "The maximum amount of time to wait in order to establish a connection before the attempt is abandoned.", "1s", "500ms").HasDefault("30s").AtVersion("3.58.0"),
 			docs.FieldString("user", "A username to assume for the connection.").Advanced(),
-			docs.FieldString("password", "A password to provide for the connection.").Advanced(),
+			docs.FieldString("password", "A password to provide for the connection.").Advanced().Secret(),
 			docs.FieldInt("keepalive", "Max seconds of inactivity before a keepalive message is sent.").Advanced(),
 			tls.FieldSpec().AtVersion("3.45.0"),
 		).ChildDefaultAndTypesFromStruct(input.NewMQTTConfig()),
@@ -116,6 +142,10 @@ func newMQTTReader(conf input.MQTTConfig, log log.Modular) (*mqttReader, error)
 		return nil, err
 	}
 
+	if conf.SharedSubscriptionGroup != "" {
+		return nil, fmt.Errorf("shared_subscription_group requires protocol_version %v", protocolVersion5)
+	}
+
 	for _, u := range conf.URLs {
 		for _, splitURL := range strings.Split(u, ",") {
 			if len(splitURL) > 0 {
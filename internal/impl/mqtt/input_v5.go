@@ -0,0 +1,282 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.golang/paho"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// mqttReaderV5 is an MQTT input that speaks protocol version 5.
+type mqttReaderV5 struct {
+	client   *paho.Client
+	msgChan  chan *paho.Publish
+	lostChan chan struct{}
+	cMut     sync.Mutex
+
+	connectTimeout time.Duration
+	conf           input.MQTTConfig
+	topics         []string
+
+	interruptChan chan struct{}
+	interruptOnce sync.Once
+
+	urls []string
+
+	log log.Modular
+}
+
+func newMQTTReaderV5(conf input.MQTTConfig, log log.Modular) (*mqttReaderV5, error) {
+	m := &mqttReaderV5{
+		conf:          conf,
+		interruptChan: make(chan struct{}),
+		log:           log,
+	}
+
+	var err error
+	if m.connectTimeout, err = time.ParseDuration(conf.ConnectTimeout); err != nil {
+		return nil, fmt.Errorf("unable to parse connect timeout duration string: %w", err)
+	}
+
+	switch m.conf.DynamicClientIDSuffix {
+	case "nanoid":
+		nid, err := gonanoid.New()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate nanoid: %w", err)
+		}
+		m.conf.ClientID += nid
+	case "":
+	default:
+		return nil, fmt.Errorf("unknown dynamic_client_id_suffix: %v", m.conf.DynamicClientIDSuffix)
+	}
+
+	if err := m.conf.Will.Validate(); err != nil {
+		return nil, err
+	}
+
+	for _, topic := range conf.Topics {
+		if conf.SharedSubscriptionGroup != "" {
+			topic = "$share/" + conf.SharedSubscriptionGroup + "/" + topic
+		}
+		m.topics = append(m.topics, topic)
+	}
+
+	for _, u := range conf.URLs {
+		for _, splitURL := range strings.Split(u, ",") {
+			if len(splitURL) > 0 {
+				m.urls = append(m.urls, splitURL)
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (m *mqttReaderV5) ConnectWithContext(ctx context.Context) error {
+	m.cMut.Lock()
+	defer m.cMut.Unlock()
+
+	if m.client != nil {
+		return nil
+	}
+
+	var tlsConf *tls.Config
+	if m.conf.TLS.Enabled {
+		var err error
+		if tlsConf, err = m.conf.TLS.Get(); err != nil {
+			return err
+		}
+	}
+
+	ctx, done := context.WithTimeout(ctx, m.connectTimeout)
+	defer done()
+
+	conn, err := dialV5(ctx, m.urls, tlsConf)
+	if err != nil {
+		return err
+	}
+
+	msgChan := make(chan *paho.Publish)
+
+	// The message channel is never closed as the handler may be sending on
+	// it, instead the lost channel is closed once the connection is lost.
+	var lostOnce sync.Once
+	lostChan := make(chan struct{})
+	connLost := func() (first bool) {
+		lostOnce.Do(func() {
+			close(lostChan)
+			first = true
+		})
+		return
+	}
+
+	client := paho.NewClient(paho.ClientConfig{
+		Conn:                       conn,
+		PacketTimeout:              m.connectTimeout,
+		EnableManualAcknowledgment: true,
+		Router: paho.NewSingleHandlerRouter(func(pub *paho.Publish) {
+			select {
+			case msgChan <- pub:
+			case <-lostChan:
+			case <-m.interruptChan:
+			}
+		}),
+		OnClientError: func(err error) {
+			if connLost() {
+				m.log.Errorf("Connection lost due to: %v\n", err)
+			}
+		},
+		OnServerDisconnect: func(d *paho.Disconnect) {
+			if connLost() {
+				reason := strconv.Itoa(int(d.ReasonCode))
+				if d.Properties != nil && d.Properties.ReasonString != "" {
+					reason = d.Properties.ReasonString
+				}
+				m.log.Errorf("Connection closed by broker: %v\n", reason)
+			}
+		},
+	})
+
+	if _, err = client.Connect(ctx, connectPacketV5(connectV5Config{
+		clientID:     m.conf.ClientID,
+		user:         m.conf.User,
+		password:     m.conf.Password,
+		keepAlive:    m.conf.KeepAlive,
+		cleanSession: m.conf.CleanSession,
+		will:         m.conf.Will,
+	})); err != nil {
+		return err
+	}
+
+	subs := map[string]paho.SubscribeOptions{}
+	for _, topic := range m.topics {
+		subs[topic] = paho.SubscribeOptions{QoS: m.conf.QoS}
+	}
+	sAck, err := client.Subscribe(ctx, &paho.Subscribe{Subscriptions: subs})
+	if err == nil {
+		for _, reason := range sAck.Reasons {
+			if reason >= 0x80 {
+				err = fmt.Errorf("subscription rejected with reason code %v", reason)
+				break
+			}
+		}
+	}
+	if err != nil {
+		_ = client.Disconnect(&paho.Disconnect{})
+		return fmt.Errorf("failed to subscribe to topics '%v': %w", m.topics, err)
+	}
+
+	m.log.Infof("Receiving MQTT 5 messages from topics: %v\n", m.topics)
+
+	m.client = client
+	m.msgChan = msgChan
+	m.lostChan = lostChan
+	return nil
+}
+
+// addMetadataV5 adds the properties of an MQTT 5 message to a message part.
+func addMetadataV5(p *message.Part, pub *paho.Publish) {
+	if props := pub.Properties; props != nil {
+		for _, prop := range props.User {
+			p.MetaSet(prop.Key, prop.Value)
+		}
+		if props.ContentType != "" {
+			p.MetaSet("mqtt_content_type", props.ContentType)
+		}
+		if props.ResponseTopic != "" {
+			p.MetaSet("mqtt_response_topic", props.ResponseTopic)
+		}
+		if len(props.CorrelationData) > 0 {
+			p.MetaSet("mqtt_correlation_data", base64.StdEncoding.EncodeToString(props.CorrelationData))
+		}
+		if props.MessageExpiry != nil {
+			p.MetaSet("mqtt_message_expiry", strconv.FormatUint(uint64(*props.MessageExpiry), 10))
+		}
+	}
+	p.MetaSet("mqtt_qos", strconv.Itoa(int(pub.QoS)))
+	p.MetaSet("mqtt_retained", strconv.FormatBool(pub.Retain))
+	p.MetaSet("mqtt_topic", pub.Topic)
+	p.MetaSet("mqtt_message_id", strconv.Itoa(int(pub.PacketID)))
+}
+
+func (m *mqttReaderV5) ReadWithContext(ctx context.Context) (*message.Batch, input.AsyncAckFn, error) {
+	select {
+	case <-m.interruptChan:
+		return nil, nil, component.ErrTypeClosed
+	default:
+	}
+
+	m.cMut.Lock()
+	client, msgChan, lostChan := m.client, m.msgChan, m.lostChan
+	m.cMut.Unlock()
+
+	if msgChan == nil {
+		return nil, nil, component.ErrNotConnected
+	}
+
+	select {
+	case <-lostChan:
+		m.cMut.Lock()
+		lost := m.client == client
+		if lost {
+			m.msgChan = nil
+			m.lostChan = nil
+			m.client = nil
+		}
+		m.cMut.Unlock()
+		if lost {
+			// Stops the goroutines of the client, which are otherwise left
+			// running after the connection is lost.
+			_ = client.Disconnect(&paho.Disconnect{})
+		}
+		return nil, nil, component.ErrNotConnected
+	case pub := <-msgChan:
+		message := message.QuickBatch([][]byte{pub.Payload})
+		addMetadataV5(message.Get(0), pub)
+
+		return message, func(ctx context.Context, res error) error {
+			if res == nil {
+				// An error here means that the connection the message was
+				// received on has since been lost, in which case the broker
+				// will redeliver it.
+				_ = client.Ack(pub)
+			}
+			return nil
+		}, nil
+	case <-ctx.Done():
+	case <-m.interruptChan:
+		return nil, nil, component.ErrTypeClosed
+	}
+	return nil, nil, component.ErrTimeout
+}
+
+func (m *mqttReaderV5) CloseAsync() {
+	m.interruptOnce.Do(func() {
+		close(m.interruptChan)
+	})
+
+	m.cMut.Lock()
+	if m.client != nil {
+		_ = m.client.Disconnect(&paho.Disconnect{})
+		m.client = nil
+		m.msgChan = nil
+		m.lostChan = nil
+	}
+	m.cMut.Unlock()
+}
+
+func (m *mqttReaderV5) WaitForClose(timeout time.Duration) error {
+	return nil
+}
@@ -0,0 +1,105 @@
+package mqtt
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.golang/packets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func TestReaderV5CloseNotConnected(t *testing.T) {
+	conf := input.NewMQTTConfig()
+	conf.ProtocolVersion = "5"
+
+	m, err := newMQTTReaderV5(conf, log.Noop())
+	require.NoError(t, err)
+
+	m.CloseAsync()
+	m.CloseAsync()
+
+	_, _, err = m.ReadWithContext(context.Background())
+	assert.Equal(t, component.ErrTypeClosed, err)
+}
+
+// fakeBrokerV5 accepts a single client, acknowledges its connection and
+// subscription, sends it a message and then drops the connection.
+func fakeBrokerV5(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		if _, err := packets.ReadPacket(conn); err != nil {
+			return
+		}
+		connack := packets.NewControlPacket(packets.CONNACK)
+		if _, err := connack.WriteTo(conn); err != nil {
+			return
+		}
+
+		sub, err := packets.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		suback := packets.NewControlPacket(packets.SUBACK)
+		suback.Content.(*packets.Suback).PacketID = sub.PacketID()
+		suback.Content.(*packets.Suback).Reasons = []byte{packets.SubackGrantedQoS1}
+		if _, err := suback.WriteTo(conn); err != nil {
+			return
+		}
+
+		pub := packets.NewControlPacket(packets.PUBLISH)
+		pub.Content.(*packets.Publish).Topic = "foo"
+		pub.Content.(*packets.Publish).Payload = []byte("hello world")
+		_, _ = pub.WriteTo(conn)
+	}()
+
+	return "tcp://" + ln.Addr().String()
+}
+
+func TestReaderV5ConnectionLost(t *testing.T) {
+	conf := input.NewMQTTConfig()
+	conf.ProtocolVersion = "5"
+	conf.URLs = []string{fakeBrokerV5(t)}
+	conf.Topics = []string{"foo"}
+	conf.ClientID = "foo"
+
+	m, err := newMQTTReaderV5(conf, log.Noop())
+	require.NoError(t, err)
+	t.Cleanup(m.CloseAsync)
+
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, m.ConnectWithContext(tCtx))
+
+	msg, _, err := m.ReadWithContext(tCtx)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(msg.Get(0).Get()))
+
+	_, _, err = m.ReadWithContext(tCtx)
+	assert.Equal(t, component.ErrNotConnected, err)
+
+	m.cMut.Lock()
+	assert.Nil(t, m.client)
+	assert.Nil(t, m.msgChan)
+	m.cMut.Unlock()
+}
@@ -20,12 +20,22 @@ import (
 	mqttconf "github.com/benthosdev/benthos/v4/internal/impl/mqtt/shared"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/metadata"
 	"github.com/benthosdev/benthos/v4/internal/tls"
 )
 
 func init() {
 	err := bundle.AllOutputs.Add(processors.WrapConstructor(func(conf output.Config, nm bundle.NewManagement) (output.Streamed, error) {
-		w, err := newMQTTWriter(conf.MQTT, nm, nm.Logger())
+		if err := validateProtocolVersion(conf.MQTT.ProtocolVersion, conf.MQTT.KeepAlive); err != nil {
+			return nil, err
+		}
+		var w output.AsyncSink
+		var err error
+		if conf.MQTT.ProtocolVersion == protocolVersion5 {
+			w, err = newMQTTWriterV5(conf.MQTT, nm, nm.Logger())
+		} else {
+			w, err = newMQTTWriter(conf.MQTT, nm, nm.Logger())
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -41,9 +51,23 @@ Pushes messages to an MQTT broker.`,
 		Description: output.Description(true, false, `
 The `+"`topic`"+` field can be dynamically set using function interpolations
 described [here](/docs/configuration/interpolation#bloblang-queries). When sending batched
-messages these interpolations are performed per message part.`),
+messages these interpolations are performed per message part.
+
+### MQTT 5
+
+When the field `+"`protocol_version`"+` is set to `+"`5`"+` the output connects using MQTT 5, in which case the metadata fields of each message are sent as user properties, filtered by the field `+"`user_properties`"+`, and the fields `+"`content_type`"+`, `+"`response_topic`"+`, `+"`correlation_data`"+` and `+"`message_expiry`"+` can be used in order to set the corresponding properties of each message. A request/response pattern can be implemented by setting the `+"`response_topic`"+` and `+"`correlation_data`"+` fields of requests, and setting the fields of responses from the metadata of the requests:
+
+`+"```yaml"+`
+output:
+  mqtt:
+    urls: [ tcp://localhost:1883 ]
+    protocol_version: "5"
+    topic: ${! meta("mqtt_response_topic") }
+    correlation_data: ${! meta("mqtt_correlation_data").decode("base64") }
+`+"```"+``),
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("urls", "A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs.", []string{"tcp://localhost:1883"}).Array(),
+			docs.FieldString("protocol_version", "The version of the MQTT protocol to connect with.").HasOptions("3.1.1", "5").AtVersion("4.4.0"),
 			docs.FieldString("topic", "The topic to publish messages to."),
 			docs.FieldString("client_id", "An identifier for the client connection."),
 			docs.FieldString("dynamic_client_id_suffix", "Append a dynamically generated suffix to the specified `client_id` on each run of the pipeline. This can be useful when clustering Benthos producers.").Optional().Advanced().HasAnnotatedOptions(
@@ -56,8 +80,13 @@ messages these interpolations are performed per message part.`),
 			docs.FieldString("retained_interpolated", "Override the value of `retained` with an interpolable value, this allows it to be dynamically set based on message contents. The value must resolve to either `true` or `false`.").IsInterpolated().Advanced().AtVersion("3.59.0"),
 			mqttconf.WillFieldSpec(),
 			docs.FieldString("user", "A username to connect with.").Advanced(),
-			docs.FieldString("password", "A password to connect with.").Advanced(),
+			docs.FieldString("password", "A password to connect with.").Advanced().Secret(),
 			docs.FieldInt("keepalive", "Max seconds of inactivity before a keepalive message is sent.").Advanced(),
+			docs.FieldString("content_type", "The content type of each message. This field is only supported when `protocol_version` is `5`.", "application/json").IsInterpolated().Advanced().AtVersion("4.4.0"),
+			docs.FieldString("response_topic", "The topic to which responses to each message should be sent. This field is only supported when `protocol_version` is `5`.").IsInterpolated().Advanced().AtVersion("4.4.0"),
+			docs.FieldString("correlation_data", "Data used by the sender of a request to identify which request a response belongs to. This field is only supported when `protocol_version` is `5`.", `${! meta("request_id") }`).IsInterpolated().Advanced().AtVersion("4.4.0"),
+			docs.FieldString("message_expiry", "An optional duration after which the broker discards each message if it has not yet been delivered. This field is only supported when `protocol_version` is `5`.", "60s", "1h").Advanced().AtVersion("4.4.0"),
+			docs.FieldObject("user_properties", "Specify criteria for which metadata values are sent as user properties. This field is only supported when `protocol_version` is `5`.").WithChildren(metadata.ExcludeFilterFields()...).Advanced().AtVersion("4.4.0"),
 			tls.FieldSpec().AtVersion("3.45.0"),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 		).ChildDefaultAndTypesFromStruct(output.NewMQTTConfig()),
@@ -129,6 +158,17 @@ func newMQTTWriter(
 		return nil, err
 	}
 
+	for name, v := range map[string]string{
+		"content_type":     conf.ContentType,
+		"response_topic":   conf.ResponseTopic,
+		"correlation_data": conf.CorrelationData,
+		"message_expiry":   conf.MessageExpiry,
+	} {
+		if v != "" {
+			return nil, fmt.Errorf("%v requires protocol_version %v", name, protocolVersion5)
+		}
+	}
+
 	for _, u := range conf.URLs {
 		for _, splitURL := range strings.Split(u, ",") {
 			if len(splitURL) > 0 {
@@ -0,0 +1,254 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.golang/paho"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/metadata"
+)
+
+// mqttWriterV5 is an MQTT output that speaks protocol version 5.
+type mqttWriterV5 struct {
+	log log.Modular
+
+	connectTimeout time.Duration
+	writeTimeout   time.Duration
+	messageExpiry  *uint32
+
+	urls            []string
+	conf            output.MQTTConfig
+	topic           *field.Expression
+	retained        *field.Expression
+	contentType     *field.Expression
+	responseTopic   *field.Expression
+	correlationData *field.Expression
+	metaFilter      *metadata.ExcludeFilter
+
+	client  *paho.Client
+	connMut sync.RWMutex
+}
+
+func newMQTTWriterV5(
+	conf output.MQTTConfig,
+	mgr bundle.NewManagement,
+	log log.Modular,
+) (*mqttWriterV5, error) {
+	m := &mqttWriterV5{
+		log:  log,
+		conf: conf,
+	}
+
+	var err error
+	if m.connectTimeout, err = time.ParseDuration(conf.ConnectTimeout); err != nil {
+		return nil, fmt.Errorf("unable to parse connect timeout duration string: %w", err)
+	}
+	if m.writeTimeout, err = time.ParseDuration(conf.WriteTimeout); err != nil {
+		return nil, fmt.Errorf("unable to parse write timeout duration string: %w", err)
+	}
+	if conf.MessageExpiry != "" {
+		expiry, err := time.ParseDuration(conf.MessageExpiry)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse message expiry duration string: %w", err)
+		}
+		if expiry < time.Second || expiry.Seconds() > math.MaxUint32 {
+			return nil, fmt.Errorf("message expiry must be between one second and %v seconds", uint32(math.MaxUint32))
+		}
+		expirySeconds := uint32(expiry.Seconds())
+		m.messageExpiry = &expirySeconds
+	}
+
+	if m.topic, err = mgr.BloblEnvironment().NewField(conf.Topic); err != nil {
+		return nil, fmt.Errorf("failed to parse topic expression: %v", err)
+	}
+	if conf.RetainedInterpolated != "" {
+		if m.retained, err = mgr.BloblEnvironment().NewField(conf.RetainedInterpolated); err != nil {
+			return nil, fmt.Errorf("failed to parse retained expression: %v", err)
+		}
+	}
+	if conf.ContentType != "" {
+		if m.contentType, err = mgr.BloblEnvironment().NewField(conf.ContentType); err != nil {
+			return nil, fmt.Errorf("failed to parse content type expression: %v", err)
+		}
+	}
+	if conf.ResponseTopic != "" {
+		if m.responseTopic, err = mgr.BloblEnvironment().NewField(conf.ResponseTopic); err != nil {
+			return nil, fmt.Errorf("failed to parse response topic expression: %v", err)
+		}
+	}
+	if conf.CorrelationData != "" {
+		if m.correlationData, err = mgr.BloblEnvironment().NewField(conf.CorrelationData); err != nil {
+			return nil, fmt.Errorf("failed to parse correlation data expression: %v", err)
+		}
+	}
+	if m.metaFilter, err = conf.UserProperties.Filter(); err != nil {
+		return nil, fmt.Errorf("failed to construct user properties filter: %w", err)
+	}
+
+	switch m.conf.DynamicClientIDSuffix {
+	case "nanoid":
+		nid, err := gonanoid.New()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate nanoid: %w", err)
+		}
+		m.conf.ClientID += nid
+	case "":
+	default:
+		return nil, fmt.Errorf("unknown dynamic_client_id_suffix: %v", m.conf.DynamicClientIDSuffix)
+	}
+
+	if err := m.conf.Will.Validate(); err != nil {
+		return nil, err
+	}
+
+	for _, u := range conf.URLs {
+		for _, splitURL := range strings.Split(u, ",") {
+			if len(splitURL) > 0 {
+				m.urls = append(m.urls, splitURL)
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (m *mqttWriterV5) ConnectWithContext(ctx context.Context) error {
+	m.connMut.Lock()
+	defer m.connMut.Unlock()
+
+	if m.client != nil {
+		return nil
+	}
+
+	var tlsConf *tls.Config
+	if m.conf.TLS.Enabled {
+		var err error
+		if tlsConf, err = m.conf.TLS.Get(); err != nil {
+			return err
+		}
+	}
+
+	ctx, done := context.WithTimeout(ctx, m.connectTimeout)
+	defer done()
+
+	conn, err := dialV5(ctx, m.urls, tlsConf)
+	if err != nil {
+		return err
+	}
+
+	var client *paho.Client
+	dropClient := func() {
+		m.connMut.Lock()
+		if m.client == client {
+			m.client = nil
+		}
+		m.connMut.Unlock()
+	}
+
+	client = paho.NewClient(paho.ClientConfig{
+		Conn:          conn,
+		PacketTimeout: m.writeTimeout,
+		OnClientError: func(err error) {
+			dropClient()
+			m.log.Errorf("Connection lost due to: %v\n", err)
+		},
+		OnServerDisconnect: func(d *paho.Disconnect) {
+			dropClient()
+			reason := strconv.Itoa(int(d.ReasonCode))
+			if d.Properties != nil && d.Properties.ReasonString != "" {
+				reason = d.Properties.ReasonString
+			}
+			m.log.Errorf("Connection closed by broker: %v\n", reason)
+		},
+	})
+
+	if _, err = client.Connect(ctx, connectPacketV5(connectV5Config{
+		clientID:     m.conf.ClientID,
+		user:         m.conf.User,
+		password:     m.conf.Password,
+		keepAlive:    m.conf.KeepAlive,
+		cleanSession: true,
+		will:         m.conf.Will,
+	})); err != nil {
+		return err
+	}
+
+	m.client = client
+	return nil
+}
+
+func (m *mqttWriterV5) WriteWithContext(ctx context.Context, msg *message.Batch) error {
+	m.connMut.RLock()
+	client := m.client
+	m.connMut.RUnlock()
+
+	if client == nil {
+		return component.ErrNotConnected
+	}
+
+	return output.IterateBatchedSend(msg, func(i int, p *message.Part) error {
+		retained := m.conf.Retained
+		if m.retained != nil {
+			var parseErr error
+			retained, parseErr = strconv.ParseBool(m.retained.String(i, msg))
+			if parseErr != nil {
+				m.log.Errorf("Error parsing boolean value from retained flag: %v \n", parseErr)
+			}
+		}
+
+		props := &paho.PublishProperties{
+			MessageExpiry: m.messageExpiry,
+		}
+		if m.contentType != nil {
+			props.ContentType = m.contentType.String(i, msg)
+		}
+		if m.responseTopic != nil {
+			props.ResponseTopic = m.responseTopic.String(i, msg)
+		}
+		if m.correlationData != nil {
+			props.CorrelationData = m.correlationData.Bytes(i, msg)
+		}
+		_ = m.metaFilter.Iter(p, func(k, v string) error {
+			props.User.Add(k, v)
+			return nil
+		})
+
+		_, err := client.Publish(ctx, &paho.Publish{
+			QoS:        m.conf.QoS,
+			Retain:     retained,
+			Topic:      m.topic.String(i, msg),
+			Properties: props,
+			Payload:    p.Get(),
+		})
+		return err
+	})
+}
+
+func (m *mqttWriterV5) CloseAsync() {
+	go func() {
+		m.connMut.Lock()
+		if m.client != nil {
+			_ = m.client.Disconnect(&paho.Disconnect{})
+			m.client = nil
+		}
+		m.connMut.Unlock()
+	}()
+}
+
+func (m *mqttWriterV5) WaitForClose(timeout time.Duration) error {
+	return nil
+}
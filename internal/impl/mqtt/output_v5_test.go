@@ -0,0 +1,190 @@
+package mqtt
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.golang/packets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// testBrokerV5 is a minimal in-process MQTT 5 broker that routes published
+// messages, including their properties, to subscribers of the exact topic.
+type testBrokerV5 struct {
+	mut  sync.Mutex
+	subs map[string][]*testBrokerConnV5
+}
+
+type testBrokerConnV5 struct {
+	conn     net.Conn
+	writeMut sync.Mutex
+	nextID   uint16
+}
+
+func (c *testBrokerConnV5) write(p *packets.ControlPacket) {
+	c.writeMut.Lock()
+	_, _ = p.WriteTo(c.conn)
+	c.writeMut.Unlock()
+}
+
+func newTestBrokerV5(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+
+	b := &testBrokerV5{subs: map[string][]*testBrokerConnV5{}}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() {
+				_ = conn.Close()
+			})
+			go b.serve(&testBrokerConnV5{conn: conn})
+		}
+	}()
+
+	return "tcp://" + ln.Addr().String()
+}
+
+func (b *testBrokerV5) serve(c *testBrokerConnV5) {
+	defer c.conn.Close()
+	for {
+		p, err := packets.ReadPacket(c.conn)
+		if err != nil {
+			return
+		}
+		switch p.Type {
+		case packets.CONNECT:
+			c.write(packets.NewControlPacket(packets.CONNACK))
+		case packets.SUBSCRIBE:
+			sub := p.Content.(*packets.Subscribe)
+			suback := packets.NewControlPacket(packets.SUBACK)
+			suback.Content.(*packets.Suback).PacketID = sub.PacketID
+			b.mut.Lock()
+			for topic, opts := range sub.Subscriptions {
+				if strings.HasPrefix(topic, "$share/") {
+					topic = strings.SplitN(topic, "/", 3)[2]
+				}
+				b.subs[topic] = append(b.subs[topic], c)
+				suback.Content.(*packets.Suback).Reasons = append(suback.Content.(*packets.Suback).Reasons, opts.QoS)
+			}
+			b.mut.Unlock()
+			c.write(suback)
+		case packets.PUBLISH:
+			pub := p.Content.(*packets.Publish)
+			if pub.QoS > 0 {
+				puback := packets.NewControlPacket(packets.PUBACK)
+				puback.Content.(*packets.Puback).PacketID = pub.PacketID
+				c.write(puback)
+			}
+			b.mut.Lock()
+			subs := b.subs[pub.Topic]
+			b.mut.Unlock()
+			for _, s := range subs {
+				fwd := packets.NewControlPacket(packets.PUBLISH)
+				fwd.Flags = p.Flags
+				fwdPub := fwd.Content.(*packets.Publish)
+				fwdPub.Topic = pub.Topic
+				fwdPub.Payload = pub.Payload
+				fwdPub.Properties = pub.Properties
+				fwdPub.QoS = pub.QoS
+				if fwdPub.QoS > 0 {
+					s.writeMut.Lock()
+					s.nextID++
+					fwdPub.PacketID = s.nextID
+					s.writeMut.Unlock()
+				}
+				s.write(fwd)
+			}
+		case packets.PINGREQ:
+			c.write(packets.NewControlPacket(packets.PINGRESP))
+		case packets.DISCONNECT:
+			return
+		}
+	}
+}
+
+func TestWriterV5ToReaderV5(t *testing.T) {
+	url := newTestBrokerV5(t)
+
+	inConf := input.NewMQTTConfig()
+	inConf.ProtocolVersion = "5"
+	inConf.URLs = []string{url}
+	inConf.Topics = []string{"foo"}
+	inConf.SharedSubscriptionGroup = "bar"
+	inConf.ClientID = "reader"
+	inConf.QoS = 1
+
+	r, err := newMQTTReaderV5(inConf, log.Noop())
+	require.NoError(t, err)
+	t.Cleanup(r.CloseAsync)
+
+	outConf := output.NewMQTTConfig()
+	outConf.ProtocolVersion = "5"
+	outConf.URLs = []string{url}
+	outConf.Topic = `${! meta("topic") }`
+	outConf.ClientID = "writer"
+	outConf.QoS = 1
+	outConf.ContentType = "text/plain"
+	outConf.ResponseTopic = "responses"
+	outConf.CorrelationData = `${! meta("id") }`
+	outConf.MessageExpiry = "1m"
+	outConf.UserProperties.ExcludePrefixes = []string{"topic"}
+
+	w, err := newMQTTWriterV5(outConf, mock.NewManager(), log.Noop())
+	require.NoError(t, err)
+	t.Cleanup(w.CloseAsync)
+
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, r.ConnectWithContext(tCtx))
+	require.NoError(t, w.ConnectWithContext(tCtx))
+
+	part := message.NewPart([]byte("hello world"))
+	part.MetaSet("topic", "foo")
+	part.MetaSet("id", "abc")
+	part.MetaSet("custom", "baz")
+	batch := message.QuickBatch(nil)
+	batch.Append(part)
+	require.NoError(t, w.WriteWithContext(tCtx, batch))
+
+	msg, ackFn, err := r.ReadWithContext(tCtx)
+	require.NoError(t, err)
+	require.Equal(t, 1, msg.Len())
+	require.NoError(t, ackFn(tCtx, nil))
+
+	p := msg.Get(0)
+	assert.Equal(t, "hello world", string(p.Get()))
+	for k, v := range map[string]string{
+		"mqtt_topic":            "foo",
+		"mqtt_qos":              "1",
+		"mqtt_content_type":     "text/plain",
+		"mqtt_response_topic":   "responses",
+		"mqtt_correlation_data": "YWJj",
+		"mqtt_message_expiry":   "60",
+		"id":                    "abc",
+		"custom":                "baz",
+	} {
+		assert.Equal(t, v, p.MetaGet(k), k)
+	}
+	assert.Equal(t, "", p.MetaGet("topic"))
+}
@@ -3,8 +3,13 @@ package parquet
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/segmentio/parquet-go"
 	"github.com/segmentio/parquet-go/compress"
@@ -19,6 +24,23 @@ func parquetEncodeProcessorConfig() *service.ConfigSpec {
 		Categories("Parsing").
 		Summary("Encodes [Parquet files](https://parquet.apache.org/docs/) from a batch of structured messages.").
 		Field(parquetSchemaConfig()).
+		Field(service.NewStringField("schema_file").
+			Description("A path to a file containing either an [Avro schema](https://avro.apache.org/docs/current/spec.html#schema_record) of a record or a [JSON Schema](https://json-schema.org/) of an object, which is converted into a parquet schema. This field cannot be set along with `schema`.").
+			Example("./schemas/foo.avsc").
+			Example("./schemas/foo.schema.json").
+			Optional()).
+		Field(service.NewIntField("inference_sample_size").
+			Description("When neither a `schema` or `schema_file` is provided the schema is inferred from the first batch processed, and this field determines the maximum number of messages of that batch to sample. Zero means all messages of the batch are sampled.").
+			Advanced().
+			Default(100)).
+		Field(service.NewStringAnnotatedEnumField("schema_evolution", map[string]string{
+			"ignore": "Fields not present within the schema are ignored.",
+			"add":    "Fields not present within the schema are inferred and added to it as optional columns, the new schema is used for the file being written and all subsequent files.",
+			"error":  "Batches containing messages with fields not present within the schema are rejected.",
+		}).
+			Description("Determines how fields of messages that are not present within the schema are handled.").
+			Advanced().
+			Default("ignore")).
 		Field(service.NewStringEnumField("default_compression",
 			"uncompressed", "snappy", "gzip", "brotli", "zstd", "lz4raw",
 		).
@@ -26,6 +48,14 @@ func parquetEncodeProcessorConfig() *service.ConfigSpec {
 			Default("uncompressed")).
 		Description(`
 This processor uses [https://github.com/segmentio/parquet-go](https://github.com/segmentio/parquet-go), which is itself experimental. Therefore changes could be made into how this processor functions outside of major version releases.
+
+### Schemas
+
+The schema of the files written can be specified with the `+"`schema`"+` field, loaded from an Avro or JSON Schema file with the `+"`schema_file`"+` field, or when neither is set it is inferred from the first batch of messages processed. Inferred columns are always optional, booleans are written as BOOLEAN, whole numbers as INT64 unless a floating point value is seen for the same field (in which case DOUBLE is used), strings as UTF8, timestamps as TIMESTAMP_MICROS and objects as groups. Fields that are null or empty arrays within the entire sample are omitted from inferred schemas.
+
+Values of timestamp columns can be timestamps, RFC 3339 strings or numbers of seconds since the unix epoch. Values of DECIMAL columns can be numbers or strings, and are rounded to the scale of the column. Values of MAP columns must be objects, where the keys are written as UTF8 strings.
+
+Fields of messages that aren't present within the schema are ignored by default, the `+"`schema_evolution`"+` field can be used to change this behaviour.
 `).
 		Version("4.4.0").
 		// TODO: Add an example that demonstrates error handling
@@ -49,6 +79,21 @@ output:
               - name: content
                 type: BYTE_ARRAY
             default_compression: zstd
+`).
+		Example("Inferring the Schema",
+			"In this example we write parquet files to AWS S3 without specifying a schema, and therefore it is inferred from the first batch of messages. New fields that appear in later messages are added to the schema of subsequent files.",
+			`
+output:
+  aws_s3:
+    bucket: TODO
+    path: 'stuff/${! timestamp_unix() }-${! uuid_v4() }.parquet'
+    batching:
+      count: 1000
+      period: 10s
+      processors:
+        - parquet_encode:
+            schema_evolution: add
+            default_compression: zstd
 `)
 }
 
@@ -69,11 +114,16 @@ func init() {
 func parquetSchemaConfig() *service.ConfigField {
 	return service.NewObjectListField("schema",
 		service.NewStringField("name").Description("The name of the column."),
-		service.NewStringEnumField("type", "BOOLEAN", "INT32", "INT64", "FLOAT", "DOUBLE", "BYTE_ARRAY").
-			Description("The type of the column, only applicable for leaf columns with no child fields.").Optional(),
+		service.NewStringEnumField("type",
+			"BOOLEAN", "INT32", "INT64", "FLOAT", "DOUBLE", "BYTE_ARRAY", "UTF8",
+			"TIMESTAMP_MILLIS", "TIMESTAMP_MICROS", "TIMESTAMP_NANOS", "DECIMAL", "MAP",
+		).
+			Description("The type of the column, only applicable for leaf columns with no child fields, or MAP columns.").Optional(),
+		service.NewIntField("precision").Description("The maximum number of digits of a DECIMAL column, up to 18.").Optional(),
+		service.NewIntField("scale").Description("The number of digits to the right of the decimal point of a DECIMAL column.").Optional(),
 		service.NewBoolField("repeated").Description("Whether the field is repeated.").Default(false),
 		service.NewBoolField("optional").Description("Whether the field is optional.").Default(false),
-		service.NewAnyListField("fields").Description("A list of child fields.").Optional().Example([]interface{}{
+		service.NewAnyListField("fields").Description("A list of child fields. For MAP columns this must be a single field named `value` describing the values of the map.").Optional().Example([]interface{}{
 			map[string]interface{}{
 				"name": "foo",
 				"type": "INT64",
@@ -83,80 +133,41 @@ func parquetSchemaConfig() *service.ConfigField {
 				"type": "BYTE_ARRAY",
 			},
 		}),
-	)
+	).Optional()
 }
 
-func parquetGroupFromConfig(columnConfs []*service.ParsedConfig) (parquet.Group, error) {
-	groupNode := parquet.Group{}
-
-	for _, colConf := range columnConfs {
-		var n parquet.Node
+//------------------------------------------------------------------------------
 
-		name, err := colConf.FieldString("name")
+func newParquetEncodeProcessorFromConfig(conf *service.ParsedConfig, logger *service.Logger) (*parquetEncodeProcessor, error) {
+	var fields []*schemaField
+	if conf.Contains("schema") {
+		schemaConfs, err := conf.FieldObjectList("schema")
 		if err != nil {
 			return nil, err
 		}
-
-		if childColumns, _ := colConf.FieldAnyList("fields"); len(childColumns) > 0 {
-			if n, err = parquetGroupFromConfig(childColumns); err != nil {
-				return nil, err
-			}
-		} else {
-			typeStr, err := colConf.FieldString("type")
-			if err != nil {
-				return nil, err
-			}
-			switch typeStr {
-			case "BOOLEAN":
-				n = parquet.Leaf(parquet.BooleanType)
-			case "INT32":
-				n = parquet.Int(32)
-			case "INT64":
-				n = parquet.Int(64)
-			case "FLOAT":
-				n = parquet.Leaf(parquet.FloatType)
-			case "DOUBLE":
-				n = parquet.Leaf(parquet.DoubleType)
-			case "BYTE_ARRAY":
-				n = parquet.Leaf(parquet.ByteArrayType)
-			default:
-				return nil, fmt.Errorf("field %v type of '%v' not recognised", name, typeStr)
-			}
+		if fields, err = schemaFieldsFromConfig(schemaConfs); err != nil {
+			return nil, err
 		}
+	}
 
-		repeated, _ := colConf.FieldBool("repeated")
-		if repeated {
-			n = parquet.Repeated(n)
+	if schemaFile, _ := conf.FieldString("schema_file"); schemaFile != "" {
+		if fields != nil {
+			return nil, errors.New("cannot specify both a schema and a schema_file")
 		}
-
-		optional, _ := colConf.FieldBool("optional")
-		if optional {
-			if repeated {
-				return nil, fmt.Errorf("column %v cannot be both repeated and optional", name)
-			}
-			n = parquet.Optional(n)
+		var err error
+		if fields, err = schemaFieldsFromFile(schemaFile); err != nil {
+			return nil, err
 		}
-
-		groupNode[name] = n
 	}
 
-	return groupNode, nil
-}
-
-//------------------------------------------------------------------------------
-
-func newParquetEncodeProcessorFromConfig(conf *service.ParsedConfig, logger *service.Logger) (*parquetEncodeProcessor, error) {
-	schemaConfs, err := conf.FieldObjectList("schema")
-	if err != nil {
-		return nil, err
-	}
-
-	node, err := parquetGroupFromConfig(schemaConfs)
-	if err != nil {
-		return nil, err
+	var schema *parquet.Schema
+	if fields != nil {
+		var err error
+		if schema, err = parquetSchemaFromFields(fields); err != nil {
+			return nil, err
+		}
 	}
 
-	schema := parquet.NewSchema("", node)
 	compressStr, err := conf.FieldString("default_compression")
 	if err != nil {
 		return nil, err
@@ -179,13 +190,31 @@ func newParquetEncodeProcessorFromConfig(conf *service.ParsedConfig, logger *ser
 	default:
 		return nil, fmt.Errorf("default_compression type %v not recognised", compressStr)
 	}
-	return newParquetEncodeProcessor(logger, schema, compressDefault)
+
+	s, err := newParquetEncodeProcessor(logger, schema, compressDefault)
+	if err != nil {
+		return nil, err
+	}
+	s.fields = fields
+	if s.inferenceSampleSize, err = conf.FieldInt("inference_sample_size"); err != nil {
+		return nil, err
+	}
+	if s.schemaEvolution, err = conf.FieldString("schema_evolution"); err != nil {
+		return nil, err
+	}
+	return s, nil
 }
 
 type parquetEncodeProcessor struct {
 	logger          *service.Logger
-	schema          *parquet.Schema
 	compressionType compress.Codec
+
+	inferenceSampleSize int
+	schemaEvolution     string
+
+	schemaMut sync.Mutex
+	schema    *parquet.Schema
+	fields    []*schemaField
 }
 
 func newParquetEncodeProcessor(logger *service.Logger, schema *parquet.Schema, compressionType compress.Codec) (*parquetEncodeProcessor, error) {
@@ -193,15 +222,66 @@ func newParquetEncodeProcessor(logger *service.Logger, schema *parquet.Schema, c
 		logger:          logger,
 		schema:          schema,
 		compressionType: compressionType,
+		schemaEvolution: "ignore",
 	}
 	return s, nil
 }
 
-func (s *parquetEncodeProcessor) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
-	buf := bytes.NewBuffer(nil)
-	pWtr := parquet.NewWriter(buf, s.schema, parquet.Compression(s.compressionType))
+// schemaFor returns the schema to encode a batch of documents with, which is
+// inferred from the first batch when a schema was not provided, and is
+// evolved to include new fields when configured to do so.
+func (s *parquetEncodeProcessor) schemaFor(objs []map[string]interface{}) (*parquet.Schema, error) {
+	s.schemaMut.Lock()
+	defer s.schemaMut.Unlock()
+
+	if s.schema == nil {
+		sample := objs
+		if s.inferenceSampleSize > 0 && len(sample) > s.inferenceSampleSize {
+			sample = sample[:s.inferenceSampleSize]
+		}
+		fields, err := inferSchemaFields(sample)
+		if err != nil {
+			return nil, fmt.Errorf("failed to infer schema: %w", err)
+		}
+		schema, err := parquetSchemaFromFields(fields)
+		if err != nil {
+			return nil, fmt.Errorf("failed to infer schema: %w", err)
+		}
+		s.schema, s.fields = schema, fields
+	}
 
-	rows := make([]parquet.Row, len(batch))
+	if s.schemaEvolution == "ignore" || s.fields == nil {
+		return s.schema, nil
+	}
+
+	fields := s.fields
+	var added []string
+	for _, obj := range objs {
+		var objAdded []string
+		var err error
+		if fields, objAdded, err = addNewFields(fields, obj); err != nil {
+			return nil, err
+		}
+		added = append(added, objAdded...)
+	}
+	if len(added) == 0 {
+		return s.schema, nil
+	}
+	if s.schemaEvolution == "error" {
+		return nil, newFieldsErr(added)
+	}
+
+	schema, err := parquetSchemaFromFields(fields)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Infof("Adding fields to parquet schema: %v", strings.Join(added, ", "))
+	s.schema, s.fields = schema, fields
+	return schema, nil
+}
+
+func (s *parquetEncodeProcessor) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	objs := make([]map[string]interface{}, len(batch))
 	for i, m := range batch {
 		ms, err := m.AsStructured()
 		if err != nil {
@@ -212,8 +292,20 @@ func (s *parquetEncodeProcessor) ProcessBatch(ctx context.Context, batch service
 		if !isObj {
 			return nil, fmt.Errorf("unable to encode message type %T as parquet row", ms)
 		}
+		objs[i] = obj
+	}
 
-		if rows[i], err = (&inserterConfig{}).toPQValuesGroup(s.schema.Fields(), obj, 0, 0); err != nil {
+	schema, err := s.schemaFor(objs)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	pWtr := parquet.NewWriter(buf, schema, parquet.Compression(s.compressionType))
+
+	rows := make([]parquet.Row, len(batch))
+	for i, obj := range objs {
+		if rows[i], err = (&inserterConfig{}).toPQValuesGroup(schema.Fields(), obj, 0, 0); err != nil {
 			return nil, err
 		}
 	}
@@ -278,6 +370,10 @@ func (c *inserterConfig) toPQValueNotRepeated(f parquet.Field, data interface{},
 			}
 		}
 
+		if obj != nil && isMapField(f) {
+			obj = mapToKeyValues(obj)
+		}
+
 		cCopy := *c
 		if f.Optional() {
 			if obj != nil {
@@ -311,13 +407,13 @@ func (c *inserterConfig) toPQValueNotRepeated(f parquet.Field, data interface{},
 			}
 			leafValue = parquet.ValueOf(b)
 		case parquet.Int32:
-			iv, err := query.IGetInt(data)
+			iv, err := toPQInt(f, data)
 			if err != nil {
 				return nil, err
 			}
 			leafValue = parquet.ValueOf(int32(iv))
 		case parquet.Int64:
-			iv, err := query.IGetInt(data)
+			iv, err := toPQInt(f, data)
 			if err != nil {
 				return nil, err
 			}
@@ -356,6 +452,97 @@ func (c *inserterConfig) toPQValueNotRepeated(f parquet.Field, data interface{},
 	return parquet.Row{leafValue}, nil
 }
 
+func isMapField(f parquet.Field) bool {
+	if lt := f.Type().LogicalType(); lt != nil && lt.Map != nil {
+		return true
+	}
+	return false
+}
+
+// mapToKeyValues converts an object into the structure of a MAP column, which
+// is a repeated group of key and value fields.
+func mapToKeyValues(obj map[string]interface{}) map[string]interface{} {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	kvs := make([]interface{}, len(keys))
+	for i, k := range keys {
+		kvs[i] = map[string]interface{}{
+			"key":   k,
+			"value": obj[k],
+		}
+	}
+	return map[string]interface{}{"key_value": kvs}
+}
+
+// toPQInt converts a value into an integer for a column of physical type
+// INT32 or INT64, taking into account logical types of the column.
+func toPQInt(f parquet.Field, data interface{}) (int64, error) {
+	lt := f.Type().LogicalType()
+	switch {
+	case lt != nil && lt.Timestamp != nil:
+		ts, err := query.IGetTimestamp(data)
+		if err != nil {
+			return 0, err
+		}
+		switch unit := lt.Timestamp.Unit; {
+		case unit.Millis != nil:
+			return ts.UnixMilli(), nil
+		case unit.Nanos != nil:
+			return ts.UnixNano(), nil
+		default:
+			return ts.UnixMicro(), nil
+		}
+	case lt != nil && lt.Decimal != nil:
+		return toPQDecimal(data, lt.Decimal.Scale, lt.Decimal.Precision)
+	}
+	return query.IGetInt(data)
+}
+
+// toPQDecimal converts a number or string into the unscaled integer value of
+// a decimal, rounding half away from zero to the scale of the column.
+func toPQDecimal(data interface{}, scale, precision int32) (int64, error) {
+	r := new(big.Rat)
+	var str string
+	switch t := data.(type) {
+	case string:
+		str = t
+	case []byte:
+		str = string(t)
+	case json.Number:
+		str = t.String()
+	}
+	switch {
+	case str != "":
+		if _, ok := r.SetString(str); !ok {
+			return 0, fmt.Errorf("failed to parse '%v' as a decimal", str)
+		}
+	default:
+		f, err := query.IGetNumber(data)
+		if err != nil {
+			return 0, err
+		}
+		r.SetFloat64(f)
+	}
+
+	r.Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)))
+
+	num, denom := r.Num(), r.Denom()
+	q, m := new(big.Int).QuoRem(num, denom, new(big.Int))
+	if m.Sign() != 0 && new(big.Int).Mul(new(big.Int).Abs(m), big.NewInt(2)).Cmp(denom) >= 0 {
+		q.Add(q, big.NewInt(int64(num.Sign())))
+	}
+
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(precision)), nil)
+	if new(big.Int).Abs(q).Cmp(limit) >= 0 {
+		return 0, fmt.Errorf("value %v exceeds the decimal precision of %v", data, precision)
+	}
+	return q.Int64(), nil
+}
+
 func (c *inserterConfig) toPQValuesRepeated(field parquet.Field, data []interface{}, defLevel, repLevel int) (parquet.Row, error) {
 	if len(data) == 0 {
 		if c.firstRepOf == nil {
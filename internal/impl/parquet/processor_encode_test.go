@@ -368,3 +368,184 @@ func TestParquetEncodeProcessor(t *testing.T) {
 		assert.JSONEq(t, string(expectedBytes), string(actualBytes))
 	})
 }
+
+func encodeDecodeBatch(t *testing.T, encodeProc *parquetEncodeProcessor, inputs ...string) []string {
+	t.Helper()
+
+	var inBatch service.MessageBatch
+	for _, in := range inputs {
+		inBatch = append(inBatch, service.NewMessage([]byte(in)))
+	}
+
+	encodedBatches, err := encodeProc.ProcessBatch(context.Background(), inBatch)
+	require.NoError(t, err)
+	require.Len(t, encodedBatches, 1)
+	require.Len(t, encodedBatches[0], 1)
+
+	encodedBytes, err := encodedBatches[0][0].AsBytes()
+	require.NoError(t, err)
+
+	decodeProc, err := newParquetDecodeProcessor(nil, &extractConfig{byteArrayAsStrings: true})
+	require.NoError(t, err)
+
+	decodedBatch, err := decodeProc.Process(context.Background(), service.NewMessage(encodedBytes))
+	require.NoError(t, err)
+
+	var outputs []string
+	for _, m := range decodedBatch {
+		mBytes, err := m.AsBytes()
+		require.NoError(t, err)
+		outputs = append(outputs, string(mBytes))
+	}
+	return outputs
+}
+
+func TestParquetEncodeLogicalTypes(t *testing.T) {
+	encodeConf, err := parquetEncodeProcessorConfig().ParseYAML(`
+schema:
+  - { name: name, type: UTF8 }
+  - { name: created_at, type: TIMESTAMP_MILLIS }
+  - { name: price, type: DECIMAL, precision: 9, scale: 2 }
+  - { name: total, type: DECIMAL, precision: 18, scale: 3, optional: true }
+  - name: tags
+    type: MAP
+    optional: true
+    fields:
+      - { name: value, type: INT64 }
+`, nil)
+	require.NoError(t, err)
+
+	encodeProc, err := newParquetEncodeProcessorFromConfig(encodeConf, nil)
+	require.NoError(t, err)
+
+	outputs := encodeDecodeBatch(t, encodeProc,
+		`{"name":"foo","created_at":"2022-01-02T03:04:05.006Z","price":12.345,"total":"-1.5","tags":{"b":2,"a":1}}`,
+		`{"name":"bar","created_at":1641092645,"price":"0.1"}`,
+	)
+	require.Len(t, outputs, 2)
+
+	assert.JSONEq(t, `{
+  "name": "foo",
+  "created_at": 1641092645006,
+  "price": 1235,
+  "total": -1500,
+  "tags": {"key_value":[{"key":"a","value":1},{"key":"b","value":2}]}
+}`, outputs[0])
+	assert.JSONEq(t, `{
+  "name": "bar",
+  "created_at": 1641092645000,
+  "price": 10,
+  "total": null,
+  "tags": null
+}`, outputs[1])
+
+	_, err = encodeProc.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"name":"baz","created_at":0,"price":10000000}`)),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the decimal precision")
+}
+
+func TestParquetEncodeSchemaInference(t *testing.T) {
+	encodeConf, err := parquetEncodeProcessorConfig().ParseYAML(`
+inference_sample_size: 2
+`, nil)
+	require.NoError(t, err)
+
+	encodeProc, err := newParquetEncodeProcessorFromConfig(encodeConf, nil)
+	require.NoError(t, err)
+
+	outputs := encodeDecodeBatch(t, encodeProc,
+		`{"id":1,"weight":2,"name":"foo","nothing":null,"nested":{"ok":true},"nums":[1,2]}`,
+		`{"id":2,"weight":2.5,"name":"bar","nested":{"ok":false,"extra":"yep"}}`,
+		`{"id":3,"weight":3,"name":"baz","not_sampled":"nope"}`,
+	)
+	assert.Equal(t, []string{
+		`{"id":1,"name":"foo","nested":{"extra":null,"ok":true},"nums":[1,2],"weight":2}`,
+		`{"id":2,"name":"bar","nested":{"extra":"yep","ok":false},"nums":null,"weight":2.5}`,
+		`{"id":3,"name":"baz","nested":null,"nums":null,"weight":3}`,
+	}, outputs)
+
+	assert.Equal(t, []*schemaField{
+		{Name: "id", Type: "INT64", Optional: true},
+		{Name: "name", Type: "UTF8", Optional: true},
+		{Name: "nested", Optional: true, Fields: []*schemaField{
+			{Name: "ok", Type: "BOOLEAN", Optional: true},
+			{Name: "extra", Type: "UTF8", Optional: true},
+		}},
+		{Name: "nums", Type: "INT64", Repeated: true},
+		{Name: "weight", Type: "DOUBLE", Optional: true},
+	}, encodeProc.fields)
+
+	// The inferred schema is retained for subsequent batches
+	outputs = encodeDecodeBatch(t, encodeProc, `{"id":4,"weight":5.5,"other":"nope"}`)
+	assert.Equal(t, []string{
+		`{"id":4,"name":null,"nested":null,"nums":null,"weight":5.5}`,
+	}, outputs)
+}
+
+func TestParquetEncodeSchemaInferenceConflicts(t *testing.T) {
+	encodeConf, err := parquetEncodeProcessorConfig().ParseYAML(``, nil)
+	require.NoError(t, err)
+
+	encodeProc, err := newParquetEncodeProcessorFromConfig(encodeConf, nil)
+	require.NoError(t, err)
+
+	_, err = encodeProc.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"id":1}`)),
+		service.NewMessage([]byte(`{"id":"two"}`)),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field id: found conflicting types INT64 and UTF8")
+}
+
+func TestParquetEncodeSchemaEvolution(t *testing.T) {
+	for _, test := range []struct {
+		mode    string
+		outputs []string
+		err     string
+	}{
+		{
+			mode:    "ignore",
+			outputs: []string{`{"id":2,"nested":{"a":"a2"}}`},
+		},
+		{
+			mode:    "add",
+			outputs: []string{`{"extra":"yep","id":2,"nested":{"a":"a2","b":true}}`},
+		},
+		{
+			mode: "error",
+			err:  "message contains fields not present within the schema: extra, nested.b",
+		},
+	} {
+		test := test
+		t.Run(test.mode, func(t *testing.T) {
+			encodeConf, err := parquetEncodeProcessorConfig().ParseYAML(`
+schema:
+  - { name: id, type: INT64 }
+  - name: nested
+    optional: true
+    fields:
+      - { name: a, type: UTF8, optional: true }
+schema_evolution: `+test.mode+`
+`, nil)
+			require.NoError(t, err)
+
+			encodeProc, err := newParquetEncodeProcessorFromConfig(encodeConf, nil)
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{`{"id":1,"nested":{"a":"a1"}}`}, encodeDecodeBatch(t, encodeProc, `{"id":1,"nested":{"a":"a1"}}`))
+
+			input := `{"id":2,"extra":"yep","nested":{"a":"a2","b":true}}`
+			if test.err != "" {
+				_, err := encodeProc.ProcessBatch(context.Background(), service.MessageBatch{
+					service.NewMessage([]byte(input)),
+				})
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.err)
+				return
+			}
+			assert.Equal(t, test.outputs, encodeDecodeBatch(t, encodeProc, input))
+		})
+	}
+}
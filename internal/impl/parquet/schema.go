@@ -0,0 +1,371 @@
+package parquet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/segmentio/parquet-go"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// schemaField is an intermediate representation of a parquet schema column
+// which can be derived from a config, a schema file or inferred from
+// documents, and can be evolved before being converted into a parquet node.
+type schemaField struct {
+	Name      string
+	Type      string
+	Repeated  bool
+	Optional  bool
+	Precision int
+	Scale     int
+
+	// Child fields of a group, or for MAP columns a single field that
+	// describes the values of the map.
+	Fields []*schemaField
+}
+
+func (f *schemaField) isGroup() bool {
+	return f.Type == "" && len(f.Fields) > 0
+}
+
+func (f *schemaField) copy() *schemaField {
+	c := *f
+	c.Fields = make([]*schemaField, len(f.Fields))
+	for i, child := range f.Fields {
+		c.Fields[i] = child.copy()
+	}
+	return &c
+}
+
+func (f *schemaField) node() (parquet.Node, error) {
+	var n parquet.Node
+
+	switch {
+	case f.Type == "MAP":
+		if len(f.Fields) != 1 || f.Fields[0].Name != "value" {
+			return nil, fmt.Errorf("map column %v must have exactly one child field named value", f.Name)
+		}
+		valueNode, err := f.Fields[0].node()
+		if err != nil {
+			return nil, err
+		}
+		n = parquet.Map(parquet.String(), valueNode)
+	case len(f.Fields) > 0:
+		group, err := parquetGroupFromFields(f.Fields)
+		if err != nil {
+			return nil, err
+		}
+		n = group
+	default:
+		switch f.Type {
+		case "BOOLEAN":
+			n = parquet.Leaf(parquet.BooleanType)
+		case "INT32":
+			n = parquet.Int(32)
+		case "INT64":
+			n = parquet.Int(64)
+		case "FLOAT":
+			n = parquet.Leaf(parquet.FloatType)
+		case "DOUBLE":
+			n = parquet.Leaf(parquet.DoubleType)
+		case "BYTE_ARRAY":
+			n = parquet.Leaf(parquet.ByteArrayType)
+		case "UTF8":
+			n = parquet.String()
+		case "TIMESTAMP_MILLIS":
+			n = parquet.Timestamp(parquet.Millisecond)
+		case "TIMESTAMP_MICROS":
+			n = parquet.Timestamp(parquet.Microsecond)
+		case "TIMESTAMP_NANOS":
+			n = parquet.Timestamp(parquet.Nanosecond)
+		case "DECIMAL":
+			if f.Precision < 1 || f.Precision > 18 {
+				return nil, fmt.Errorf("decimal column %v must have a precision between 1 and 18, got %v", f.Name, f.Precision)
+			}
+			if f.Scale < 0 || f.Scale > f.Precision {
+				return nil, fmt.Errorf("decimal column %v must have a scale between 0 and its precision, got %v", f.Name, f.Scale)
+			}
+			typ := parquet.Int64Type
+			if f.Precision <= 9 {
+				typ = parquet.Int32Type
+			}
+			n = parquet.Decimal(f.Scale, f.Precision, typ)
+		default:
+			return nil, fmt.Errorf("field %v type of '%v' not recognised", f.Name, f.Type)
+		}
+	}
+
+	if f.Repeated {
+		n = parquet.Repeated(n)
+	}
+
+	if f.Optional {
+		if f.Repeated {
+			return nil, fmt.Errorf("column %v cannot be both repeated and optional", f.Name)
+		}
+		n = parquet.Optional(n)
+	}
+	return n, nil
+}
+
+func parquetGroupFromFields(fields []*schemaField) (parquet.Group, error) {
+	groupNode := parquet.Group{}
+	for _, f := range fields {
+		n, err := f.node()
+		if err != nil {
+			return nil, err
+		}
+		groupNode[f.Name] = n
+	}
+	return groupNode, nil
+}
+
+func parquetSchemaFromFields(fields []*schemaField) (*parquet.Schema, error) {
+	node, err := parquetGroupFromFields(fields)
+	if err != nil {
+		return nil, err
+	}
+	return parquet.NewSchema("", node), nil
+}
+
+//------------------------------------------------------------------------------
+
+func schemaFieldsFromConfig(columnConfs []*service.ParsedConfig) ([]*schemaField, error) {
+	var fields []*schemaField
+	for _, colConf := range columnConfs {
+		name, err := colConf.FieldString("name")
+		if err != nil {
+			return nil, err
+		}
+
+		f := &schemaField{Name: name}
+		f.Type, _ = colConf.FieldString("type")
+		f.Repeated, _ = colConf.FieldBool("repeated")
+		f.Optional, _ = colConf.FieldBool("optional")
+		f.Precision, _ = colConf.FieldInt("precision")
+		f.Scale, _ = colConf.FieldInt("scale")
+
+		if childColumns, _ := colConf.FieldAnyList("fields"); len(childColumns) > 0 {
+			if f.Type != "MAP" {
+				f.Type = ""
+			}
+			if f.Fields, err = schemaFieldsFromConfig(childColumns); err != nil {
+				return nil, err
+			}
+		} else if f.Type == "" {
+			return nil, fmt.Errorf("field %v must have either a type or child fields", name)
+		}
+
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+//------------------------------------------------------------------------------
+
+// inferSchemaFields infers the schema of a sample of structured documents,
+// where fields are merged across documents and numeric fields are widened to
+// DOUBLE when any document contains a floating point value for them.
+func inferSchemaFields(sample []map[string]interface{}) ([]*schemaField, error) {
+	var fields []*schemaField
+	for _, obj := range sample {
+		objFields, err := inferObjectFields(obj)
+		if err != nil {
+			return nil, err
+		}
+		if fields, err = mergeInferredFields(fields, objFields); err != nil {
+			return nil, err
+		}
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("unable to infer any fields from the sample of messages")
+	}
+	return fields, nil
+}
+
+func inferObjectFields(obj map[string]interface{}) ([]*schemaField, error) {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var fields []*schemaField
+	for _, k := range keys {
+		f, err := inferField(k, obj[k])
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			fields = append(fields, f)
+		}
+	}
+	return fields, nil
+}
+
+// inferField returns the inferred schema of a value, or nil if the type of
+// the value cannot be determined, e.g. it is null or an empty array.
+func inferField(name string, v interface{}) (*schemaField, error) {
+	f := &schemaField{Name: name, Optional: true}
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		f.Type = "BOOLEAN"
+	case json.Number:
+		if _, err := t.Int64(); err == nil {
+			f.Type = "INT64"
+		} else {
+			f.Type = "DOUBLE"
+		}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		f.Type = "INT64"
+	case float32, float64:
+		f.Type = "DOUBLE"
+	case string:
+		f.Type = "UTF8"
+	case []byte:
+		f.Type = "BYTE_ARRAY"
+	case time.Time:
+		f.Type = "TIMESTAMP_MICROS"
+	case map[string]interface{}:
+		children, err := inferObjectFields(t)
+		if err != nil {
+			return nil, fmt.Errorf("field %v: %w", name, err)
+		}
+		if len(children) == 0 {
+			return nil, nil
+		}
+		f.Fields = children
+	case []interface{}:
+		var elem *schemaField
+		for _, e := range t {
+			if _, isArr := e.([]interface{}); isArr {
+				return nil, fmt.Errorf("field %v: nested arrays are not supported", name)
+			}
+			eField, err := inferField(name, e)
+			if err != nil {
+				return nil, err
+			}
+			if elem, err = mergeInferredField(elem, eField); err != nil {
+				return nil, err
+			}
+		}
+		if elem == nil {
+			return nil, nil
+		}
+		elem.Repeated = true
+		elem.Optional = false
+		return elem, nil
+	default:
+		return nil, fmt.Errorf("field %v: unable to infer parquet type of %T", name, v)
+	}
+	return f, nil
+}
+
+func mergeInferredFields(fields, others []*schemaField) ([]*schemaField, error) {
+	for _, o := range others {
+		var found bool
+		for i, f := range fields {
+			if f.Name != o.Name {
+				continue
+			}
+			found = true
+			merged, err := mergeInferredField(f, o)
+			if err != nil {
+				return nil, err
+			}
+			fields[i] = merged
+			break
+		}
+		if !found {
+			fields = append(fields, o)
+		}
+	}
+	return fields, nil
+}
+
+func mergeInferredField(f, o *schemaField) (*schemaField, error) {
+	if f == nil {
+		return o, nil
+	}
+	if o == nil {
+		return f, nil
+	}
+	if f.Repeated != o.Repeated {
+		return nil, fmt.Errorf("field %v: found both array and non-array values", f.Name)
+	}
+	if f.isGroup() || o.isGroup() {
+		if !f.isGroup() || !o.isGroup() {
+			return nil, fmt.Errorf("field %v: found both object and non-object values", f.Name)
+		}
+		children, err := mergeInferredFields(f.Fields, o.Fields)
+		if err != nil {
+			return nil, fmt.Errorf("field %v: %w", f.Name, err)
+		}
+		f.Fields = children
+		return f, nil
+	}
+	if f.Type == o.Type {
+		return f, nil
+	}
+	if (f.Type == "INT64" && o.Type == "DOUBLE") || (f.Type == "DOUBLE" && o.Type == "INT64") {
+		f.Type = "DOUBLE"
+		return f, nil
+	}
+	return nil, fmt.Errorf("field %v: found conflicting types %v and %v", f.Name, f.Type, o.Type)
+}
+
+//------------------------------------------------------------------------------
+
+// addNewFields returns a copy of a schema extended with any fields of a
+// document that the schema does not contain, along with the paths of the
+// fields that were added. Fields that conflict with the existing schema are
+// left as they are.
+func addNewFields(fields []*schemaField, obj map[string]interface{}) ([]*schemaField, []string, error) {
+	inferred, err := inferObjectFields(obj)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var added []string
+	var addFields func(path string, fields, inferred []*schemaField) []*schemaField
+	addFields = func(path string, fields, inferred []*schemaField) []*schemaField {
+		fields = append([]*schemaField(nil), fields...)
+		for _, in := range inferred {
+			var existing *schemaField
+			var existingIndex int
+			for i, f := range fields {
+				if f.Name == in.Name {
+					existing, existingIndex = f, i
+					break
+				}
+			}
+			if existing == nil {
+				added = append(added, path+in.Name)
+				fields = append(fields, in)
+				continue
+			}
+			if existing.isGroup() && in.isGroup() {
+				before := len(added)
+				children := addFields(path+in.Name+".", existing.Fields, in.Fields)
+				if len(added) > before {
+					existing = existing.copy()
+					existing.Fields = children
+					fields[existingIndex] = existing
+				}
+			}
+		}
+		return fields
+	}
+	return addFields("", fields, inferred), added, nil
+}
+
+func newFieldsErr(added []string) error {
+	return fmt.Errorf("message contains fields not present within the schema: %v", strings.Join(added, ", "))
+}
@@ -0,0 +1,272 @@
+package parquet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// schemaFieldsFromFile parses a schema file, which can either be an Avro
+// schema of a record or a JSON Schema of an object.
+func schemaFieldsFromFile(path string) ([]*schemaField, error) {
+	schemaBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var root map[string]interface{}
+	if err := json.Unmarshal(schemaBytes, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse schema file: %w", err)
+	}
+
+	var fields []*schemaField
+	if t, _ := root["type"].(string); t == "record" {
+		fields, err = avroRecordFields(root)
+	} else if _, hasProps := root["properties"]; hasProps {
+		fields, err = jsonSchemaObjectFields(root)
+	} else {
+		err = errors.New("expected either an Avro record schema or a JSON Schema object with properties")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema file: %w", err)
+	}
+	return fields, nil
+}
+
+//------------------------------------------------------------------------------
+
+func avroRecordFields(record map[string]interface{}) ([]*schemaField, error) {
+	rawFields, ok := record["fields"].([]interface{})
+	if !ok {
+		return nil, errors.New("record is missing a list of fields")
+	}
+
+	var fields []*schemaField
+	for _, rf := range rawFields {
+		fObj, ok := rf.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected record field object, got %T", rf)
+		}
+		name, _ := fObj["name"].(string)
+		if name == "" {
+			return nil, errors.New("record field is missing a name")
+		}
+		f, err := avroField(name, fObj["type"])
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func avroField(name string, t interface{}) (*schemaField, error) {
+	f := &schemaField{Name: name}
+
+	switch tv := t.(type) {
+	case string:
+		switch tv {
+		case "boolean":
+			f.Type = "BOOLEAN"
+		case "int":
+			f.Type = "INT32"
+		case "long":
+			f.Type = "INT64"
+		case "float":
+			f.Type = "FLOAT"
+		case "double":
+			f.Type = "DOUBLE"
+		case "bytes":
+			f.Type = "BYTE_ARRAY"
+		case "string":
+			f.Type = "UTF8"
+		default:
+			return nil, fmt.Errorf("field %v: type %v is not supported", name, tv)
+		}
+	case []interface{}:
+		var nonNull []interface{}
+		for _, ut := range tv {
+			if ut != "null" {
+				nonNull = append(nonNull, ut)
+			}
+		}
+		if len(nonNull) != 1 {
+			return nil, fmt.Errorf("field %v: only unions of null and a single other type are supported", name)
+		}
+		inner, err := avroField(name, nonNull[0])
+		if err != nil {
+			return nil, err
+		}
+		if len(nonNull) < len(tv) && !inner.Repeated {
+			inner.Optional = true
+		}
+		return inner, nil
+	case map[string]interface{}:
+		switch lt, _ := tv["logicalType"].(string); lt {
+		case "timestamp-millis":
+			f.Type = "TIMESTAMP_MILLIS"
+			return f, nil
+		case "timestamp-micros":
+			f.Type = "TIMESTAMP_MICROS"
+			return f, nil
+		case "decimal":
+			precision, _ := tv["precision"].(float64)
+			scale, _ := tv["scale"].(float64)
+			f.Type = "DECIMAL"
+			f.Precision, f.Scale = int(precision), int(scale)
+			return f, nil
+		}
+
+		switch typeStr, _ := tv["type"].(string); typeStr {
+		case "record":
+			var err error
+			if f.Fields, err = avroRecordFields(tv); err != nil {
+				return nil, fmt.Errorf("field %v: %w", name, err)
+			}
+		case "array":
+			elem, err := avroField(name, tv["items"])
+			if err != nil {
+				return nil, err
+			}
+			if elem.Repeated {
+				return nil, fmt.Errorf("field %v: nested arrays are not supported", name)
+			}
+			elem.Repeated, elem.Optional = true, false
+			return elem, nil
+		case "map":
+			value, err := avroField("value", tv["values"])
+			if err != nil {
+				return nil, fmt.Errorf("field %v: %w", name, err)
+			}
+			f.Type = "MAP"
+			f.Fields = []*schemaField{value}
+		case "enum":
+			f.Type = "UTF8"
+		case "fixed":
+			f.Type = "BYTE_ARRAY"
+		default:
+			return avroField(name, typeStr)
+		}
+	default:
+		return nil, fmt.Errorf("field %v: unexpected type definition %T", name, t)
+	}
+	return f, nil
+}
+
+//------------------------------------------------------------------------------
+
+func jsonSchemaObjectFields(obj map[string]interface{}) ([]*schemaField, error) {
+	props, ok := obj["properties"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("object is missing properties")
+	}
+
+	required := map[string]bool{}
+	if reqList, ok := obj["required"].([]interface{}); ok {
+		for _, r := range reqList {
+			if rStr, ok := r.(string); ok {
+				required[rStr] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(props))
+	for k := range props {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var fields []*schemaField
+	for _, name := range names {
+		propSchema, ok := props[name].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("field %v: expected schema object, got %T", name, props[name])
+		}
+		f, err := jsonSchemaField(name, propSchema)
+		if err != nil {
+			return nil, err
+		}
+		if !required[name] && !f.Repeated {
+			f.Optional = true
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func jsonSchemaField(name string, s map[string]interface{}) (*schemaField, error) {
+	if _, isRef := s["$ref"]; isRef {
+		return nil, fmt.Errorf("field %v: references are not supported", name)
+	}
+
+	var typeStr string
+	var nullable bool
+	switch t := s["type"].(type) {
+	case string:
+		typeStr = t
+	case []interface{}:
+		for _, e := range t {
+			if e == "null" {
+				nullable = true
+			} else if eStr, ok := e.(string); ok {
+				if typeStr != "" {
+					return nil, fmt.Errorf("field %v: only a single non-null type is supported", name)
+				}
+				typeStr = eStr
+			}
+		}
+	}
+
+	f := &schemaField{Name: name, Optional: nullable}
+	switch typeStr {
+	case "boolean":
+		f.Type = "BOOLEAN"
+	case "integer":
+		f.Type = "INT64"
+	case "number":
+		f.Type = "DOUBLE"
+	case "string":
+		if format, _ := s["format"].(string); format == "date-time" {
+			f.Type = "TIMESTAMP_MICROS"
+		} else {
+			f.Type = "UTF8"
+		}
+	case "array":
+		items, ok := s["items"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("field %v: arrays must have an items schema", name)
+		}
+		elem, err := jsonSchemaField(name, items)
+		if err != nil {
+			return nil, err
+		}
+		if elem.Repeated {
+			return nil, fmt.Errorf("field %v: nested arrays are not supported", name)
+		}
+		elem.Repeated, elem.Optional = true, false
+		return elem, nil
+	case "object":
+		if _, hasProps := s["properties"]; hasProps {
+			var err error
+			if f.Fields, err = jsonSchemaObjectFields(s); err != nil {
+				return nil, fmt.Errorf("field %v: %w", name, err)
+			}
+			break
+		}
+		values, ok := s["additionalProperties"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("field %v: objects must have either properties or an additionalProperties schema", name)
+		}
+		value, err := jsonSchemaField("value", values)
+		if err != nil {
+			return nil, fmt.Errorf("field %v: %w", name, err)
+		}
+		f.Type = "MAP"
+		f.Fields = []*schemaField{value}
+	default:
+		return nil, fmt.Errorf("field %v: type '%v' is not supported", name, typeStr)
+	}
+	return f, nil
+}
@@ -0,0 +1,154 @@
+package parquet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaFieldsFromFile(t *testing.T) {
+	expected := []*schemaField{
+		{Name: "id", Type: "INT64"},
+		{Name: "name", Type: "UTF8", Optional: true},
+		{Name: "created_at", Type: "TIMESTAMP_MICROS"},
+		{Name: "price", Type: "DECIMAL", Precision: 10, Scale: 2},
+		{Name: "tags", Type: "UTF8", Repeated: true},
+		{Name: "attributes", Type: "MAP", Fields: []*schemaField{
+			{Name: "value", Type: "DOUBLE"},
+		}},
+		{Name: "nested", Optional: true, Fields: []*schemaField{
+			{Name: "ok", Type: "BOOLEAN"},
+		}},
+	}
+
+	for _, test := range []struct {
+		name     string
+		schema   string
+		expected []*schemaField
+		err      string
+	}{
+		{
+			name: "avro",
+			schema: `{
+  "type": "record",
+  "name": "foo",
+  "fields": [
+    { "name": "id", "type": "long" },
+    { "name": "name", "type": [ "null", "string" ] },
+    { "name": "created_at", "type": { "type": "long", "logicalType": "timestamp-micros" } },
+    { "name": "price", "type": { "type": "bytes", "logicalType": "decimal", "precision": 10, "scale": 2 } },
+    { "name": "tags", "type": { "type": "array", "items": "string" } },
+    { "name": "attributes", "type": { "type": "map", "values": "double" } },
+    { "name": "nested", "type": [ "null", { "type": "record", "name": "bar", "fields": [
+      { "name": "ok", "type": "boolean" }
+    ] } ] }
+  ]
+}`,
+			expected: expected,
+		},
+		{
+			name: "json schema",
+			schema: `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "required": [ "id", "created_at", "attributes" ],
+  "properties": {
+    "id": { "type": "integer" },
+    "name": { "type": "string" },
+    "created_at": { "type": "string", "format": "date-time" },
+    "tags": { "type": "array", "items": { "type": "string" } },
+    "attributes": { "type": "object", "additionalProperties": { "type": "number" } },
+    "nested": { "type": "object", "required": [ "ok" ], "properties": {
+      "ok": { "type": "boolean" }
+    } }
+  }
+}`,
+			expected: []*schemaField{
+				{Name: "attributes", Type: "MAP", Fields: []*schemaField{
+					{Name: "value", Type: "DOUBLE"},
+				}},
+				{Name: "created_at", Type: "TIMESTAMP_MICROS"},
+				{Name: "id", Type: "INT64"},
+				{Name: "name", Type: "UTF8", Optional: true},
+				{Name: "nested", Optional: true, Fields: []*schemaField{
+					{Name: "ok", Type: "BOOLEAN"},
+				}},
+				{Name: "tags", Type: "UTF8", Repeated: true},
+			},
+		},
+		{
+			name:   "avro multiple type union",
+			schema: `{"type":"record","name":"foo","fields":[{"name":"id","type":["long","string"]}]}`,
+			err:    "field id: only unions of null and a single other type are supported",
+		},
+		{
+			name:   "json schema ref",
+			schema: `{"type":"object","properties":{"id":{"$ref":"#/$defs/id"}}}`,
+			err:    "field id: references are not supported",
+		},
+		{
+			name:   "unknown format",
+			schema: `{"type":"string"}`,
+			err:    "expected either an Avro record schema or a JSON Schema object with properties",
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			schemaPath := filepath.Join(t.TempDir(), "schema.json")
+			require.NoError(t, os.WriteFile(schemaPath, []byte(test.schema), 0o644))
+
+			fields, err := schemaFieldsFromFile(schemaPath)
+			if test.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, fields)
+
+			_, err = parquetSchemaFromFields(fields)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestParquetEncodeSchemaFile(t *testing.T) {
+	schemaPath := filepath.Join(t.TempDir(), "schema.avsc")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{
+  "type": "record",
+  "name": "foo",
+  "fields": [
+    { "name": "id", "type": "long" },
+    { "name": "name", "type": [ "null", "string" ] }
+  ]
+}`), 0o644))
+
+	encodeConf, err := parquetEncodeProcessorConfig().ParseYAML(`
+schema_file: `+schemaPath+`
+`, nil)
+	require.NoError(t, err)
+
+	encodeProc, err := newParquetEncodeProcessorFromConfig(encodeConf, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`{"id":1,"name":"foo"}`,
+		`{"id":2,"name":null}`,
+	}, encodeDecodeBatch(t, encodeProc, `{"id":1,"name":"foo"}`, `{"id":2}`))
+}
+
+func TestParquetEncodeEmptySchemaFields(t *testing.T) {
+	encodeConf, err := parquetEncodeProcessorConfig().ParseYAML(`
+schema: []
+schema_file: ""
+`, nil)
+	require.NoError(t, err)
+
+	encodeProc, err := newParquetEncodeProcessorFromConfig(encodeConf, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{`{"id":1}`}, encodeDecodeBatch(t, encodeProc, `{"id":1}`))
+}
@@ -29,7 +29,7 @@ func authField() *service.ConfigField {
 				Default(false),
 			service.NewStringField("token").
 				Description("Actual base64 encoded token.").
-				Default(""),
+				Default("").Secret(),
 		).Description("Parameters for Pulsar Token authentication.").
 			Optional(),
 	).Description("Optional configuration of Pulsar authentication methods.").
@@ -0,0 +1,529 @@
+package pure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/sketch"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const aggregateApproximationsDocs = `### Approximations
+
+The ` + "`distinct`" + ` aggregation estimates the number of distinct values with a [HyperLogLog](https://en.wikipedia.org/wiki/HyperLogLog) with a standard error of roughly 0.8%, and the ` + "`percentiles`" + ` aggregation estimates percentiles with a [t-digest](https://github.com/tdunning/t-digest), which is most accurate for extreme percentiles.`
+
+// aggregateFields returns the fields shared by the aggregate processor and
+// buffer.
+func aggregateFields() []*service.ConfigField {
+	return []*service.ConfigField{
+		service.NewBloblangField("key").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) that provides the key to group aggregations by, which must result in a string or number.").
+			Example("root = this.user.id").
+			Example(`root = meta("kafka_key")`),
+		service.NewObjectListField("aggregations",
+			service.NewStringField("name").
+				Description("The name of the field to write the result of the aggregation to."),
+			service.NewStringAnnotatedEnumField("type", map[string]string{
+				"count":       "The number of messages.",
+				"sum":         "The sum of numerical values.",
+				"min":         "The minimum numerical value.",
+				"max":         "The maximum numerical value.",
+				"avg":         "The mean of numerical values.",
+				"distinct":    "An estimate of the number of distinct values.",
+				"percentiles": "Estimates of percentiles of numerical values, written as an object with a field for each percentile, e.g. `p99`.",
+			}).
+				Description("The type of aggregation."),
+			service.NewBloblangField("value").
+				Description("A [Bloblang mapping](/docs/guides/bloblang/about) that provides the value to aggregate, which is required for all types except `count`.").
+				Example("root = this.price").
+				Optional(),
+			service.NewAnyListField("percentiles").
+				Description("The percentiles to estimate for the `percentiles` type, as quantiles between 0 and 1.").
+				Default([]interface{}{0.5, 0.9, 0.99}),
+		).Description("A list of aggregations to maintain for each key."),
+		service.NewStringField("cache").
+			Description("An optional cache resource to store aggregations within, otherwise they are stored in memory.").
+			Optional(),
+	}
+}
+
+// aggregateFlushFields returns the flush trigger fields shared by the
+// aggregate processor and buffer.
+func aggregateFlushFields() []*service.ConfigField {
+	return []*service.ConfigField{
+		service.NewIntField("key_count").
+			Description("Flush aggregations once this number of keys have been aggregated, zero disables this trigger.").
+			Default(0),
+		service.NewBloblangField("check").
+			Description("An optional [Bloblang query](/docs/guides/bloblang/about) that is executed for each message once it has been aggregated, if the result is `true` all aggregations are flushed.").
+			Example(`root = this.type == "end_of_day"`).
+			Optional(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type aggregation struct {
+	name        string
+	aggType     string
+	value       *bloblang.Executor
+	percentiles []float64
+}
+
+// aggregator maintains aggregations of messages grouped by a key. It is not
+// safe for concurrent use.
+type aggregator struct {
+	mgr          *service.Resources
+	key          *bloblang.Executor
+	aggregations []aggregation
+	cacheName    string
+
+	flushKeyCount int
+	flushCheck    *bloblang.Executor
+
+	keys   map[string]struct{}
+	states map[string][]*aggregateValue
+}
+
+func newAggregatorFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*aggregator, error) {
+	a := &aggregator{
+		mgr:    mgr,
+		keys:   map[string]struct{}{},
+		states: map[string][]*aggregateValue{},
+	}
+
+	var err error
+	if a.key, err = conf.FieldBloblang("key"); err != nil {
+		return nil, err
+	}
+
+	aggConfs, err := conf.FieldObjectList("aggregations")
+	if err != nil {
+		return nil, err
+	}
+	if len(aggConfs) == 0 {
+		return nil, errors.New("at least one aggregation must be specified")
+	}
+
+	names := map[string]struct{}{"key": {}}
+	for _, aggConf := range aggConfs {
+		var agg aggregation
+		if agg.name, err = aggConf.FieldString("name"); err != nil {
+			return nil, err
+		}
+		if _, exists := names[agg.name]; exists {
+			return nil, fmt.Errorf("aggregation name %v is either duplicated or reserved", agg.name)
+		}
+		names[agg.name] = struct{}{}
+
+		if agg.aggType, err = aggConf.FieldString("type"); err != nil {
+			return nil, err
+		}
+		if aggConf.Contains("value") {
+			if agg.value, err = aggConf.FieldBloblang("value"); err != nil {
+				return nil, err
+			}
+		} else if agg.aggType != "count" {
+			return nil, fmt.Errorf("aggregation %v of type %v requires a value mapping", agg.name, agg.aggType)
+		}
+
+		if agg.aggType == "percentiles" {
+			pConfs, err := aggConf.FieldAnyList("percentiles")
+			if err != nil {
+				return nil, err
+			}
+			for _, pConf := range pConfs {
+				p, err := pConf.FieldFloat()
+				if err != nil {
+					return nil, err
+				}
+				if p < 0 || p > 1 {
+					return nil, fmt.Errorf("aggregation %v percentile %v must be between 0 and 1", agg.name, p)
+				}
+				agg.percentiles = append(agg.percentiles, p)
+			}
+		}
+		a.aggregations = append(a.aggregations, agg)
+	}
+
+	if conf.Contains("cache") {
+		if a.cacheName, err = conf.FieldString("cache"); err != nil {
+			return nil, err
+		}
+		if !mgr.HasCache(a.cacheName) {
+			return nil, fmt.Errorf("cache named %v not found", a.cacheName)
+		}
+	}
+
+	if a.flushKeyCount, err = conf.FieldInt("flush", "key_count"); err != nil {
+		return nil, err
+	}
+	if conf.Contains("flush", "check") {
+		if a.flushCheck, err = conf.FieldBloblang("flush", "check"); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// queryValue executes a mapping on a message and returns the raw value of the
+// result, or nil if the mapping results in the root being deleted or not
+// assigned. Unlike BloblangQuery the type of the result is preserved, which
+// allows a mapping that results in the string "null" to be distinguished from
+// one that results in a null value. The mapping is executed on a copy of the
+// message and therefore cannot modify it.
+func queryValue(exec *bloblang.Executor, msg *service.Message) (interface{}, error) {
+	uw := exec.XUnwrapper().(interface {
+		Unwrap() *mapping.Executor
+	}).Unwrap()
+
+	msgBytes, err := msg.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+	part := message.NewPart(msgBytes)
+	_ = msg.MetaWalk(func(k, v string) error {
+		part.MetaSet(k, v)
+		return nil
+	})
+
+	batch := message.QuickBatch(nil)
+	batch.Append(part)
+
+	var valuePtr *interface{}
+	res, err := uw.Exec(query.FunctionContext{
+		Maps:     uw.Maps(),
+		Vars:     map[string]interface{}{},
+		MsgBatch: batch,
+		NewMeta:  part,
+	}.WithValueFunc(func() *interface{} {
+		if valuePtr == nil {
+			if jObj, err := part.JSON(); err == nil {
+				valuePtr = &jObj
+			}
+		}
+		return valuePtr
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	switch res.(type) {
+	case query.Delete, query.Nothing:
+		return nil, nil
+	}
+	return res, nil
+}
+
+//------------------------------------------------------------------------------
+
+type aggregateValue struct {
+	Count  int64               `json:"count,omitempty"`
+	Sum    float64             `json:"sum,omitempty"`
+	Min    *float64            `json:"min,omitempty"`
+	Max    *float64            `json:"max,omitempty"`
+	HLL    *sketch.HyperLogLog `json:"hll,omitempty"`
+	Digest *sketch.TDigest     `json:"digest,omitempty"`
+}
+
+func (v *aggregateValue) add(aggType string, value interface{}) error {
+	if aggType == "distinct" {
+		if v.HLL == nil {
+			var err error
+			if v.HLL, err = sketch.NewHyperLogLog(14); err != nil {
+				return err
+			}
+		}
+		v.HLL.Add(value.([]byte))
+		return nil
+	}
+
+	v.Count++
+	if aggType == "count" {
+		return nil
+	}
+
+	f := value.(float64)
+	switch aggType {
+	case "sum", "avg":
+		v.Sum += f
+	case "min":
+		if v.Min == nil || f < *v.Min {
+			v.Min = &f
+		}
+	case "max":
+		if v.Max == nil || f > *v.Max {
+			v.Max = &f
+		}
+	case "percentiles":
+		if v.Digest == nil {
+			v.Digest = sketch.NewTDigest(100)
+		}
+		v.Digest.Add(f)
+	}
+	return nil
+}
+
+func (v *aggregateValue) result(agg aggregation) interface{} {
+	switch agg.aggType {
+	case "count":
+		return v.Count
+	case "sum":
+		return v.Sum
+	case "min":
+		if v.Min == nil {
+			return nil
+		}
+		return *v.Min
+	case "max":
+		if v.Max == nil {
+			return nil
+		}
+		return *v.Max
+	case "avg":
+		if v.Count == 0 {
+			return nil
+		}
+		return v.Sum / float64(v.Count)
+	case "distinct":
+		if v.HLL == nil {
+			return int64(0)
+		}
+		return int64(v.HLL.Count())
+	case "percentiles":
+		res := map[string]interface{}{}
+		for _, p := range agg.percentiles {
+			name := "p" + strconv.FormatFloat(p*100, 'f', -1, 64)
+			if v.Digest == nil {
+				res[name] = nil
+			} else {
+				res[name] = v.Digest.Quantile(p)
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func (a *aggregator) loadState(ctx context.Context, key string) ([]*aggregateValue, error) {
+	if a.cacheName == "" {
+		state, exists := a.states[key]
+		if !exists {
+			state = make([]*aggregateValue, len(a.aggregations))
+			for i := range state {
+				state[i] = &aggregateValue{}
+			}
+			a.states[key] = state
+		}
+		return state, nil
+	}
+
+	var stateBytes []byte
+	var err error
+	if cerr := a.mgr.AccessCache(ctx, a.cacheName, func(c service.Cache) {
+		stateBytes, err = c.Get(ctx, key)
+	}); cerr != nil {
+		return nil, cerr
+	}
+
+	state := make([]*aggregateValue, len(a.aggregations))
+	if errors.Is(err, service.ErrKeyNotFound) {
+		for i := range state {
+			state[i] = &aggregateValue{}
+		}
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(stateBytes, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse cached aggregations, this indicates the data was not set by this processor: %w", err)
+	}
+	if len(state) != len(a.aggregations) {
+		return nil, errors.New("cached aggregations do not match the configured aggregations")
+	}
+	return state, nil
+}
+
+func (a *aggregator) storeState(ctx context.Context, key string, state []*aggregateValue) error {
+	if a.cacheName == "" {
+		return nil
+	}
+
+	stateBytes, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if cerr := a.mgr.AccessCache(ctx, a.cacheName, func(c service.Cache) {
+		err = c.Set(ctx, key, stateBytes, nil)
+	}); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+func (a *aggregator) deleteState(ctx context.Context, key string) error {
+	if a.cacheName == "" {
+		delete(a.states, key)
+		return nil
+	}
+
+	var err error
+	if cerr := a.mgr.AccessCache(ctx, a.cacheName, func(c service.Cache) {
+		err = c.Delete(ctx, key)
+	}); cerr != nil {
+		return cerr
+	}
+	if errors.Is(err, service.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+// values extracts the value of each aggregation from a message.
+func (a *aggregator) values(msg *service.Message) ([]interface{}, error) {
+	values := make([]interface{}, len(a.aggregations))
+	for i, agg := range a.aggregations {
+		if agg.value == nil {
+			continue
+		}
+
+		v, err := queryValue(agg.value, msg)
+		if err != nil {
+			return nil, fmt.Errorf("aggregation %v: %w", agg.name, err)
+		}
+		if v == nil {
+			return nil, fmt.Errorf("aggregation %v: value mapping resulted in a null or deleted value", agg.name)
+		}
+
+		if agg.aggType == "distinct" {
+			values[i] = query.IToBytes(v)
+			continue
+		}
+		if values[i], err = query.IGetNumber(v); err != nil {
+			return nil, fmt.Errorf("aggregation %v: %w", agg.name, err)
+		}
+	}
+	return values, nil
+}
+
+func (a *aggregator) aggregate(ctx context.Context, msg *service.Message) error {
+	keyValue, err := queryValue(a.key, msg)
+	if err != nil {
+		return fmt.Errorf("key mapping failed: %w", err)
+	}
+	if keyValue == nil {
+		return errors.New("key mapping resulted in a null or deleted value")
+	}
+	key := query.IToString(keyValue)
+
+	values, err := a.values(msg)
+	if err != nil {
+		return err
+	}
+
+	state, err := a.loadState(ctx, key)
+	if err != nil {
+		return err
+	}
+	for i, agg := range a.aggregations {
+		if err := state[i].add(agg.aggType, values[i]); err != nil {
+			return err
+		}
+	}
+	if err := a.storeState(ctx, key, state); err != nil {
+		return err
+	}
+
+	a.keys[key] = struct{}{}
+	return nil
+}
+
+// flush removes all aggregations and returns a message containing the results
+// of each. If an error occurs the results of the aggregations that were
+// removed before it are returned along with the error, and the remaining
+// aggregations are kept to be flushed later.
+func (a *aggregator) flush(ctx context.Context) (service.MessageBatch, error) {
+	keys := make([]string, 0, len(a.keys))
+	for k := range a.keys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var batch service.MessageBatch
+	for _, k := range keys {
+		state, err := a.loadState(ctx, k)
+		if err != nil {
+			return batch, err
+		}
+
+		result := map[string]interface{}{"key": k}
+		for i, agg := range a.aggregations {
+			result[agg.name] = state[i].result(agg)
+		}
+
+		if err := a.deleteState(ctx, k); err != nil {
+			return batch, err
+		}
+		delete(a.keys, k)
+
+		msg := service.NewMessage(nil)
+		msg.SetStructured(result)
+		batch = append(batch, msg)
+	}
+	return batch, nil
+}
+
+// flushInto flushes all aggregations and appends the results to a batch,
+// logging any error.
+func (a *aggregator) flushInto(ctx context.Context, batch service.MessageBatch) service.MessageBatch {
+	flushed, err := a.flush(ctx)
+	if err != nil {
+		a.mgr.Logger().Errorf("Failed to flush aggregations: %v", err)
+	}
+	return append(batch, flushed...)
+}
+
+// add aggregates each message of a batch and returns the messages to be
+// emitted as a result, which are the results of any flushes triggered by the
+// batch along with messages that could not be aggregated.
+func (a *aggregator) add(ctx context.Context, batch service.MessageBatch) service.MessageBatch {
+	var outBatch service.MessageBatch
+	for _, msg := range batch {
+		if err := a.aggregate(ctx, msg); err != nil {
+			a.mgr.Logger().Debugf("Failed to aggregate message: %v", err)
+			msg = msg.Copy()
+			msg.SetError(err)
+			outBatch = append(outBatch, msg)
+			continue
+		}
+
+		if a.flushCheck == nil {
+			continue
+		}
+
+		v, err := queryValue(a.flushCheck, msg)
+		if err != nil {
+			a.mgr.Logger().Errorf("Flush check failed: %v", err)
+			continue
+		}
+		if b, _ := v.(bool); b {
+			outBatch = a.flushInto(ctx, outBatch)
+		}
+	}
+
+	if a.flushKeyCount > 0 && len(a.keys) >= a.flushKeyCount {
+		outBatch = a.flushInto(ctx, outBatch)
+	}
+	return outBatch
+}
@@ -0,0 +1,229 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func aggregateBufferConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		// Stable(). TODO
+		Version("4.4.0").
+		Categories("Windowing").
+		Summary("Maintains running aggregations of messages grouped by a key, and emits the results of those aggregations at an interval of time or when another flush is triggered.").
+		Description(`
+Messages consumed by this buffer are aggregated and removed from the stream, and when a flush is triggered a message is emitted for each key that has been aggregated since the last flush, containing the key under the field ` + "`key`" + ` and the result of each aggregation under a field of its name:
+
+` + "```json" + `
+{"key":"foo","total":13,"latency":{"p50":12.5,"p99":103.2}}
+` + "```" + `
+
+Aggregations are then reset. Messages where the key or a value could not be obtained are flagged as having failed and are passed through the buffer unchanged, and can be handled with [error handling patterns](/docs/configuration/error_handling).
+
+This buffer works the same way as the ` + "[`aggregate` processor](/docs/components/processors/aggregate)" + `, but is able to emit the results of flushes at any time, and therefore supports flushing at an interval of time regardless of whether messages are arriving. When the input of the pipeline ends all aggregations are flushed.
+
+### Storage
+
+By default aggregations are stored in memory. When a ` + "`cache`" + ` is specified aggregations are instead stored within that cache resource, which allows them to be persisted. The set of keys waiting to be flushed is always tracked in memory, and therefore aggregations left within a persisted cache after a restart are only flushed once messages of the same key arrive.
+
+## Delivery Guarantees
+
+Messages are acknowledged at the input level once they are aggregated, and this buffer therefore weakens the delivery guarantees of the pipeline. Messages emitted by this buffer that are rejected downstream are emitted again.
+
+` + aggregateApproximationsDocs)
+
+	for _, f := range aggregateFields() {
+		spec = spec.Field(f)
+	}
+
+	flushFields := append([]*service.ConfigField{
+		service.NewDurationField("interval").
+			Description("An optional period of time at which aggregations are flushed.").
+			Example("10s").
+			Optional(),
+	}, aggregateFlushFields()...)
+
+	return spec.
+		Field(service.NewObjectField("flush", flushFields...).
+			Description("Conditions that trigger a flush of all aggregations, any of which can trigger a flush.")).
+		Example("Per-User Order Totals",
+			"In this example we emit the number of orders, the total spent and the number of distinct items ordered per user every minute:",
+			`
+buffer:
+  aggregate:
+    key: root = this.user_id
+    aggregations:
+      - name: orders
+        type: count
+      - name: spent
+        type: sum
+        value: root = this.price
+      - name: items
+        type: distinct
+        value: root = this.item_id
+    flush:
+      interval: 1m
+`)
+}
+
+func init() {
+	err := service.RegisterBatchBuffer(
+		"aggregate", aggregateBufferConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchBuffer, error) {
+			return newAggregateBufferFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newAggregateBufferFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*aggregateBuffer, error) {
+	agg, err := newAggregatorFromConfig(conf, mgr)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &aggregateBuffer{
+		agg:      agg,
+		notify:   make(chan struct{}, 1),
+		inputEnd: make(chan struct{}),
+	}
+	if conf.Contains("flush", "interval") {
+		if a.interval, err = conf.FieldDuration("flush", "interval"); err != nil {
+			return nil, err
+		}
+	}
+	if a.interval <= 0 && agg.flushKeyCount <= 0 && agg.flushCheck == nil {
+		return nil, errors.New("at least one flush trigger must be specified")
+	}
+	a.nextFlush = time.Now().Add(a.interval)
+	return a, nil
+}
+
+//------------------------------------------------------------------------------
+
+type aggregateBuffer struct {
+	interval          time.Duration
+	notify            chan struct{}
+	inputEnd          chan struct{}
+	closeInputEndOnce sync.Once
+
+	mut       sync.Mutex
+	agg       *aggregator
+	nextFlush time.Time
+	pending   service.MessageBatch
+	unacked   int
+}
+
+func (a *aggregateBuffer) WriteBatch(ctx context.Context, batch service.MessageBatch, aFn service.AckFunc) error {
+	a.mut.Lock()
+	a.pending = append(a.pending, a.agg.add(ctx, batch)...)
+	a.mut.Unlock()
+
+	select {
+	case a.notify <- struct{}{}:
+	default:
+	}
+	return aFn(ctx, nil)
+}
+
+func (a *aggregateBuffer) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	var flushTimer *time.Timer
+	defer func() {
+		if flushTimer != nil {
+			flushTimer.Stop()
+		}
+	}()
+
+	for {
+		var inputEnded bool
+		select {
+		case <-a.inputEnd:
+			inputEnded = true
+		default:
+		}
+
+		a.mut.Lock()
+		if inputEnded {
+			a.pending = a.agg.flushInto(ctx, a.pending)
+		} else if a.interval > 0 && !time.Now().Before(a.nextFlush) {
+			a.pending = a.agg.flushInto(ctx, a.pending)
+			for !time.Now().Before(a.nextFlush) {
+				a.nextFlush = a.nextFlush.Add(a.interval)
+			}
+		}
+		if len(a.pending) > 0 {
+			batch := a.pending
+			a.pending = nil
+			a.unacked++
+			a.mut.Unlock()
+			return batch, func(ctx context.Context, err error) error {
+				a.mut.Lock()
+				a.unacked--
+				if err != nil {
+					a.pending = append(batch, a.pending...)
+				}
+				a.mut.Unlock()
+
+				select {
+				case a.notify <- struct{}{}:
+				default:
+				}
+				return nil
+			}, nil
+		}
+
+		var nextFlush <-chan time.Time
+		if a.interval > 0 && !inputEnded {
+			wait := time.Until(a.nextFlush)
+			if flushTimer == nil {
+				flushTimer = time.NewTimer(wait)
+			} else {
+				if !flushTimer.Stop() {
+					select {
+					case <-flushTimer.C:
+					default:
+					}
+				}
+				flushTimer.Reset(wait)
+			}
+			nextFlush = flushTimer.C
+		}
+		unacked := a.unacked
+		a.mut.Unlock()
+
+		// Batches that are yet to be acknowledged might be rejected and
+		// emitted again.
+		if inputEnded && unacked == 0 {
+			return nil, nil, service.ErrEndOfBuffer
+		}
+
+		inputEnd := a.inputEnd
+		if inputEnded {
+			inputEnd = nil
+		}
+
+		select {
+		case <-nextFlush:
+		case <-a.notify:
+		case <-inputEnd:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+}
+
+func (a *aggregateBuffer) EndOfInput() {
+	a.closeInputEndOnce.Do(func() {
+		close(a.inputEnd)
+	})
+}
+
+func (a *aggregateBuffer) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,127 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func newTestAggregateBuffer(t *testing.T, flushConf string) *aggregateBuffer {
+	t.Helper()
+
+	conf, err := aggregateBufferConfig().ParseYAML(`
+key: root = this.user
+aggregations:
+  - name: sum
+    type: sum
+    value: root = this.price
+flush:
+`+flushConf, nil)
+	require.NoError(t, err)
+
+	a, err := newAggregateBufferFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+	return a
+}
+
+func writeAggregateMessages(t *testing.T, a *aggregateBuffer, docs ...string) {
+	t.Helper()
+
+	var acked bool
+	require.NoError(t, a.WriteBatch(context.Background(), aggregateTestBatch(docs...), func(ctx context.Context, err error) error {
+		acked = true
+		return err
+	}))
+	assert.True(t, acked)
+}
+
+func readAggregateMessages(t *testing.T, a *aggregateBuffer, ackErr error) []string {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	batch, aFn, err := a.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.NoError(t, aFn(ctx, ackErr))
+	return aggregateResults(t, []service.MessageBatch{batch})
+}
+
+func TestAggregateBufferInterval(t *testing.T) {
+	a := newTestAggregateBuffer(t, `  interval: 10ms`)
+
+	writeAggregateMessages(t, a,
+		`{"user":"a","price":10}`,
+		`{"user":"b","price":5}`,
+		`{"user":"a","price":3}`,
+	)
+
+	// Results are emitted without any further messages arriving.
+	assert.Equal(t, []string{
+		`{"key":"a","sum":13}`,
+		`{"key":"b","sum":5}`,
+	}, readAggregateMessages(t, a, nil))
+
+	writeAggregateMessages(t, a, `{"user":"a","price":1}`)
+	assert.Equal(t, []string{
+		`{"key":"a","sum":1}`,
+	}, readAggregateMessages(t, a, nil))
+}
+
+func TestAggregateBufferEndOfInput(t *testing.T) {
+	a := newTestAggregateBuffer(t, `  interval: 1h`)
+
+	writeAggregateMessages(t, a,
+		`{"user":"a","price":10}`,
+		`{"price":10}`,
+	)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	batch, aFn, err := a.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	assert.Error(t, batch[0].GetError())
+	require.NoError(t, aFn(ctx, nil))
+
+	a.EndOfInput()
+
+	// Rejected results are emitted again.
+	assert.Equal(t, []string{`{"key":"a","sum":10}`}, readAggregateMessages(t, a, errors.New("nope")))
+	assert.Equal(t, []string{`{"key":"a","sum":10}`}, readAggregateMessages(t, a, nil))
+
+	_, _, err = a.ReadBatch(ctx)
+	assert.Equal(t, service.ErrEndOfBuffer, err)
+}
+
+func TestAggregateBufferKeyCount(t *testing.T) {
+	a := newTestAggregateBuffer(t, `  key_count: 2`)
+
+	writeAggregateMessages(t, a, `{"user":"a","price":10}`)
+	writeAggregateMessages(t, a, `{"user":"b","price":5}`)
+
+	assert.Equal(t, []string{
+		`{"key":"a","sum":10}`,
+		`{"key":"b","sum":5}`,
+	}, readAggregateMessages(t, a, nil))
+}
+
+func TestAggregateBufferConfigErrors(t *testing.T) {
+	conf, err := aggregateBufferConfig().ParseYAML(`
+key: root = this.user
+aggregations:
+  - { name: count, type: count }
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newAggregateBufferFromConfig(conf, service.MockResources())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "at least one flush trigger must be specified")
+}
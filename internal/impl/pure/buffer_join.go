@@ -0,0 +1,627 @@
+package pure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func joinBufferConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Version("4.4.0").
+		Categories("Windowing").
+		Summary("Joins messages from two sources that share a key within a window of time, emitting merged documents according to a join type.").
+		Description(`
+Messages consumed by this buffer are tagged with a source by the `+"[`source` mapping](#source)"+`, which is usually obtained from metadata added to messages by each input of a `+"[`broker`](/docs/components/inputs/broker)"+`. Messages from the sources named by the fields `+"`left` and `right`"+` are stored within a [cache resource](/docs/components/caches/about) under a key obtained with the `+"[`key` mapping](#key)"+`, and messages from any other source are passed through the buffer unchanged.
+
+When a message arrives with a key that matches one or more stored messages from the opposite source a merged document is emitted for each match, which is an object containing each side of the join under a field named after its source:
+
+`+"```json"+`
+{
+  "orders": {"id":"foo","item":"bar"},
+  "payments": {"order_id":"foo","amount":10}
+}
+`+"```"+`
+
+The metadata of merged documents is taken from the message that completed the match.
+
+Messages that cannot be joined, because the `+"`source` or `key`"+` mapping fails or because they cannot be parsed as structured data, are passed through the buffer unchanged and flagged with an error, which can be handled with [error handling patterns](/docs/configuration/error_handling).
+
+Messages are retained for the duration of the `+"`window`"+`, which begins once the first message of a key arrives. Once a window expires the messages stored for its key are removed from the cache, and any that were never matched are either dropped or emitted with a `+"`null`"+` counterpart, depending on the join `+"`type`"+`. When the input of the pipeline ends all windows are expired immediately.
+
+## Delivery Guarantees
+
+Messages are acknowledged at the input level once they are stored within the cache, and this buffer therefore weakens the delivery guarantees of the pipeline. If a batch cannot be fully stored then the changes it made to the cache are reverted and it is rejected, and messages emitted by this buffer that are rejected downstream are emitted again. Once `+"`max_pending`"+` messages are waiting to be emitted writes to this buffer are blocked until they are read. The expiry of windows is tracked in memory, and therefore if the service is restarted messages that remain within a persisted cache are never emitted as unmatched, although they can still be matched by messages that arrive for the same key.`).
+		Field(service.NewStringField("cache").
+			Description("A cache resource to store messages waiting to be joined.")).
+		Field(service.NewBloblangField("key").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) that provides the key to join messages on, which must result in a string or number.").
+			Example("root = this.order_id").
+			Example(`root = meta("kafka_key")`)).
+		Field(service.NewBloblangField("source").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) that provides the source of each message, which is compared with the `left` and `right` fields.").
+			Default(`root = meta("source")`)).
+		Field(service.NewStringField("left").
+			Description("The source of messages that form the left side of the join.").
+			Example("orders")).
+		Field(service.NewStringField("right").
+			Description("The source of messages that form the right side of the join.").
+			Example("payments")).
+		Field(service.NewStringAnnotatedEnumField("type", map[string]string{
+			"inner": "Only merged documents are emitted, and unmatched messages are dropped once their window expires.",
+			"left":  "Unmatched messages of the left source are emitted once their window expires.",
+			"right": "Unmatched messages of the right source are emitted once their window expires.",
+			"full":  "Unmatched messages of either source are emitted once their window expires.",
+		}).
+			Description("The type of join to perform.").
+			Default("inner")).
+		Field(service.NewDurationField("window").
+			Description("The period of time to retain the messages of a key after the first one arrives.").
+			Example("30s").Example("1h")).
+		Field(service.NewIntField("max_pending").
+			Description("The maximum number of messages waiting to be emitted by the buffer, beyond which writes are blocked until they are read.").
+			Default(1000).
+			Advanced()).
+		Example("Joining Orders and Payments", `Given a stream of orders and a stream of payments consumed from separate Kafka topics, we can emit orders merged with their payments when they arrive within ten minutes of each other, and also emit orders that were not paid within that time:`,
+			`
+input:
+  broker:
+    inputs:
+      - kafka:
+          addresses: [ TODO ]
+          topics: [ orders ]
+        processors:
+          - mapping: 'meta source = "orders"'
+      - kafka:
+          addresses: [ TODO ]
+          topics: [ payments ]
+        processors:
+          - mapping: 'meta source = "payments"'
+
+buffer:
+  join:
+    cache: joins
+    key: 'root = this.order_id'
+    left: orders
+    right: payments
+    type: left
+    window: 10m
+
+cache_resources:
+  - label: joins
+    memory: {}
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchBuffer(
+		"join", joinBufferConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchBuffer, error) {
+			return newJoinBufferFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newJoinBufferFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*joinBuffer, error) {
+	j := &joinBuffer{
+		mgr:      mgr,
+		clock:    time.Now,
+		index:    map[string]struct{}{},
+		notify:   make(chan struct{}, 1),
+		space:    make(chan struct{}, 1),
+		inputEnd: make(chan struct{}),
+	}
+
+	var err error
+	if j.cacheName, err = conf.FieldString("cache"); err != nil {
+		return nil, err
+	}
+	if !mgr.HasCache(j.cacheName) {
+		return nil, fmt.Errorf("cache named %v not found", j.cacheName)
+	}
+	if j.key, err = conf.FieldBloblang("key"); err != nil {
+		return nil, err
+	}
+	if j.source, err = conf.FieldBloblang("source"); err != nil {
+		return nil, err
+	}
+	if j.left, err = conf.FieldString("left"); err != nil {
+		return nil, err
+	}
+	if j.right, err = conf.FieldString("right"); err != nil {
+		return nil, err
+	}
+	if j.left == j.right {
+		return nil, errors.New("the left and right sources must be different")
+	}
+
+	joinType, err := conf.FieldString("type")
+	if err != nil {
+		return nil, err
+	}
+	switch joinType {
+	case "inner":
+	case "left":
+		j.emitLeft = true
+	case "right":
+		j.emitRight = true
+	case "full":
+		j.emitLeft, j.emitRight = true, true
+	default:
+		return nil, fmt.Errorf("join type %v not recognised", joinType)
+	}
+
+	if j.window, err = conf.FieldDuration("window"); err != nil {
+		return nil, err
+	}
+	if j.window <= 0 {
+		return nil, errors.New("window must be greater than zero")
+	}
+	if j.maxPending, err = conf.FieldInt("max_pending"); err != nil {
+		return nil, err
+	}
+	if j.maxPending <= 0 {
+		return nil, errors.New("max_pending must be greater than zero")
+	}
+	return j, nil
+}
+
+//------------------------------------------------------------------------------
+
+type joinEntry struct {
+	Content  []byte            `json:"content"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Matched  bool              `json:"matched"`
+}
+
+type joinGroup struct {
+	Left  []*joinEntry `json:"left,omitempty"`
+	Right []*joinEntry `json:"right,omitempty"`
+}
+
+type joinDeadline struct {
+	key      string
+	deadline time.Time
+}
+
+type joinBuffer struct {
+	mgr *service.Resources
+
+	cacheName           string
+	key                 *bloblang.Executor
+	source              *bloblang.Executor
+	left, right         string
+	emitLeft, emitRight bool
+	window              time.Duration
+	maxPending          int
+	clock               func() time.Time
+	notify              chan struct{}
+	space               chan struct{}
+	inputEnd            chan struct{}
+	closeInputEndOnce   sync.Once
+
+	mut sync.Mutex
+
+	// Windows are all the same length and therefore expire in the order that
+	// they were created.
+	deadlines []joinDeadline
+	index     map[string]struct{}
+	pending   service.MessageBatch
+	unacked   int
+}
+
+// mappedString executes a mapping on a message and returns the result as a
+// string.
+func mappedString(exec *bloblang.Executor, msg *service.Message) (string, error) {
+	res, err := msg.BloblangQuery(exec)
+	if err != nil {
+		return "", err
+	}
+	if res == nil {
+		return "", errors.New("mapping resulted in a deleted message")
+	}
+	resBytes, err := res.AsBytes()
+	if err != nil {
+		return "", err
+	}
+	return string(resBytes), nil
+}
+
+// getGroupBytes returns the serialised group of a key, or nil if the key has
+// no group.
+func (j *joinBuffer) getGroupBytes(ctx context.Context, key string) ([]byte, error) {
+	var groupBytes []byte
+	var err error
+	if cerr := j.mgr.AccessCache(ctx, j.cacheName, func(c service.Cache) {
+		groupBytes, err = c.Get(ctx, key)
+	}); cerr != nil {
+		return nil, cerr
+	}
+	if errors.Is(err, service.ErrKeyNotFound) {
+		return nil, nil
+	}
+	return groupBytes, err
+}
+
+func parseGroup(groupBytes []byte) (*joinGroup, error) {
+	var group joinGroup
+	if groupBytes == nil {
+		return &group, nil
+	}
+	if err := json.Unmarshal(groupBytes, &group); err != nil {
+		return nil, fmt.Errorf("failed to parse cached join entries, this indicates the data was not set by this buffer: %w", err)
+	}
+	return &group, nil
+}
+
+func (j *joinBuffer) getGroup(ctx context.Context, key string) (*joinGroup, error) {
+	groupBytes, err := j.getGroupBytes(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return parseGroup(groupBytes)
+}
+
+func (j *joinBuffer) setGroup(ctx context.Context, key string, group *joinGroup) error {
+	groupBytes, err := json.Marshal(group)
+	if err != nil {
+		return err
+	}
+	return j.setGroupBytes(ctx, key, groupBytes)
+}
+
+func (j *joinBuffer) setGroupBytes(ctx context.Context, key string, groupBytes []byte) error {
+	// The TTL is a safety net for entries that are orphaned by a restart, as
+	// windows are otherwise expired explicitly.
+	ttl := j.window * 2
+	var err error
+	if cerr := j.mgr.AccessCache(ctx, j.cacheName, func(c service.Cache) {
+		err = c.Set(ctx, key, groupBytes, &ttl)
+	}); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+func (j *joinBuffer) deleteGroup(ctx context.Context, key string) error {
+	var err error
+	if cerr := j.mgr.AccessCache(ctx, j.cacheName, func(c service.Cache) {
+		err = c.Delete(ctx, key)
+	}); cerr != nil {
+		return cerr
+	}
+	if errors.Is(err, service.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (j *joinBuffer) entryDoc(e *joinEntry) (interface{}, error) {
+	var doc interface{}
+	if err := json.Unmarshal(e.Content, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (j *joinBuffer) joined(from *service.Message, leftDoc, rightDoc interface{}) *service.Message {
+	msg := from.Copy()
+	msg.SetStructured(map[string]interface{}{
+		j.left:  leftDoc,
+		j.right: rightDoc,
+	})
+	return msg
+}
+
+func (j *joinBuffer) WriteBatch(ctx context.Context, batch service.MessageBatch, aFn service.AckFunc) error {
+	// Writes are blocked until there is room for more messages to be emitted.
+	for {
+		j.mut.Lock()
+		if len(j.pending) < j.maxPending {
+			break
+		}
+		j.mut.Unlock()
+
+		select {
+		case <-j.space:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	defer j.mut.Unlock()
+
+	// Windows that have expired but not yet been read must be expired before
+	// new messages can be added to their keys.
+	j.expire(ctx, j.clock(), false)
+
+	emitted, err := j.join(ctx, batch)
+	if err != nil {
+		return err
+	}
+	j.pending = append(j.pending, emitted...)
+
+	select {
+	case j.notify <- struct{}{}:
+	default:
+	}
+	return aFn(ctx, nil)
+}
+
+// join adds the messages of a batch to the groups of their keys and returns
+// the messages to be emitted as a result. If the batch cannot be fully added
+// then any groups that were already modified are restored, such that the batch
+// can be reattempted without duplicating messages. Must be called with mut
+// held.
+func (j *joinBuffer) join(ctx context.Context, batch service.MessageBatch) (emitted service.MessageBatch, err error) {
+	// The serialised groups of each key modified by the batch before they
+	// were modified, or nil if the key had no group.
+	originals := map[string][]byte{}
+	defer func() {
+		if err != nil {
+			j.restoreGroups(ctx, originals)
+		}
+	}()
+
+	var newKeys []string
+	for _, msg := range batch {
+		source, err := mappedString(j.source, msg)
+		if err != nil {
+			emitted = append(emitted, j.failed(msg, fmt.Errorf("source mapping failed: %w", err)))
+			continue
+		}
+
+		isLeft := source == j.left
+		if !isLeft && source != j.right {
+			emitted = append(emitted, msg)
+			continue
+		}
+
+		key, err := mappedString(j.key, msg)
+		if err != nil {
+			emitted = append(emitted, j.failed(msg, fmt.Errorf("key mapping failed: %w", err)))
+			continue
+		}
+
+		doc, err := msg.AsStructured()
+		if err != nil {
+			emitted = append(emitted, j.failed(msg, fmt.Errorf("failed to parse message as structured data: %w", err)))
+			continue
+		}
+
+		entry := &joinEntry{Metadata: map[string]string{}}
+		if entry.Content, err = msg.AsBytes(); err != nil {
+			return nil, err
+		}
+		_ = msg.MetaWalk(func(k, v string) error {
+			entry.Metadata[k] = v
+			return nil
+		})
+
+		groupBytes, err := j.getGroupBytes(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := originals[key]; !exists {
+			originals[key] = groupBytes
+		}
+		group, err := parseGroup(groupBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		others := group.Right
+		if !isLeft {
+			others = group.Left
+		}
+		for _, other := range others {
+			otherDoc, err := j.entryDoc(other)
+			if err != nil {
+				j.mgr.Logger().Errorf("Failed to parse stored message as structured data: %v", err)
+				continue
+			}
+			other.Matched, entry.Matched = true, true
+			if isLeft {
+				emitted = append(emitted, j.joined(msg, doc, otherDoc))
+			} else {
+				emitted = append(emitted, j.joined(msg, otherDoc, doc))
+			}
+		}
+
+		if isLeft {
+			group.Left = append(group.Left, entry)
+		} else {
+			group.Right = append(group.Right, entry)
+		}
+		if err := j.setGroup(ctx, key, group); err != nil {
+			return nil, err
+		}
+		newKeys = append(newKeys, key)
+	}
+
+	for _, key := range newKeys {
+		if _, exists := j.index[key]; !exists {
+			j.index[key] = struct{}{}
+			j.deadlines = append(j.deadlines, joinDeadline{key: key, deadline: j.clock().Add(j.window)})
+		}
+	}
+	return emitted, nil
+}
+
+// restoreGroups reverts the groups of keys to their serialised state from
+// before a batch was written.
+func (j *joinBuffer) restoreGroups(ctx context.Context, originals map[string][]byte) {
+	for key, groupBytes := range originals {
+		var err error
+		if groupBytes == nil {
+			err = j.deleteGroup(ctx, key)
+		} else {
+			err = j.setGroupBytes(ctx, key, groupBytes)
+		}
+		if err != nil {
+			j.mgr.Logger().Errorf("Failed to restore join entries of key '%v' after a failed write: %v", key, err)
+		}
+	}
+}
+
+// failed flags a message that cannot be joined with the error that prevented
+// it from being joined, in order for it to be emitted unchanged.
+func (j *joinBuffer) failed(msg *service.Message, err error) *service.Message {
+	j.mgr.Logger().Errorf("Passing message through the join buffer unchanged: %v", err)
+	msg = msg.Copy()
+	msg.SetError(err)
+	return msg
+}
+
+// expire removes the windows that have expired before a given time from the
+// cache and adds any unmatched messages to be emitted. Must be called with
+// mut held.
+func (j *joinBuffer) expire(ctx context.Context, before time.Time, all bool) {
+	for len(j.deadlines) > 0 && (all || !j.deadlines[0].deadline.After(before)) {
+		key := j.deadlines[0].key
+		j.deadlines = j.deadlines[1:]
+		delete(j.index, key)
+
+		group, err := j.getGroup(ctx, key)
+		if err == nil {
+			err = j.deleteGroup(ctx, key)
+		}
+		if err != nil {
+			j.mgr.Logger().Errorf("Failed to expire join window of key '%v': %v", key, err)
+			continue
+		}
+
+		emitUnmatched := func(entries []*joinEntry, isLeft bool) {
+			for _, e := range entries {
+				if e.Matched {
+					continue
+				}
+				doc, err := j.entryDoc(e)
+				if err != nil {
+					j.mgr.Logger().Errorf("Failed to parse stored message as structured data: %v", err)
+					continue
+				}
+				msg := service.NewMessage(nil)
+				for k, v := range e.Metadata {
+					msg.MetaSet(k, v)
+				}
+				if isLeft {
+					msg = j.joined(msg, doc, nil)
+				} else {
+					msg = j.joined(msg, nil, doc)
+				}
+				j.pending = append(j.pending, msg)
+			}
+		}
+		if j.emitLeft {
+			emitUnmatched(group.Left, true)
+		}
+		if j.emitRight {
+			emitUnmatched(group.Right, false)
+		}
+	}
+}
+
+func (j *joinBuffer) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	var deadlineTimer *time.Timer
+	defer func() {
+		if deadlineTimer != nil {
+			deadlineTimer.Stop()
+		}
+	}()
+
+	for {
+		var inputEnded bool
+		select {
+		case <-j.inputEnd:
+			inputEnded = true
+		default:
+		}
+
+		j.mut.Lock()
+		j.expire(ctx, j.clock(), inputEnded)
+		if len(j.pending) > 0 {
+			batch := j.pending
+			j.pending = nil
+			j.unacked++
+			j.mut.Unlock()
+
+			select {
+			case j.space <- struct{}{}:
+			default:
+			}
+			return batch, func(ctx context.Context, err error) error {
+				j.mut.Lock()
+				j.unacked--
+				if err != nil {
+					j.pending = append(batch, j.pending...)
+				}
+				j.mut.Unlock()
+
+				select {
+				case j.notify <- struct{}{}:
+				default:
+				}
+				return nil
+			}, nil
+		}
+
+		var nextDeadline <-chan time.Time
+		if len(j.deadlines) > 0 {
+			wait := j.deadlines[0].deadline.Sub(j.clock())
+			if deadlineTimer == nil {
+				deadlineTimer = time.NewTimer(wait)
+			} else {
+				if !deadlineTimer.Stop() {
+					select {
+					case <-deadlineTimer.C:
+					default:
+					}
+				}
+				deadlineTimer.Reset(wait)
+			}
+			nextDeadline = deadlineTimer.C
+		}
+		unacked := j.unacked
+		j.mut.Unlock()
+
+		// Batches that are yet to be acknowledged might be rejected and
+		// emitted again.
+		if inputEnded && unacked == 0 {
+			return nil, nil, service.ErrEndOfBuffer
+		}
+
+		inputEnd := j.inputEnd
+		if inputEnded {
+			inputEnd = nil
+		}
+
+		select {
+		case <-nextDeadline:
+		case <-j.notify:
+		case <-inputEnd:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+}
+
+func (j *joinBuffer) EndOfInput() {
+	j.closeInputEndOnce.Do(func() {
+		close(j.inputEnd)
+	})
+}
+
+func (j *joinBuffer) Close(ctx context.Context) error {
+	return nil
+}
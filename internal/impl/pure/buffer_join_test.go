@@ -0,0 +1,358 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func newTestJoinBuffer(t *testing.T, joinType string) (*joinBuffer, *time.Time, *sync.Mutex) {
+	t.Helper()
+
+	conf, err := joinBufferConfig().ParseYAML(`
+cache: joins
+key: 'root = this.id'
+left: orders
+right: payments
+type: `+joinType+`
+window: 1m
+`, nil)
+	require.NoError(t, err)
+
+	j, err := newJoinBufferFromConfig(conf, service.MockResources(service.MockResourcesOptAddCache("joins")))
+	require.NoError(t, err)
+
+	now := time.Now()
+	var nowMut sync.Mutex
+	j.clock = func() time.Time {
+		nowMut.Lock()
+		defer nowMut.Unlock()
+		return now
+	}
+	return j, &now, &nowMut
+}
+
+func writeJoinMessages(t *testing.T, j *joinBuffer, msgs ...[2]string) {
+	t.Helper()
+
+	var batch service.MessageBatch
+	for _, m := range msgs {
+		msg := service.NewMessage([]byte(m[1]))
+		msg.MetaSet("source", m[0])
+		batch = append(batch, msg)
+	}
+
+	var acked bool
+	require.NoError(t, j.WriteBatch(context.Background(), batch, func(ctx context.Context, err error) error {
+		acked = true
+		return err
+	}))
+	assert.True(t, acked)
+}
+
+func readJoinMessages(t *testing.T, j *joinBuffer) []string {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+
+	batch, aFn, err := j.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.NoError(t, aFn(ctx, nil))
+
+	var results []string
+	for _, m := range batch {
+		mBytes, err := m.AsBytes()
+		require.NoError(t, err)
+		results = append(results, string(mBytes))
+	}
+	sort.Strings(results)
+	return results
+}
+
+func TestJoinBufferInner(t *testing.T) {
+	j, _, _ := newTestJoinBuffer(t, "inner")
+
+	writeJoinMessages(t, j,
+		[2]string{"orders", `{"id":"a","item":"foo"}`},
+		[2]string{"orders", `{"id":"b","item":"bar"}`},
+		[2]string{"other", `{"id":"a","passthrough":true}`},
+	)
+	assert.Equal(t, []string{`{"id":"a","passthrough":true}`}, readJoinMessages(t, j))
+
+	writeJoinMessages(t, j,
+		[2]string{"payments", `{"id":"a","amount":10}`},
+		[2]string{"payments", `{"id":"a","amount":20}`},
+	)
+	assert.Equal(t, []string{
+		`{"orders":{"id":"a","item":"foo"},"payments":{"amount":10,"id":"a"}}`,
+		`{"orders":{"id":"a","item":"foo"},"payments":{"amount":20,"id":"a"}}`,
+	}, readJoinMessages(t, j))
+
+	// Unmatched messages are dropped at the end of input
+	j.EndOfInput()
+	_, _, err := j.ReadBatch(context.Background())
+	assert.Equal(t, service.ErrEndOfBuffer, err)
+}
+
+func TestJoinBufferLeftExpiry(t *testing.T) {
+	j, now, nowMut := newTestJoinBuffer(t, "left")
+
+	writeJoinMessages(t, j,
+		[2]string{"orders", `{"id":"a","item":"foo"}`},
+		[2]string{"orders", `{"id":"b","item":"bar"}`},
+		[2]string{"payments", `{"id":"c","amount":30}`},
+	)
+
+	nowMut.Lock()
+	*now = now.Add(time.Second * 30)
+	nowMut.Unlock()
+
+	writeJoinMessages(t, j,
+		[2]string{"payments", `{"id":"a","amount":10}`},
+	)
+	assert.Equal(t, []string{
+		`{"orders":{"id":"a","item":"foo"},"payments":{"amount":10,"id":"a"}}`,
+	}, readJoinMessages(t, j))
+
+	nowMut.Lock()
+	*now = now.Add(time.Minute)
+	nowMut.Unlock()
+
+	writeJoinMessages(t, j,
+		[2]string{"payments", `{"id":"b","amount":20}`},
+	)
+	assert.Equal(t, []string{
+		`{"orders":{"id":"b","item":"bar"},"payments":null}`,
+	}, readJoinMessages(t, j))
+
+	// Key b has a new window after expiring, and therefore the payment is not
+	// emitted as it is unmatched within a left join.
+	j.EndOfInput()
+	_, _, err := j.ReadBatch(context.Background())
+	assert.Equal(t, service.ErrEndOfBuffer, err)
+}
+
+func TestJoinBufferFullEndOfInput(t *testing.T) {
+	j, _, _ := newTestJoinBuffer(t, "full")
+
+	orderMsg := service.NewMessage([]byte(`{"id":"a","item":"foo"}`))
+	orderMsg.MetaSet("source", "orders")
+	orderMsg.MetaSet("foo", "bar")
+	require.NoError(t, j.WriteBatch(context.Background(), service.MessageBatch{orderMsg}, func(context.Context, error) error {
+		return nil
+	}))
+
+	writeJoinMessages(t, j,
+		[2]string{"payments", `{"id":"b","amount":20}`},
+	)
+
+	j.EndOfInput()
+
+	batch, aFn, err := j.ReadBatch(context.Background())
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+	require.NoError(t, aFn(context.Background(), nil))
+
+	var results []string
+	for _, m := range batch {
+		mBytes, err := m.AsBytes()
+		require.NoError(t, err)
+		results = append(results, string(mBytes))
+	}
+	assert.Equal(t, []string{
+		`{"orders":{"id":"a","item":"foo"},"payments":null}`,
+		`{"orders":null,"payments":{"amount":20,"id":"b"}}`,
+	}, results)
+
+	v, exists := batch[0].MetaGet("foo")
+	assert.True(t, exists)
+	assert.Equal(t, "bar", v)
+
+	_, _, err = j.ReadBatch(context.Background())
+	assert.Equal(t, service.ErrEndOfBuffer, err)
+}
+
+func TestJoinBufferWaitsForExpiry(t *testing.T) {
+	conf, err := joinBufferConfig().ParseYAML(`
+cache: joins
+key: 'root = this.id'
+left: orders
+right: payments
+type: left
+window: 10ms
+`, nil)
+	require.NoError(t, err)
+
+	j, err := newJoinBufferFromConfig(conf, service.MockResources(service.MockResourcesOptAddCache("joins")))
+	require.NoError(t, err)
+
+	writeJoinMessages(t, j,
+		[2]string{"orders", `{"id":"a","item":"foo"}`},
+	)
+	assert.Equal(t, []string{
+		`{"orders":{"id":"a","item":"foo"},"payments":null}`,
+	}, readJoinMessages(t, j))
+}
+
+func TestJoinBufferPassesFailedMessages(t *testing.T) {
+	conf, err := joinBufferConfig().ParseYAML(`
+cache: joins
+key: 'root = if this.id == null { deleted() } else { this.id }'
+left: orders
+right: payments
+window: 1m
+`, nil)
+	require.NoError(t, err)
+
+	j, err := newJoinBufferFromConfig(conf, service.MockResources(service.MockResourcesOptAddCache("joins")))
+	require.NoError(t, err)
+
+	writeJoinMessages(t, j,
+		[2]string{"orders", `{"item":"foo"}`},
+		[2]string{"payments", `not structured`},
+		[2]string{"orders", `{"id":"a","item":"bar"}`},
+	)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+
+	batch, aFn, err := j.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.NoError(t, aFn(ctx, nil))
+	require.Len(t, batch, 2)
+
+	for i, exp := range []struct {
+		content string
+		err     string
+	}{
+		{content: `{"item":"foo"}`, err: "key mapping failed: mapping resulted in a deleted message"},
+		{content: `not structured`, err: "key mapping failed"},
+	} {
+		mBytes, err := batch[i].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, exp.content, string(mBytes))
+		require.Error(t, batch[i].GetError())
+		assert.Contains(t, batch[i].GetError().Error(), exp.err)
+	}
+}
+
+func TestJoinBufferNackRequeues(t *testing.T) {
+	j, _, _ := newTestJoinBuffer(t, "inner")
+
+	writeJoinMessages(t, j,
+		[2]string{"other", `{"id":"a"}`},
+	)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+
+	batch, aFn, err := j.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	j.EndOfInput()
+	require.NoError(t, aFn(ctx, errors.New("nope")))
+
+	assert.Equal(t, []string{`{"id":"a"}`}, readJoinMessages(t, j))
+
+	_, _, err = j.ReadBatch(ctx)
+	assert.Equal(t, service.ErrEndOfBuffer, err)
+}
+
+func TestJoinBufferFailedWriteRestoresCache(t *testing.T) {
+	conf, err := joinBufferConfig().ParseYAML(`
+cache: joins
+key: 'root = this.id'
+left: orders
+right: payments
+window: 1m
+`, nil)
+	require.NoError(t, err)
+
+	var cacheItems map[string]mock.CacheItem
+	j, err := newJoinBufferFromConfig(conf, service.MockResources(func(m *mock.Manager) {
+		cacheItems = map[string]mock.CacheItem{
+			"b": {Value: "not a join group"},
+		}
+		m.Caches["joins"] = cacheItems
+	}))
+	require.NoError(t, err)
+
+	writeJoinMessages(t, j,
+		[2]string{"payments", `{"id":"a","amount":10}`},
+	)
+
+	var batch service.MessageBatch
+	for _, m := range []string{`{"id":"a","item":"foo"}`, `{"id":"b","item":"bar"}`} {
+		msg := service.NewMessage([]byte(m))
+		msg.MetaSet("source", "orders")
+		batch = append(batch, msg)
+	}
+	require.Error(t, j.WriteBatch(context.Background(), batch, func(ctx context.Context, err error) error {
+		t.Error("batch should not be acked")
+		return nil
+	}))
+
+	// The order of key a was stored before the batch failed, which must be
+	// reverted along with the joined message it produced.
+	group, err := j.getGroup(context.Background(), "a")
+	require.NoError(t, err)
+	assert.Len(t, group.Left, 0)
+	require.Len(t, group.Right, 1)
+	assert.False(t, group.Right[0].Matched)
+	assert.Empty(t, j.pending)
+}
+
+func TestJoinBufferBackpressure(t *testing.T) {
+	conf, err := joinBufferConfig().ParseYAML(`
+cache: joins
+key: 'root = this.id'
+left: orders
+right: payments
+window: 1m
+max_pending: 1
+`, nil)
+	require.NoError(t, err)
+
+	j, err := newJoinBufferFromConfig(conf, service.MockResources(service.MockResourcesOptAddCache("joins")))
+	require.NoError(t, err)
+
+	writeJoinMessages(t, j,
+		[2]string{"other", `{"id":"a"}`},
+	)
+
+	msg := service.NewMessage([]byte(`{"id":"b"}`))
+	msg.MetaSet("source", "other")
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer done()
+	assert.Equal(t, context.DeadlineExceeded, j.WriteBatch(ctx, service.MessageBatch{msg}, func(context.Context, error) error {
+		return nil
+	}))
+
+	writeErr := make(chan error, 1)
+	go func() {
+		writeErr <- j.WriteBatch(context.Background(), service.MessageBatch{msg}, func(context.Context, error) error {
+			return nil
+		})
+	}()
+
+	assert.Equal(t, []string{`{"id":"a"}`}, readJoinMessages(t, j))
+	select {
+	case err := <-writeErr:
+		require.NoError(t, err)
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for blocked write")
+	}
+	assert.Equal(t, []string{`{"id":"b"}`}, readJoinMessages(t, j))
+}
@@ -0,0 +1,115 @@
+package pure
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/circuitbreaker"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const circuitBreakerDescription = `
+### Circuit States
+
+While the circuit is ` + "`closed`" + ` all requests are attempted and their results are tracked. Once at least ` + "`min_requests`" + ` requests have been attempted within the ` + "`window`" + ` and the ratio of those requests that failed reaches ` + "`error_threshold`" + ` the circuit is ` + "`open`" + `.
+
+While the circuit is ` + "`open`" + ` requests are not attempted for the ` + "`open_period`" + `, after which the circuit is ` + "`half_open`" + ` and up to ` + "`half_open_requests`" + ` probe requests are attempted. If all probes succeed the circuit is closed again, otherwise it is reopened.
+
+### Metrics
+
+The gauge ` + "`circuit_breaker_state`" + ` reports the current state of the circuit, where ` + "`0`" + ` is closed, ` + "`1`" + ` is half open and ` + "`2`" + ` is open. The counter ` + "`circuit_breaker_opened`" + ` is incremented each time the circuit opens, and the counter ` + "`circuit_breaker_rejected`" + ` is incremented for each request that is not attempted because the circuit is open.
+
+### HTTP API
+
+The current state of the circuit, along with the number of requests and failures within the current window, can be obtained as a JSON object with a GET request to the endpoint ` + "`/circuit_breakers/{label}`" + `, where ` + "`{label}`" + ` is the label of the component. This endpoint is only registered for components that have a label.`
+
+func circuitBreakerFields() []*service.ConfigField {
+	return []*service.ConfigField{
+		service.NewFloatField("error_threshold").
+			Description("The ratio of failed requests within the `window`, between 0 and 1, at or above which the circuit opens.").
+			Default(0.5),
+		service.NewIntField("min_requests").
+			Description("The minimum number of requests that must be attempted within the `window` before the `error_threshold` is considered.").
+			Default(10),
+		service.NewDurationField("window").
+			Description("The sliding period of time over which the error rate of requests is measured.").
+			Default("30s"),
+		service.NewDurationField("open_period").
+			Description("The period of time for which requests are not attempted once the circuit opens, after which the circuit is half open.").
+			Default("30s"),
+		service.NewIntField("half_open_requests").
+			Description("The number of probe requests attempted while the circuit is half open, all of which must succeed in order for the circuit to close.").
+			Default(1).
+			Advanced(),
+	}
+}
+
+// circuitBreakerFromConfig creates a circuit breaker from a parsed config
+// containing the fields of circuitBreakerFields, and hooks it up to metrics
+// and the HTTP API of the component.
+func circuitBreakerFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*circuitbreaker.Breaker, error) {
+	var bConf circuitbreaker.Config
+
+	var err error
+	if bConf.ErrorThreshold, err = conf.FieldFloat("error_threshold"); err != nil {
+		return nil, err
+	}
+	if bConf.MinRequests, err = conf.FieldInt("min_requests"); err != nil {
+		return nil, err
+	}
+	if bConf.Window, err = conf.FieldDuration("window"); err != nil {
+		return nil, err
+	}
+	if bConf.OpenPeriod, err = conf.FieldDuration("open_period"); err != nil {
+		return nil, err
+	}
+	if bConf.HalfOpenRequests, err = conf.FieldInt("half_open_requests"); err != nil {
+		return nil, err
+	}
+
+	breaker, err := circuitbreaker.New(bConf)
+	if err != nil {
+		return nil, err
+	}
+
+	log := mgr.Logger()
+	mState := mgr.Metrics().NewGauge("circuit_breaker_state")
+	mOpened := mgr.Metrics().NewCounter("circuit_breaker_opened")
+
+	mState.Set(int64(circuitbreaker.StateClosed))
+	breaker.OnStateChange(func(from, to circuitbreaker.State) {
+		mState.Set(int64(to))
+		if to == circuitbreaker.StateOpen {
+			mOpened.Incr(1)
+			log.Warnf("Circuit breaker has opened")
+		} else {
+			log.Infof("Circuit breaker has changed state from %v to %v", from, to)
+		}
+	})
+
+	// Endpoints are identified by label, and so components without one would
+	// overwrite each other.
+	if mgr.Label() == "" {
+		return breaker, nil
+	}
+	if nm, ok := mgr.XUnwrapper().(interface {
+		Unwrap() bundle.NewManagement
+	}); ok {
+		nm.Unwrap().RegisterEndpoint(
+			path.Join("/circuit_breakers", mgr.Label()),
+			"Returns the current state of a circuit breaker.",
+			func(w http.ResponseWriter, r *http.Request) {
+				resBytes, err := json.Marshal(breaker.Status())
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write(resBytes)
+			},
+		)
+	}
+	return breaker, nil
+}
@@ -81,12 +81,32 @@ func (i *fanInInputBroker) loop() {
 	}
 }
 
+func (i *fanInInputBroker) InFlight() (int, bool) {
+	total, known := 0, true
+	for _, in := range i.closables {
+		n, ok := component.InFlightOf(in)
+		total += n
+		known = known && ok
+	}
+	return total, known
+}
+
 func (i *fanInInputBroker) CloseAsync() {
 	for _, closable := range i.closables {
 		closable.CloseAsync()
 	}
 }
 
+func (i *fanInInputBroker) WaitForStopConsuming(timeout time.Duration) error {
+	stopBy := time.Now().Add(timeout)
+	for _, in := range i.closables {
+		if err := input.WaitForStopConsuming(in, time.Until(stopBy)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (i *fanInInputBroker) WaitForClose(timeout time.Duration) error {
 	select {
 	case <-i.closedChan:
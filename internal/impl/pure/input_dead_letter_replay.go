@@ -0,0 +1,134 @@
+package pure
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func deadLetterReplayInputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Version("4.4.0").
+		Categories("Utility").
+		Summary("Reads messages from a child input that consumes a dead letter queue written by the [`dead_letter` output](/docs/components/outputs/dead_letter), and prepares them to be replayed through the pipeline.").
+		Description(`
+The failure context added by the `+"`dead_letter`"+` output is removed from each message so that replayed messages are indistinguishable from fresh ones, with the exception of the following metadata fields:
+
+`+"``` text"+`
+- dead_letter_replays
+- dead_letter_timestamp_unix
+`+"```"+`
+
+The field `+"`dead_letter_replays`"+` counts the number of times a message has been replayed, and can be used in order to avoid replaying messages indefinitely. The field `+"`dead_letter_timestamp_unix`"+` is preserved so that the time of the original failure is kept should the message be routed to a dead letter output again.
+
+Messages that originate from the child input are acknowledged only once they have been successfully processed and delivered by the pipeline.`).
+		Field(service.NewInputField("input").
+			Description("A child input that consumes a dead letter queue.")).
+		Field(service.NewIntField("max_replays").
+			Description("The maximum number of times a message can be replayed, messages that exceed this limit are logged and acknowledged without being replayed. If set to zero there is no limit.").
+			Default(0).
+			Advanced()).
+		Example(
+			"Replaying a Kafka Dead Letter Topic",
+			"In this example messages written to a Kafka topic by a `dead_letter` output are consumed and reprocessed, with each message being given at most three replays.",
+			`
+input:
+  dead_letter_replay:
+    max_replays: 3
+    input:
+      kafka:
+        addresses: [ localhost:9092 ]
+        topics: [ messages_dlq ]
+        consumer_group: benthos_dlq_replay
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchInput(
+		"dead_letter_replay", deadLetterReplayInputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			return newDeadLetterReplayInputFromConfig(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type deadLetterReplayInput struct {
+	log *service.Logger
+
+	input      *service.OwnedInput
+	maxReplays int
+}
+
+func newDeadLetterReplayInputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*deadLetterReplayInput, error) {
+	r := &deadLetterReplayInput{
+		log: mgr.Logger(),
+	}
+
+	var err error
+	if r.maxReplays, err = conf.FieldInt("max_replays"); err != nil {
+		return nil, err
+	}
+	if r.input, err = conf.FieldInput("input"); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *deadLetterReplayInput) Connect(ctx context.Context) error {
+	return nil
+}
+
+// prepareReplay strips the failure context from a dead lettered message and
+// increments its replay count, returning false if the message has exceeded
+// the maximum number of replays.
+func (r *deadLetterReplayInput) prepareReplay(msg *service.Message) bool {
+	replays := 0
+	if replaysStr, exists := msg.MetaGet(dlqMetaReplays); exists {
+		replays, _ = strconv.Atoi(replaysStr)
+	}
+	replays++
+	if r.maxReplays > 0 && replays > r.maxReplays {
+		reason, _ := msg.MetaGet(dlqMetaError)
+		r.log.Warnf("Discarding dead lettered message that exceeded %v replays, last error: %v", r.maxReplays, reason)
+		return false
+	}
+
+	for _, k := range []string{dlqMetaReason, dlqMetaError, dlqMetaLabel, dlqMetaAttempts} {
+		msg.MetaDelete(k)
+	}
+	msg.MetaSet(dlqMetaReplays, strconv.Itoa(replays))
+	return true
+}
+
+func (r *deadLetterReplayInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	for {
+		batch, ackFn, err := r.input.ReadBatch(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		replayBatch := make(service.MessageBatch, 0, len(batch))
+		for _, msg := range batch {
+			if r.prepareReplay(msg) {
+				replayBatch = append(replayBatch, msg)
+			}
+		}
+		if len(replayBatch) == 0 {
+			if err := ackFn(ctx, nil); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		return replayBatch, ackFn, nil
+	}
+}
+
+func (r *deadLetterReplayInput) Close(ctx context.Context) error {
+	return r.input.Close(ctx)
+}
@@ -116,6 +116,14 @@ func (o *fanOutOutputBroker) loop() {
 	}
 }
 
+func (o *fanOutOutputBroker) InFlight() (int, bool) {
+	return output.InFlightSum(o.outputs)
+}
+
+func (o *fanOutOutputBroker) TriggerFlush() {
+	output.TriggerFlushAll(o.outputs)
+}
+
 func (o *fanOutOutputBroker) CloseAsync() {
 	o.shutSig.CloseAtLeisure()
 }
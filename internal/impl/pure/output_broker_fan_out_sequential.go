@@ -123,6 +123,14 @@ func (o *fanOutSequentialOutputBroker) loop() {
 	}
 }
 
+func (o *fanOutSequentialOutputBroker) InFlight() (int, bool) {
+	return output.InFlightSum(o.outputs)
+}
+
+func (o *fanOutSequentialOutputBroker) TriggerFlush() {
+	output.TriggerFlushAll(o.outputs)
+}
+
 func (o *fanOutSequentialOutputBroker) CloseAsync() {
 	o.shutSig.CloseAtLeisure()
 }
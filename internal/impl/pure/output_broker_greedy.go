@@ -36,6 +36,14 @@ func (g *greedyOutputBroker) Connected() bool {
 	return true
 }
 
+func (g *greedyOutputBroker) InFlight() (int, bool) {
+	return output.InFlightSum(g.outputs)
+}
+
+func (g *greedyOutputBroker) TriggerFlush() {
+	output.TriggerFlushAll(g.outputs)
+}
+
 func (g *greedyOutputBroker) CloseAsync() {
 	for _, out := range g.outputs {
 		out.CloseAsync()
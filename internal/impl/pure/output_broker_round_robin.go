@@ -92,6 +92,14 @@ func (o *roundRobinOutputBroker) loop() {
 	}
 }
 
+func (o *roundRobinOutputBroker) InFlight() (int, bool) {
+	return output.InFlightSum(o.outputs)
+}
+
+func (o *roundRobinOutputBroker) TriggerFlush() {
+	output.TriggerFlushAll(o.outputs)
+}
+
 func (o *roundRobinOutputBroker) CloseAsync() {
 	if atomic.CompareAndSwapInt32(&o.running, 1, 0) {
 		close(o.closeChan)
@@ -0,0 +1,136 @@
+package pure
+
+import (
+	"context"
+
+	"github.com/benthosdev/benthos/v4/internal/circuitbreaker"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func circuitBreakerOutputSpec() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		Version("4.4.0").
+		Categories("Utility").
+		Summary("Writes messages to a child output through a circuit breaker, which stops writes from being attempted while the error rate of the child output is too high.").
+		Description(`
+Outputs that fail to write messages will usually have them reattempted, either by the input that produced them or by an output such as ` + "[`retry`](/docs/components/outputs/retry)" + `. When a downstream service is struggling this results in a continuous stream of attempts that could potentially prolong an outage.
+
+A circuit breaker tracks the ratio of writes that fail, and once it is too high writes are no longer attempted for a period of time. During this time messages are either written to the ` + "`fallback`" + ` output, if one is configured, or rejected immediately, in which case they are nacked.
+` + circuitBreakerDescription).
+		Field(service.NewOutputField("output").
+			Description("A child output to write messages to through the circuit breaker.")).
+		Field(service.NewOutputField("fallback").
+			Description("An optional output to write messages to while the circuit is open. If omitted messages are rejected while the circuit is open.").
+			Optional())
+
+	for _, f := range circuitBreakerFields() {
+		spec = spec.Field(f)
+	}
+
+	return spec.
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of message batches to have in flight at a given time.").
+			Default(64).
+			Advanced()).
+		Example(
+			"Protecting an HTTP Service",
+			"In this example writes to an HTTP endpoint are no longer attempted for one minute once half of the writes over the last thirty seconds have failed, and messages are instead written to a file during that time.",
+			`
+output:
+  label: foo_endpoint
+  circuit_breaker:
+    error_threshold: 0.5
+    window: 30s
+    open_period: 1m
+    output:
+      http_client:
+        url: http://example.com/messages
+        verb: POST
+    fallback:
+      file:
+        path: ./fallback/${! timestamp_unix() }.jsonl
+        codec: lines
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput(
+		"circuit_breaker", circuitBreakerOutputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, pol service.BatchPolicy, maxInFlight int, err error) {
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			out, err = newCircuitBreakerOutputFromConfig(conf, mgr)
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type circuitBreakerOutput struct {
+	breaker  *circuitbreaker.Breaker
+	output   *service.OwnedOutput
+	fallback *service.OwnedOutput
+
+	mRejected *service.MetricCounter
+}
+
+func newCircuitBreakerOutputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*circuitBreakerOutput, error) {
+	c := &circuitBreakerOutput{
+		mRejected: mgr.Metrics().NewCounter("circuit_breaker_rejected"),
+	}
+
+	var err error
+	if c.breaker, err = circuitBreakerFromConfig(conf, mgr); err != nil {
+		return nil, err
+	}
+	if c.output, err = conf.FieldOutput("output"); err != nil {
+		return nil, err
+	}
+	if conf.Contains("fallback") {
+		if c.fallback, err = conf.FieldOutput("fallback"); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *circuitBreakerOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (c *circuitBreakerOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	done, err := c.breaker.Allow()
+	if err != nil {
+		c.mRejected.Incr(int64(len(batch)))
+		if c.fallback != nil {
+			return c.fallback.WriteBatch(ctx, batch)
+		}
+		return err
+	}
+
+	err = c.output.WriteBatch(ctx, batch)
+	if err != nil && ctx.Err() != nil {
+		// Shutting down is not a reflection of the health of the output.
+		done(nil)
+		return err
+	}
+	done(err)
+	return err
+}
+
+func (c *circuitBreakerOutput) Close(ctx context.Context) error {
+	c.breaker.Close()
+
+	if err := c.output.Close(ctx); err != nil {
+		return err
+	}
+	if c.fallback != nil {
+		return c.fallback.Close(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package pure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/circuitbreaker"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestCircuitBreakerOutputFallback(t *testing.T) {
+	child, fallback := &dlqTestOutput{failures: 2}, &dlqTestOutput{}
+	env := dlqTestEnv(t, map[string]*dlqTestOutput{"child_out": child, "fallback_out": fallback})
+
+	conf, err := circuitBreakerOutputSpec().ParseYAML(`
+error_threshold: 0.5
+min_requests: 2
+window: 1m
+open_period: 1m
+output:
+  child_out: {}
+fallback:
+  fallback_out: {}
+`, env)
+	require.NoError(t, err)
+
+	c, err := newCircuitBreakerOutputFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.Error(t, c.WriteBatch(tCtx, dlqTestBatch("foo")))
+	require.Error(t, c.WriteBatch(tCtx, dlqTestBatch("bar")))
+	assert.Equal(t, circuitbreaker.StateOpen, c.breaker.State())
+
+	require.NoError(t, c.WriteBatch(tCtx, dlqTestBatch("baz")))
+	require.NoError(t, c.Close(tCtx))
+
+	assert.Empty(t, child.getBatches())
+	require.Len(t, fallback.getBatches(), 1)
+
+	mBytes, err := fallback.getBatches()[0][0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "baz", string(mBytes))
+}
+
+func TestCircuitBreakerOutputRejected(t *testing.T) {
+	child := &dlqTestOutput{failures: 1}
+	env := dlqTestEnv(t, map[string]*dlqTestOutput{"child_out": child})
+
+	conf, err := circuitBreakerOutputSpec().ParseYAML(`
+min_requests: 1
+open_period: 1m
+output:
+  child_out: {}
+`, env)
+	require.NoError(t, err)
+
+	c, err := newCircuitBreakerOutputFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.Error(t, c.WriteBatch(tCtx, dlqTestBatch("foo")))
+	assert.Equal(t, circuitbreaker.ErrOpen, c.WriteBatch(tCtx, dlqTestBatch("bar")))
+	require.NoError(t, c.Close(tCtx))
+
+	assert.Empty(t, child.getBatches())
+}
@@ -0,0 +1,422 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OneOfOne/xxhash"
+	"github.com/cenkalti/backoff/v4"
+	"gopkg.in/yaml.v3"
+
+	ibatch "github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	dlqMetaReason    = "dead_letter_reason"
+	dlqMetaError     = "dead_letter_error"
+	dlqMetaLabel     = "dead_letter_label"
+	dlqMetaAttempts  = "dead_letter_attempts"
+	dlqMetaTimestamp = "dead_letter_timestamp_unix"
+	dlqMetaReplays   = "dead_letter_replays"
+
+	dlqReasonProcessing = "processing"
+	dlqReasonDelivery   = "delivery"
+
+	// The maximum number of messages that failed processing to track the
+	// attempts of at a given time.
+	dlqMaxTrackedFailures = 10000
+)
+
+var dlqDefaultTimestampMetadata = []string{
+	"kafka_timestamp_unix",
+	"gcp_pubsub_publish_time_unix",
+	"pulsar_event_time_unix",
+	"pulsar_publish_time_unix",
+	"amqp_timestamp",
+}
+
+func deadLetterOutputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Version("4.4.0").
+		Categories("Utility").
+		Summary("Attempts to write messages to a child output and, once a number of delivery or processing attempts have failed, routes them to a dead letter output annotated with the cause of the failure.").
+		Description(`
+Messages that fail to be delivered to the child `+"`output`"+` are reattempted up to `+"`max_retries`"+` times. If all attempts fail the messages are written to the `+"`dlq`"+` output instead, and are only nacked if that write also fails.
+
+Messages that were flagged as having failed processing upstream are, by default, never sent to the child output. Instead they are rejected so that the input reattempts them, which also reattempts their processing, and once a message has failed processing more than `+"`max_retries`"+` times it is written to the `+"`dlq`"+` output. Messages are identified across attempts by their contents and metadata, and therefore inputs that do not reattempt rejected messages should be combined with a `+"`max_retries`"+` of zero in order to route processing failures immediately.
+
+This output is therefore a more convenient alternative to combining `+"[`switch`](/docs/components/outputs/switch)"+`, `+"[`fallback`](/docs/components/outputs/fallback)"+` and `+"[`catch`](/docs/components/processors/catch)"+` in order to implement a dead letter queue.
+
+### Metadata
+
+Messages written to the `+"`dlq`"+` output have the following metadata fields added:
+
+`+"``` text"+`
+- dead_letter_reason
+- dead_letter_error
+- dead_letter_label
+- dead_letter_attempts
+- dead_letter_timestamp_unix
+`+"```"+`
+
+The field `+"`dead_letter_reason`"+` is either `+"`processing`"+` or `+"`delivery`"+`, and `+"`dead_letter_error`"+` contains the error that caused the message to be routed. For delivery failures `+"`dead_letter_label`"+` is the label of the child output, and for processing failures it is the label of the processor that flagged the error. When that component has no label the label of this output is used instead.
+
+When the child output reports which messages of a batch failed only those messages are retried and routed to the `+"`dlq`"+` output, and the messages that were delivered are acknowledged.
+
+The field `+"`dead_letter_attempts`"+` is the number of delivery attempts made against the child output, or for processing failures the number of times the message failed processing. The field `+"`dead_letter_timestamp_unix`"+` is the original time of the message taken from the first metadata field of `+"`timestamp_metadata`"+` that contains a unix timestamp, such as the `+"`kafka_timestamp_unix`"+` field added by Kafka inputs, or the time at which the message was first routed to a dead letter output when there is none. If the message already has a timestamp, for example because it was replayed with the `+"[`dead_letter_replay`](/docs/components/inputs/dead_letter_replay)"+` input, then the original timestamp is kept.
+
+### Metrics
+
+The counter `+"`output_dead_letter_routed`"+`, labelled by `+"`reason`"+`, is incremented for each message written to the `+"`dlq`"+` output.`).
+		Field(service.NewOutputField("output").
+			Description("A child output to deliver messages to.")).
+		Field(service.NewOutputField("dlq").
+			Description("An output to route messages to once they have failed processing or delivery.")).
+		Field(service.NewIntField("max_retries").
+			Description("The maximum number of times a failed delivery to the child output, or a message that failed processing, is reattempted before messages are routed to the `dlq` output. If set to zero messages are routed after the first failure.").
+			Default(3)).
+		Field(service.NewBackOffField("backoff", false, &backoff.ExponentialBackOff{
+			InitialInterval: time.Millisecond * 500,
+			MaxInterval:     time.Second * 3,
+			MaxElapsedTime:  time.Minute,
+		}).Advanced()).
+		Field(service.NewBoolField("route_processing_errors").
+			Description("Whether messages flagged as having failed processing should be routed to the `dlq` output rather than the child output.").
+			Default(true).
+			Advanced()).
+		Field(service.NewStringListField("timestamp_metadata").
+			Description("A list of metadata fields that may contain the original time of a message as a unix timestamp, where the first field present is used as the `dead_letter_timestamp_unix` of routed messages.").
+			Default(dlqDefaultTimestampMetadata).
+			Advanced()).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of message batches to have in flight at a given time.").
+			Default(64).
+			Advanced()).
+		Example(
+			"Kafka Dead Letter Topic",
+			"In this example messages that fail processing, or that cannot be written to an HTTP endpoint after three retries, are written to a Kafka topic along with the reason for the failure.",
+			`
+output:
+  dead_letter:
+    max_retries: 3
+    output:
+      http_client:
+        url: http://example.com/messages
+        verb: POST
+    dlq:
+      kafka:
+        addresses: [ localhost:9092 ]
+        topic: messages_dlq
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput(
+		"dead_letter", deadLetterOutputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, pol service.BatchPolicy, maxInFlight int, err error) {
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			out, err = newDeadLetterOutputFromConfig(conf, mgr)
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type deadLetterOutput struct {
+	log *service.Logger
+
+	output     *service.OwnedOutput
+	dlq        *service.OwnedOutput
+	label      string
+	childLabel string
+
+	maxRetries       int
+	backoffCtor      func() backoff.BackOff
+	routeProcessErrs bool
+	timestampMeta    []string
+
+	procFailuresMut sync.Mutex
+	procFailures    map[uint64]int
+
+	mRouted *service.MetricCounter
+}
+
+func newDeadLetterOutputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*deadLetterOutput, error) {
+	d := &deadLetterOutput{
+		log:          mgr.Logger(),
+		procFailures: map[uint64]int{},
+		mRouted:      mgr.Metrics().NewCounter("output_dead_letter_routed", "reason"),
+	}
+
+	var err error
+	if d.maxRetries, err = conf.FieldInt("max_retries"); err != nil {
+		return nil, err
+	}
+	if d.maxRetries < 0 {
+		return nil, errors.New("max_retries must not be negative")
+	}
+
+	boff, err := conf.FieldBackOff("backoff")
+	if err != nil {
+		return nil, err
+	}
+	d.backoffCtor = func() backoff.BackOff {
+		boffCopy := *boff
+		return &boffCopy
+	}
+
+	if d.routeProcessErrs, err = conf.FieldBool("route_processing_errors"); err != nil {
+		return nil, err
+	}
+	if d.timestampMeta, err = conf.FieldStringList("timestamp_metadata"); err != nil {
+		return nil, err
+	}
+
+	d.label = mgr.Label()
+	d.childLabel = d.label
+	if childLabel := outputLabelFromParsed(conf, "output"); childLabel != "" {
+		d.childLabel = childLabel
+	}
+
+	if d.output, err = conf.FieldOutput("output"); err != nil {
+		return nil, err
+	}
+	if d.dlq, err = conf.FieldOutput("dlq"); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// outputLabelFromParsed attempts to extract the label of a child output field
+// without instantiating it, returning an empty string if it has none.
+func outputLabelFromParsed(conf *service.ParsedConfig, path ...string) string {
+	v, err := conf.FieldAny(path...)
+	if err != nil {
+		return ""
+	}
+	node, ok := v.(*yaml.Node)
+	if !ok {
+		return ""
+	}
+	var labelled struct {
+		Label string `yaml:"label"`
+	}
+	if err := node.Decode(&labelled); err != nil {
+		return ""
+	}
+	return labelled.Label
+}
+
+func (d *deadLetterOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+// writeWithRetries attempts to deliver a batch to the child output, where only
+// the messages that failed are reattempted. Returns the number of attempts made
+// and the messages that could not be delivered along with their errors.
+func (d *deadLetterOutput) writeWithRetries(ctx context.Context, batch service.MessageBatch) (int, service.MessageBatch, []error) {
+	var boff backoff.BackOff
+	attempts := 0
+	for {
+		attempts++
+		err := d.output.WriteBatch(ctx, batch)
+		if err == nil {
+			return attempts, nil, nil
+		}
+
+		failed, errs := failedMessages(batch, err)
+		if attempts > d.maxRetries || ctx.Err() != nil {
+			return attempts, failed, errs
+		}
+		batch = failed
+
+		d.log.Warnf("Failed to send message batch, attempt %v of %v: %v", attempts, d.maxRetries+1, err)
+		if boff == nil {
+			boff = d.backoffCtor()
+		}
+		nextBackoff := boff.NextBackOff()
+		if nextBackoff == backoff.Stop {
+			return attempts, failed, errs
+		}
+		select {
+		case <-time.After(nextBackoff):
+		case <-ctx.Done():
+			return attempts, failed, errs
+		}
+	}
+}
+
+// failedMessages returns the messages of a batch that failed to be delivered
+// along with their errors. When the error does not indicate which messages
+// failed then they all did.
+func failedMessages(batch service.MessageBatch, err error) (failed service.MessageBatch, errs []error) {
+	if walkable, ok := err.(ibatch.WalkableError); ok && walkable.IndexedErrors() > 0 {
+		walkable.WalkParts(func(i int, _ *message.Part, partErr error) bool {
+			if partErr != nil && i < len(batch) {
+				failed = append(failed, batch[i])
+				errs = append(errs, partErr)
+			}
+			return true
+		})
+		return
+	}
+	for _, msg := range batch {
+		failed = append(failed, msg)
+		errs = append(errs, err)
+	}
+	return
+}
+
+func (d *deadLetterOutput) annotate(msg *service.Message, reason string, err error, label string, attempts int) *service.Message {
+	msg = msg.Copy()
+	msg.MetaSet(dlqMetaReason, reason)
+	msg.MetaSet(dlqMetaError, err.Error())
+	msg.MetaSet(dlqMetaLabel, label)
+	msg.MetaSet(dlqMetaAttempts, strconv.Itoa(attempts))
+	if _, exists := msg.MetaGet(dlqMetaTimestamp); !exists {
+		msg.MetaSet(dlqMetaTimestamp, strconv.FormatInt(d.originalTimestamp(msg), 10))
+	}
+	// Clear the processing error so that it doesn't trip up any error handling
+	// within the dead letter output itself.
+	msg.SetError(nil)
+	return msg
+}
+
+// originalTimestamp returns the unix timestamp of the first timestamp metadata
+// field of a message, or the current time if there are none.
+func (d *deadLetterOutput) originalTimestamp(msg *service.Message) int64 {
+	for _, k := range d.timestampMeta {
+		v, exists := msg.MetaGet(k)
+		if !exists {
+			continue
+		}
+		if ts, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return ts
+		}
+	}
+	return time.Now().Unix()
+}
+
+// fingerprint returns a hash of the contents and metadata of a message, which
+// identifies it when it is reattempted by the input.
+func fingerprint(msg *service.Message) uint64 {
+	h := xxhash.New64()
+	mBytes, _ := msg.AsBytes()
+	_, _ = h.Write(mBytes)
+
+	var keys []string
+	_ = msg.MetaWalk(func(k, _ string) error {
+		keys = append(keys, k)
+		return nil
+	})
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, _ := msg.MetaGet(k)
+		_, _ = h.WriteString("\x00" + k + "\x00" + v)
+	}
+	return h.Sum64()
+}
+
+// processingFailed records that a message failed processing and returns the
+// number of times it has now failed.
+func (d *deadLetterOutput) processingFailed(key uint64) int {
+	d.procFailuresMut.Lock()
+	defer d.procFailuresMut.Unlock()
+
+	attempts, exists := d.procFailures[key]
+	if !exists && len(d.procFailures) >= dlqMaxTrackedFailures {
+		// Messages that are never reattempted would otherwise be tracked
+		// forever, so we make room by forgetting an arbitrary message.
+		for k := range d.procFailures {
+			delete(d.procFailures, k)
+			break
+		}
+	}
+	attempts++
+	d.procFailures[key] = attempts
+	return attempts
+}
+
+func (d *deadLetterOutput) forgetProcessingFailures(keys []uint64) {
+	d.procFailuresMut.Lock()
+	defer d.procFailuresMut.Unlock()
+	for _, k := range keys {
+		delete(d.procFailures, k)
+	}
+}
+
+func (d *deadLetterOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	var toDeliver, dead service.MessageBatch
+	var deadKeys []uint64
+	var rejectErr *ibatch.Error
+	for i, msg := range batch {
+		if procErr := msg.GetError(); procErr != nil && d.routeProcessErrs {
+			key := fingerprint(msg)
+			attempts := d.processingFailed(key)
+			if attempts <= d.maxRetries {
+				if rejectErr == nil {
+					d.log.Warnf("Rejecting message that failed processing, attempt %v of %v: %v", attempts, d.maxRetries+1, procErr)
+					rejectErr = ibatch.NewError(nil, procErr)
+				}
+				rejectErr.Failed(i, procErr)
+				continue
+			}
+
+			label := d.label
+			if procLabel, _ := processor.ErrorLabel(procErr); procLabel != "" {
+				label = procLabel
+			}
+			dead = append(dead, d.annotate(msg, dlqReasonProcessing, procErr, label, attempts))
+			deadKeys = append(deadKeys, key)
+			d.mRouted.Incr(1, dlqReasonProcessing)
+			continue
+		}
+		toDeliver = append(toDeliver, msg)
+	}
+
+	if len(toDeliver) > 0 {
+		attempts, failed, errs := d.writeWithRetries(ctx, toDeliver)
+		if len(failed) > 0 {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			d.log.Errorf("Routing %v messages to dead letter output after %v failed attempts: %v", len(failed), attempts, errs[0])
+			for i, msg := range failed {
+				dead = append(dead, d.annotate(msg, dlqReasonDelivery, errs[i], d.childLabel, attempts))
+			}
+			d.mRouted.Incr(int64(len(failed)), dlqReasonDelivery)
+		}
+	}
+
+	if len(dead) > 0 {
+		if err := d.dlq.WriteBatch(ctx, dead); err != nil {
+			return err
+		}
+		d.forgetProcessingFailures(deadKeys)
+	}
+	if rejectErr != nil {
+		// The batch of the error is attached by the service API.
+		return rejectErr
+	}
+	return nil
+}
+
+func (d *deadLetterOutput) Close(ctx context.Context) error {
+	if err := d.output.Close(ctx); err != nil {
+		return err
+	}
+	return d.dlq.Close(ctx)
+}
@@ -0,0 +1,381 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ibatch "github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type dlqTestOutput struct {
+	mut         sync.Mutex
+	failures    int
+	failContent string
+	batches     []service.MessageBatch
+}
+
+func (d *dlqTestOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (d *dlqTestOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	if d.failures > 0 {
+		d.failures--
+		if d.failContent == "" {
+			return errors.New("simulated failure")
+		}
+
+		// Only the messages matching failContent fail, and the rest of the
+		// batch is delivered.
+		bErr := ibatch.NewError(message.QuickBatch(make([][]byte, len(batch))), errors.New("simulated failure"))
+		var delivered service.MessageBatch
+		for i, msg := range batch {
+			if mBytes, _ := msg.AsBytes(); string(mBytes) == d.failContent {
+				bErr.Failed(i, errors.New("simulated partial failure"))
+			} else {
+				delivered = append(delivered, msg)
+			}
+		}
+		d.batches = append(d.batches, delivered)
+		return bErr
+	}
+	d.batches = append(d.batches, batch)
+	return nil
+}
+
+func (d *dlqTestOutput) Close(ctx context.Context) error {
+	return nil
+}
+
+func (d *dlqTestOutput) getBatches() []service.MessageBatch {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	return d.batches
+}
+
+func dlqTestEnv(t *testing.T, outputs map[string]*dlqTestOutput) *service.Environment {
+	t.Helper()
+
+	env := service.NewEnvironment()
+	for name, out := range outputs {
+		out := out
+		require.NoError(t, env.RegisterBatchOutput(name, service.NewConfigSpec(),
+			func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchOutput, service.BatchPolicy, int, error) {
+				return out, service.BatchPolicy{}, 1, nil
+			}))
+	}
+	return env
+}
+
+func dlqTestBatch(contents ...string) service.MessageBatch {
+	var batch service.MessageBatch
+	for _, c := range contents {
+		batch = append(batch, service.NewMessage([]byte(c)))
+	}
+	return batch
+}
+
+func TestDeadLetterOutputDelivered(t *testing.T) {
+	child, dlq := &dlqTestOutput{failures: 2}, &dlqTestOutput{}
+	env := dlqTestEnv(t, map[string]*dlqTestOutput{"child_out": child, "dlq_out": dlq})
+
+	conf, err := deadLetterOutputSpec().ParseYAML(`
+max_retries: 2
+backoff:
+  initial_interval: 1ms
+  max_interval: 1ms
+output:
+  child_out: {}
+dlq:
+  dlq_out: {}
+`, env)
+	require.NoError(t, err)
+
+	d, err := newDeadLetterOutputFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, d.WriteBatch(tCtx, dlqTestBatch("foo", "bar")))
+	require.NoError(t, d.Close(tCtx))
+
+	require.Len(t, child.getBatches(), 1)
+	assert.Len(t, child.getBatches()[0], 2)
+	assert.Empty(t, dlq.getBatches())
+}
+
+func TestDeadLetterOutputDeliveryFailure(t *testing.T) {
+	child, dlq := &dlqTestOutput{failures: 10}, &dlqTestOutput{}
+	env := dlqTestEnv(t, map[string]*dlqTestOutput{"child_out": child, "dlq_out": dlq})
+
+	conf, err := deadLetterOutputSpec().ParseYAML(`
+max_retries: 1
+backoff:
+  initial_interval: 1ms
+  max_interval: 1ms
+output:
+  label: foo_child
+  child_out: {}
+dlq:
+  dlq_out: {}
+`, env)
+	require.NoError(t, err)
+
+	d, err := newDeadLetterOutputFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	input := dlqTestBatch("foo")
+	input[0].MetaSet(dlqMetaTimestamp, "123")
+
+	require.NoError(t, d.WriteBatch(tCtx, input))
+	require.NoError(t, d.Close(tCtx))
+
+	assert.Empty(t, child.getBatches())
+	require.Len(t, dlq.getBatches(), 1)
+	require.Len(t, dlq.getBatches()[0], 1)
+
+	msg := dlq.getBatches()[0][0]
+	for k, v := range map[string]string{
+		dlqMetaReason:    dlqReasonDelivery,
+		dlqMetaError:     "simulated failure",
+		dlqMetaLabel:     "foo_child",
+		dlqMetaAttempts:  "2",
+		dlqMetaTimestamp: "123",
+	} {
+		actual, _ := msg.MetaGet(k)
+		assert.Equal(t, v, actual, k)
+	}
+}
+
+func TestDeadLetterOutputPartialDeliveryFailure(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRetries string
+		failures   int
+		childSent  [][]string
+		dlqSent    []string
+	}{
+		{
+			name:       "retried",
+			maxRetries: "1",
+			failures:   1,
+			childSent:  [][]string{{"foo", "baz"}, {"bar"}},
+		},
+		{
+			name:       "routed",
+			maxRetries: "1",
+			failures:   2,
+			childSent:  [][]string{{"foo", "baz"}, {}},
+			dlqSent:    []string{"bar"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			child, dlq := &dlqTestOutput{failures: test.failures, failContent: "bar"}, &dlqTestOutput{}
+			env := dlqTestEnv(t, map[string]*dlqTestOutput{"child_out": child, "dlq_out": dlq})
+
+			conf, err := deadLetterOutputSpec().ParseYAML(`
+max_retries: `+test.maxRetries+`
+backoff:
+  initial_interval: 1ms
+  max_interval: 1ms
+output:
+  child_out: {}
+dlq:
+  dlq_out: {}
+`, env)
+			require.NoError(t, err)
+
+			d, err := newDeadLetterOutputFromConfig(conf, service.MockResources())
+			require.NoError(t, err)
+
+			tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+			defer done()
+
+			require.NoError(t, d.WriteBatch(tCtx, dlqTestBatch("foo", "bar", "baz")))
+			require.NoError(t, d.Close(tCtx))
+
+			batchContents := func(batch service.MessageBatch) []string {
+				contents := []string{}
+				for _, msg := range batch {
+					mBytes, err := msg.AsBytes()
+					require.NoError(t, err)
+					contents = append(contents, string(mBytes))
+				}
+				return contents
+			}
+
+			var childSent [][]string
+			for _, b := range child.getBatches() {
+				childSent = append(childSent, batchContents(b))
+			}
+			assert.Equal(t, test.childSent, childSent)
+
+			if test.dlqSent == nil {
+				assert.Empty(t, dlq.getBatches())
+				return
+			}
+			require.Len(t, dlq.getBatches(), 1)
+			assert.Equal(t, test.dlqSent, batchContents(dlq.getBatches()[0]))
+
+			errStr, _ := dlq.getBatches()[0][0].MetaGet(dlqMetaError)
+			assert.Equal(t, "simulated partial failure", errStr)
+		})
+	}
+}
+
+func TestDeadLetterOutputProcessingFailure(t *testing.T) {
+	child, dlq := &dlqTestOutput{}, &dlqTestOutput{}
+	env := dlqTestEnv(t, map[string]*dlqTestOutput{"child_out": child, "dlq_out": dlq})
+
+	conf, err := deadLetterOutputSpec().ParseYAML(`
+max_retries: 0
+output:
+  child_out: {}
+dlq:
+  dlq_out: {}
+`, env)
+	require.NoError(t, err)
+
+	d, err := newDeadLetterOutputFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	input := dlqTestBatch("foo", "bar", "baz")
+	input[1].SetError(errors.New("processing failed"))
+	input[2].SetError(&processor.LabelledError{Label: "foo_proc", Err: errors.New("processing failed")})
+
+	require.NoError(t, d.WriteBatch(tCtx, input))
+	require.NoError(t, d.Close(tCtx))
+
+	require.Len(t, child.getBatches(), 1)
+	require.Len(t, child.getBatches()[0], 1)
+
+	require.Len(t, dlq.getBatches(), 1)
+	require.Len(t, dlq.getBatches()[0], 2)
+
+	label, _ := dlq.getBatches()[0][1].MetaGet(dlqMetaLabel)
+	assert.Equal(t, "foo_proc", label)
+
+	msg := dlq.getBatches()[0][0]
+	mBytes, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(mBytes))
+	assert.NoError(t, msg.GetError())
+
+	reason, _ := msg.MetaGet(dlqMetaReason)
+	assert.Equal(t, dlqReasonProcessing, reason)
+
+	errStr, _ := msg.MetaGet(dlqMetaError)
+	assert.Equal(t, "processing failed", errStr)
+
+	attempts, _ := msg.MetaGet(dlqMetaAttempts)
+	assert.Equal(t, "1", attempts)
+
+	_, exists := msg.MetaGet(dlqMetaTimestamp)
+	assert.True(t, exists)
+}
+
+func TestDeadLetterOutputProcessingRetries(t *testing.T) {
+	child, dlq := &dlqTestOutput{}, &dlqTestOutput{}
+	env := dlqTestEnv(t, map[string]*dlqTestOutput{"child_out": child, "dlq_out": dlq})
+
+	conf, err := deadLetterOutputSpec().ParseYAML(`
+max_retries: 2
+output:
+  child_out: {}
+dlq:
+  dlq_out: {}
+`, env)
+	require.NoError(t, err)
+
+	d, err := newDeadLetterOutputFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	newInput := func() service.MessageBatch {
+		input := dlqTestBatch("foo", "bar")
+		input[1].MetaSet("kafka_timestamp_unix", "1234")
+		input[1].SetError(errors.New("processing failed"))
+		return input
+	}
+
+	// The message that failed processing is rejected until it has failed
+	// more than max_retries times.
+	for i := 0; i < 2; i++ {
+		err := d.WriteBatch(tCtx, newInput())
+		require.Error(t, err)
+
+		walkable, ok := err.(ibatch.WalkableError)
+		require.True(t, ok, err)
+		assert.Equal(t, 1, walkable.IndexedErrors())
+		assert.Empty(t, dlq.getBatches())
+	}
+
+	require.NoError(t, d.WriteBatch(tCtx, newInput()))
+	require.NoError(t, d.Close(tCtx))
+
+	require.Len(t, child.getBatches(), 3)
+	require.Len(t, dlq.getBatches(), 1)
+	require.Len(t, dlq.getBatches()[0], 1)
+
+	msg := dlq.getBatches()[0][0]
+	attempts, _ := msg.MetaGet(dlqMetaAttempts)
+	assert.Equal(t, "3", attempts)
+
+	timestamp, _ := msg.MetaGet(dlqMetaTimestamp)
+	assert.Equal(t, "1234", timestamp)
+}
+
+func TestDeadLetterReplayPrepare(t *testing.T) {
+	r := &deadLetterReplayInput{
+		log:        service.MockResources().Logger(),
+		maxReplays: 2,
+	}
+
+	msg := service.NewMessage([]byte("foo"))
+	msg.MetaSet(dlqMetaReason, dlqReasonDelivery)
+	msg.MetaSet(dlqMetaError, "nope")
+	msg.MetaSet(dlqMetaLabel, "bar")
+	msg.MetaSet(dlqMetaAttempts, "3")
+	msg.MetaSet(dlqMetaTimestamp, "123")
+
+	require.True(t, r.prepareReplay(msg))
+	for _, k := range []string{dlqMetaReason, dlqMetaError, dlqMetaLabel, dlqMetaAttempts} {
+		_, exists := msg.MetaGet(k)
+		assert.False(t, exists, k)
+	}
+
+	replays, _ := msg.MetaGet(dlqMetaReplays)
+	assert.Equal(t, "1", replays)
+
+	timestamp, _ := msg.MetaGet(dlqMetaTimestamp)
+	assert.Equal(t, "123", timestamp)
+
+	require.True(t, r.prepareReplay(msg))
+	replays, _ = msg.MetaGet(dlqMetaReplays)
+	assert.Equal(t, "2", replays)
+
+	assert.False(t, r.prepareReplay(msg))
+}
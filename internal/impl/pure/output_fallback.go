@@ -210,6 +210,17 @@ func (t *fallbackBroker) loop() {
 	}
 }
 
+// InFlight returns the number of messages held by the child outputs that are
+// yet to be resolved.
+func (t *fallbackBroker) InFlight() (int, bool) {
+	return output.InFlightSum(t.outputs)
+}
+
+// TriggerFlush signals the child outputs to flush any pending batches.
+func (t *fallbackBroker) TriggerFlush() {
+	output.TriggerFlushAll(t.outputs)
+}
+
 // CloseAsync shuts down the fallbackBroker broker and stops processing requests.
 func (t *fallbackBroker) CloseAsync() {
 	t.shutSig.CloseAtLeisure()
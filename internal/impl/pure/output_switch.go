@@ -455,6 +455,14 @@ func (o *switchOutput) loop() {
 	}
 }
 
+func (o *switchOutput) InFlight() (int, bool) {
+	return output.InFlightSum(o.outputs)
+}
+
+func (o *switchOutput) TriggerFlush() {
+	output.TriggerFlushAll(o.outputs)
+}
+
 func (o *switchOutput) CloseAsync() {
 	o.shutSig.CloseAtLeisure()
 }
@@ -0,0 +1,107 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func aggregateProcConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		// Stable(). TODO
+		Version("4.4.0").
+		Categories("Utility").
+		Summary("Maintains running aggregations of messages grouped by a key across batches, and emits the results of those aggregations when a flush is triggered.").
+		Description(`
+Messages that are aggregated are removed from the pipeline, and when a flush is triggered a message is emitted for each key that has been aggregated since the last flush, containing the key under the field ` + "`key`" + ` and the result of each aggregation under a field of its name:
+
+` + "```json" + `
+{"key":"foo","total":13,"latency":{"p50":12.5,"p99":103.2}}
+` + "```" + `
+
+Aggregations are then reset. Messages where the key or a value could not be obtained are flagged as having failed and are passed through unchanged, and can be handled with [error handling patterns](/docs/configuration/error_handling).
+
+### Flushing
+
+Flushes are triggered by the fields within ` + "`flush`" + `, which are evaluated as messages are processed, and their results are emitted immediately. A processor is only able to emit messages whilst processing a batch, and therefore in order to flush aggregations at an interval of time, or when the input of the pipeline ends, use the ` + "[`aggregate` buffer](/docs/components/buffers/aggregate)" + ` instead. Aggregations that have not been flushed when the pipeline is shut down are lost.
+
+### Storage
+
+By default aggregations are stored in memory. When a ` + "`cache`" + ` is specified aggregations are instead stored within that cache resource, which allows them to be shared across processors or persisted. The set of keys waiting to be flushed is always tracked in memory, and therefore aggregations left within a persisted cache after a restart are only flushed once messages of the same key arrive.
+
+` + aggregateApproximationsDocs)
+
+	for _, f := range aggregateFields() {
+		spec = spec.Field(f)
+	}
+
+	return spec.
+		Field(service.NewObjectField("flush", aggregateFlushFields()...).
+			Description("Conditions that trigger a flush of all aggregations, any of which can trigger a flush.")).
+		Example("Per-User Order Totals",
+			"In this example we emit the number of orders, the total spent and the number of distinct items ordered per user for every 1000 users that place orders:",
+			`
+pipeline:
+  processors:
+    - aggregate:
+        key: root = this.user_id
+        aggregations:
+          - name: orders
+            type: count
+          - name: spent
+            type: sum
+            value: root = this.price
+          - name: items
+            type: distinct
+            value: root = this.item_id
+        flush:
+          key_count: 1000
+`)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"aggregate", aggregateProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newAggregateProcFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type aggregateProc struct {
+	mut sync.Mutex
+	agg *aggregator
+}
+
+func newAggregateProcFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*aggregateProc, error) {
+	agg, err := newAggregatorFromConfig(conf, mgr)
+	if err != nil {
+		return nil, err
+	}
+	if agg.flushKeyCount <= 0 && agg.flushCheck == nil {
+		return nil, errors.New("at least one flush trigger must be specified")
+	}
+	return &aggregateProc{agg: agg}, nil
+}
+
+func (a *aggregateProc) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	outBatch := a.agg.add(ctx, batch)
+	if len(outBatch) == 0 {
+		return nil, nil
+	}
+	return []service.MessageBatch{outBatch}, nil
+}
+
+func (a *aggregateProc) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,404 @@
+package pure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func aggregateTestBatch(docs ...string) service.MessageBatch {
+	var batch service.MessageBatch
+	for _, d := range docs {
+		batch = append(batch, service.NewMessage([]byte(d)))
+	}
+	return batch
+}
+
+func aggregateResults(t *testing.T, batches []service.MessageBatch) []string {
+	t.Helper()
+
+	var results []string
+	for _, b := range batches {
+		for _, m := range b {
+			mBytes, err := m.AsBytes()
+			require.NoError(t, err)
+			results = append(results, string(mBytes))
+		}
+	}
+	return results
+}
+
+func TestAggregateKeyCount(t *testing.T) {
+	conf, err := aggregateProcConfig().ParseYAML(`
+key: root = this.user
+aggregations:
+  - name: count
+    type: count
+  - name: sum
+    type: sum
+    value: root = this.price
+  - name: min
+    type: min
+    value: root = this.price
+  - name: max
+    type: max
+    value: root = this.price
+  - name: avg
+    type: avg
+    value: root = this.price
+  - name: items
+    type: distinct
+    value: root = this.item
+flush:
+  key_count: 2
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newAggregateProcFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+
+	tCtx := context.Background()
+
+	res, err := proc.ProcessBatch(tCtx, aggregateTestBatch(
+		`{"user":"a","price":10,"item":"foo"}`,
+		`{"user":"a","price":20,"item":"bar"}`,
+	))
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	res, err = proc.ProcessBatch(tCtx, aggregateTestBatch(
+		`{"user":"a","price":3,"item":"foo"}`,
+		`{"user":"b","price":5.5,"item":"baz"}`,
+	))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`{"avg":11,"count":3,"items":2,"key":"a","max":20,"min":3,"sum":33}`,
+		`{"avg":5.5,"count":1,"items":1,"key":"b","max":5.5,"min":5.5,"sum":5.5}`,
+	}, aggregateResults(t, res))
+
+	// Aggregations are reset after a flush
+	res, err = proc.ProcessBatch(tCtx, aggregateTestBatch(
+		`{"user":"a","price":1,"item":"foo"}`,
+		`{"user":"c","price":2,"item":"foo"}`,
+	))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`{"avg":1,"count":1,"items":1,"key":"a","max":1,"min":1,"sum":1}`,
+		`{"avg":2,"count":1,"items":1,"key":"c","max":2,"min":2,"sum":2}`,
+	}, aggregateResults(t, res))
+}
+
+func TestAggregateErrorsPassThrough(t *testing.T) {
+	conf, err := aggregateProcConfig().ParseYAML(`
+key: root = this.user
+aggregations:
+  - name: sum
+    type: sum
+    value: root = this.price
+flush:
+  check: root = this.last == true
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newAggregateProcFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+
+	res, err := proc.ProcessBatch(context.Background(), aggregateTestBatch(
+		`{"user":"a","price":10}`,
+		`{"user":"a","price":"nope"}`,
+		`{"price":10}`,
+		`{"user":"a","price":5,"last":true}`,
+	))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0], 3)
+
+	assert.Error(t, res[0][0].GetError())
+	assert.Error(t, res[0][1].GetError())
+	assert.Equal(t, []string{
+		`{"user":"a","price":"nope"}`,
+		`{"price":10}`,
+		`{"key":"a","sum":15}`,
+	}, aggregateResults(t, res))
+}
+
+func TestAggregateNullAndDeletedValues(t *testing.T) {
+	conf, err := aggregateProcConfig().ParseYAML(`
+key: 'root = if this.user == "gone" { deleted() } else { this.user }'
+aggregations:
+  - name: sum
+    type: sum
+    value: 'root = if this.price == null { deleted() } else { this.price }'
+flush:
+  check: 'root = if this.last == null { deleted() } else { this.last }'
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newAggregateProcFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+
+	res, err := proc.ProcessBatch(context.Background(), aggregateTestBatch(
+		`{"user":"null","price":10}`,
+		`{"user":"gone","price":10}`,
+		`{"user":null,"price":10}`,
+		`{"user":"null"}`,
+		`{"user":"null","price":5,"last":true}`,
+	))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0], 4)
+
+	for i := 0; i < 3; i++ {
+		assert.Error(t, res[0][i].GetError())
+	}
+	assert.Equal(t, []string{
+		`{"user":"gone","price":10}`,
+		`{"user":null,"price":10}`,
+		`{"user":"null"}`,
+		`{"key":"null","sum":15}`,
+	}, aggregateResults(t, res))
+}
+
+func TestAggregatePercentiles(t *testing.T) {
+	conf, err := aggregateProcConfig().ParseYAML(`
+key: root = "all"
+aggregations:
+  - name: latency
+    type: percentiles
+    value: root = this.latency
+    percentiles: [ 0.5, 0.999 ]
+flush:
+  check: root = this.latency == 1000
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newAggregateProcFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, proc.Close(context.Background()))
+	}()
+
+	var docs []string
+	for i := 0; i <= 1000; i++ {
+		docs = append(docs, fmt.Sprintf(`{"latency":%v}`, i))
+	}
+
+	res, err := proc.ProcessBatch(context.Background(), aggregateTestBatch(docs...))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0], 1)
+
+	v, err := res[0][0].AsStructured()
+	require.NoError(t, err)
+
+	latency := v.(map[string]interface{})["latency"].(map[string]interface{})
+	assert.InDelta(t, 500, latency["p50"], 10)
+	assert.InDelta(t, 999, latency["p99.9"], 2)
+}
+
+func TestAggregateCache(t *testing.T) {
+	conf, err := aggregateProcConfig().ParseYAML(`
+key: root = this.user
+cache: foo
+aggregations:
+  - name: count
+    type: count
+  - name: items
+    type: distinct
+    value: root = this.item
+  - name: latency
+    type: percentiles
+    value: root = this.latency
+    percentiles: [ 0.5 ]
+flush:
+  key_count: 2
+`, nil)
+	require.NoError(t, err)
+
+	mgr := service.MockResources(service.MockResourcesOptAddCache("foo"))
+
+	proc, err := newAggregateProcFromConfig(conf, mgr)
+	require.NoError(t, err)
+
+	tCtx := context.Background()
+
+	res, err := proc.ProcessBatch(tCtx, aggregateTestBatch(
+		`{"user":"a","item":"foo","latency":10}`,
+		`{"user":"a","item":"bar","latency":20}`,
+	))
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	var cached []byte
+	require.NoError(t, mgr.AccessCache(tCtx, "foo", func(c service.Cache) {
+		cached, err = c.Get(tCtx, "a")
+	}))
+	require.NoError(t, err)
+	assert.True(t, json.Valid(cached))
+
+	res, err = proc.ProcessBatch(tCtx, aggregateTestBatch(
+		`{"user":"b","item":"foo","latency":30}`,
+	))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`{"count":2,"items":2,"key":"a","latency":{"p50":15}}`,
+		`{"count":1,"items":1,"key":"b","latency":{"p50":30}}`,
+	}, aggregateResults(t, res))
+
+	require.NoError(t, mgr.AccessCache(tCtx, "foo", func(c service.Cache) {
+		_, err = c.Get(tCtx, "a")
+	}))
+	assert.ErrorIs(t, err, service.ErrKeyNotFound)
+}
+
+func TestAggregatePartialFlush(t *testing.T) {
+	conf, err := aggregateProcConfig().ParseYAML(`
+key: root = this.user
+cache: foo
+aggregations:
+  - name: count
+    type: count
+flush:
+  check: root = this.last == true
+`, nil)
+	require.NoError(t, err)
+
+	var cacheItems map[string]mock.CacheItem
+	proc, err := newAggregateProcFromConfig(conf, service.MockResources(func(m *mock.Manager) {
+		cacheItems = map[string]mock.CacheItem{}
+		m.Caches["foo"] = cacheItems
+	}))
+	require.NoError(t, err)
+
+	tCtx := context.Background()
+
+	res, err := proc.ProcessBatch(tCtx, aggregateTestBatch(
+		`{"user":"a"}`,
+		`{"user":"b"}`,
+	))
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	// Corrupt the aggregations of key b so that the flush fails part way.
+	cacheItems["b"] = mock.CacheItem{Value: "nope"}
+
+	res, err = proc.ProcessBatch(tCtx, aggregateTestBatch(
+		`{"user":"a","last":true}`,
+	))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`{"count":2,"key":"a"}`,
+	}, aggregateResults(t, res))
+
+	proc.mut.Lock()
+	assert.Equal(t, map[string]struct{}{"b": {}}, proc.agg.keys)
+	proc.mut.Unlock()
+}
+
+func TestAggregateMappingDoesNotModifyMessage(t *testing.T) {
+	conf, err := aggregateProcConfig().ParseYAML(`
+key: |
+  meta foo = "changed"
+  root = this.user
+aggregations:
+  - name: count
+    type: count
+flush:
+  key_count: 2
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newAggregateProcFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+
+	msg := service.NewMessage([]byte(`{"user":"a"}`))
+	msg.MetaSet("foo", "original")
+
+	_, err = proc.ProcessBatch(context.Background(), service.MessageBatch{msg})
+	require.NoError(t, err)
+
+	v, _ := msg.MetaGet("foo")
+	assert.Equal(t, "original", v)
+}
+
+func TestAggregateQueryValue(t *testing.T) {
+	msg := service.NewMessage([]byte(`{"content":"hello world"}`))
+	msg.MetaSet("foo", "bar")
+
+	for _, test := range []struct {
+		mapping string
+		result  interface{}
+	}{
+		{mapping: `root = this.content.uppercase()`, result: "HELLO WORLD"},
+		{mapping: `root = meta("foo")`, result: "bar"},
+		{mapping: `root = "null"`, result: "null"},
+		{mapping: `root = null`, result: nil},
+		{mapping: `root = deleted()`, result: nil},
+		{mapping: `root = this.content.length()`, result: int64(11)},
+	} {
+		blobl, err := bloblang.Parse(test.mapping)
+		require.NoError(t, err, test.mapping)
+
+		res, err := queryValue(blobl, msg)
+		require.NoError(t, err, test.mapping)
+		assert.Equal(t, test.result, res, test.mapping)
+	}
+}
+
+func TestAggregateConfigErrors(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{
+			name: "missing value",
+			config: `
+key: root = this.user
+aggregations:
+  - { name: sum, type: sum }
+flush:
+  key_count: 1
+`,
+			err: "aggregation sum of type sum requires a value mapping",
+		},
+		{
+			name: "reserved name",
+			config: `
+key: root = this.user
+aggregations:
+  - { name: key, type: count }
+flush:
+  key_count: 1
+`,
+			err: "aggregation name key is either duplicated or reserved",
+		},
+		{
+			name: "no flush",
+			config: `
+key: root = this.user
+aggregations:
+  - { name: count, type: count }
+`,
+			err: "at least one flush trigger must be specified",
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := aggregateProcConfig().ParseYAML(test.config, nil)
+			require.NoError(t, err)
+
+			_, err = newAggregateProcFromConfig(conf, service.MockResources())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.err)
+		})
+	}
+}
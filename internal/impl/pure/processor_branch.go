@@ -1,12 +1,14 @@
 package pure
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sort"
 	"strconv"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
@@ -17,6 +19,8 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/old/util/retries"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
 	"github.com/benthosdev/benthos/v4/internal/tracing"
 )
 
@@ -53,6 +57,28 @@ root.bar.id = this.user.id`,
 	this
 }`,
 	).HasDefault(""),
+	docs.FieldBloblang(
+		"check",
+		"An optional [Bloblang query](/docs/guides/bloblang/about) that should return a boolean value indicating whether the branch should be executed for a given message. When the query returns `false` the branch is skipped for that message.",
+		`this.type == "foo"`,
+		`this.user.id != null`,
+	).AtVersion("4.4.0").HasDefault(""),
+	docs.FieldString(
+		"timeout",
+		"An optional maximum period of time to wait for the child processors of the branch to complete. When exceeded the branch fails for all messages of the batch and any results it later produces are discarded. Timed out processors are not interrupted, and therefore should have timeouts of their own where possible.",
+		"1s", "500ms",
+	).AtVersion("4.4.0").Advanced().HasDefault(""),
+	docs.FieldObject(
+		"retry",
+		"Determines how the child processors of the branch are retried when they fail or time out. Messages that fail the `request_map` or `check` are not retried.",
+	).WithChildren(
+		docs.FieldInt("max_retries", "The maximum number of retries before giving up on a message. If set to zero the child processors are not retried.").HasDefault(0),
+		docs.FieldObject("backoff", "Control time intervals between retry attempts.").WithChildren(
+			docs.FieldString("initial_interval", "The initial period to wait between retry attempts.").HasDefault("500ms"),
+			docs.FieldString("max_interval", "The maximum period to wait between retry attempts.").HasDefault("3s"),
+			docs.FieldString("max_elapsed_time", "The maximum period to wait before retry attempts are abandoned. If zero then no limit is used.").HasDefault("0s"),
+		),
+	).AtVersion("4.4.0").Advanced(),
 }
 
 func init() {
@@ -94,7 +120,16 @@ order to filter, DLQ or recover the failed messages.
 
 If the root of your request map is set to ` + "`deleted()`" + ` then the branch
 processors are skipped for the given message, this allows you to conditionally
-branch messages.`,
+branch messages. Alternatively, a ` + "`check`" + ` query can be specified,
+and messages for which it returns ` + "`false`" + ` skip the branch.
+
+### Timeouts and Retries
+
+When a ` + "`timeout`" + ` is configured and the child processors do not
+complete within it the branch fails for every message of the batch. Messages
+that fail within the child processors, or that are part of a batch that timed
+out, can be retried by setting ` + "`retry.max_retries`" + ` to a non-zero
+value, in which case only the failed messages are mapped and processed again.`,
 		Examples: []docs.AnnotatedExample{
 			{
 				Title: "HTTP Request",
@@ -197,8 +232,14 @@ type Branch struct {
 
 	requestMap *mapping.Executor
 	resultMap  *mapping.Executor
+	check      *mapping.Executor
 	children   []processor.V1
 
+	timeout   time.Duration
+	retryCtor func() backoff.BackOff
+
+	shutSig *shutdown.Signaller
+
 	// Metrics
 	mReceived      metrics.StatCounter
 	mBatchReceived metrics.StatCounter
@@ -227,6 +268,7 @@ func newBranch(conf processor.BranchConfig, mgr bundle.NewManagement) (*Branch,
 		children: children,
 		log:      mgr.Logger(),
 		tracer:   mgr.Tracer(),
+		shutSig:  shutdown.NewSignaller(),
 
 		mReceived:      stats.GetCounter("processor_received"),
 		mBatchReceived: stats.GetCounter("processor_batch_received"),
@@ -247,6 +289,28 @@ func newBranch(conf processor.BranchConfig, mgr bundle.NewManagement) (*Branch,
 			return nil, fmt.Errorf("failed to parse result mapping: %w", err)
 		}
 	}
+	if len(conf.Check) > 0 {
+		if b.check, err = mgr.BloblEnvironment().NewMapping(conf.Check); err != nil {
+			return nil, fmt.Errorf("failed to parse check: %w", err)
+		}
+	}
+	if len(conf.Timeout) > 0 {
+		if b.timeout, err = time.ParseDuration(conf.Timeout); err != nil {
+			return nil, fmt.Errorf("failed to parse timeout: %w", err)
+		}
+	}
+	if conf.Retry.MaxRetries < 0 {
+		return nil, errors.New("retry max_retries must not be negative")
+	}
+	if conf.Retry.MaxRetries > 0 {
+		retryConf := retries.Config{
+			MaxRetries: uint64(conf.Retry.MaxRetries),
+			Backoff:    conf.Retry.Backoff,
+		}
+		if b.retryCtor, err = retryConf.GetCtor(); err != nil {
+			return nil, err
+		}
+	}
 
 	return b, nil
 }
@@ -256,12 +320,16 @@ func newBranch(conf processor.BranchConfig, mgr bundle.NewManagement) (*Branch,
 // TargetsUsed returns a list of paths that this branch depends on. Each path is
 // prefixed by a namespace `metadata` or `path` indicating the source.
 func (b *Branch) targetsUsed() [][]string {
-	if b.requestMap == nil {
-		return nil
+	var queryTargets []query.TargetPath
+	for _, m := range []*mapping.Executor{b.requestMap, b.check} {
+		if m == nil {
+			continue
+		}
+		_, targets := m.QueryTargets(query.TargetsContext{})
+		queryTargets = append(queryTargets, targets...)
 	}
 
 	var paths [][]string
-	_, queryTargets := b.requestMap.QueryTargets(query.TargetsContext{})
 
 pathLoop:
 	for _, p := range queryTargets {
@@ -329,7 +397,7 @@ func (b *Branch) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
 		return nil
 	})
 
-	resultParts, mapErrs, err := b.createResult(parts, msg)
+	resultParts, mapErrs, err := b.executeResult(parts, msg)
 	if err != nil {
 		result := msg.Copy()
 		// Add general error to all messages.
@@ -373,10 +441,144 @@ func (b *Branch) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
 type branchMapError struct {
 	index int
 	err   error
+
+	// Whether the error occurred within the child processors and can
+	// therefore be retried.
+	retryable bool
 }
 
 func newBranchMapError(index int, err error) branchMapError {
-	return branchMapError{index, err}
+	return branchMapError{index: index, err: err}
+}
+
+//------------------------------------------------------------------------------
+
+// executeResult wraps createResult with the check, timeout and retry
+// behaviour of the branch.
+func (b *Branch) executeResult(parts []*message.Part, referenceMsg *message.Batch) ([]*message.Part, []branchMapError, error) {
+	parts = append([]*message.Part(nil), parts...)
+
+	var checkErrs []branchMapError
+	if b.check != nil {
+		for i, p := range parts {
+			if p == nil {
+				continue
+			}
+			pass, err := b.check.QueryPart(i, referenceMsg)
+			if err != nil {
+				b.mError.Incr(1)
+				b.log.Debugf("Failed to check message '%v': %v\n", i, err)
+				checkErrs = append(checkErrs, newBranchMapError(i, fmt.Errorf("check failed: %w", err)))
+			}
+			if !pass {
+				parts[i] = nil
+			}
+		}
+	}
+
+	results, mapErrs, err := b.createResultWithTimeout(parts, referenceMsg)
+	if b.retryCtor != nil {
+		// Retries are abandoned when the processor is closed.
+		ctx, done := b.shutSig.CloseNowCtx(context.Background())
+		defer done()
+
+		boff := b.retryCtor()
+		for {
+			retryParts := make([]*message.Part, len(parts))
+			var retrying bool
+			if err != nil {
+				copy(retryParts, parts)
+				retrying = true
+			} else {
+				for _, e := range mapErrs {
+					if e.retryable {
+						retryParts[e.index] = parts[e.index]
+						retrying = true
+					}
+				}
+			}
+			if !retrying {
+				break
+			}
+
+			wait := boff.NextBackOff()
+			if wait == backoff.Stop {
+				break
+			}
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, checkErrs, ctx.Err()
+			}
+
+			retryResults, retryErrs, retryErr := b.createResultWithTimeout(retryParts, referenceMsg)
+			if err != nil {
+				if retryErr != nil {
+					err = retryErr
+					continue
+				}
+				results, mapErrs, err = retryResults, retryErrs, nil
+				continue
+			}
+
+			mergedErrs := make([]branchMapError, 0, len(mapErrs))
+			for _, e := range mapErrs {
+				if retryParts[e.index] == nil {
+					mergedErrs = append(mergedErrs, e)
+				} else if retryErr != nil {
+					mergedErrs = append(mergedErrs, branchMapError{index: e.index, err: retryErr, retryable: true})
+				}
+			}
+			if retryErr == nil {
+				for i, p := range retryParts {
+					if p != nil {
+						results[i] = retryResults[i]
+					}
+				}
+				mergedErrs = append(mergedErrs, retryErrs...)
+			}
+			mapErrs = mergedErrs
+		}
+	}
+	return results, append(checkErrs, mapErrs...), err
+}
+
+// createResultWithTimeout executes createResult on a copy of the parts
+// provided, abandoning the result if it does not complete within the timeout
+// of the branch.
+func (b *Branch) createResultWithTimeout(parts []*message.Part, referenceMsg *message.Batch) ([]*message.Part, []branchMapError, error) {
+	attemptParts := make([]*message.Part, len(parts))
+	for i, p := range parts {
+		if p != nil {
+			attemptParts[i] = p.Copy()
+		}
+	}
+	if b.timeout <= 0 {
+		return b.createResult(attemptParts, referenceMsg)
+	}
+
+	type branchResult struct {
+		parts   []*message.Part
+		mapErrs []branchMapError
+		err     error
+	}
+	resChan := make(chan branchResult, 1)
+	go func() {
+		var res branchResult
+		res.parts, res.mapErrs, res.err = b.createResult(attemptParts, referenceMsg)
+		resChan <- res
+	}()
+
+	select {
+	case res := <-resChan:
+		return res.parts, res.mapErrs, res.err
+	case <-time.After(b.timeout):
+	}
+	b.mError.Incr(1)
+	b.log.Errorf("Child processors timed out after %v\n", b.timeout)
+	return nil, nil, fmt.Errorf("child processors timed out after %v", b.timeout)
 }
 
 //------------------------------------------------------------------------------
@@ -455,7 +657,11 @@ func (b *Branch) createResult(parts []*message.Part, referenceMsg *message.Batch
 		}
 		if fail := p.ErrorGet(); fail != nil {
 			alignedResult[i] = nil
-			mapErrs = append(mapErrs, newBranchMapError(i, fmt.Errorf("processors failed: %w", fail)))
+			mapErrs = append(mapErrs, branchMapError{
+				index:     i,
+				err:       fmt.Errorf("processors failed: %w", fail),
+				retryable: true,
+			})
 		}
 	}
 
@@ -556,6 +762,7 @@ func alignBranchResult(length int, skipped, failed []int, result []*message.Batc
 
 // CloseAsync shuts down the processor and stops processing requests.
 func (b *Branch) CloseAsync() {
+	b.shutSig.CloseNow()
 	for _, child := range b.children {
 		child.CloseAsync()
 	}
@@ -34,6 +34,7 @@ func TestBranchBasic(t *testing.T) {
 		requestMap   string
 		processorMap string
 		resultMap    string
+		check        string
 		input        []mockMsg
 		output       []mockMsg
 	}{
@@ -59,6 +60,22 @@ func TestBranchBasic(t *testing.T) {
 				msg(`{"value":"foobar"}`),
 			},
 		},
+		"check skips messages": {
+			requestMap:   "root = this",
+			processorMap: "root.nested = this",
+			resultMap:    "root.result = this.nested.value",
+			check:        `this.value != "skipme"`,
+			input: []mockMsg{
+				msg(`{"value":"foobar"}`),
+				msg(`{"value":"skipme"}`),
+				msg(`{"nope":"foobar"}`),
+			},
+			output: []mockMsg{
+				msg(`{"result":"foobar","value":"foobar"}`),
+				msg(`{"value":"skipme"}`),
+				msg(`{"nope":"foobar","result":null}`),
+			},
+		},
 		"copy metadata over only": {
 			requestMap:   `meta foo = meta("foo")`,
 			processorMap: `meta foo = meta("foo") + " and this"`,
@@ -210,6 +227,7 @@ func TestBranchBasic(t *testing.T) {
 			conf.Branch.RequestMap = test.requestMap
 			conf.Branch.Processors = append(conf.Branch.Processors, procConf)
 			conf.Branch.ResultMap = test.resultMap
+			conf.Branch.Check = test.check
 
 			proc, err := mock.NewManager().NewProcessor(conf)
 			require.NoError(t, err)
@@ -270,3 +288,68 @@ func TestBranchBasic(t *testing.T) {
 		})
 	}
 }
+
+func TestBranchTimeout(t *testing.T) {
+	sleepConf := processor.NewConfig()
+	sleepConf.Type = "sleep"
+	sleepConf.Sleep.Duration = "500ms"
+
+	conf := processor.NewConfig()
+	conf.Type = "branch"
+	conf.Branch.Processors = append(conf.Branch.Processors, sleepConf)
+	conf.Branch.ResultMap = "root.result = this"
+	conf.Branch.Timeout = "10ms"
+
+	proc, err := mock.NewManager().NewProcessor(conf)
+	require.NoError(t, err)
+
+	outMsgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{
+		[]byte(`{"value":"foo"}`),
+		[]byte(`{"value":"bar"}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, outMsgs, 1)
+	require.Equal(t, 2, outMsgs[0].Len())
+
+	for i, exp := range []string{`{"value":"foo"}`, `{"value":"bar"}`} {
+		assert.Equal(t, exp, string(outMsgs[0].Get(i).Get()))
+		assert.EqualError(t, outMsgs[0].Get(i).ErrorGet(), "child processors timed out after 10ms")
+	}
+
+	proc.CloseAsync()
+	assert.NoError(t, proc.WaitForClose(time.Second))
+}
+
+func TestBranchRetryClosed(t *testing.T) {
+	failConf := processor.NewConfig()
+	failConf.Type = "bloblang"
+	failConf.Bloblang = `root = throw("nope")`
+
+	conf := processor.NewConfig()
+	conf.Type = "branch"
+	conf.Branch.Processors = append(conf.Branch.Processors, failConf)
+	conf.Branch.ResultMap = "root.result = this"
+	conf.Branch.Retry.MaxRetries = 3
+	conf.Branch.Retry.Backoff.InitialInterval = "1h"
+	conf.Branch.Retry.Backoff.MaxInterval = "1h"
+
+	proc, err := mock.NewManager().NewProcessor(conf)
+	require.NoError(t, err)
+
+	go func() {
+		<-time.After(time.Millisecond * 50)
+		proc.CloseAsync()
+	}()
+
+	started := time.Now()
+	outMsgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{
+		[]byte(`{"value":"foo"}`),
+	}))
+	assert.Less(t, int64(time.Since(started)), int64(time.Second))
+	require.Nil(t, res)
+	require.Len(t, outMsgs, 1)
+	assert.Equal(t, `{"value":"foo"}`, string(outMsgs[0].Get(0).Get()))
+	assert.EqualError(t, outMsgs[0].Get(0).ErrorGet(), "context canceled")
+
+	assert.NoError(t, proc.WaitForClose(time.Second))
+}
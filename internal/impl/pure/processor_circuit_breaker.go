@@ -0,0 +1,168 @@
+package pure
+
+import (
+	"context"
+	"errors"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/benthosdev/benthos/v4/internal/circuitbreaker"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func circuitBreakerProcessorSpec() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		Version("4.4.0").
+		Categories("Utility").
+		Summary("Executes a list of child processors through a circuit breaker, which stops them from being executed while their error rate is too high.").
+		Description(`
+This processor is intended to wrap processors that make requests to other services, such as ` + "[`http`](/docs/components/processors/http)" + `, ` + "[`cache`](/docs/components/processors/cache)" + ` or the ` + "`sql_*`" + ` processors, in order to avoid overwhelming those services when they are struggling.
+
+Each batch executed by the child processors counts as a single request, which fails if any resulting message is flagged with an error by the child processors. Messages that were already flagged with an error before reaching this processor do not count as failures. While the circuit is open the child processors are not executed, instead messages are processed by the ` + "`fallback`" + ` processors, if any are configured, or are otherwise flagged with an error that can be handled using the methods outlined in the [error handling documentation](/docs/configuration/error_handling).
+` + circuitBreakerDescription).
+		Field(service.NewProcessorListField("processors").
+			Description("A list of child processors to execute through the circuit breaker.")).
+		Field(service.NewProcessorListField("fallback").
+			Description("An optional list of processors to execute instead of the child processors while the circuit is open.").
+			Optional())
+
+	for _, f := range circuitBreakerFields() {
+		spec = spec.Field(f)
+	}
+
+	return spec.
+		Example(
+			"Protecting an Enrichment Service",
+			"In this example an HTTP enrichment is skipped for one minute once half of the requests over the last thirty seconds have failed, and during that time messages are marked as not enriched instead.",
+			`
+pipeline:
+  processors:
+    - label: enrich
+      circuit_breaker:
+        error_threshold: 0.5
+        window: 30s
+        open_period: 1m
+        processors:
+          - branch:
+              processors:
+                - http:
+                    url: http://example.com/enrich
+                    verb: POST
+              result_map: 'root.enrichment = this'
+        fallback:
+          - bloblang: 'root.enrichment = null'
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"circuit_breaker", circuitBreakerProcessorSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newCircuitBreakerProcessorFromConfig(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type circuitBreakerProcessor struct {
+	breaker    *circuitbreaker.Breaker
+	processors []*service.OwnedProcessor
+	fallback   []*service.OwnedProcessor
+
+	mRejected *service.MetricCounter
+}
+
+func newCircuitBreakerProcessorFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*circuitBreakerProcessor, error) {
+	c := &circuitBreakerProcessor{
+		mRejected: mgr.Metrics().NewCounter("circuit_breaker_rejected"),
+	}
+
+	var err error
+	if c.breaker, err = circuitBreakerFromConfig(conf, mgr); err != nil {
+		return nil, err
+	}
+	if c.processors, err = conf.FieldProcessorList("processors"); err != nil {
+		return nil, err
+	}
+	if conf.Contains("fallback") {
+		if c.fallback, err = conf.FieldProcessorList("fallback"); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *circuitBreakerProcessor) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	done, err := c.breaker.Allow()
+	if err != nil {
+		c.mRejected.Incr(int64(len(batch)))
+		if len(c.fallback) > 0 {
+			return service.ExecuteProcessors(ctx, c.fallback, batch)
+		}
+		rejected := batch.Copy()
+		for _, msg := range rejected {
+			msg.SetError(err)
+		}
+		return []service.MessageBatch{rejected}, nil
+	}
+
+	var priorErrs []error
+	for _, msg := range batch {
+		if err := msg.GetError(); err != nil {
+			priorErrs = append(priorErrs, err)
+		}
+	}
+
+	results, err := service.ExecuteProcessors(ctx, c.processors, batch)
+	if err != nil {
+		// Only context errors are returned here, which aren't a reflection of
+		// the health of the child processors.
+		done(nil)
+		return nil, err
+	}
+
+	var resErr error
+resultsLoop:
+	for _, b := range results {
+		for _, msg := range b {
+			if resErr = newError(msg.GetError(), priorErrs); resErr != nil {
+				break resultsLoop
+			}
+		}
+	}
+	done(resErr)
+	return results, nil
+}
+
+// newError returns an error if it is not one of a set of errors that messages
+// were flagged with before processing, otherwise nil.
+func newError(err error, priorErrs []error) error {
+	if err == nil {
+		return nil
+	}
+	for _, prior := range priorErrs {
+		if errors.Is(err, prior) {
+			return nil
+		}
+	}
+	return err
+}
+
+func (c *circuitBreakerProcessor) Close(ctx context.Context) error {
+	c.breaker.Close()
+
+	var group errgroup.Group
+	for _, procs := range [][]*service.OwnedProcessor{c.processors, c.fallback} {
+		for _, ownedProc := range procs {
+			op := ownedProc
+			group.Go(func() error {
+				return op.Close(ctx)
+			})
+		}
+	}
+	return group.Wait()
+}
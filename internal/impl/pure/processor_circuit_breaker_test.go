@@ -0,0 +1,125 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/circuitbreaker"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestCircuitBreakerProcessor(t *testing.T) {
+	conf, err := circuitBreakerProcessorSpec().ParseYAML(`
+min_requests: 1
+open_period: 1m
+processors:
+  - bloblang: 'root = if content() == "fail" { throw("nope") } else { content().uppercase() }'
+`, nil)
+	require.NoError(t, err)
+
+	c, err := newCircuitBreakerProcessorFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	res, err := c.ProcessBatch(tCtx, dlqTestBatch("foo"))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0], 1)
+	mBytes, err := res[0][0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "FOO", string(mBytes))
+
+	res, err = c.ProcessBatch(tCtx, dlqTestBatch("fail"))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Error(t, res[0][0].GetError())
+	assert.Equal(t, circuitbreaker.StateOpen, c.breaker.State())
+
+	res, err = c.ProcessBatch(tCtx, dlqTestBatch("bar"))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0], 1)
+	assert.Equal(t, circuitbreaker.ErrOpen, res[0][0].GetError())
+	mBytes, err = res[0][0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(mBytes))
+
+	require.NoError(t, c.Close(tCtx))
+}
+
+func TestCircuitBreakerProcessorUpstreamErrors(t *testing.T) {
+	conf, err := circuitBreakerProcessorSpec().ParseYAML(`
+min_requests: 1
+open_period: 1m
+processors:
+  - bloblang: 'root = content().uppercase()'
+`, nil)
+	require.NoError(t, err)
+
+	c, err := newCircuitBreakerProcessorFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	errUpstream := errors.New("upstream")
+
+	batch := dlqTestBatch("foo", "bar")
+	batch[0].SetError(errUpstream)
+
+	res, err := c.ProcessBatch(tCtx, batch)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0], 2)
+	assert.Equal(t, errUpstream, res[0][0].GetError())
+	assert.NoError(t, res[0][1].GetError())
+
+	assert.Equal(t, circuitbreaker.StateClosed, c.breaker.State())
+	assert.Equal(t, int64(0), c.breaker.Status().Failures)
+
+	require.NoError(t, c.Close(tCtx))
+}
+
+func TestCircuitBreakerProcessorFallback(t *testing.T) {
+	conf, err := circuitBreakerProcessorSpec().ParseYAML(`
+min_requests: 1
+open_period: 1m
+processors:
+  - bloblang: 'root = throw("nope")'
+fallback:
+  - bloblang: 'root = "fallback: " + content()'
+`, nil)
+	require.NoError(t, err)
+
+	c, err := newCircuitBreakerProcessorFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	res, err := c.ProcessBatch(tCtx, dlqTestBatch("foo"))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Error(t, res[0][0].GetError())
+	assert.Equal(t, circuitbreaker.StateOpen, c.breaker.State())
+
+	res, err = c.ProcessBatch(tCtx, dlqTestBatch("bar", "baz"))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0], 2)
+	for i, exp := range []string{"fallback: bar", "fallback: baz"} {
+		assert.NoError(t, res[0][i].GetError())
+		mBytes, err := res[0][i].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(mBytes))
+	}
+
+	require.NoError(t, c.Close(tCtx))
+}
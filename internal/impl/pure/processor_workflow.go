@@ -9,6 +9,8 @@ import (
 	"github.com/Jeffail/gabs/v2"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
@@ -78,10 +80,16 @@ The object is of the following form:
 	"skipped": [ "bar" ],
 	"failed": {
 		"baz": "the error message from the branch"
+	},
+	"timings": {
+		"foo": "12.3ms",
+		"baz": "1.002s"
 	}
 }
 ` + "```" + `
 
+The ` + "`timings`" + ` object contains the time taken to execute each branch that was not skipped, including any retries.
+
 If a message already has a meta object at the given path when it is processed then the object is used in order to determine which branches have already been performed on the message (or skipped) and can therefore be skipped on this run.
 
 This is a useful pattern when replaying messages that have failed some branches previously. For example, given the above example object the branches foo and bar would automatically be skipped, and baz would be reattempted.
@@ -90,6 +98,12 @@ The previous meta object will also be preserved in the field ` + "`<meta_path>.p
 
 If a field ` + "`<meta_path>.apply`" + ` exists in the meta object for a message and is an array then it will be used as an explicit list of stages to apply, all other stages will be skipped.
 
+## Selecting Branches
+
+The field ` + "`apply_map`" + ` can be used in order to select which branches should be executed for each individual message. The mapping is executed on each message before any branches are executed and must result in an array of branch names, all other branches are skipped for that message. The branches selected are still executed in the order of the workflow DAG, but no other branches are included automatically, and therefore any branches that a selected branch depends on must also be selected.
+
+If the mapping fails for a message then all branches are skipped and the message is flagged as having failed, which can be handled using [standard error handling patterns][configuration.error-handling].
+
 ## Resources
 
 It's common to configure processors (and other components) [as resources][configuration.resources] in order to keep the pipeline configuration cleaner. With the workflow processor you can include branch processors configured as resources within your workflow either by specifying them by name in the field ` + "`order`" + `, if Benthos doesn't find a branch within the workflow configuration of that name it'll refer to the resources.
@@ -198,6 +212,44 @@ pipeline:
               - http:
                   url: TODO_SOMEWHERE_ELSE
             result_map: 'root.tmp.result = this'
+`,
+			},
+			{
+				Title: "Dynamic Branches",
+				Summary: `
+The ` + "`apply_map`" + ` field selects which branches to execute for each message, and individual branches can be given a ` + "`check`" + `, ` + "`timeout`" + ` and ` + "`retry`" + ` policy. In this example documents of type "order" are enriched by both branches, whereas all other documents are only enriched by the branch ` + "`user`" + `, and the branch ` + "`stock`" + ` is only executed when the order contains items.`,
+				Config: `
+pipeline:
+  processors:
+    - workflow:
+        meta_path: meta.workflow
+        apply_map: |
+          root = if this.type == "order" {
+            [ "user", "stock" ]
+          } else {
+            [ "user" ]
+          }
+        branches:
+          user:
+            request_map: 'root.id = this.user_id'
+            processors:
+              - http:
+                  url: TODO
+            result_map: 'root.user = this'
+            timeout: 500ms
+
+          stock:
+            check: 'this.items.length() > 0'
+            request_map: 'root.items = this.items'
+            processors:
+              - http:
+                  url: TODO
+            result_map: 'root.stock = this'
+            timeout: 1s
+            retry:
+              max_retries: 3
+              backoff:
+                initial_interval: 100ms
 `,
 			},
 			{
@@ -256,6 +308,12 @@ processor_resources:
 				"branches",
 				"An object of named [`branch` processors](/docs/components/processors/branch) that make up the workflow. The order and parallelism in which branches are executed can either be made explicit with the field `order`, or if omitted an attempt is made to automatically resolve an ordering based on the mappings of each branch.",
 			).Map().WithChildren(branchFields...).HasDefault(map[string]interface{}{}),
+			docs.FieldBloblang(
+				"apply_map",
+				"An optional [Bloblang mapping](/docs/guides/bloblang/about) that selects which branches should be executed for each message by resulting in an array of branch names. Branches that are not selected are skipped for that message. For more information check out the section on [selecting branches](#selecting-branches).",
+				`root = [ "foo", "bar" ]`,
+				`root = if this.type == "foo" { [ "foo" ] } else { [ "bar", "baz" ] }`,
+			).AtVersion("4.4.0").HasDefault(""),
 		),
 	})
 	if err != nil {
@@ -275,6 +333,7 @@ type Workflow struct {
 	children  *workflowBranchMap
 	allStages map[string]struct{}
 	metaPath  []string
+	applyMap  *mapping.Executor
 
 	// Metrics
 	mReceived      metrics.StatCounter
@@ -313,6 +372,11 @@ func NewWorkflow(conf processor.WorkflowConfig, mgr bundle.NewManagement) (*Work
 	for k := range w.children.dynamicBranches {
 		w.allStages[k] = struct{}{}
 	}
+	if len(conf.ApplyMap) > 0 {
+		if w.applyMap, err = mgr.BloblEnvironment().NewMapping(conf.ApplyMap); err != nil {
+			return nil, fmt.Errorf("failed to parse apply_map: %w", err)
+		}
+	}
 
 	return w, nil
 }
@@ -328,6 +392,7 @@ type resultTracker struct {
 	succeeded map[string]struct{}
 	skipped   map[string]struct{}
 	failed    map[string]string
+	timings   map[string]time.Duration
 	sync.Mutex
 }
 
@@ -336,6 +401,7 @@ func trackerFromTree(tree [][]string) *resultTracker {
 		succeeded: map[string]struct{}{},
 		skipped:   map[string]struct{}{},
 		failed:    map[string]string{},
+		timings:   map[string]time.Duration{},
 	}
 	for _, layer := range tree {
 		for _, k := range layer {
@@ -362,10 +428,17 @@ func (r *resultTracker) Failed(k, why string) {
 	r.Unlock()
 }
 
+func (r *resultTracker) Timing(k string, d time.Duration) {
+	r.Lock()
+	r.timings[k] = d
+	r.Unlock()
+}
+
 func (r *resultTracker) ToObject() map[string]interface{} {
 	succeeded := make([]interface{}, 0, len(r.succeeded))
 	skipped := make([]interface{}, 0, len(r.skipped))
 	failed := make(map[string]interface{}, len(r.failed))
+	timings := make(map[string]interface{}, len(r.timings))
 
 	for k := range r.succeeded {
 		succeeded = append(succeeded, k)
@@ -382,6 +455,12 @@ func (r *resultTracker) ToObject() map[string]interface{} {
 	for k, v := range r.failed {
 		failed[k] = v
 	}
+	for k, v := range r.timings {
+		// Skipped branches were not executed for this message.
+		if _, isSkipped := r.skipped[k]; !isSkipped {
+			timings[k] = v.String()
+		}
+	}
 
 	m := map[string]interface{}{}
 	if len(succeeded) > 0 {
@@ -393,6 +472,9 @@ func (r *resultTracker) ToObject() map[string]interface{} {
 	if len(failed) > 0 {
 		m["failed"] = failed
 	}
+	if len(timings) > 0 {
+		m["timings"] = timings
+	}
 	return m
 }
 
@@ -445,6 +527,44 @@ func (w *Workflow) skipFromMeta(root interface{}) map[string]struct{} {
 	return skipList
 }
 
+// Adds branches to a skip list that weren't selected by the apply mapping.
+func (w *Workflow) skipFromApplyMap(index int, msg *message.Batch, skipList map[string]struct{}) error {
+	p, err := w.applyMap.MapPart(index, msg)
+	if err != nil {
+		return err
+	}
+
+	var apply []interface{}
+	if p != nil {
+		v, err := p.JSON()
+		if err != nil {
+			return err
+		}
+		var isArray bool
+		if apply, isArray = v.([]interface{}); !isArray {
+			return query.NewTypeErrorFrom("mapping", v, query.ValueArray)
+		}
+	}
+
+	selected := make(map[string]struct{}, len(apply))
+	for _, id := range apply {
+		idStr, isString := id.(string)
+		if !isString {
+			return query.NewTypeErrorFrom("branch name", id, query.ValueString)
+		}
+		if _, exists := w.allStages[idStr]; !exists {
+			return fmt.Errorf("branch %v does not exist", idStr)
+		}
+		selected[idStr] = struct{}{}
+	}
+	for k := range w.allStages {
+		if _, exists := selected[k]; !exists {
+			skipList[k] = struct{}{}
+		}
+	}
+	return nil
+}
+
 // ProcessMessage applies workflow stages to each part of a message type.
 func (w *Workflow) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
 	w.mReceived.Incr(int64(msg.Len()))
@@ -478,6 +598,16 @@ func (w *Workflow) ProcessMessage(msg *message.Batch) ([]*message.Batch, error)
 		} else {
 			skipOnMeta[i] = map[string]struct{}{}
 		}
+		if w.applyMap != nil {
+			if err := w.skipFromApplyMap(i, payload, skipOnMeta[i]); err != nil {
+				w.mError.Incr(1)
+				w.log.Errorf("Failed to select workflow branches: %v\n", err)
+				p.ErrorSet(fmt.Errorf("apply_map failed: %w", err))
+				for k := range w.allStages {
+					skipOnMeta[i][k] = struct{}{}
+				}
+			}
+		}
 		return nil
 	})
 
@@ -510,10 +640,15 @@ func (w *Workflow) ProcessMessage(msg *message.Batch) ([]*message.Batch, error)
 				})
 
 				var mapErrs []branchMapError
-				results[index], mapErrs, errors[index] = children[id].createResult(branchParts, propMsg)
+				branchStartedAt := time.Now()
+				results[index], mapErrs, errors[index] = children[id].executeResult(branchParts, propMsg)
+				branchTime := time.Since(branchStartedAt)
 				for _, s := range branchSpans {
 					s.Finish()
 				}
+				for j := range records {
+					records[j].Timing(id, branchTime)
+				}
 				for j, p := range results[index] {
 					if p == nil {
 						records[j].Skipped(id)
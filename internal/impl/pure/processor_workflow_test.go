@@ -1,6 +1,7 @@
 package pure_test
 
 import (
+	"encoding/json"
 	"errors"
 	"sort"
 	"strconv"
@@ -19,6 +20,23 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
 
+// Branch timings within the structured metadata of a workflow vary between
+// runs and are therefore removed before comparing results.
+func withoutWorkflowTimings(b []byte) string {
+	var v map[string]interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return string(b)
+	}
+	meta, _ := v["meta"].(map[string]interface{})
+	workflow, _ := meta["workflow"].(map[string]interface{})
+	if _, exists := workflow["timings"]; !exists {
+		return string(b)
+	}
+	delete(workflow, "timings")
+	out, _ := json.Marshal(v)
+	return string(out)
+}
+
 func TestWorkflowDeps(t *testing.T) {
 	tests := []struct {
 		branches      [][2]string
@@ -468,7 +486,7 @@ func TestWorkflows(t *testing.T) {
 				assert.Equal(t, len(test.output), msgs[0].Len())
 				for i, out := range test.output {
 					comparePart := mockMsg{
-						content: string(msgs[0].Get(i).Get()),
+						content: withoutWorkflowTimings(msgs[0].Get(i).Get()),
 						meta:    map[string]string{},
 					}
 
@@ -654,7 +672,7 @@ func TestWorkflowsWithResources(t *testing.T) {
 				require.Len(t, msgs, 1)
 				var output []string
 				for _, b := range message.GetAllBytes(msgs[0]) {
-					output = append(output, string(b))
+					output = append(output, withoutWorkflowTimings(b))
 				}
 				assert.Equal(t, test.output, output)
 			}
@@ -728,7 +746,7 @@ func TestWorkflowsParallel(t *testing.T) {
 					require.Len(t, msgs, 1)
 					var actual []string
 					for _, b := range message.GetAllBytes(msgs[0]) {
-						actual = append(actual, string(b))
+						actual = append(actual, withoutWorkflowTimings(b))
 					}
 					assert.Equal(t, output, actual)
 				}
@@ -912,7 +930,7 @@ func TestWorkflowsWithOrderResources(t *testing.T) {
 				require.Len(t, msgs, 1)
 				var output []string
 				for _, b := range message.GetAllBytes(msgs[0]) {
-					output = append(output, string(b))
+					output = append(output, withoutWorkflowTimings(b))
 				}
 				assert.Equal(t, test.output, output)
 			}
@@ -922,3 +940,113 @@ func TestWorkflowsWithOrderResources(t *testing.T) {
 		})
 	}
 }
+
+func quickTestBranchConfig(procMapping, resultMap string) processor.BranchConfig {
+	proc := processor.NewConfig()
+	proc.Type = "bloblang"
+	proc.Bloblang = procMapping
+
+	branchConf := processor.NewBranchConfig()
+	branchConf.Processors = append(branchConf.Processors, proc)
+	branchConf.ResultMap = resultMap
+	return branchConf
+}
+
+func TestWorkflowApplyMap(t *testing.T) {
+	conf := processor.NewConfig()
+	conf.Workflow.ApplyMap = `root = this.apply`
+	conf.Workflow.Branches["a"] = quickTestBranchConfig(`root.v = "done"`, `root.a = this.v`)
+	conf.Workflow.Branches["b"] = quickTestBranchConfig(`root.v = "done"`, `root.b = this.v`)
+
+	p, err := pure.NewWorkflow(conf.Workflow, mock.NewManager())
+	require.NoError(t, err)
+
+	msgs, res := p.ProcessMessage(message.QuickBatch([][]byte{
+		[]byte(`{"apply":["a","b"]}`),
+		[]byte(`{"apply":["b"]}`),
+		[]byte(`{"apply":[]}`),
+		[]byte(`{"apply":{"a":true}}`),
+		[]byte(`{"apply":["c"]}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	var output []string
+	for _, b := range message.GetAllBytes(msgs[0]) {
+		output = append(output, withoutWorkflowTimings(b))
+	}
+	assert.Equal(t, []string{
+		`{"a":"done","apply":["a","b"],"b":"done","meta":{"workflow":{"succeeded":["a","b"]}}}`,
+		`{"apply":["b"],"b":"done","meta":{"workflow":{"skipped":["a"],"succeeded":["b"]}}}`,
+		`{"apply":[],"meta":{"workflow":{"skipped":["a","b"]}}}`,
+		`{"apply":{"a":true},"meta":{"workflow":{"skipped":["a","b"]}}}`,
+		`{"apply":["c"],"meta":{"workflow":{"skipped":["a","b"]}}}`,
+	}, output)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, msgs[0].Get(i).ErrorGet(), i)
+	}
+	assert.EqualError(t, msgs[0].Get(3).ErrorGet(), "apply_map failed: expected array value, got object from mapping")
+	assert.EqualError(t, msgs[0].Get(4).ErrorGet(), "apply_map failed: branch c does not exist")
+
+	p.CloseAsync()
+	assert.NoError(t, p.WaitForClose(time.Second))
+}
+
+func TestWorkflowBranchCheckTimeoutRetry(t *testing.T) {
+	conf := processor.NewConfig()
+	conf.Workflow.Order = [][]string{{"checked", "slow", "flaky"}}
+
+	checked := quickTestBranchConfig(`root.v = "done"`, `root.checked = this.v`)
+	checked.Check = `this.id > 0`
+	conf.Workflow.Branches["checked"] = checked
+
+	slow := quickTestBranchConfig(`root.v = "done"`, `root.slow = this.v`)
+	sleepConf := processor.NewConfig()
+	sleepConf.Type = "sleep"
+	sleepConf.Sleep.Duration = "500ms"
+	slow.Processors = append([]processor.Config{sleepConf}, slow.Processors...)
+	slow.Timeout = "10ms"
+	conf.Workflow.Branches["slow"] = slow
+
+	// Fails the first two attempts, where each attempt counts each message of
+	// the batch.
+	flaky := quickTestBranchConfig(`root = if count("workflow_branch_retry_test") <= 4 {
+  throw("not yet")
+} else {
+  {"v": "done"}
+}`, `root.flaky = this.v`)
+	flaky.Retry.MaxRetries = 2
+	flaky.Retry.Backoff.InitialInterval = "1ms"
+	flaky.Retry.Backoff.MaxInterval = "1ms"
+	conf.Workflow.Branches["flaky"] = flaky
+
+	p, err := pure.NewWorkflow(conf.Workflow, mock.NewManager())
+	require.NoError(t, err)
+
+	msgs, res := p.ProcessMessage(message.QuickBatch([][]byte{
+		[]byte(`{"id":0}`),
+		[]byte(`{"id":1}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	var output []string
+	for _, b := range message.GetAllBytes(msgs[0]) {
+		output = append(output, withoutWorkflowTimings(b))
+	}
+	assert.Equal(t, []string{
+		`{"flaky":"done","id":0,"meta":{"workflow":{"failed":{"slow":"child processors timed out after 10ms"},"skipped":["checked"],"succeeded":["flaky"]}}}`,
+		`{"checked":"done","flaky":"done","id":1,"meta":{"workflow":{"failed":{"slow":"child processors timed out after 10ms"},"succeeded":["checked","flaky"]}}}`,
+	}, output)
+
+	v, err := msgs[0].Get(0).JSON()
+	require.NoError(t, err)
+	timings := v.(map[string]interface{})["meta"].(map[string]interface{})["workflow"].(map[string]interface{})["timings"].(map[string]interface{})
+	assert.Contains(t, timings, "slow")
+	assert.Contains(t, timings, "flaky")
+	assert.NotContains(t, timings, "checked")
+
+	p.CloseAsync()
+	assert.NoError(t, p.WaitForClose(time.Second))
+}
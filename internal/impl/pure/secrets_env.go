@@ -0,0 +1,27 @@
+package pure
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func init() {
+	err := service.RegisterSecretsProvider("env", envSecretLookup)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// envSecretLookup obtains a secret from an environment variable. Unlike
+// regular environment variable interpolations a secret that is not set results
+// in an error rather than an empty value.
+func envSecretLookup(ctx context.Context, path string) (string, error) {
+	v, exists := os.LookupEnv(path)
+	if !exists {
+		return "", fmt.Errorf("environment variable '%v' is not set", path)
+	}
+	return v, nil
+}
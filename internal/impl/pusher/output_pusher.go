@@ -26,7 +26,7 @@ func pusherOutputConfig() *service.ConfigSpec {
 		Field(service.NewStringField("key").
 			Description("Pusher key")).
 		Field(service.NewStringField("secret").
-			Description("Pusher secret")).
+			Description("Pusher secret").Secret()).
 		Field(service.NewStringField("cluster").
 			Description("Pusher cluster")).
 		Field(service.NewBoolField("secure").
@@ -3,6 +3,8 @@ package redis
 import (
 	"context"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -28,7 +30,27 @@ Pulls messages from Redis (v5.0+) streams with the XREADGROUP command. The
 		Description: `
 Redis stream entries are key/value pairs, as such it is necessary to specify the
 key that contains the body of the message. All other keys/value pairs are saved
-as metadata fields.`,
+as metadata fields.
+
+### Claiming Pending Entries
+
+Entries that are read by a consumer of a group remain pending until they are
+acknowledged, and if a consumer is terminated without acknowledging them they
+are never delivered again. When ` + "`claim_period`" + ` is set this input
+periodically claims entries pending on other consumers of the group that have
+been idle for longer than ` + "`claim_min_idle`" + `, and delivers them again.
+Each attempt inspects up to 1000 pending entries of each stream, continuing
+from where the previous attempt left off, until the end of the pending entries
+list is reached and the next attempt starts from the beginning again.
+
+The metadata field ` + "`redis_stream_delivery_count`" + ` is set to the
+number of times an entry has been delivered for entries that are read for the
+first time and for entries that are claimed. When ` + "`max_deliveries`" + ` is
+set, entries that have been delivered that many times are no longer claimed and
+are instead written to the ` + "`dead_letter_stream`" + `, if one is
+configured, before being acknowledged. Entries written to the dead letter
+stream have the fields ` + "`source_stream`" + `, ` + "`source_id`" + ` and
+` + "`delivery_count`" + ` added to them.`,
 		Config: docs.FieldComponent().WithChildren(old.ConfigDocs()...).WithChildren(
 			docs.FieldString("body_key", "The field key to extract the raw message from. All other keys will be stored in the message as metadata."),
 			docs.FieldString("streams", "A list of streams to consume from.").Array(),
@@ -39,6 +61,10 @@ as metadata fields.`,
 			docs.FieldBool("start_from_oldest", "If an offset is not found for a stream, determines whether to consume from the oldest available offset, otherwise messages are consumed from the latest offset.").Advanced(),
 			docs.FieldString("commit_period", "The period of time between each commit of the current offset. Offsets are always committed during shutdown.").Advanced(),
 			docs.FieldString("timeout", "The length of time to poll for new messages before reattempting.").Advanced(),
+			docs.FieldString("claim_period", "The period of time between each attempt to claim entries pending on other consumers of the group. If empty pending entries are never claimed.", "30s", "1m").Advanced().AtVersion("4.4.0"),
+			docs.FieldString("claim_min_idle", "The minimum period of time that an entry must have been pending on another consumer before it can be claimed.").Advanced().AtVersion("4.4.0"),
+			docs.FieldInt("max_deliveries", "The maximum number of times an entry can be delivered before it is no longer claimed, and is instead acknowledged and written to the `dead_letter_stream`, if one is configured. If zero there is no limit.").Advanced().AtVersion("4.4.0"),
+			docs.FieldString("dead_letter_stream", "An optional stream to write entries to that have exceeded `max_deliveries`.").Advanced().AtVersion("4.4.0"),
 		).ChildDefaultAndTypesFromStruct(input.NewRedisStreamsConfig()),
 		Categories: []string{
 			"Services",
@@ -74,6 +100,16 @@ type redisStreamsReader struct {
 	timeout      time.Duration
 	commitPeriod time.Duration
 
+	claimPeriod  time.Duration
+	claimMinIdle time.Duration
+	lastClaim    time.Time
+
+	// The ID of each stream from which the next scan of pending entries
+	// begins, which allows scans to page through pending entries lists that
+	// are larger than the scan limit.
+	pendingCursors   map[string]string
+	pendingScanLimit int64
+
 	conf input.RedisStreamsConfig
 
 	backlogs map[string]string
@@ -96,6 +132,9 @@ func newRedisStreamsReader(conf input.RedisStreamsConfig, log log.Modular) (*red
 		ackSend:    make(map[string][]string, len(conf.Streams)),
 		closeChan:  make(chan struct{}),
 		closedChan: make(chan struct{}),
+
+		pendingCursors:   make(map[string]string, len(conf.Streams)),
+		pendingScanLimit: redisStreamsPendingScanLimit,
 	}
 
 	for _, str := range conf.Streams {
@@ -120,6 +159,20 @@ func newRedisStreamsReader(conf input.RedisStreamsConfig, log log.Modular) (*red
 		}
 	}
 
+	if period := conf.ClaimPeriod; len(period) > 0 {
+		var err error
+		if r.claimPeriod, err = time.ParseDuration(period); err != nil {
+			return nil, fmt.Errorf("failed to parse claim period string: %v", err)
+		}
+	}
+
+	if idle := conf.ClaimMinIdle; len(idle) > 0 {
+		var err error
+		if r.claimMinIdle, err = time.ParseDuration(idle); err != nil {
+			return nil, fmt.Errorf("failed to parse claim min idle string: %v", err)
+		}
+	}
+
 	go r.loop()
 	return r, nil
 }
@@ -228,6 +281,209 @@ func (r *redisStreamsReader) ConnectWithContext(ctx context.Context) error {
 	return nil
 }
 
+// The maximum number of pending entries inspected for each stream during each
+// attempt to claim entries.
+const redisStreamsPendingScanLimit = 1000
+
+// nextStreamID returns the lowest possible entry ID that is greater than the
+// one provided, which is used as the inclusive start of a range in order to
+// resume after an entry. Returns "-" if the ID cannot be parsed.
+func nextStreamID(id string) string {
+	i := strings.Index(id, "-")
+	if i == -1 {
+		return "-"
+	}
+	ms, err := strconv.ParseUint(id[:i], 10, 64)
+	if err != nil {
+		return "-"
+	}
+	seq, err := strconv.ParseUint(id[i+1:], 10, 64)
+	if err != nil {
+		return "-"
+	}
+	if seq == math.MaxUint64 {
+		return strconv.FormatUint(ms+1, 10) + "-0"
+	}
+	return strconv.FormatUint(ms, 10) + "-" + strconv.FormatUint(seq+1, 10)
+}
+
+// selectPending chooses from a page of pending entries those that should be
+// claimed and those that have exceeded the maximum number of deliveries, along
+// with the delivery count of each. Also returns the cursor from which the next
+// scan should begin, which is "-" once the end of the list has been reached.
+func (r *redisStreamsReader) selectPending(pending []redis.XPendingExt) (ids, deadIDs []string, deliveries map[string]int64, cursor string) {
+	deliveries = map[string]int64{}
+	for i, p := range pending {
+		if r.conf.Limit > 0 && int64(len(ids)) >= r.conf.Limit {
+			// The remaining entries are inspected during the next scan.
+			return ids, deadIDs, deliveries, pending[i].ID
+		}
+		// Entries pending on this consumer are either in flight or will be
+		// read from the backlog.
+		if p.Consumer == r.conf.ClientID || p.Idle < r.claimMinIdle {
+			continue
+		}
+		if r.conf.MaxDeliveries > 0 && p.RetryCount >= r.conf.MaxDeliveries {
+			deliveries[p.ID] = p.RetryCount
+			deadIDs = append(deadIDs, p.ID)
+			continue
+		}
+		deliveries[p.ID] = p.RetryCount + 1
+		ids = append(ids, p.ID)
+	}
+	if len(pending) == 0 || int64(len(pending)) < r.pendingScanLimit {
+		return ids, deadIDs, deliveries, "-"
+	}
+	return ids, deadIDs, deliveries, nextStreamID(pending[len(pending)-1].ID)
+}
+
+func (r *redisStreamsReader) toPendingMsg(stream string, xmsg redis.XMessage, deliveryCount int64) (pendingRedisStreamMsg, bool) {
+	body, exists := xmsg.Values[r.conf.BodyKey]
+	if !exists {
+		return pendingRedisStreamMsg{}, false
+	}
+	delete(xmsg.Values, r.conf.BodyKey)
+
+	var bodyBytes []byte
+	switch t := body.(type) {
+	case string:
+		bodyBytes = []byte(t)
+	case []byte:
+		bodyBytes = t
+	}
+	if bodyBytes == nil {
+		return pendingRedisStreamMsg{}, false
+	}
+
+	part := message.NewPart(bodyBytes)
+	part.MetaSet("redis_stream", xmsg.ID)
+	if deliveryCount > 0 {
+		part.MetaSet("redis_stream_delivery_count", strconv.FormatInt(deliveryCount, 10))
+	}
+	for k, v := range xmsg.Values {
+		part.MetaSet(k, fmt.Sprintf("%v", v))
+	}
+
+	msg := pendingRedisStreamMsg{
+		payload: message.QuickBatch(nil),
+		stream:  stream,
+		id:      xmsg.ID,
+	}
+	msg.payload.Append(part)
+	return msg, true
+}
+
+// deadLetter writes entries that have exceeded the maximum number of deliveries
+// to the dead letter stream, if one is configured, and acknowledges them.
+func (r *redisStreamsReader) deadLetter(client redis.UniversalClient, stream string, ids []string, deliveries map[string]int64) error {
+	if r.conf.DeadLetterStream != "" {
+		xmsgs, err := client.XClaim(&redis.XClaimArgs{
+			Stream:   stream,
+			Group:    r.conf.ConsumerGroup,
+			Consumer: r.conf.ClientID,
+			MinIdle:  r.claimMinIdle,
+			Messages: ids,
+		}).Result()
+		if err != nil {
+			return err
+		}
+
+		// Only entries that were successfully claimed are acknowledged, as
+		// another consumer may have claimed the others first.
+		ids = ids[:0]
+		for _, xmsg := range xmsgs {
+			if xmsg.Values != nil {
+				values := make(map[string]interface{}, len(xmsg.Values)+3)
+				for k, v := range xmsg.Values {
+					values[k] = v
+				}
+				values["source_stream"] = stream
+				values["source_id"] = xmsg.ID
+				values["delivery_count"] = deliveries[xmsg.ID]
+				if err := client.XAdd(&redis.XAddArgs{
+					Stream: r.conf.DeadLetterStream,
+					Values: values,
+				}).Err(); err != nil {
+					return err
+				}
+			}
+			ids = append(ids, xmsg.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	r.log.Warnf("Acknowledging %v entries of stream %v that exceeded %v deliveries\n", len(ids), stream, r.conf.MaxDeliveries)
+	return client.XAck(stream, r.conf.ConsumerGroup, ids...).Err()
+}
+
+// claimPending claims entries of each stream that have been pending on other
+// consumers of the group for longer than the minimum idle period. Each call
+// inspects a page of the pending entries list of each stream, continuing from
+// where the previous call left off.
+func (r *redisStreamsReader) claimPending(client redis.UniversalClient) ([]pendingRedisStreamMsg, error) {
+	var claimed []pendingRedisStreamMsg
+	for _, str := range r.conf.Streams {
+		start := r.pendingCursors[str]
+		if start == "" {
+			start = "-"
+		}
+		pending, err := client.XPendingExt(&redis.XPendingExtArgs{
+			Stream: str,
+			Group:  r.conf.ConsumerGroup,
+			Start:  start,
+			End:    "+",
+			Count:  r.pendingScanLimit,
+		}).Result()
+		if err != nil {
+			return claimed, err
+		}
+
+		ids, deadIDs, deliveries, cursor := r.selectPending(pending)
+
+		if len(deadIDs) > 0 {
+			if err := r.deadLetter(client, str, deadIDs, deliveries); err != nil {
+				return claimed, err
+			}
+		}
+		r.pendingCursors[str] = cursor
+		if len(ids) == 0 {
+			continue
+		}
+
+		xmsgs, err := client.XClaim(&redis.XClaimArgs{
+			Stream:   str,
+			Group:    r.conf.ConsumerGroup,
+			Consumer: r.conf.ClientID,
+			MinIdle:  r.claimMinIdle,
+			Messages: ids,
+		}).Result()
+		if err != nil {
+			return claimed, err
+		}
+
+		var deletedIDs []string
+		for _, xmsg := range xmsgs {
+			if xmsg.Values == nil {
+				// The entry was deleted from the stream whilst pending.
+				deletedIDs = append(deletedIDs, xmsg.ID)
+				continue
+			}
+			if msg, ok := r.toPendingMsg(str, xmsg, deliveries[xmsg.ID]); ok {
+				claimed = append(claimed, msg)
+			}
+		}
+		if len(deletedIDs) > 0 {
+			r.addAsyncAcks(str, deletedIDs...)
+		}
+		if len(xmsgs) > 0 {
+			r.log.Infof("Claimed %v pending entries of stream %v\n", len(xmsgs), str)
+		}
+	}
+	return claimed, nil
+}
+
 func (r *redisStreamsReader) read() (pendingRedisStreamMsg, error) {
 	var client redis.UniversalClient
 	var msg pendingRedisStreamMsg
@@ -248,6 +504,18 @@ func (r *redisStreamsReader) read() (pendingRedisStreamMsg, error) {
 		return msg, nil
 	}
 
+	if r.claimPeriod > 0 && time.Since(r.lastClaim) >= r.claimPeriod {
+		r.lastClaim = time.Now()
+		claimed, err := r.claimPending(client)
+		if err != nil {
+			r.log.Errorf("Failed to claim pending entries: %v\n", err)
+		}
+		if len(claimed) > 0 {
+			r.pendingMsgs = claimed[1:]
+			return claimed[0], nil
+		}
+	}
+
 	strs := make([]string, len(r.conf.Streams)*2)
 	for i, str := range r.conf.Streams {
 		strs[i] = str
@@ -277,7 +545,11 @@ func (r *redisStreamsReader) read() (pendingRedisStreamMsg, error) {
 
 	pendingMsgs := []pendingRedisStreamMsg{}
 	for _, strRes := range res {
+		// Entries read from the backlog have been delivered before, but we do
+		// not know how many times.
+		var deliveryCount int64 = 1
 		if _, exists := r.backlogs[strRes.Stream]; exists {
+			deliveryCount = 0
 			if len(strRes.Messages) > 0 {
 				r.backlogs[strRes.Stream] = strRes.Messages[len(strRes.Messages)-1].ID
 			} else {
@@ -285,35 +557,10 @@ func (r *redisStreamsReader) read() (pendingRedisStreamMsg, error) {
 			}
 		}
 		for _, xmsg := range strRes.Messages {
-			body, exists := xmsg.Values[r.conf.BodyKey]
-			if !exists {
-				continue
-			}
-			delete(xmsg.Values, r.conf.BodyKey)
-
-			var bodyBytes []byte
-			switch t := body.(type) {
-			case string:
-				bodyBytes = []byte(t)
-			case []byte:
-				bodyBytes = t
-			}
-			if bodyBytes == nil {
+			nextMsg, ok := r.toPendingMsg(strRes.Stream, xmsg, deliveryCount)
+			if !ok {
 				continue
 			}
-
-			part := message.NewPart(bodyBytes)
-			part.MetaSet("redis_stream", xmsg.ID)
-			for k, v := range xmsg.Values {
-				part.MetaSet(k, fmt.Sprintf("%v", v))
-			}
-
-			nextMsg := pendingRedisStreamMsg{
-				payload: message.QuickBatch(nil),
-				stream:  strRes.Stream,
-				id:      xmsg.ID,
-			}
-			nextMsg.payload.Append(part)
 			if msg.payload == nil {
 				msg = nextMsg
 			} else {
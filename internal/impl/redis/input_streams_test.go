@@ -0,0 +1,81 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func TestNextStreamID(t *testing.T) {
+	for in, exp := range map[string]string{
+		"1526985054069-0":                    "1526985054069-1",
+		"1526985054069-41":                   "1526985054069-42",
+		"1526985054069-18446744073709551615": "1526985054070-0",
+		"nope":                               "-",
+		"1526985054069-nope":                 "-",
+		"":                                   "-",
+	} {
+		assert.Equal(t, exp, nextStreamID(in), in)
+	}
+}
+
+func testStreamsReader(t *testing.T, limit, scanLimit int64) *redisStreamsReader {
+	t.Helper()
+
+	conf := input.NewRedisStreamsConfig()
+	conf.URL = "redis://localhost:6379"
+	conf.ClientID = "foo"
+	conf.Limit = limit
+	conf.MaxDeliveries = 3
+	conf.ClaimMinIdle = "1m"
+
+	r, err := newRedisStreamsReader(conf, log.Noop())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		r.CloseAsync()
+	})
+	r.pendingScanLimit = scanLimit
+	return r
+}
+
+func TestRedisStreamsSelectPending(t *testing.T) {
+	r := testStreamsReader(t, 10, 5)
+
+	ids, deadIDs, deliveries, cursor := r.selectPending([]redis.XPendingExt{
+		{ID: "1-0", Consumer: "bar", Idle: time.Hour, RetryCount: 1},
+		{ID: "2-0", Consumer: "foo", Idle: time.Hour, RetryCount: 1},
+		{ID: "3-0", Consumer: "bar", Idle: time.Second, RetryCount: 1},
+		{ID: "4-0", Consumer: "bar", Idle: time.Hour, RetryCount: 3},
+		{ID: "5-0", Consumer: "bar", Idle: time.Hour, RetryCount: 2},
+	})
+	assert.Equal(t, []string{"1-0", "5-0"}, ids)
+	assert.Equal(t, []string{"4-0"}, deadIDs)
+	assert.Equal(t, map[string]int64{"1-0": 2, "4-0": 3, "5-0": 3}, deliveries)
+	assert.Equal(t, "5-1", cursor, "full page continues after the last entry")
+
+	_, _, _, cursor = r.selectPending([]redis.XPendingExt{
+		{ID: "6-0", Consumer: "bar", Idle: time.Hour, RetryCount: 1},
+	})
+	assert.Equal(t, "-", cursor, "partial page starts again from the beginning")
+
+	_, _, _, cursor = r.selectPending(nil)
+	assert.Equal(t, "-", cursor)
+}
+
+func TestRedisStreamsSelectPendingLimit(t *testing.T) {
+	r := testStreamsReader(t, 2, 5)
+
+	ids, _, _, cursor := r.selectPending([]redis.XPendingExt{
+		{ID: "1-0", Consumer: "bar", Idle: time.Hour},
+		{ID: "2-0", Consumer: "bar", Idle: time.Hour},
+		{ID: "3-0", Consumer: "bar", Idle: time.Hour},
+	})
+	assert.Equal(t, []string{"1-0", "2-0"}, ids)
+	assert.Equal(t, "3-0", cursor, "entries beyond the limit are inspected next")
+}
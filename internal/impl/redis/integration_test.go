@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/integration"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
@@ -103,6 +105,89 @@ input:
 		})
 	})
 
+	t.Run("streams_claim_pending", func(t *testing.T) {
+		t.Parallel()
+
+		client := redis.NewClient(&redis.Options{
+			Addr: fmt.Sprintf("localhost:%v", resource.GetPort("6379/tcp")),
+		})
+		t.Cleanup(func() {
+			_ = client.Close()
+		})
+
+		require.NoError(t, client.XGroupCreateMkStream("claimstream", "claimgroup", "0").Err())
+		for _, body := range []string{"foo", "bar"} {
+			require.NoError(t, client.XAdd(&redis.XAddArgs{
+				Stream: "claimstream",
+				Values: map[string]interface{}{"body": body},
+			}).Err())
+		}
+
+		// Read both entries as a consumer that never acknowledges them.
+		res, err := client.XReadGroup(&redis.XReadGroupArgs{
+			Group:    "claimgroup",
+			Consumer: "deadconsumer",
+			Streams:  []string{"claimstream", ">"},
+			Count:    1,
+		}).Result()
+		require.NoError(t, err)
+		require.Len(t, res[0].Messages, 1)
+
+		conf := input.NewRedisStreamsConfig()
+		conf.URL = fmt.Sprintf("tcp://localhost:%v", resource.GetPort("6379/tcp"))
+		conf.Streams = []string{"claimstream"}
+		conf.ConsumerGroup = "claimgroup"
+		conf.ClientID = "liveconsumer"
+		conf.ClaimPeriod = "1ms"
+		conf.ClaimMinIdle = "1ms"
+		conf.MaxDeliveries = 2
+		conf.DeadLetterStream = "claimdlq"
+
+		r, err := newRedisStreamsReader(conf, mock.NewManager().Logger())
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			r.CloseAsync()
+			_ = r.WaitForClose(time.Second)
+		})
+		require.NoError(t, r.ConnectWithContext(context.Background()))
+
+		<-time.After(time.Millisecond * 10)
+
+		// The first read claims the entry pending on the dead consumer.
+		msg, ackFn, err := r.ReadWithContext(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "foo", string(msg.Get(0).Get()))
+		assert.Equal(t, "2", msg.Get(0).MetaGet("redis_stream_delivery_count"))
+
+		// Nack the entry so that it remains pending, and then reassign it to
+		// the dead consumer so that it can be claimed again.
+		require.NoError(t, ackFn(context.Background(), errors.New("nope")))
+		require.NoError(t, client.XClaim(&redis.XClaimArgs{
+			Stream:   "claimstream",
+			Group:    "claimgroup",
+			Consumer: "deadconsumer",
+			Messages: []string{msg.Get(0).MetaGet("redis_stream")},
+		}).Err())
+		r.pendingMsgsMut.Lock()
+		r.pendingMsgs = nil
+		r.pendingMsgsMut.Unlock()
+
+		<-time.After(time.Millisecond * 10)
+
+		// The entry has now exceeded its deliveries and is dead lettered, and
+		// so the next read is the second entry.
+		msg, _, err = r.ReadWithContext(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "bar", string(msg.Get(0).Get()))
+		assert.Equal(t, "1", msg.Get(0).MetaGet("redis_stream_delivery_count"))
+
+		dlqRes, err := client.XRange("claimdlq", "-", "+").Result()
+		require.NoError(t, err)
+		require.Len(t, dlqRes, 1)
+		assert.Equal(t, "foo", dlqRes[0].Values["body"])
+		assert.Equal(t, "claimstream", dlqRes[0].Values["source_stream"])
+	})
+
 	t.Run("pubsub", func(t *testing.T) {
 		t.Parallel()
 		template := `
@@ -0,0 +1,326 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/gofrs/uuid"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	rlAlgorithmTokenBucket   = "token_bucket"
+	rlAlgorithmSlidingWindow = "sliding_window"
+
+	rlOnErrorFail  = "fail"
+	rlOnErrorAllow = "allow"
+	rlOnErrorLocal = "local"
+)
+
+// Refills the bucket in proportion to the time elapsed since it was last
+// accessed and attempts to take a token from it. Returns zero if a token was
+// taken, otherwise the number of milliseconds until a token is available. The
+// clock of the Redis server is used so that instances of Benthos with skewed
+// clocks agree on the state of the bucket, and the timestamp of the bucket
+// never moves backwards.
+var rlTokenBucketScript = redis.NewScript(`
+redis.replicate_commands()
+
+local capacity = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+  tokens = capacity
+  ts = now
+end
+
+if now > ts then
+  tokens = math.min(capacity, tokens + ((now - ts) * capacity / interval))
+  ts = now
+end
+
+local wait = 0
+if tokens < 1 then
+  wait = math.ceil((1 - tokens) * interval / capacity)
+else
+  tokens = tokens - 1
+end
+
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(ts))
+redis.call("PEXPIRE", KEYS[1], interval * 2)
+return wait
+`)
+
+// Removes requests that have fallen outside of the window and records a new
+// request if there is room. Returns zero if the request was recorded,
+// otherwise the number of milliseconds until the oldest request within the
+// window expires. As with the token bucket the clock of the Redis server is
+// used.
+var rlSlidingWindowScript = redis.NewScript(`
+redis.replicate_commands()
+
+local count = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - interval)
+if redis.call("ZCARD", KEYS[1]) < count then
+  redis.call("ZADD", KEYS[1], now, ARGV[3])
+  redis.call("PEXPIRE", KEYS[1], interval)
+  return 0
+end
+
+local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+return math.max(1, tonumber(oldest[2]) + interval - now)
+`)
+
+func redisRatelimitConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		Version("4.4.0").
+		Summary(`A rate limit that is shared across any number of instances of Benthos by storing its state in Redis.`).
+		Description(`
+Each access of the rate limit is performed with an atomic Lua script, and therefore the ` + "`count`" + ` is honoured across all instances of Benthos that share the same Redis key. Time is measured with the clock of the Redis server and so the clocks of those instances do not need to be synchronised. The key is made up of the ` + "`prefix`" + ` followed by the label of the rate limit resource, and so different rate limit resources never share a quota unless they have the same label.
+
+### Algorithms
+
+The ` + "`token_bucket`" + ` algorithm allows bursts of up to ` + "`count`" + ` requests and refills the bucket gradually such that ` + "`count`" + ` requests are allowed per ` + "`interval`" + ` on average. The state of each rate limit is a single hash and is therefore cheap regardless of the ` + "`count`" + `.
+
+The ` + "`sliding_window`" + ` algorithm records each request in a sorted set and guarantees that no more than ` + "`count`" + ` requests are allowed within any period of ` + "`interval`" + `. This is stricter than a token bucket but the memory used by each rate limit grows with the ` + "`count`" + `.
+
+### Redis Failures
+
+When Redis cannot be reached the behaviour is determined by the field ` + "`on_error`" + `. With ` + "`fail`" + ` the error is returned to the component accessing the rate limit, which will typically wait before trying again. With ` + "`allow`" + ` all requests are allowed until Redis can be reached again, and with ` + "`local`" + ` requests are limited to ` + "`count`" + ` per ` + "`interval`" + ` by each instance individually, in the same way as the ` + "[`local`](/docs/components/rate_limits/local)" + ` rate limit.`)
+
+	for _, f := range clientFields() {
+		spec = spec.Field(f)
+	}
+
+	return spec.
+		Field(service.NewIntField("count").
+			Description("The maximum number of requests to allow for a given period of time.").
+			Default(1000)).
+		Field(service.NewDurationField("interval").
+			Description("The time window to limit requests by.").
+			Default("1s")).
+		Field(service.NewStringEnumField("algorithm", rlAlgorithmTokenBucket, rlAlgorithmSlidingWindow).
+			Description("The algorithm used to limit requests.").
+			Default(rlAlgorithmTokenBucket)).
+		Field(service.NewStringField("prefix").
+			Description("A prefix added to the label of the rate limit resource in order to produce the key that its state is stored under.").
+			Default("benthos_rate_limit:").
+			Advanced()).
+		Field(service.NewStringEnumField("on_error", rlOnErrorFail, rlOnErrorAllow, rlOnErrorLocal).
+			Description("The behaviour of the rate limit when Redis cannot be reached.").
+			Default(rlOnErrorFail).
+			Advanced()).
+		Example("Shared API Quota", "In this example all instances of Benthos that share this config are limited to a combined total of 100 requests per second to an HTTP API.", `
+rate_limit_resources:
+  - label: foo_api
+    redis:
+      url: redis://localhost:6379
+      count: 100
+      interval: 1s
+
+output:
+  http_client:
+    url: http://example.com/api
+    verb: POST
+    rate_limit: foo_api
+`)
+}
+
+func init() {
+	err := service.RegisterRateLimit(
+		"redis", redisRatelimitConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.RateLimit, error) {
+			return newRedisRatelimitFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newRedisRatelimitFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*redisRatelimit, error) {
+	client, err := getClient(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := conf.FieldInt("count")
+	if err != nil {
+		return nil, err
+	}
+	interval, err := conf.FieldDuration("interval")
+	if err != nil {
+		return nil, err
+	}
+	algorithm, err := conf.FieldString("algorithm")
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := conf.FieldString("prefix")
+	if err != nil {
+		return nil, err
+	}
+	onError, err := conf.FieldString("on_error")
+	if err != nil {
+		return nil, err
+	}
+	return newRedisRatelimit(client, mgr.Logger(), prefix+mgr.Label(), algorithm, onError, count, interval)
+}
+
+//------------------------------------------------------------------------------
+
+type redisRatelimit struct {
+	client redis.UniversalClient
+	log    *service.Logger
+
+	key      string
+	script   *redis.Script
+	onError  string
+	count    int
+	interval time.Duration
+
+	// Used when the on_error behaviour is local.
+	localMut    sync.Mutex
+	localBucket int
+	localReset  time.Time
+}
+
+func newRedisRatelimit(
+	client redis.UniversalClient,
+	log *service.Logger,
+	key, algorithm, onError string,
+	count int,
+	interval time.Duration,
+) (*redisRatelimit, error) {
+	if count <= 0 {
+		return nil, errors.New("count must be larger than zero")
+	}
+	if interval < time.Millisecond {
+		return nil, errors.New("interval must be at least one millisecond")
+	}
+
+	r := &redisRatelimit{
+		client:      client,
+		log:         log,
+		key:         key,
+		onError:     onError,
+		count:       count,
+		interval:    interval,
+		localBucket: count,
+		localReset:  time.Now().Add(interval),
+	}
+
+	switch algorithm {
+	case rlAlgorithmTokenBucket:
+		r.script = rlTokenBucketScript
+	case rlAlgorithmSlidingWindow:
+		r.script = rlSlidingWindowScript
+	default:
+		return nil, fmt.Errorf("unrecognised algorithm: %v", algorithm)
+	}
+	return r, nil
+}
+
+// ctxScripter runs scripts with a context, which the scripting methods of
+// the client otherwise do not accept.
+type ctxScripter struct {
+	ctx    context.Context
+	client redis.UniversalClient
+}
+
+func (c ctxScripter) eval(cmd, script string, keys []string, args ...interface{}) *redis.Cmd {
+	cmdArgs := make([]interface{}, 0, 3+len(keys)+len(args))
+	cmdArgs = append(cmdArgs, cmd, script, len(keys))
+	for _, k := range keys {
+		cmdArgs = append(cmdArgs, k)
+	}
+	cmdArgs = append(cmdArgs, args...)
+	return c.client.DoContext(c.ctx, cmdArgs...)
+}
+
+func (c ctxScripter) Eval(script string, keys []string, args ...interface{}) *redis.Cmd {
+	return c.eval("eval", script, keys, args...)
+}
+
+func (c ctxScripter) EvalSha(sha1 string, keys []string, args ...interface{}) *redis.Cmd {
+	return c.eval("evalsha", sha1, keys, args...)
+}
+
+func (c ctxScripter) ScriptExists(hashes ...string) *redis.BoolSliceCmd {
+	return c.client.ScriptExists(hashes...)
+}
+
+func (c ctxScripter) ScriptLoad(script string) *redis.StringCmd {
+	return c.client.ScriptLoad(script)
+}
+
+func (r *redisRatelimit) accessRedis(ctx context.Context) (time.Duration, error) {
+	args := []interface{}{r.count, r.interval.Milliseconds()}
+	if r.script == rlSlidingWindowScript {
+		// Each request within the window requires a unique member.
+		id, err := uuid.NewV4()
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, id.String())
+	}
+
+	waitMs, err := r.script.Run(ctxScripter{ctx: ctx, client: r.client}, []string{r.key}, args...).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(waitMs) * time.Millisecond, nil
+}
+
+func (r *redisRatelimit) accessLocal() time.Duration {
+	r.localMut.Lock()
+	defer r.localMut.Unlock()
+
+	if now := time.Now(); !now.Before(r.localReset) {
+		r.localBucket = r.count
+		r.localReset = now.Add(r.interval)
+	}
+	if r.localBucket <= 0 {
+		return time.Until(r.localReset)
+	}
+	r.localBucket--
+	return 0
+}
+
+func (r *redisRatelimit) Access(ctx context.Context) (time.Duration, error) {
+	wait, err := r.accessRedis(ctx)
+	if err == nil {
+		return wait, nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return 0, ctxErr
+	}
+
+	switch r.onError {
+	case rlOnErrorAllow:
+		r.log.Debugf("Allowing request as rate limit state could not be accessed: %v", err)
+		return 0, nil
+	case rlOnErrorLocal:
+		r.log.Debugf("Falling back to local rate limit as state could not be accessed: %v", err)
+		return r.accessLocal(), nil
+	}
+	return 0, err
+}
+
+func (r *redisRatelimit) Close(ctx context.Context) error {
+	return r.client.Close()
+}
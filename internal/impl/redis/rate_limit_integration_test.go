@@ -0,0 +1,81 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/integration"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestIntegrationRedisRatelimit(t *testing.T) {
+	integration.CheckSkip(t)
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pool.MaxWait = time.Second * 30
+
+	resource, err := pool.Run("redis", "latest", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	_ = resource.Expire(900)
+
+	url := fmt.Sprintf("tcp://localhost:%v/1", resource.GetPort("6379/tcp"))
+	newRatelimit := func(algorithm string) *redisRatelimit {
+		pConf, err := redisRatelimitConfig().ParseYAML(fmt.Sprintf(`
+url: %v
+count: 3
+interval: 1s
+algorithm: %v
+prefix: %v_
+`, url, algorithm, t.Name()), nil)
+		require.NoError(t, err)
+
+		r, err := newRedisRatelimitFromConfig(pConf, service.MockResources())
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = r.Close(context.Background())
+		})
+		return r
+	}
+
+	require.NoError(t, pool.Retry(func() error {
+		return newRatelimit("token_bucket").client.Ping().Err()
+	}))
+
+	for _, algorithm := range []string{"token_bucket", "sliding_window"} {
+		// Two instances sharing the same key must share the same quota.
+		rOne, rTwo := newRatelimit(algorithm), newRatelimit(algorithm)
+		rOne.key += algorithm
+		rTwo.key += algorithm
+
+		ctx := context.Background()
+		for i, r := range []*redisRatelimit{rOne, rTwo, rOne} {
+			wait, err := r.Access(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, time.Duration(0), wait, "%v: %v", algorithm, i)
+		}
+
+		wait, err := rTwo.Access(ctx)
+		require.NoError(t, err)
+		assert.Greater(t, int64(wait), int64(0), algorithm)
+		assert.LessOrEqual(t, int64(wait), int64(time.Second), algorithm)
+
+		<-time.After(wait)
+
+		wait, err = rOne.Access(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(0), wait, algorithm)
+	}
+}
@@ -0,0 +1,85 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestRedisRatelimitUnreachable(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	for _, onError := range []string{"fail", "allow", "local"} {
+		onError := onError
+		t.Run(onError, func(t *testing.T) {
+			conf, err := redisRatelimitConfig().ParseYAML(`
+url: redis://localhost:1
+count: 2
+interval: 1m
+on_error: `+onError+`
+`, nil)
+			require.NoError(t, err)
+
+			r, err := newRedisRatelimitFromConfig(conf, service.MockResources())
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				_ = r.Close(tCtx)
+			})
+
+			for i := 0; i < 3; i++ {
+				wait, err := r.Access(tCtx)
+				switch onError {
+				case "fail":
+					require.Error(t, err)
+				case "allow":
+					require.NoError(t, err)
+					assert.Equal(t, time.Duration(0), wait)
+				case "local":
+					require.NoError(t, err)
+					if i < 2 {
+						assert.Equal(t, time.Duration(0), wait, i)
+					} else {
+						assert.Greater(t, int64(wait), int64(0))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRedisRatelimitContextCancelled(t *testing.T) {
+	conf, err := redisRatelimitConfig().ParseYAML(`
+url: redis://localhost:1
+on_error: allow
+`, nil)
+	require.NoError(t, err)
+
+	r, err := newRedisRatelimitFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = r.Close(context.Background())
+	})
+
+	tCtx, done := context.WithCancel(context.Background())
+	done()
+
+	_, err = r.Access(tCtx)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestRedisRatelimitBadConfig(t *testing.T) {
+	conf, err := redisRatelimitConfig().ParseYAML(`
+url: redis://localhost:6379
+count: 0
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newRedisRatelimitFromConfig(conf, service.MockResources())
+	require.Error(t, err)
+}
@@ -0,0 +1,111 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func init() {
+	lookup := &secretsLookup{
+		client: http.DefaultClient,
+		getenv: os.Getenv,
+	}
+	err := service.RegisterSecretsProvider("vault", lookup.Lookup)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// secretsLookup obtains secrets from HashiCorp Vault, where the path is the
+// API path of a secret followed by a hash and the field to select
+// (secret/data/foo#field). Both KV version 1 and 2 secrets engines are
+// supported, and the field can be omitted when a secret has only one.
+//
+// The address and token of the Vault server are read from the standard
+// VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE environment variables.
+type secretsLookup struct {
+	client *http.Client
+	getenv func(string) string
+}
+
+func (s *secretsLookup) Lookup(ctx context.Context, path string) (string, error) {
+	secretPath, field := path, ""
+	if i := strings.LastIndexByte(path, '#'); i != -1 {
+		secretPath, field = path[:i], path[i+1:]
+	}
+
+	addr := s.getenv("VAULT_ADDR")
+	if addr == "" {
+		addr = "https://127.0.0.1:8200"
+	}
+	token := s.getenv("VAULT_TOKEN")
+	if token == "" {
+		return "", errors.New("environment variable VAULT_TOKEN is not set")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(secretPath, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := s.getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		return "", fmt.Errorf("unexpected status code %v: %s", res.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	// KV version 2 secrets are nested within a further data object alongside
+	// metadata.
+	data := body.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, hasMeta := data["metadata"]; hasMeta {
+			data = nested
+		}
+	}
+
+	if field == "" {
+		if len(data) != 1 {
+			return "", fmt.Errorf("secret has %v fields and therefore one must be selected with path#field", len(data))
+		}
+		for k := range data {
+			field = k
+		}
+	}
+
+	v, exists := data[field]
+	if !exists {
+		return "", fmt.Errorf("field '%v' does not exist within secret", field)
+	}
+	if str, ok := v.(string); ok {
+		return str, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,74 @@
+package vault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultSecretsLookup(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "footoken" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/db":
+			_, _ = w.Write([]byte(`{"data":{"data":{"user":"foo","pass":"bar"},"metadata":{"version":1}}}`))
+		case "/v1/kv/single":
+			_, _ = w.Write([]byte(`{"data":{"value":"hunter2"}}`))
+		case "/v1/kv/port":
+			_, _ = w.Write([]byte(`{"data":{"port":5432}}`))
+		default:
+			http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	env := map[string]string{
+		"VAULT_ADDR":  ts.URL,
+		"VAULT_TOKEN": "footoken",
+	}
+	lookup := &secretsLookup{
+		client: ts.Client(),
+		getenv: func(k string) string {
+			return env[k]
+		},
+	}
+
+	tests := map[string]string{
+		"secret/data/db#user":  "foo",
+		"/secret/data/db#pass": "bar",
+		"kv/single":            "hunter2",
+		"kv/single#value":      "hunter2",
+		"kv/port":              "5432",
+	}
+	for path, exp := range tests {
+		v, err := lookup.Lookup(context.Background(), path)
+		require.NoError(t, err, path)
+		assert.Equal(t, exp, v, path)
+	}
+
+	for path, errContains := range map[string]string{
+		"secret/data/db":      "secret has 2 fields",
+		"secret/data/db#nope": "field 'nope' does not exist",
+		"kv/nope":             "unexpected status code 404",
+	} {
+		_, err := lookup.Lookup(context.Background(), path)
+		require.Error(t, err, path)
+		assert.Contains(t, err.Error(), errContains, path)
+	}
+
+	env["VAULT_TOKEN"] = "bartoken"
+	_, err := lookup.Lookup(context.Background(), "kv/single")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "permission denied")
+
+	delete(env, "VAULT_TOKEN")
+	_, err = lookup.Lookup(context.Background(), "kv/single")
+	require.EqualError(t, err, "environment variable VAULT_TOKEN is not set")
+}
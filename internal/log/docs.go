@@ -15,11 +15,21 @@ func Spec() docs.FieldSpecs {
 		docs.FieldString("level", "Set the minimum severity level for emitting logs.").HasOptions(
 			"OFF", "FATAL", "ERROR", "WARN", "INFO", "DEBUG", "TRACE", "ALL", "NONE",
 		).HasDefault("INFO").LinterFunc(nil),
+		docs.FieldString("level_overrides", "A map of log levels for individual components, which take precedence over `level`. Components can be targeted by their label, or by a path prefix beginning with `root`, such as `root.output`, which applies to the component at that path and all of its children. Levels can also be viewed and changed at runtime with the `/log/level` endpoint of the [HTTP server](/docs/components/http/about).", map[string]string{
+			"my_http_output":             "DEBUG",
+			"root.input.broker.inputs.0": "TRACE",
+		}).Map().HasDefault(map[string]string{}).Advanced(),
 		docs.FieldString("format", "Set the format of emitted logs.").HasOptions("json", "logfmt").HasDefault("logfmt"),
 		docs.FieldBool("add_timestamp", "Whether to include timestamps in logs.").HasDefault(false),
 		docs.FieldString("static_fields", "A map of key/value pairs to add to each structured log.").Map().HasDefault(map[string]string{
 			"@service": "benthos",
 		}),
+		docs.FieldObject("sampling", "Limit the rate at which repeated log messages are emitted. Messages are counted by their component, level and message within each period, where the first `initial` occurrences are emitted followed by every `thereafter`th occurrence.").WithChildren(
+			docs.FieldBool("enabled", "Whether to enable sampling of log messages.").HasDefault(false),
+			docs.FieldString("period", "The period after which the counts of messages are reset.").HasDefault("1s"),
+			docs.FieldInt("initial", "The number of occurrences of a message emitted within each period before sampling is applied.").HasDefault(10),
+			docs.FieldInt("thereafter", "After the initial occurrences of a message only every Nth occurrence is emitted within each period. Setting this to zero drops all occurrences after the initial count.").HasDefault(100),
+		).Advanced(),
 		docs.FieldObject("file", "Experimental: Specify fields for optionally writing logs to a file.").WithChildren(
 			docs.FieldString("path", "The file path to write logs to, if the file does not exist it will be created. Leave this field empty or unset to disable file based logging.").HasDefault(""),
 			docs.FieldBool("rotate", "Whether to rotate log files automatically.").HasDefault(false),
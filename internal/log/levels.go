@@ -0,0 +1,248 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+func parseLevel(level string) (logrus.Level, error) {
+	switch strings.ToUpper(level) {
+	case "OFF", "NONE":
+		return logrus.PanicLevel, nil
+	case "FATAL":
+		return logrus.FatalLevel, nil
+	case "ERROR":
+		return logrus.ErrorLevel, nil
+	case "WARN":
+		return logrus.WarnLevel, nil
+	case "INFO":
+		return logrus.InfoLevel, nil
+	case "DEBUG":
+		return logrus.DebugLevel, nil
+	case "TRACE", "ALL":
+		return logrus.TraceLevel, nil
+	}
+	return logrus.InfoLevel, fmt.Errorf("log level '%v' not recognized", level)
+}
+
+func levelString(level logrus.Level) string {
+	switch level {
+	case logrus.PanicLevel:
+		return "OFF"
+	case logrus.FatalLevel:
+		return "FATAL"
+	case logrus.ErrorLevel:
+		return "ERROR"
+	case logrus.WarnLevel:
+		return "WARN"
+	case logrus.InfoLevel:
+		return "INFO"
+	case logrus.DebugLevel:
+		return "DEBUG"
+	}
+	return "TRACE"
+}
+
+//------------------------------------------------------------------------------
+
+type levelsSnapshot struct {
+	level     logrus.Level
+	overrides map[string]logrus.Level
+}
+
+// Levels holds the log level of a logger along with overrides for individual
+// components, which can be changed at runtime. Overrides are keyed either by
+// the label of a component, or by a path prefix beginning with `root`, such as
+// `root.output`, which applies to the component at that path and all of its
+// children.
+type Levels struct {
+	writeMut sync.Mutex
+	current  atomic.Value
+}
+
+func newLevels(level logrus.Level, overrides map[string]string) (*Levels, error) {
+	snap := &levelsSnapshot{
+		level:     level,
+		overrides: make(map[string]logrus.Level, len(overrides)),
+	}
+	for k, v := range overrides {
+		lvl, err := parseLevel(v)
+		if err != nil {
+			return nil, fmt.Errorf("level override '%v': %w", k, err)
+		}
+		snap.overrides[k] = lvl
+	}
+	l := &Levels{}
+	l.current.Store(snap)
+	return l, nil
+}
+
+func (l *Levels) snapshot() *levelsSnapshot {
+	return l.current.Load().(*levelsSnapshot)
+}
+
+func isPathKey(key string) bool {
+	return key == "root" || strings.HasPrefix(key, "root.")
+}
+
+// levelFor returns the effective log level of a component identified by its
+// label and path. A label override takes precedence over path overrides, and
+// of the path overrides the longest matching prefix wins.
+func (l *Levels) levelFor(label, path string) logrus.Level {
+	snap := l.snapshot()
+	if len(snap.overrides) == 0 {
+		return snap.level
+	}
+	if label != "" {
+		if lvl, exists := snap.overrides[label]; exists {
+			return lvl
+		}
+	}
+	if path == "" {
+		return snap.level
+	}
+	lvl, matched := snap.level, -1
+	for k, v := range snap.overrides {
+		if !isPathKey(k) || len(k) <= matched {
+			continue
+		}
+		if path == k || strings.HasPrefix(path, k+".") {
+			lvl, matched = v, len(k)
+		}
+	}
+	return lvl
+}
+
+// Get returns the current log level and the overrides of components.
+func (l *Levels) Get() (level string, overrides map[string]string) {
+	snap := l.snapshot()
+	overrides = make(map[string]string, len(snap.overrides))
+	for k, v := range snap.overrides {
+		overrides[k] = levelString(v)
+	}
+	return levelString(snap.level), overrides
+}
+
+// SetLevel changes the log level of all components without an override.
+func (l *Levels) SetLevel(level string) error {
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	l.writeMut.Lock()
+	defer l.writeMut.Unlock()
+
+	prev := l.snapshot()
+	l.current.Store(&levelsSnapshot{
+		level:     lvl,
+		overrides: prev.overrides,
+	})
+	return nil
+}
+
+// SetOverride changes the log level of components matching a label or path
+// prefix, an empty level removes the override.
+func (l *Levels) SetOverride(key, level string) error {
+	var lvl logrus.Level
+	if level != "" {
+		var err error
+		if lvl, err = parseLevel(level); err != nil {
+			return err
+		}
+	}
+
+	l.writeMut.Lock()
+	defer l.writeMut.Unlock()
+
+	prev := l.snapshot()
+	overrides := make(map[string]logrus.Level, len(prev.overrides)+1)
+	for k, v := range prev.overrides {
+		overrides[k] = v
+	}
+	if level == "" {
+		delete(overrides, key)
+	} else {
+		overrides[key] = lvl
+	}
+	l.current.Store(&levelsSnapshot{
+		level:     prev.level,
+		overrides: overrides,
+	})
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type levelsBody struct {
+	Level          string            `json:"level"`
+	LevelOverrides map[string]string `json:"level_overrides"`
+}
+
+// LevelsHandlerFunc returns an http.HandlerFunc for viewing and changing the
+// log levels of a logger at runtime, or nil if the logger does not support
+// this.
+//
+// A GET request returns the current level and overrides as a JSON object. A
+// POST request with a JSON object of the same form changes the level if
+// specified, and adds or replaces the overrides listed, where an override with
+// an empty level is removed.
+func LevelsHandlerFunc(logger Modular) http.HandlerFunc {
+	l, ok := logger.(*Logger)
+	if !ok || l.levels == nil {
+		return nil
+	}
+	levels := l.levels
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost, http.MethodPut:
+			var body levelsBody
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, fmt.Sprintf("Failed to parse request body: %v", err), http.StatusBadRequest)
+				return
+			}
+			if body.Level != "" {
+				if _, err := parseLevel(body.Level); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+			for k, v := range body.LevelOverrides {
+				if v == "" {
+					continue
+				}
+				if _, err := parseLevel(v); err != nil {
+					http.Error(w, fmt.Sprintf("Level override '%v': %v", k, err), http.StatusBadRequest)
+					return
+				}
+			}
+
+			if body.Level != "" {
+				_ = levels.SetLevel(body.Level)
+			}
+			for k, v := range body.LevelOverrides {
+				_ = levels.SetOverride(k, v)
+			}
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var res levelsBody
+		res.Level, res.LevelOverrides = levels.Get()
+		resBytes, err := json.Marshal(res)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resBytes)
+	}
+}
@@ -0,0 +1,143 @@
+package log
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerLevelOverrides(t *testing.T) {
+	loggerConfig := NewConfig()
+	loggerConfig.LogLevel = "WARN"
+	loggerConfig.StaticFields = map[string]string{}
+	loggerConfig.LevelOverrides = map[string]string{
+		"foo":                 "DEBUG",
+		"root.output":         "INFO",
+		"root.output.broker":  "TRACE",
+		"root.pipeline.quiet": "OFF",
+	}
+
+	var buf bytes.Buffer
+
+	logger, err := NewV2(&buf, loggerConfig)
+	require.NoError(t, err)
+
+	logger.Infoln("root info")
+	logger.Warnln("root warn")
+
+	logger.WithFields(map[string]string{"label": "foo"}).Debugln("foo debug")
+	logger.WithFields(map[string]string{"label": "foo", "path": "root.output.broker"}).Traceln("foo trace")
+
+	outLogger := logger.WithFields(map[string]string{"path": "root.output"})
+	outLogger.Infoln("output info")
+	outLogger.Debugln("output debug")
+
+	logger.WithFields(map[string]string{"path": "root.output.broker.outputs.0"}).Traceln("broker trace")
+	logger.WithFields(map[string]string{"path": "root.outputs"}).Infoln("outputs info")
+	logger.With("path", "root.pipeline.quiet").Errorln("quiet error")
+
+	expected := `level=warning msg="root warn"
+level=debug msg="foo debug" label=foo
+level=info msg="output info" path=root.output
+level=trace msg="broker trace" path=root.output.broker.outputs.0
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestLoggerBadLevelOverride(t *testing.T) {
+	loggerConfig := NewConfig()
+	loggerConfig.LevelOverrides = map[string]string{"foo": "NOPE"}
+
+	_, err := NewV2(&bytes.Buffer{}, loggerConfig)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "foo")
+}
+
+func TestLoggerSampling(t *testing.T) {
+	loggerConfig := NewConfig()
+	loggerConfig.StaticFields = map[string]string{}
+	loggerConfig.Sampling.Enabled = true
+	loggerConfig.Sampling.Period = "1h"
+	loggerConfig.Sampling.Initial = 2
+	loggerConfig.Sampling.Thereafter = 3
+
+	var buf bytes.Buffer
+
+	logger, err := NewV2(&buf, loggerConfig)
+	require.NoError(t, err)
+
+	fooLogger := logger.With("label", "foo")
+	barLogger := logger.With("label", "bar")
+	for i := 0; i < 10; i++ {
+		fooLogger.Infof("count: %v", i)
+		barLogger.Infof("other: %v", i)
+		if i < 3 {
+			fooLogger.Warnf("count: %v", i)
+		}
+	}
+
+	var fooCounts, barCounts, warnCounts int
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		switch {
+		case strings.Contains(line, "level=warning"):
+			warnCounts++
+		case strings.Contains(line, "label=foo"):
+			fooCounts++
+		case strings.Contains(line, "label=bar"):
+			barCounts++
+		}
+	}
+
+	// 2 initial messages followed by every third of the remaining 8.
+	assert.Equal(t, 4, fooCounts)
+	assert.Equal(t, 4, barCounts)
+	assert.Equal(t, 2, warnCounts)
+	assert.Contains(t, buf.String(), `msg="count: 4" label=foo`)
+	assert.Contains(t, buf.String(), `msg="count: 7" label=foo`)
+}
+
+func TestLevelsHandler(t *testing.T) {
+	loggerConfig := NewConfig()
+	loggerConfig.LogLevel = "WARN"
+	loggerConfig.StaticFields = map[string]string{}
+	loggerConfig.LevelOverrides = map[string]string{"foo": "debug"}
+
+	var buf bytes.Buffer
+
+	logger, err := NewV2(&buf, loggerConfig)
+	require.NoError(t, err)
+
+	handler := LevelsHandlerFunc(logger)
+	require.NotNil(t, handler)
+	assert.Nil(t, LevelsHandlerFunc(Wrap(nil)))
+
+	res := httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodGet, "/log/level", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.JSONEq(t, `{"level":"WARN","level_overrides":{"foo":"DEBUG"}}`, res.Body.String())
+
+	res = httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodPost, "/log/level", strings.NewReader(`{"level":"info","level_overrides":{"foo":"","bar":"TRACE"}}`)))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.JSONEq(t, `{"level":"INFO","level_overrides":{"bar":"TRACE"}}`, res.Body.String())
+
+	logger.Infoln("root info")
+	logger.With("label", "foo").Debugln("foo debug")
+	logger.With("label", "bar").Traceln("bar trace")
+	assert.Equal(t, `level=info msg="root info"
+level=trace msg="bar trace" label=bar
+`, buf.String())
+
+	res = httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodPost, "/log/level", strings.NewReader(`{"level_overrides":{"baz":"NOPE"}}`)))
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+
+	res = httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodDelete, "/log/level", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+}
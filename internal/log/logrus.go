@@ -7,15 +7,19 @@ import (
 	"strings"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
 )
 
 // Config holds configuration options for a logger object.
 type Config struct {
-	LogLevel     string            `json:"level" yaml:"level"`
-	Format       string            `json:"format" yaml:"format"`
-	AddTimeStamp bool              `json:"add_timestamp" yaml:"add_timestamp"`
-	StaticFields map[string]string `json:"static_fields" yaml:"static_fields"`
-	File         File              `json:"file" yaml:"file"`
+	LogLevel       string            `json:"level" yaml:"level"`
+	LevelOverrides map[string]string `json:"level_overrides" yaml:"level_overrides"`
+	Format         string            `json:"format" yaml:"format"`
+	AddTimeStamp   bool              `json:"add_timestamp" yaml:"add_timestamp"`
+	StaticFields   map[string]string `json:"static_fields" yaml:"static_fields"`
+	Sampling       Sampling          `json:"sampling" yaml:"sampling"`
+	File           File              `json:"file" yaml:"file"`
 }
 
 // File contains configuration for file based logging.
@@ -28,12 +32,14 @@ type File struct {
 // NewConfig returns a config struct with the default values for each field.
 func NewConfig() Config {
 	return Config{
-		LogLevel:     "INFO",
-		Format:       "logfmt",
-		AddTimeStamp: false,
+		LogLevel:       "INFO",
+		LevelOverrides: map[string]string{},
+		Format:         "logfmt",
+		AddTimeStamp:   false,
 		StaticFields: map[string]string{
 			"@service": "benthos",
 		},
+		Sampling: NewSampling(),
 	}
 }
 
@@ -84,7 +90,14 @@ func (conf *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 // Logger is an object with support for levelled logging and modular components.
 type Logger struct {
-	entry *logrus.Entry
+	entry   *logrus.Entry
+	levels  *Levels
+	sampler *sampler
+
+	// The label and path of the component being logged, which are used for
+	// resolving level overrides.
+	label string
+	path  string
 }
 
 // NewV2 returns a new logger from a config, or returns an error if the config
@@ -108,22 +121,21 @@ func NewV2(stream io.Writer, config Config) (Modular, error) {
 		return nil, fmt.Errorf("log format '%v' not recognized", config.Format)
 	}
 
-	switch strings.ToUpper(config.LogLevel) {
-	case "OFF", "NONE":
-		logger.Level = logrus.PanicLevel
-	case "FATAL":
-		logger.Level = logrus.FatalLevel
-	case "ERROR":
-		logger.Level = logrus.ErrorLevel
-	case "WARN":
-		logger.Level = logrus.WarnLevel
-	case "INFO":
-		logger.Level = logrus.InfoLevel
-	case "DEBUG":
-		logger.Level = logrus.DebugLevel
-	case "TRACE", "ALL":
-		logger.Level = logrus.TraceLevel
-		logger.Level = logrus.TraceLevel
+	// Levels are resolved per component by the Logger itself, and therefore
+	// the underlying logger emits everything it is given.
+	logger.Level = logrus.TraceLevel
+	logger.AddHook(scrubHook{})
+
+	// Unrecognised levels have historically fallen back to INFO.
+	level, _ := parseLevel(config.LogLevel)
+	levels, err := newLevels(level, config.LevelOverrides)
+	if err != nil {
+		return nil, err
+	}
+
+	sampler, err := newSampler(config.Sampling)
+	if err != nil {
+		return nil, err
 	}
 
 	sFields := logrus.Fields{}
@@ -132,7 +144,25 @@ func NewV2(stream io.Writer, config Config) (Modular, error) {
 	}
 	logEntry := logger.WithFields(sFields)
 
-	return &Logger{entry: logEntry}, nil
+	return &Logger{
+		entry:   logEntry,
+		levels:  levels,
+		sampler: sampler,
+	}, nil
+}
+
+// scrubHook removes credentials from any URLs within log messages, as
+// components commonly log the URLs they connect to along with errors that
+// include them.
+type scrubHook struct{}
+
+func (scrubHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (scrubHook) Fire(entry *logrus.Entry) error {
+	entry.Message = docs.ScrubURLCredentials(entry.Message)
+	return nil
 }
 
 //------------------------------------------------------------------------------
@@ -154,12 +184,19 @@ func (l *Logger) WithFields(inboundFields map[string]string) Modular {
 
 	newLogger := *l
 	newLogger.entry = l.entry.WithFields(newFields)
+	if label, exists := inboundFields["label"]; exists {
+		newLogger.label = label
+	}
+	if path, exists := inboundFields["path"]; exists {
+		newLogger.path = path
+	}
 	return &newLogger
 }
 
 // With returns a copy of the logger with new labels added to the logging
 // context.
 func (l *Logger) With(keyValues ...interface{}) Modular {
+	newLogger := *l
 	newEntry := l.entry.WithFields(logrus.Fields{})
 	for i := 0; i < (len(keyValues) - 1); i += 2 {
 		key, ok := keyValues[i].(string)
@@ -167,42 +204,83 @@ func (l *Logger) With(keyValues ...interface{}) Modular {
 			continue
 		}
 		newEntry = newEntry.WithField(key, keyValues[i+1])
+		if value, isStr := keyValues[i+1].(string); isStr {
+			switch key {
+			case "label":
+				newLogger.label = value
+			case "path":
+				newLogger.path = value
+			}
+		}
 	}
 
-	newLogger := *l
 	newLogger.entry = newEntry
 	return &newLogger
 }
 
+// enabled returns whether a log message should be emitted based on the level
+// of the component and, when sampling is enabled, how often the message has
+// been repeated.
+func (l *Logger) enabled(level logrus.Level, msg string) bool {
+	if l.levels == nil {
+		return l.entry.Logger.IsLevelEnabled(level)
+	}
+	if l.levels.levelFor(l.label, l.path) < level {
+		return false
+	}
+	if l.sampler != nil && !l.sampler.allow(l.label+"\x00"+l.path, level, msg) {
+		return false
+	}
+	return true
+}
+
 //------------------------------------------------------------------------------
 
 // Fatalf prints a fatal message to the console. Does NOT cause panic.
 func (l *Logger) Fatalf(format string, v ...interface{}) {
+	if !l.enabled(logrus.FatalLevel, format) {
+		return
+	}
 	l.entry.Fatalf(strings.TrimSuffix(format, "\n"), v...)
 }
 
 // Errorf prints an error message to the console.
 func (l *Logger) Errorf(format string, v ...interface{}) {
+	if !l.enabled(logrus.ErrorLevel, format) {
+		return
+	}
 	l.entry.Errorf(strings.TrimSuffix(format, "\n"), v...)
 }
 
 // Warnf prints a warning message to the console.
 func (l *Logger) Warnf(format string, v ...interface{}) {
+	if !l.enabled(logrus.WarnLevel, format) {
+		return
+	}
 	l.entry.Warnf(strings.TrimSuffix(format, "\n"), v...)
 }
 
 // Infof prints an information message to the console.
 func (l *Logger) Infof(format string, v ...interface{}) {
+	if !l.enabled(logrus.InfoLevel, format) {
+		return
+	}
 	l.entry.Infof(strings.TrimSuffix(format, "\n"), v...)
 }
 
 // Debugf prints a debug message to the console.
 func (l *Logger) Debugf(format string, v ...interface{}) {
+	if !l.enabled(logrus.DebugLevel, format) {
+		return
+	}
 	l.entry.Debugf(strings.TrimSuffix(format, "\n"), v...)
 }
 
 // Tracef prints a trace message to the console.
 func (l *Logger) Tracef(format string, v ...interface{}) {
+	if !l.enabled(logrus.TraceLevel, format) {
+		return
+	}
 	l.entry.Tracef(strings.TrimSuffix(format, "\n"), v...)
 }
 
@@ -210,30 +288,48 @@ func (l *Logger) Tracef(format string, v ...interface{}) {
 
 // Fatalln prints a fatal message to the console. Does NOT cause panic.
 func (l *Logger) Fatalln(message string) {
+	if !l.enabled(logrus.FatalLevel, message) {
+		return
+	}
 	l.entry.Fatalln(message)
 }
 
 // Errorln prints an error message to the console.
 func (l *Logger) Errorln(message string) {
+	if !l.enabled(logrus.ErrorLevel, message) {
+		return
+	}
 	l.entry.Errorln(message)
 }
 
 // Warnln prints a warning message to the console.
 func (l *Logger) Warnln(message string) {
+	if !l.enabled(logrus.WarnLevel, message) {
+		return
+	}
 	l.entry.Warnln(message)
 }
 
 // Infoln prints an information message to the console.
 func (l *Logger) Infoln(message string) {
+	if !l.enabled(logrus.InfoLevel, message) {
+		return
+	}
 	l.entry.Infoln(message)
 }
 
 // Debugln prints a debug message to the console.
 func (l *Logger) Debugln(message string) {
+	if !l.enabled(logrus.DebugLevel, message) {
+		return
+	}
 	l.entry.Debugln(message)
 }
 
 // Traceln prints a trace message to the console.
 func (l *Logger) Traceln(message string) {
+	if !l.enabled(logrus.TraceLevel, message) {
+		return
+	}
 	l.entry.Traceln(message)
 }
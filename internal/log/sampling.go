@@ -0,0 +1,79 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Sampling contains configuration for limiting the rate at which repeated log
+// messages are emitted.
+type Sampling struct {
+	Enabled    bool   `json:"enabled" yaml:"enabled"`
+	Period     string `json:"period" yaml:"period"`
+	Initial    int    `json:"initial" yaml:"initial"`
+	Thereafter int    `json:"thereafter" yaml:"thereafter"`
+}
+
+// NewSampling returns a sampling config with default values.
+func NewSampling() Sampling {
+	return Sampling{
+		Enabled:    false,
+		Period:     "1s",
+		Initial:    10,
+		Thereafter: 100,
+	}
+}
+
+// sampler counts log messages by their component, level and message template
+// within a period, and permits the first N occurrences followed by every Mth
+// occurrence thereafter.
+type sampler struct {
+	initial    int
+	thereafter int
+	period     time.Duration
+
+	mut     sync.Mutex
+	resetAt time.Time
+	counts  map[string]int
+}
+
+func newSampler(conf Sampling) (*sampler, error) {
+	if !conf.Enabled {
+		return nil, nil
+	}
+	period, err := time.ParseDuration(conf.Period)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sampling period: %w", err)
+	}
+	if period <= 0 {
+		return nil, fmt.Errorf("sampling period must be greater than zero, got %v", period)
+	}
+	return &sampler{
+		initial:    conf.Initial,
+		thereafter: conf.Thereafter,
+		period:     period,
+		counts:     map[string]int{},
+	}, nil
+}
+
+func (s *sampler) allow(component string, level logrus.Level, msg string) bool {
+	key := component + "\x00" + level.String() + "\x00" + msg
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if now := time.Now(); now.After(s.resetAt) {
+		s.counts = map[string]int{}
+		s.resetAt = now.Add(s.period)
+	}
+
+	n := s.counts[key] + 1
+	s.counts[key] = n
+	if n <= s.initial {
+		return true
+	}
+	return s.thereafter > 0 && (n-s.initial)%s.thereafter == 0
+}
@@ -10,6 +10,7 @@ import (
 
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/benthosdev/benthos/v4/internal/api"
 	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
@@ -64,6 +65,8 @@ type Type struct {
 	label string
 
 	apiReg APIReg
+	taps   *api.Taps
+	health *api.Health
 
 	inputs       map[string]*inputWrapper
 	caches       map[string]cache.V1
@@ -95,6 +98,22 @@ func OptSetAPIReg(r APIReg) OptFunc {
 	}
 }
 
+// OptSetTaps sets a registry of tap points, allowing samples of the messages
+// passing through labelled components to be streamed via the API.
+func OptSetTaps(taps *api.Taps) OptFunc {
+	return func(t *Type) {
+		t.taps = taps
+	}
+}
+
+// OptSetHealth sets a registry of health trackers, allowing components to
+// report their health via the API.
+func OptSetHealth(health *api.Health) OptFunc {
+	return func(t *Type) {
+		t.health = health
+	}
+}
+
 // OptSetLogger sets the logger from which the manager emits log events for
 // components.
 func OptSetLogger(logger log.Modular) OptFunc {
@@ -308,6 +327,31 @@ func (t *Type) intoPath(segments ...string) *Type {
 	return &newT
 }
 
+// TapPoint returns a new tap point of the labelled component holding this
+// manager, or nil if the component has no label or taps are not enabled. The
+// point should be closed when the component is closed.
+func (t *Type) TapPoint() *api.TapPoint {
+	if t.taps == nil || t.label == "" {
+		return nil
+	}
+	return t.taps.Point(t.stream, t.label)
+}
+
+// HealthTracker returns a new health tracker for the component of a given kind
+// holding this manager, or nil if health tracking is not enabled. Components of
+// the kinds input and output begin disconnected.
+func (t *Type) HealthTracker(kind string) *api.HealthTracker {
+	if t.health == nil {
+		return nil
+	}
+	connected := kind != api.HealthKindInput && kind != api.HealthKindOutput
+	path := "root"
+	if len(t.componentPath) > 0 {
+		path += "." + query.SliceToDotPath(t.componentPath...)
+	}
+	return t.health.NewTracker(kind, t.stream, t.label, path, connected, t.stats)
+}
+
 // Path returns the current component path held by a manager.
 func (t *Type) Path() []string {
 	return t.componentPath
@@ -2,11 +2,16 @@ package manager_test
 
 import (
 	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/api"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/cache"
@@ -15,6 +20,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/impl/pure"
 	"github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/message"
 
@@ -242,6 +248,163 @@ func TestManagerProcessor(t *testing.T) {
 	require.False(t, mgr.ProbeProcessor("baz"))
 }
 
+func TestManagerProcessorTap(t *testing.T) {
+	taps := api.NewTaps()
+
+	mgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetTaps(taps))
+	require.NoError(t, err)
+
+	server := httptest.NewServer(taps.HandlerFunc(mgr.BloblEnvironment()))
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "?label=foo&count=1")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	point := taps.Point("", "foo")
+	require.Eventually(t, point.Active, time.Second*5, time.Millisecond*10)
+
+	conf := processor.NewConfig()
+	conf.Type = "bloblang"
+	conf.Bloblang = `root = content().uppercase()`
+
+	unlabelled, err := mgr.NewProcessor(conf)
+	require.NoError(t, err)
+
+	conf.Label = "foo"
+	labelled, err := mgr.NewProcessor(conf)
+	require.NoError(t, err)
+
+	_, err = unlabelled.ProcessMessage(message.QuickBatch([][]byte{[]byte("nope")}))
+	require.NoError(t, err)
+
+	msgs, err := labelled.ProcessMessage(message.QuickBatch([][]byte{[]byte("hello world")}))
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "HELLO WORLD", string(msgs[0].Get(0).Get()))
+
+	resBytes, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(resBytes), `"content":"HELLO WORLD"`)
+	assert.NotContains(t, string(resBytes), "NOPE")
+}
+
+func TestManagerProcessorHealth(t *testing.T) {
+	health, err := api.NewHealth(api.NewHealthConfig())
+	require.NoError(t, err)
+
+	mgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetHealth(health))
+	require.NoError(t, err)
+
+	conf := processor.NewConfig()
+	conf.Type = "bloblang"
+	conf.Bloblang = `root = this`
+	conf.Label = "foo"
+
+	proc, err := mgr.NewProcessor(conf)
+	require.NoError(t, err)
+
+	statuses := health.Statuses()
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "processor", statuses[0].Kind)
+	assert.Equal(t, "foo", statuses[0].Label)
+	assert.True(t, statuses[0].Healthy)
+
+	_, err = proc.ProcessMessage(message.QuickBatch([][]byte{[]byte("not json")}))
+	require.NoError(t, err)
+
+	statuses = health.Statuses()
+	require.Len(t, statuses, 1)
+	assert.False(t, statuses[0].Healthy)
+	assert.NotEmpty(t, statuses[0].LastError)
+
+	_, err = proc.ProcessMessage(message.QuickBatch([][]byte{[]byte(`{"hello":"world"}`)}))
+	require.NoError(t, err)
+	assert.True(t, health.Statuses()[0].Healthy)
+
+	proc.CloseAsync()
+	assert.Empty(t, health.Statuses())
+}
+
+func TestManagerCacheHealth(t *testing.T) {
+	health, err := api.NewHealth(api.NewHealthConfig())
+	require.NoError(t, err)
+
+	conf := manager.NewResourceConfig()
+
+	fooCache := cache.NewConfig()
+	fooCache.Label = "foo"
+	conf.ResourceCaches = append(conf.ResourceCaches, fooCache)
+
+	mgr, err := manager.New(conf, manager.OptSetHealth(health))
+	require.NoError(t, err)
+
+	statuses := health.Statuses()
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "cache", statuses[0].Kind)
+	assert.Equal(t, "foo", statuses[0].Label)
+
+	require.NoError(t, mgr.AccessCache(context.Background(), "foo", func(c cache.V1) {
+		_, err := c.Get(context.Background(), "missing")
+		assert.ErrorIs(t, err, component.ErrKeyNotFound)
+	}))
+	assert.True(t, health.Statuses()[0].Healthy)
+
+	mgr.CloseAsync()
+	require.NoError(t, mgr.WaitForClose(time.Second))
+	assert.Empty(t, health.Statuses())
+}
+
+func TestManagerResourceProcessorTap(t *testing.T) {
+	taps := api.NewTaps()
+
+	fooConf := processor.NewConfig()
+	fooConf.Label = "foo"
+	fooConf.Type = "bloblang"
+	fooConf.Bloblang = `root = content().uppercase()`
+
+	barConf := processor.NewConfig()
+	barConf.Label = "bar"
+	barConf.Type = "branch"
+	barConf.Branch.RequestMap = "root = this"
+	barConf.Branch.ResultMap = "root.bar = this.foo"
+	barConf.Branch.Processors = append(barConf.Branch.Processors, processor.NewConfig())
+
+	resConf := manager.NewResourceConfig()
+	resConf.ResourceProcessors = append(resConf.ResourceProcessors, fooConf, barConf)
+
+	mgr, err := manager.New(resConf, manager.OptSetTaps(taps))
+	require.NoError(t, err)
+
+	server := httptest.NewServer(taps.HandlerFunc(mgr.BloblEnvironment()))
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "?label=foo&count=1")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	point := taps.Point("", "foo")
+	require.Eventually(t, point.Active, time.Second*5, time.Millisecond*10)
+
+	require.NoError(t, mgr.AccessProcessor(context.Background(), "foo", func(p processor.V1) {
+		msgs, err := p.ProcessMessage(message.QuickBatch([][]byte{[]byte("hello world")}))
+		require.NoError(t, err)
+		require.Len(t, msgs, 1)
+		assert.Equal(t, "HELLO WORLD", string(msgs[0].Get(0).Get()))
+	}))
+
+	resBytes, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(resBytes), `"content":"HELLO WORLD"`)
+
+	// Branch resources are accessed directly by workflows, and so must not be
+	// wrapped by a tap.
+	require.NoError(t, mgr.AccessProcessor(context.Background(), "bar", func(p processor.V1) {
+		_, isBranch := p.(*pure.Branch)
+		assert.True(t, isBranch, "%T", p)
+	}))
+}
+
 func TestManagerProcessorList(t *testing.T) {
 	cFoo := processor.NewConfig()
 	cFoo.Label = "foo"
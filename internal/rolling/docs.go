@@ -0,0 +1,39 @@
+package rolling
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/codec"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// FieldSpec returns a spec for a common rolling field.
+func FieldSpec() docs.FieldSpec {
+	return docs.FieldObject(
+		"rolling", "Allows you to write messages to files grouped by an interpolated partition path, where each file is rolled once it reaches a size, record count or age. When enabled the `path` field is ignored. Messages are only acknowledged once the file they were written to has been finalised, and therefore the number of messages within each file is also limited by the number of messages in flight.",
+	).WithChildren(
+		docs.FieldBool("enabled", "Whether messages should be written to rolled files."),
+		docs.FieldString(
+			"partition", "The directory path of the partition that each message is written to, files within a partition are named with the `file_prefix` followed by a sequence number, an identifier unique to the running instance of the output, and the `file_suffix`.",
+			`dt=${! now().ts_format("2006-01-02") }/hour=${! now().ts_format("15") }`,
+			`${! meta("kafka_topic") }`,
+		).IsInterpolated(),
+		docs.FieldString("file_prefix", "A prefix to add to the name of each file."),
+		docs.FieldString("file_suffix", "A suffix to add to the name of each file, usually an extension.", ".jsonl", ".json.gz", ".parquet"),
+		codec.WriterDocs,
+		docs.FieldInt("max_size", "The number of bytes of message data at which a file is rolled. If `0` disables size based rolling."),
+		docs.FieldInt("max_records", "The number of messages at which a file is rolled. If `0` disables count based rolling."),
+		docs.FieldString("max_age", "A period after which a file is rolled regardless of its size. If empty disables time based rolling.", "1m", "1h"),
+		docs.FieldProcessor(
+			"processors", "A list of [processors](/docs/components/processors/about) to apply to all messages of a file as it is finalised, the resulting messages are then written to the file with the configured codec. This allows you to write files of formats that require all messages at once, such as Parquet. When processors are configured the messages of each file are buffered in memory until it is rolled.",
+			[]map[string]interface{}{
+				{
+					"parquet_encode": map[string]interface{}{
+						"schema": []map[string]interface{}{
+							{"name": "id", "type": "INT64"},
+							{"name": "content", "type": "BYTE_ARRAY"},
+						},
+					},
+				},
+			},
+		).Array().Optional(),
+	).Advanced()
+}
@@ -0,0 +1,4 @@
+// Package rolling provides a writer shared by file based outputs that groups
+// messages into files by partition, and rolls those files once they reach a
+// size, record count or age.
+package rolling
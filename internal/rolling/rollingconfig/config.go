@@ -0,0 +1,32 @@
+package rollingconfig
+
+import "github.com/benthosdev/benthos/v4/internal/component/processor"
+
+// Config contains configuration parameters for writing messages to rolled
+// files grouped by partition.
+type Config struct {
+	Enabled    bool               `json:"enabled" yaml:"enabled"`
+	Partition  string             `json:"partition" yaml:"partition"`
+	FilePrefix string             `json:"file_prefix" yaml:"file_prefix"`
+	FileSuffix string             `json:"file_suffix" yaml:"file_suffix"`
+	Codec      string             `json:"codec" yaml:"codec"`
+	MaxSize    int                `json:"max_size" yaml:"max_size"`
+	MaxRecords int                `json:"max_records" yaml:"max_records"`
+	MaxAge     string             `json:"max_age" yaml:"max_age"`
+	Processors []processor.Config `json:"processors" yaml:"processors"`
+}
+
+// NewConfig creates a default rolling Config.
+func NewConfig() Config {
+	return Config{
+		Enabled:    false,
+		Partition:  "",
+		FilePrefix: "part-",
+		FileSuffix: "",
+		Codec:      "lines",
+		MaxSize:    0,
+		MaxRecords: 0,
+		MaxAge:     "1m",
+		Processors: []processor.Config{},
+	}
+}
@@ -0,0 +1,452 @@
+package rolling
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/codec"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/rolling/rollingconfig"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+// Store is the destination of the files created by a Writer.
+type Store interface {
+	// Create a new file at a path, where the first message to be written to
+	// the file is provided in order to derive properties of the file such as
+	// its content type. The contents of the file must not be visible at the
+	// path until the file is committed.
+	Create(ctx context.Context, path string, first *message.Part) (File, error)
+}
+
+// File is a file of a Store that is being written to.
+type File interface {
+	io.Writer
+
+	// Commit finalises the file, atomically making its full contents visible
+	// at its path.
+	Commit(ctx context.Context) error
+
+	// Abort discards the file.
+	Abort(ctx context.Context) error
+}
+
+// PutFunc writes the full contents of a file to a path.
+type PutFunc func(ctx context.Context, path string, first *message.Part, contents []byte) error
+
+// NewBufferedStore returns a Store that buffers the contents of each file in
+// memory and writes them with a PutFunc once the file is committed, which is
+// suitable for object stores where uploads are atomic.
+func NewBufferedStore(put PutFunc) Store {
+	return bufferedStore(put)
+}
+
+type bufferedStore PutFunc
+
+func (b bufferedStore) Create(ctx context.Context, path string, first *message.Part) (File, error) {
+	return &bufferedFile{put: PutFunc(b), path: path, first: first}, nil
+}
+
+type bufferedFile struct {
+	bytes.Buffer
+
+	put   PutFunc
+	path  string
+	first *message.Part
+}
+
+func (b *bufferedFile) Commit(ctx context.Context) error {
+	return b.put(ctx, b.path, b.first, b.Bytes())
+}
+
+func (b *bufferedFile) Abort(ctx context.Context) error {
+	b.Reset()
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// Writer writes messages to files of a Store grouped by an interpolated
+// partition, and rolls those files once they reach a size, record count or
+// age. Writes block until the files they were written to are committed.
+type Writer struct {
+	log   log.Modular
+	store Store
+
+	partition   *field.Expression
+	filePrefix  string
+	fileSuffix  string
+	codec       codec.WriterConstructor
+	maxSize     int
+	maxRecords  int
+	maxAge      time.Duration
+	procs       []iprocessor.V1
+	maxInFlight int
+	instanceID  string
+
+	filesMut sync.Mutex
+	files    map[string]*rollingFile
+	seqs     map[string]int
+	pending  int
+	commits  sync.WaitGroup
+
+	shutSig *shutdown.Signaller
+}
+
+// NewWriter creates a Writer from a config. The maximum number of messages
+// in flight of the output is required in order to roll files early when all
+// writes are blocked waiting for their files to be committed.
+func NewWriter(conf rollingconfig.Config, maxInFlight int, store Store, mgr bundle.NewManagement) (*Writer, error) {
+	if maxInFlight < 1 {
+		return nil, errors.New("max in flight must be greater than zero")
+	}
+
+	w := &Writer{
+		log:         mgr.Logger(),
+		store:       store,
+		filePrefix:  conf.FilePrefix,
+		fileSuffix:  conf.FileSuffix,
+		maxSize:     conf.MaxSize,
+		maxRecords:  conf.MaxRecords,
+		maxInFlight: maxInFlight,
+		files:       map[string]*rollingFile{},
+		seqs:        map[string]int{},
+		shutSig:     shutdown.NewSignaller(),
+	}
+
+	var err error
+	if w.partition, err = mgr.BloblEnvironment().NewField(conf.Partition); err != nil {
+		return nil, fmt.Errorf("failed to parse partition expression: %w", err)
+	}
+	if w.codec, _, err = codec.GetWriter(conf.Codec); err != nil {
+		return nil, err
+	}
+	if conf.MaxAge != "" {
+		if w.maxAge, err = time.ParseDuration(conf.MaxAge); err != nil {
+			return nil, fmt.Errorf("failed to parse max age duration string: %w", err)
+		}
+	}
+	for i, pconf := range conf.Processors {
+		pMgr := mgr.IntoPath("rolling", "processors", strconv.Itoa(i))
+		proc, err := pMgr.NewProcessor(pconf)
+		if err != nil {
+			return nil, err
+		}
+		w.procs = append(w.procs, proc)
+	}
+	if w.instanceID, err = gonanoid.Generate("0123456789abcdefghijklmnopqrstuvwxyz", 8); err != nil {
+		return nil, fmt.Errorf("failed to generate instance id: %w", err)
+	}
+
+	go w.loop()
+	return w, nil
+}
+
+//------------------------------------------------------------------------------
+
+type rollingFile struct {
+	partition string
+	path      string
+	created   time.Time
+	size      int
+	records   int
+
+	file   File
+	handle codec.Writer
+	parts  []*message.Part
+
+	done chan struct{}
+	err  error
+}
+
+// nopCloser prevents a codec from closing a file, as files are finalised by
+// committing them instead.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func (w *Writer) openFile(ctx context.Context, partition string, first *message.Part) (*rollingFile, error) {
+	seq := w.seqs[partition]
+	w.seqs[partition] = seq + 1
+
+	name := fmt.Sprintf("%v%05d-%v%v", w.filePrefix, seq, w.instanceID, w.fileSuffix)
+	f := &rollingFile{
+		partition: partition,
+		path:      path.Join(partition, name),
+		created:   time.Now(),
+		done:      make(chan struct{}),
+	}
+
+	var err error
+	if f.file, err = w.store.Create(ctx, f.path, first); err != nil {
+		return nil, err
+	}
+	if len(w.procs) == 0 {
+		if f.handle, err = w.codec(nopCloser{f.file}); err != nil {
+			_ = f.file.Abort(ctx)
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (w *Writer) shouldRoll(f *rollingFile) bool {
+	if w.maxRecords > 0 && f.records >= w.maxRecords {
+		return true
+	}
+	if w.maxSize > 0 && f.size >= w.maxSize {
+		return true
+	}
+	if w.maxAge > 0 && time.Since(f.created) >= w.maxAge {
+		return true
+	}
+	return false
+}
+
+// roll removes a file from the set of open files and commits it in the
+// background. Must be called with filesMut held.
+func (w *Writer) roll(f *rollingFile) {
+	w.detach(f)
+	w.commitAsync(f)
+}
+
+// detach removes a file from the set of open files so that no further messages
+// are written to it. Must be called with filesMut held.
+func (w *Writer) detach(f *rollingFile) {
+	if w.files[f.partition] == f {
+		delete(w.files, f.partition)
+	}
+}
+
+// discard aborts a file that is in an unknown state, which fails all writes
+// waiting on it. Must be called with filesMut held.
+func (w *Writer) discard(ctx context.Context, f *rollingFile, err error) {
+	w.detach(f)
+	_ = f.file.Abort(ctx)
+	f.err = err
+	close(f.done)
+}
+
+// commitAsync commits a file in the background. Must be called with filesMut
+// held.
+func (w *Writer) commitAsync(f *rollingFile) {
+	w.commits.Add(1)
+	go func() {
+		defer w.commits.Done()
+
+		ctx, done := w.shutSig.CloseNowCtx(context.Background())
+		defer done()
+
+		if f.err = w.commit(ctx, f); f.err != nil {
+			w.log.Errorf("Failed to commit file '%v': %v\n", f.path, f.err)
+			_ = f.file.Abort(ctx)
+		} else {
+			w.log.Debugf("Committed file '%v' with %v messages\n", f.path, f.records)
+		}
+		close(f.done)
+	}()
+}
+
+// rollAll rolls every open file. Must be called with filesMut held.
+func (w *Writer) rollAll() {
+	for _, f := range w.files {
+		w.roll(f)
+	}
+}
+
+func (w *Writer) commit(ctx context.Context, f *rollingFile) error {
+	if f.handle == nil {
+		batch := message.QuickBatch(nil)
+		batch.Append(f.parts...)
+		f.parts = nil
+
+		resultMsgs, err := iprocessor.ExecuteAll(w.procs, batch)
+		if err != nil {
+			return fmt.Errorf("file processors resulted in error: %w", err)
+		}
+
+		if f.handle, err = w.codec(nopCloser{f.file}); err != nil {
+			return err
+		}
+		for _, m := range resultMsgs {
+			if err := m.Iter(func(_ int, p *message.Part) error {
+				return f.handle.Write(ctx, p)
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	if err := f.handle.Close(ctx); err != nil {
+		return err
+	}
+	return f.file.Commit(ctx)
+}
+
+func (w *Writer) loop() {
+	defer func() {
+		w.filesMut.Lock()
+		w.rollAll()
+		w.filesMut.Unlock()
+
+		w.commits.Wait()
+		for _, p := range w.procs {
+			p.CloseAsync()
+		}
+		w.shutSig.ShutdownComplete()
+	}()
+
+	if w.maxAge <= 0 {
+		<-w.shutSig.CloseAtLeisureChan()
+		return
+	}
+
+	checkPeriod := time.Second
+	if w.maxAge < checkPeriod {
+		checkPeriod = w.maxAge
+	}
+	ticker := time.NewTicker(checkPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.filesMut.Lock()
+			for _, f := range w.files {
+				if time.Since(f.created) >= w.maxAge {
+					w.roll(f)
+				}
+			}
+			w.filesMut.Unlock()
+		case <-w.shutSig.CloseAtLeisureChan():
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// WriteWithContext writes each message of a batch to the open file of its
+// partition, and blocks until all files written to have been committed.
+//
+// Files that reach their limits part way through a batch are only committed
+// once the whole batch has been written. If any message of the batch fails to
+// be written then every file written to by the batch is discarded, failing all
+// writes waiting on those files, as otherwise the messages of the batch that
+// were written would be committed and then duplicated once the batch is
+// reattempted.
+func (w *Writer) WriteWithContext(ctx context.Context, msg *message.Batch) error {
+	w.filesMut.Lock()
+	if w.shutSig.ShouldCloseAtLeisure() {
+		w.filesMut.Unlock()
+		return component.ErrTypeClosed
+	}
+
+	var written, rolled []*rollingFile
+	err := msg.Iter(func(i int, p *message.Part) error {
+		partition := w.partition.String(i, msg)
+
+		f, exists := w.files[partition]
+		if !exists {
+			var err error
+			if f, err = w.openFile(ctx, partition, p); err != nil {
+				return err
+			}
+			w.files[partition] = f
+		}
+
+		if len(written) == 0 || written[len(written)-1] != f {
+			written = append(written, f)
+		}
+
+		if f.handle != nil {
+			if err := f.handle.Write(ctx, p); err != nil {
+				return err
+			}
+		} else {
+			f.parts = append(f.parts, p)
+		}
+		f.size += len(p.Get())
+		f.records++
+
+		if w.shouldRoll(f) {
+			w.detach(f)
+			rolled = append(rolled, f)
+		}
+		return nil
+	})
+	if err != nil {
+		discarded := map[*rollingFile]struct{}{}
+		for _, f := range written {
+			if _, exists := discarded[f]; !exists {
+				discarded[f] = struct{}{}
+				w.discard(ctx, f, err)
+			}
+		}
+		w.filesMut.Unlock()
+		return err
+	}
+	for _, f := range rolled {
+		w.commitAsync(f)
+	}
+
+	// Once every message in flight is waiting on a file to be committed no
+	// further writes can occur, and therefore we roll all open files.
+	w.pending++
+	if w.pending >= w.maxInFlight {
+		w.rollAll()
+	}
+	w.filesMut.Unlock()
+
+	defer func() {
+		w.filesMut.Lock()
+		w.pending--
+		w.filesMut.Unlock()
+	}()
+
+	for _, f := range written {
+		select {
+		case <-f.done:
+			if f.err != nil {
+				return f.err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// CloseAsync begins committing all open files and cleaning up resources.
+func (w *Writer) CloseAsync() {
+	w.shutSig.CloseAtLeisure()
+}
+
+// WaitForClose blocks until all open files are committed or the timeout
+// occurs, in which case any commits still in progress are abandoned.
+func (w *Writer) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-w.shutSig.HasClosedChan():
+	case <-time.After(timeout):
+		w.shutSig.CloseNow()
+		return component.ErrTimeout
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+package rolling_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/rolling"
+	"github.com/benthosdev/benthos/v4/internal/rolling/rollingconfig"
+
+	_ "github.com/benthosdev/benthos/v4/internal/impl/pure"
+)
+
+type memStore struct {
+	mut   sync.Mutex
+	files map[string]string
+	err   error
+}
+
+func newMemStore() *memStore {
+	return &memStore{files: map[string]string{}}
+}
+
+func (m *memStore) put(ctx context.Context, path string, first *message.Part, contents []byte) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.files[path] = string(contents)
+	return nil
+}
+
+func (m *memStore) contents() map[string]string {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	// File names contain an instance identifier which we strip for the sake
+	// of comparisons.
+	files := map[string]string{}
+	for k, v := range m.files {
+		dir, name := path.Split(k)
+		name = name[:strings.LastIndex(name, "-")]
+		files[dir+name] = v
+	}
+	return files
+}
+
+func writeAll(t *testing.T, w *rolling.Writer, batches ...*message.Batch) {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	for _, b := range batches {
+		wg.Add(1)
+		go func(b *message.Batch) {
+			defer wg.Done()
+			assert.NoError(t, w.WriteWithContext(context.Background(), b))
+		}(b)
+	}
+	wg.Wait()
+}
+
+func TestRollingMaxRecords(t *testing.T) {
+	conf := rollingconfig.NewConfig()
+	conf.Enabled = true
+	conf.Partition = `${! meta("partition") }`
+	conf.MaxRecords = 2
+	conf.MaxAge = ""
+
+	store := newMemStore()
+	w, err := rolling.NewWriter(conf, 10, rolling.NewBufferedStore(store.put), mock.NewManager())
+	require.NoError(t, err)
+
+	batch := message.QuickBatch([][]byte{
+		[]byte("foo1"), []byte("bar1"), []byte("foo2"), []byte("bar2"),
+	})
+	for i, p := range []string{"foo", "bar", "foo", "bar"} {
+		batch.Get(i).MetaSet("partition", "dt="+p)
+	}
+	writeAll(t, w, batch)
+
+	assert.Equal(t, map[string]string{
+		"dt=foo/part-00000": "foo1\nfoo2\n",
+		"dt=bar/part-00000": "bar1\nbar2\n",
+	}, store.contents())
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second))
+}
+
+func TestRollingMaxInFlight(t *testing.T) {
+	conf := rollingconfig.NewConfig()
+	conf.Enabled = true
+	conf.MaxAge = ""
+
+	store := newMemStore()
+	w, err := rolling.NewWriter(conf, 1, rolling.NewBufferedStore(store.put), mock.NewManager())
+	require.NoError(t, err)
+
+	writeAll(t, w, message.QuickBatch([][]byte{[]byte("foo"), []byte("bar")}))
+	writeAll(t, w, message.QuickBatch([][]byte{[]byte("baz")}))
+
+	assert.Equal(t, map[string]string{
+		"part-00000": "foo\nbar\n",
+		"part-00001": "baz\n",
+	}, store.contents())
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second))
+}
+
+func TestRollingMaxAge(t *testing.T) {
+	conf := rollingconfig.NewConfig()
+	conf.Enabled = true
+	conf.FilePrefix = "data-"
+	conf.FileSuffix = ".txt"
+	conf.MaxAge = "10ms"
+
+	store := newMemStore()
+	w, err := rolling.NewWriter(conf, 10, rolling.NewBufferedStore(store.put), mock.NewManager())
+	require.NoError(t, err)
+
+	writeAll(t, w,
+		message.QuickBatch([][]byte{[]byte("foo")}),
+		message.QuickBatch([][]byte{[]byte("bar")}),
+	)
+
+	files := store.contents()
+	require.Len(t, files, 1)
+	lines := strings.Split(strings.TrimSpace(files["data-00000"]), "\n")
+	sort.Strings(lines)
+	assert.Equal(t, []string{"bar", "foo"}, lines)
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second))
+}
+
+func TestRollingShutdown(t *testing.T) {
+	conf := rollingconfig.NewConfig()
+	conf.Enabled = true
+	conf.MaxAge = ""
+
+	store := newMemStore()
+	w, err := rolling.NewWriter(conf, 10, rolling.NewBufferedStore(store.put), mock.NewManager())
+	require.NoError(t, err)
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- w.WriteWithContext(context.Background(), message.QuickBatch([][]byte{[]byte("foo")}))
+	}()
+
+	select {
+	case err := <-errChan:
+		t.Fatalf("write returned before file was committed: %v", err)
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second))
+	require.NoError(t, <-errChan)
+
+	assert.Equal(t, map[string]string{
+		"part-00000": "foo\n",
+	}, store.contents())
+
+	assert.Equal(t, component.ErrTypeClosed, w.WriteWithContext(context.Background(), message.QuickBatch([][]byte{[]byte("bar")})))
+}
+
+func TestRollingCommitError(t *testing.T) {
+	conf := rollingconfig.NewConfig()
+	conf.Enabled = true
+	conf.MaxRecords = 1
+
+	store := newMemStore()
+	store.err = errors.New("nope")
+
+	w, err := rolling.NewWriter(conf, 10, rolling.NewBufferedStore(store.put), mock.NewManager())
+	require.NoError(t, err)
+
+	assert.EqualError(t, w.WriteWithContext(context.Background(), message.QuickBatch([][]byte{[]byte("foo")})), "nope")
+	assert.Empty(t, store.contents())
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second))
+}
+
+func TestRollingProcessors(t *testing.T) {
+	archiveConf := processor.NewConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+archive:
+  format: json_array
+`), &archiveConf))
+
+	conf := rollingconfig.NewConfig()
+	conf.Enabled = true
+	conf.Codec = "gzip/append"
+	conf.MaxRecords = 3
+	conf.Processors = []processor.Config{archiveConf}
+
+	store := newMemStore()
+	w, err := rolling.NewWriter(conf, 10, rolling.NewBufferedStore(store.put), mock.NewManager())
+	require.NoError(t, err)
+
+	writeAll(t, w, message.QuickBatch([][]byte{
+		[]byte(`{"id":1}`), []byte(`{"id":2}`), []byte(`{"id":3}`),
+	}))
+
+	files := store.contents()
+	require.Contains(t, files, "part-00000")
+
+	r, err := gzip.NewReader(bytes.NewReader([]byte(files["part-00000"])))
+	require.NoError(t, err)
+
+	contents, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, `[{"id":1},{"id":2},{"id":3}]`, string(contents))
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second))
+}
+
+type failingStore struct {
+	*memStore
+	failPrefix string
+}
+
+type failingFile struct {
+	bytes.Buffer
+	store *failingStore
+	path  string
+	first *message.Part
+}
+
+func (f *failingStore) Create(ctx context.Context, path string, first *message.Part) (rolling.File, error) {
+	return &failingFile{store: f, path: path, first: first}, nil
+}
+
+func (f *failingFile) Write(p []byte) (int, error) {
+	if strings.HasPrefix(f.path, f.store.failPrefix) {
+		return 0, errors.New("nope")
+	}
+	return f.Buffer.Write(p)
+}
+
+func (f *failingFile) Commit(ctx context.Context) error {
+	return f.store.put(ctx, f.path, f.first, f.Bytes())
+}
+
+func (f *failingFile) Abort(ctx context.Context) error {
+	return nil
+}
+
+func TestRollingPartialBatchError(t *testing.T) {
+	conf := rollingconfig.NewConfig()
+	conf.Enabled = true
+	conf.Partition = `${! content() }`
+	conf.MaxRecords = 1
+
+	store := &failingStore{memStore: newMemStore(), failPrefix: "bad"}
+	w, err := rolling.NewWriter(conf, 10, store, mock.NewManager())
+	require.NoError(t, err)
+
+	// The first message reaches the record limit of its file before the
+	// second message fails, but must not be committed.
+	assert.EqualError(t, w.WriteWithContext(context.Background(), message.QuickBatch([][]byte{
+		[]byte("good"), []byte("bad"),
+	})), "nope")
+
+	writeAll(t, w, message.QuickBatch([][]byte{[]byte("good")}))
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second))
+
+	assert.Equal(t, map[string]string{
+		"good/part-00001": "good\n",
+	}, store.contents())
+}
@@ -0,0 +1,104 @@
+package serverless
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// CliCommand returns the serverless-http subcommand for running Benthos as an
+// HTTP function.
+func CliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "serverless-http",
+		Usage: "Run Benthos as a CloudEvents compatible HTTP function",
+		Description: `
+Runs Benthos as an HTTP function suitable for platforms such as Google Cloud
+Functions, Cloud Run, Knative and OpenFaaS. Each request is processed by the
+pipeline of the config, and messages routed to a sync_response output are
+returned as the response.
+
+Requests containing CloudEvents in either structured or binary content mode
+are decoded into messages with the event attributes set as metadata with the
+prefix ce_. When a config is not specified it is read from the environment
+variable BENTHOS_CONFIG, or else a list of default paths.
+
+  benthos -c ./config.yaml serverless-http
+  benthos serverless-http --address 0.0.0.0:8080`[1:],
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "address",
+				Aliases: []string{"a"},
+				Value:   "",
+				Usage:   "the address to listen on, defaults to 0.0.0.0 with the port of the environment variable PORT, or 8080",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			os.Exit(runServerlessHTTP(c.String("config"), c.String("address")))
+			return nil
+		},
+	}
+}
+
+func runServerlessHTTP(confPath, address string) int {
+	conf, err := ReadConfig(confPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
+		return 1
+	}
+
+	handler, err := NewHandler(conf)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Initialisation error: %v\n", err)
+		return 1
+	}
+
+	if address == "" {
+		port := os.Getenv("PORT")
+		if port == "" {
+			port = "8080"
+		}
+		address = net.JoinHostPort("0.0.0.0", port)
+	}
+
+	server := &http.Server{
+		Addr:    address,
+		Handler: handler,
+	}
+
+	serverErrChan := make(chan error, 1)
+	go func() {
+		serverErrChan <- server.ListenAndServe()
+	}()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	exitCode := 0
+	select {
+	case <-sigChan:
+	case err := <-serverErrChan:
+		if !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "HTTP server error: %v\n", err)
+			exitCode = 1
+		}
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	_ = server.Shutdown(ctx)
+	if err := handler.Close(time.Second * 30); err != nil {
+		fmt.Fprintf(os.Stderr, "Shut down error: %v\n", err)
+		return 1
+	}
+	return exitCode
+}
@@ -0,0 +1,109 @@
+package serverless
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/internal/cloudevents"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// cloudEventsRequestToBatch decodes an HTTP request into a message batch. The
+// request may contain a CloudEvent in either structured or binary content mode,
+// a batch of structured CloudEvents, or any other payload, which becomes the
+// contents of a single message. The attributes of CloudEvents are added to
+// messages as metadata with the prefix ce_.
+func cloudEventsRequestToBatch(r *http.Request) (*message.Batch, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	msg := message.QuickBatch(nil)
+	events, err := cloudevents.ParseHTTP(r.Header, body)
+	if err != nil {
+		if !errors.Is(err, cloudevents.ErrNotEvent) {
+			return nil, err
+		}
+		msg.Append(message.NewPart(body))
+		return msg, nil
+	}
+	for _, event := range events {
+		part := message.NewPart(event.Data)
+		event.WalkMetadata(part.MetaSet)
+		msg.Append(part)
+	}
+	return msg, nil
+}
+
+// writeCloudEventsResponse writes the batches that were routed to a
+// sync_response output as an HTTP response. A single message is written as the
+// raw response body, where metadata with the prefix ce_ is set as CloudEvents
+// headers, and multiple messages are written as a JSON array.
+func writeCloudEventsResponse(w http.ResponseWriter, resultBatches []*message.Batch) error {
+	var parts []*message.Part
+	for _, b := range resultBatches {
+		_ = b.Iter(func(i int, p *message.Part) error {
+			parts = append(parts, p)
+			return nil
+		})
+	}
+
+	if len(parts) != 1 {
+		res, err := resultsToJSON(resultBatches)
+		if err != nil {
+			return err
+		}
+		resBytes, err := json.Marshal(res)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resBytes)
+		return nil
+	}
+
+	_ = parts[0].MetaIter(func(k, v string) error {
+		if !strings.HasPrefix(k, cloudevents.MetaPrefix) {
+			return nil
+		}
+		if k == "ce_datacontenttype" {
+			w.Header().Set("Content-Type", v)
+			return nil
+		}
+		w.Header().Set(cloudevents.HTTPHeaderPrefix+strings.TrimPrefix(k, cloudevents.MetaPrefix), v)
+		return nil
+	})
+	_, _ = w.Write(parts[0].Get())
+	return nil
+}
+
+// ServeHTTP implements http.Handler by injecting requests, which may contain
+// CloudEvents, into the underlying Benthos pipeline and writing any messages
+// routed to a sync_response output as the response.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	msg, err := cloudEventsRequestToBatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resultBatches, err := h.HandleBatch(r.Context(), msg)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeCloudEventsResponse(w, resultBatches); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,123 @@
+package serverless_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/serverless"
+)
+
+func newCloudEventsTestHandler(t *testing.T, mapping string) *serverless.Handler {
+	t.Helper()
+
+	conf := config.New()
+	conf.Output.Type = serverless.ServerlessResponseType
+
+	pConf := processor.NewConfig()
+	pConf.Type = "bloblang"
+	pConf.Bloblang = mapping
+	conf.Pipeline.Processors = append(conf.Pipeline.Processors, pConf)
+
+	h, err := serverless.NewHandler(conf)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, h.Close(time.Second*10))
+	})
+	return h
+}
+
+func TestCloudEventsBinaryMode(t *testing.T) {
+	h := newCloudEventsTestHandler(t, `
+root.content = content().string().uppercase()
+root.type = meta("ce_type")
+root.source = meta("ce_source")
+meta ce_type = "com.example.reply"
+`)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`hello world`))
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("Ce-Specversion", "1.0")
+	req.Header.Set("Ce-Id", "1")
+	req.Header.Set("Ce-Type", "com.example.foo")
+	req.Header.Set("Ce-Source", "/example")
+
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, `{"content":"HELLO WORLD","source":"/example","type":"com.example.foo"}`, res.Body.String())
+	assert.Equal(t, "com.example.reply", res.Header().Get("Ce-Type"))
+	assert.Equal(t, "1", res.Header().Get("Ce-Id"))
+	assert.Equal(t, "1.0", res.Header().Get("Ce-Specversion"))
+}
+
+func TestCloudEventsStructuredMode(t *testing.T) {
+	h := newCloudEventsTestHandler(t, `
+root.data = this
+root.id = meta("ce_id")
+root.ext = meta("ce_someext")
+meta = deleted()
+`)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{
+  "specversion": "1.0",
+  "id": "abc",
+  "type": "com.example.foo",
+  "source": "/example",
+  "datacontenttype": "application/json",
+  "someext": 10,
+  "data": {"foo":"bar"}
+}`))
+	req.Header.Set("Content-Type", "application/cloudevents+json; charset=utf-8")
+
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, `{"data":{"foo":"bar"},"ext":"10","id":"abc"}`, res.Body.String())
+	assert.Empty(t, res.Header().Get("Ce-Id"))
+}
+
+func TestCloudEventsBatchMode(t *testing.T) {
+	h := newCloudEventsTestHandler(t, `
+root.content = content().string()
+root.id = meta("ce_id")
+`)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[
+  {"specversion":"1.0","id":"a","type":"foo","source":"/bar","datacontenttype":"text/plain","data":"first"},
+  {"specversion":"1.0","id":"b","type":"foo","source":"/bar","data_base64":"c2Vjb25k"}
+]`))
+	req.Header.Set("Content-Type", "application/cloudevents-batch+json")
+
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+	assert.Equal(t, `[{"content":"first","id":"a"},{"content":"second","id":"b"}]`, res.Body.String())
+}
+
+func TestCloudEventsBadRequests(t *testing.T) {
+	h := newCloudEventsTestHandler(t, `root = this`)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":"abc","data":"nope"}`))
+	req.Header.Set("Content-Type", "application/cloudevents+json")
+	res = httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	assert.Contains(t, res.Body.String(), "missing the attribute specversion")
+}
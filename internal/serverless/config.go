@@ -0,0 +1,81 @@
+package serverless
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/config"
+)
+
+// DefaultConfig returns a config suitable for serverless distributions of
+// Benthos, where the output rejects messages that failed processing and
+// returns all other messages as a response.
+func DefaultConfig() config.Type {
+	conf := config.New()
+	conf.Metrics.Type = "none"
+	conf.Logger.Format = "json"
+
+	conf.Output.Type = "switch"
+	conf.Output.Switch.RetryUntilSuccess = false
+
+	errorCase := output.NewSwitchConfigCase()
+	errorCase.Check = "errored()"
+	errorCase.Output.Type = "reject"
+	errorCase.Output.Reject = "processing failed due to: ${! error() }"
+
+	responseCase := output.NewSwitchConfigCase()
+	responseCase.Output.Type = ServerlessResponseType
+
+	conf.Output.Switch.Cases = append(conf.Output.Switch.Cases, errorCase, responseCase)
+	return conf
+}
+
+// ReadConfig reads a config on top of DefaultConfig. When a path is specified
+// the config is read from that file, otherwise it is read from the environment
+// variable BENTHOS_CONFIG when set, or else from the first file found in a list
+// of default paths, beginning with the environment variable
+// BENTHOS_CONFIG_PATH.
+func ReadConfig(path string) (config.Type, error) {
+	conf := DefaultConfig()
+
+	if path != "" {
+		if _, err := config.ReadFile(path, &conf); err != nil {
+			return conf, err
+		}
+		return conf, nil
+	}
+
+	if confStr := os.Getenv("BENTHOS_CONFIG"); len(confStr) > 0 {
+		confBytes, err := config.ReplaceEnvVariables([]byte(confStr))
+		if err == nil {
+			err = yaml.Unmarshal(confBytes, &conf)
+		}
+		return conf, err
+	}
+
+	// A list of default config paths to check for if not explicitly defined
+	defaultPaths := []string{
+		"./benthos.yaml",
+		"./config.yaml",
+		"/benthos.yaml",
+		"/etc/benthos/config.yaml",
+		"/etc/benthos.yaml",
+	}
+	if path := os.Getenv("BENTHOS_CONFIG_PATH"); path != "" {
+		defaultPaths = append([]string{path}, defaultPaths...)
+	}
+
+	// Iterate default config paths
+	for _, path := range defaultPaths {
+		if _, err := os.Stat(path); err == nil {
+			if _, err = config.ReadFile(path, &conf); err != nil {
+				return conf, fmt.Errorf("%v: %w", path, err)
+			}
+			break
+		}
+	}
+	return conf, nil
+}
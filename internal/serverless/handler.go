@@ -47,6 +47,16 @@ func (h *Handler) Handle(ctx context.Context, obj interface{}) (interface{}, err
 	part.SetJSON(obj)
 	msg.Append(part)
 
+	resultBatches, err := h.HandleBatch(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	return resultsToJSON(resultBatches)
+}
+
+// HandleBatch injects a message batch into the underlying Benthos pipeline and
+// returns any batches that were routed to a sync_response output.
+func (h *Handler) HandleBatch(ctx context.Context, msg *message.Batch) ([]*message.Batch, error) {
 	store := transaction.NewResultStore()
 	transaction.AddResultStore(msg, store)
 
@@ -66,8 +76,13 @@ func (h *Handler) Handle(ctx context.Context, obj interface{}) (interface{}, err
 	case <-ctx.Done():
 		return nil, errors.New("request cancelled")
 	}
+	return store.Get(), nil
+}
 
-	resultBatches := store.Get()
+// resultsToJSON converts response batches into a structured value, where a
+// single message is returned as-is, a single batch as an array, and multiple
+// batches as an array of arrays.
+func resultsToJSON(resultBatches []*message.Batch) (interface{}, error) {
 	if len(resultBatches) == 0 {
 		return map[string]interface{}{"message": "request successful"}, nil
 	}
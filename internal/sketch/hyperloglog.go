@@ -0,0 +1,97 @@
+package sketch
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// HyperLogLog is a probabilistic estimator of the number of distinct values
+// added to it, using a fixed amount of memory determined by its precision.
+type HyperLogLog struct {
+	P         uint8  `json:"p"`
+	Registers []byte `json:"registers"`
+}
+
+// NewHyperLogLog creates a HyperLogLog with a precision between 4 and 18,
+// which uses 2^precision bytes of memory and has a standard error of roughly
+// 1.04/sqrt(2^precision).
+func NewHyperLogLog(precision uint8) (*HyperLogLog, error) {
+	if precision < 4 || precision > 18 {
+		return nil, errors.New("precision must be between 4 and 18")
+	}
+	return &HyperLogLog{
+		P:         precision,
+		Registers: make([]byte, 1<<precision),
+	}, nil
+}
+
+func hash64(data []byte) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(data)
+
+	// FNV has a poor avalanche effect on its higher bits, which we rely on for
+	// register selection, and therefore we apply a finalizer.
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// Add a value to the estimator.
+func (h *HyperLogLog) Add(data []byte) {
+	x := hash64(data)
+	idx := x >> (64 - h.P)
+	rank := uint8(bits.LeadingZeros64((x<<h.P)|(1<<(h.P-1)))) + 1
+	if rank > h.Registers[idx] {
+		h.Registers[idx] = rank
+	}
+}
+
+// Merge the values of another estimator of the same precision into this one.
+func (h *HyperLogLog) Merge(other *HyperLogLog) error {
+	if h.P != other.P {
+		return errors.New("cannot merge estimators of different precisions")
+	}
+	for i, r := range other.Registers {
+		if r > h.Registers[i] {
+			h.Registers[i] = r
+		}
+	}
+	return nil
+}
+
+// Count returns the estimated number of distinct values added.
+func (h *HyperLogLog) Count() uint64 {
+	m := float64(len(h.Registers))
+
+	var sum float64
+	var zeros int
+	for _, r := range h.Registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	alpha := 0.7213 / (1 + 1.079/m)
+	switch len(h.Registers) {
+	case 16:
+		alpha = 0.673
+	case 32:
+		alpha = 0.697
+	case 64:
+		alpha = 0.709
+	}
+
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		// Linear counting is more accurate for small cardinalities.
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
@@ -0,0 +1,4 @@
+// Package sketch contains probabilistic data structures for summarising
+// streams of values in a bounded amount of memory, which can be serialised as
+// JSON in order to be stored within caches.
+package sketch
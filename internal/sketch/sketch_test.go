@@ -0,0 +1,99 @@
+package sketch
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHyperLogLogCount(t *testing.T) {
+	for _, n := range []int{0, 10, 1000, 100000} {
+		h, err := NewHyperLogLog(14)
+		require.NoError(t, err)
+
+		for i := 0; i < n; i++ {
+			// Add each value twice in order to ensure duplicates are ignored
+			h.Add([]byte(strconv.Itoa(i)))
+			h.Add([]byte(strconv.Itoa(i)))
+		}
+		assert.InEpsilon(t, float64(n)+1, float64(h.Count())+1, 0.02, "n: %v", n)
+	}
+}
+
+func TestHyperLogLogMergeAndJSON(t *testing.T) {
+	a, err := NewHyperLogLog(12)
+	require.NoError(t, err)
+	b, err := NewHyperLogLog(12)
+	require.NoError(t, err)
+
+	for i := 0; i < 5000; i++ {
+		a.Add([]byte(strconv.Itoa(i)))
+		b.Add([]byte(strconv.Itoa(i + 2500)))
+	}
+	require.NoError(t, a.Merge(b))
+	assert.InEpsilon(t, 7500, float64(a.Count()), 0.04)
+
+	aBytes, err := json.Marshal(a)
+	require.NoError(t, err)
+
+	var c HyperLogLog
+	require.NoError(t, json.Unmarshal(aBytes, &c))
+	assert.Equal(t, a.Count(), c.Count())
+
+	d, err := NewHyperLogLog(10)
+	require.NoError(t, err)
+	assert.Error(t, a.Merge(d))
+
+	_, err = NewHyperLogLog(3)
+	assert.Error(t, err)
+}
+
+func TestTDigestQuantiles(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	td := NewTDigest(100)
+	assert.True(t, math.IsNaN(td.Quantile(0.5)))
+
+	values := rng.Perm(100001)
+	for _, v := range values {
+		td.Add(float64(v))
+	}
+
+	assert.Equal(t, float64(100001), td.Count())
+	assert.Equal(t, float64(0), td.Quantile(0))
+	assert.Equal(t, float64(100000), td.Quantile(1))
+	for _, q := range []float64{0.01, 0.1, 0.5, 0.9, 0.99, 0.999} {
+		assert.InDelta(t, q*100000, td.Quantile(q), 500, "q: %v", q)
+	}
+}
+
+func TestTDigestMergeAndJSON(t *testing.T) {
+	a, b := NewTDigest(100), NewTDigest(100)
+	for i := 0; i < 1000; i++ {
+		a.Add(float64(i))
+		b.Add(float64(i + 1000))
+	}
+	a.Merge(b)
+	assert.Equal(t, float64(2000), a.Count())
+	assert.InDelta(t, 1000, a.Quantile(0.5), 20)
+
+	aBytes, err := json.Marshal(a)
+	require.NoError(t, err)
+
+	var c TDigest
+	require.NoError(t, json.Unmarshal(aBytes, &c))
+	assert.Equal(t, a.Quantile(0.5), c.Quantile(0.5))
+	assert.Equal(t, float64(1999), c.Quantile(1))
+
+	c.Add(5000)
+	assert.Equal(t, float64(5000), c.Quantile(1))
+
+	single := NewTDigest(100)
+	single.Add(42)
+	assert.Equal(t, float64(42), single.Quantile(0.5))
+}
@@ -0,0 +1,216 @@
+package sketch
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+)
+
+// Centroid is a cluster of values within a TDigest.
+type Centroid struct {
+	Mean   float64 `json:"m"`
+	Weight float64 `json:"w"`
+}
+
+// TDigest is a probabilistic estimator of the quantiles of the values added
+// to it, which is most accurate at the extreme quantiles.
+//
+// https://github.com/tdunning/t-digest/blob/main/docs/t-digest-paper/histo.pdf
+type TDigest struct {
+	compression float64
+	centroids   []Centroid
+	unmerged    []Centroid
+	min, max    float64
+}
+
+// NewTDigest creates a TDigest with a compression parameter, which limits the
+// number of centroids retained to roughly that amount. A compression of 100
+// is a sensible default.
+func NewTDigest(compression float64) *TDigest {
+	return &TDigest{
+		compression: compression,
+		min:         math.Inf(1),
+		max:         math.Inf(-1),
+	}
+}
+
+// Add a value to the estimator.
+func (t *TDigest) Add(v float64) {
+	t.addCentroid(Centroid{Mean: v, Weight: 1})
+}
+
+func (t *TDigest) addCentroid(c Centroid) {
+	if c.Mean < t.min {
+		t.min = c.Mean
+	}
+	if c.Mean > t.max {
+		t.max = c.Mean
+	}
+	t.unmerged = append(t.unmerged, c)
+	if len(t.unmerged) >= int(t.compression)*5 {
+		t.compress()
+	}
+}
+
+// Merge the values of another estimator into this one.
+func (t *TDigest) Merge(other *TDigest) {
+	other.compress()
+	for _, c := range other.centroids {
+		t.addCentroid(c)
+	}
+	if other.min < t.min {
+		t.min = other.min
+	}
+	if other.max > t.max {
+		t.max = other.max
+	}
+}
+
+// Count returns the number of values added.
+func (t *TDigest) Count() float64 {
+	t.compress()
+	var total float64
+	for _, c := range t.centroids {
+		total += c.Weight
+	}
+	return total
+}
+
+func (t *TDigest) k(q float64) float64 {
+	return t.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+func (t *TDigest) kInv(k float64) float64 {
+	if k >= t.compression/4 {
+		return 1
+	}
+	return (math.Sin(k*2*math.Pi/t.compression) + 1) / 2
+}
+
+func (t *TDigest) compress() {
+	if len(t.unmerged) == 0 {
+		return
+	}
+
+	all := append(t.centroids, t.unmerged...)
+	t.unmerged = nil
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Mean < all[j].Mean
+	})
+
+	var total float64
+	for _, c := range all {
+		total += c.Weight
+	}
+
+	merged := make([]Centroid, 0, int(t.compression))
+	current := all[0]
+	var soFar float64
+	qLimit := t.kInv(t.k(0) + 1)
+	for _, c := range all[1:] {
+		if (soFar+current.Weight+c.Weight)/total <= qLimit {
+			current.Weight += c.Weight
+			current.Mean += (c.Mean - current.Mean) * c.Weight / current.Weight
+			continue
+		}
+		soFar += current.Weight
+		merged = append(merged, current)
+		qLimit = t.kInv(t.k(soFar/total) + 1)
+		current = c
+	}
+	t.centroids = append(merged, current)
+}
+
+// Quantile returns the estimated value at a quantile between 0 and 1, or NaN
+// if no values have been added.
+func (t *TDigest) Quantile(q float64) float64 {
+	t.compress()
+
+	n := len(t.centroids)
+	if n == 0 {
+		return math.NaN()
+	}
+	if n == 1 || q <= 0 {
+		if q >= 1 {
+			return t.max
+		}
+		if q <= 0 {
+			return t.min
+		}
+		return t.centroids[0].Mean
+	}
+	if q >= 1 {
+		return t.max
+	}
+
+	var total float64
+	for _, c := range t.centroids {
+		total += c.Weight
+	}
+	target := q * total
+
+	// Values between the extremes and the first or last centroid are
+	// interpolated from the minimum and maximum values seen.
+	first := t.centroids[0]
+	if target < first.Weight/2 {
+		return t.min + (first.Mean-t.min)*target/(first.Weight/2)
+	}
+
+	var cumulative float64
+	for i := 0; i < n-1; i++ {
+		left := cumulative + t.centroids[i].Weight/2
+		right := cumulative + t.centroids[i].Weight + t.centroids[i+1].Weight/2
+		if target <= right {
+			frac := (target - left) / (right - left)
+			return t.centroids[i].Mean + frac*(t.centroids[i+1].Mean-t.centroids[i].Mean)
+		}
+		cumulative += t.centroids[i].Weight
+	}
+
+	last := t.centroids[n-1]
+	remaining := total - target
+	if remaining <= 0 {
+		return t.max
+	}
+	return t.max - (t.max-last.Mean)*remaining/(last.Weight/2)
+}
+
+type tdigestJSON struct {
+	Compression float64    `json:"compression"`
+	Centroids   []Centroid `json:"centroids"`
+	Min         float64    `json:"min"`
+	Max         float64    `json:"max"`
+}
+
+// MarshalJSON serialises the estimator.
+func (t *TDigest) MarshalJSON() ([]byte, error) {
+	t.compress()
+	j := tdigestJSON{
+		Compression: t.compression,
+		Centroids:   t.centroids,
+		Min:         t.min,
+		Max:         t.max,
+	}
+	if len(t.centroids) == 0 {
+		j.Min, j.Max = 0, 0
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON parses a serialised estimator.
+func (t *TDigest) UnmarshalJSON(data []byte) error {
+	var j tdigestJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*t = TDigest{
+		compression: j.Compression,
+		centroids:   j.Centroids,
+		min:         j.Min,
+		max:         j.Max,
+	}
+	if len(t.centroids) == 0 {
+		t.min, t.max = math.Inf(1), math.Inf(-1)
+	}
+	return nil
+}
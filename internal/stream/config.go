@@ -19,6 +19,7 @@ type Config struct {
 	Buffer   buffer.Config   `json:"buffer" yaml:"buffer"`
 	Pipeline pipeline.Config `json:"pipeline" yaml:"pipeline"`
 	Output   output.Config   `json:"output" yaml:"output"`
+	Shutdown ShutdownConfig  `json:"shutdown" yaml:"shutdown"`
 }
 
 // NewConfig returns a new configuration with default values.
@@ -28,6 +29,7 @@ func NewConfig() Config {
 		Buffer:   buffer.NewConfig(),
 		Pipeline: pipeline.NewConfig(),
 		Output:   output.NewConfig(),
+		Shutdown: NewShutdownConfig(),
 	}
 }
 
@@ -42,6 +44,7 @@ func (c Config) Sanitised() (interface{}, error) {
 
 	sanitConf := docs.NewSanitiseConfig()
 	sanitConf.RemoveTypeField = true
+	sanitConf.ScrubSecrets = true
 	if err := Spec().SanitiseYAML(&node, sanitConf); err != nil {
 		return nil, err
 	}
@@ -14,5 +14,6 @@ func Spec() docs.FieldSpecs {
 			docs.FieldProcessor("processors", "A list of processors to apply to messages.").Array().HasDefault([]interface{}{}),
 		),
 		docs.FieldOutput("output", "An output to sink messages to.").Optional(),
+		shutdownSpec(),
 	}
 }
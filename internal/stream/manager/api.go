@@ -288,7 +288,9 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 		if confBytes, err = io.ReadAll(r.Body); err != nil {
 			return
 		}
-		confBytes = config.ReplaceEnvVariables(confBytes)
+		if confBytes, err = config.ReplaceEnvVariables(confBytes); err != nil {
+			return
+		}
 
 		if r.URL.Query().Get("chilled") != "true" {
 			var node yaml.Node
@@ -317,15 +319,17 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 		type aliasedOut output.Config
 
 		aliasedConf := struct {
-			Input    aliasedIn   `json:"input"`
-			Buffer   aliasedBuf  `json:"buffer"`
-			Pipeline aliasedPipe `json:"pipeline"`
-			Output   aliasedOut  `json:"output"`
+			Input    aliasedIn             `json:"input"`
+			Buffer   aliasedBuf            `json:"buffer"`
+			Pipeline aliasedPipe           `json:"pipeline"`
+			Output   aliasedOut            `json:"output"`
+			Shutdown stream.ShutdownConfig `json:"shutdown"`
 		}{
 			Input:    aliasedIn(confIn.Input),
 			Buffer:   aliasedBuf(confIn.Buffer),
 			Pipeline: aliasedPipe(confIn.Pipeline),
 			Output:   aliasedOut(confIn.Output),
+			Shutdown: confIn.Shutdown,
 		}
 		if err = yaml.Unmarshal(patchBytes, &aliasedConf); err != nil {
 			return
@@ -335,6 +339,7 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 			Buffer:   buffer.Config(aliasedConf.Buffer),
 			Pipeline: pipeline.Config(aliasedConf.Pipeline),
 			Output:   output.Config(aliasedConf.Output),
+			Shutdown: aliasedConf.Shutdown,
 		}
 		return
 	}
@@ -513,7 +518,9 @@ func (m *Type) HandleResourceCRUD(w http.ResponseWriter, r *http.Request) {
 		if confBytes, requestErr = io.ReadAll(r.Body); requestErr != nil {
 			return
 		}
-		confBytes = config.ReplaceEnvVariables(confBytes)
+		if confBytes, requestErr = config.ReplaceEnvVariables(confBytes); requestErr != nil {
+			return
+		}
 
 		var node yaml.Node
 		if requestErr = yaml.Unmarshal(confBytes, &node); requestErr != nil {
@@ -621,11 +628,23 @@ func (m *Type) HandleStreamReady(w http.ResponseWriter, r *http.Request) {
 	}
 	m.lock.Unlock()
 
-	if len(notReady) == 0 {
+	var checkErrs []error
+	for _, check := range m.readyChecks {
+		if err := check(); err != nil {
+			checkErrs = append(checkErrs, err)
+		}
+	}
+
+	if len(notReady) == 0 && len(checkErrs) == 0 {
 		_, _ = w.Write([]byte("OK"))
 		return
 	}
 
 	w.WriteHeader(http.StatusServiceUnavailable)
-	fmt.Fprintf(w, "streams %v are not connected\n", strings.Join(notReady, ", "))
+	if len(notReady) > 0 {
+		fmt.Fprintf(w, "streams %v are not connected\n", strings.Join(notReady, ", "))
+	}
+	for _, err := range checkErrs {
+		fmt.Fprintln(w, err)
+	}
 }
@@ -3,6 +3,7 @@ package manager_test
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -882,3 +883,26 @@ file:
 	require.NoError(t, err)
 	assert.Equal(t, `{"id":"second","content":"hello world 2"}`, string(file2Bytes))
 }
+
+func TestTypeAPIReadyChecks(t *testing.T) {
+	mgr, err := bmanager.New(bmanager.NewResourceConfig())
+	require.NoError(t, err)
+
+	var checkErr error
+	smgr := manager.New(mgr, manager.OptAddReadyCheck(func() error {
+		return checkErr
+	}))
+
+	request := genRequest("GET", "/ready", nil)
+	response := httptest.NewRecorder()
+	smgr.HandleStreamReady(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "OK", response.Body.String())
+
+	checkErr = errors.New("failed to reload configs: foo.yaml: nope")
+
+	response = httptest.NewRecorder()
+	smgr.HandleStreamReady(response, request)
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	assert.Equal(t, "failed to reload configs: foo.yaml: nope\n", response.Body.String())
+}
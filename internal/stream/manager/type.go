@@ -1,6 +1,7 @@
 package manager
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
@@ -85,8 +86,9 @@ type Type struct {
 	closed  bool
 	streams map[string]*StreamStatus
 
-	manager    bundle.NewManagement
-	apiEnabled bool
+	manager     bundle.NewManagement
+	apiEnabled  bool
+	readyChecks []func() error
 
 	lock sync.Mutex
 }
@@ -115,6 +117,15 @@ func OptAPIEnabled(b bool) func(*Type) {
 	}
 }
 
+// OptAddReadyCheck adds a check to be performed by the /ready endpoint in
+// addition to whether all streams are connected. When the provided func returns
+// an error the endpoint reports that the service is not ready.
+func OptAddReadyCheck(fn func() error) func(*Type) {
+	return func(t *Type) {
+		t.readyChecks = append(t.readyChecks, fn)
+	}
+}
+
 //------------------------------------------------------------------------------
 
 // Errors specifically returned by a stream manager.
@@ -178,8 +189,10 @@ func (m *Type) Read(id string) (*StreamStatus, error) {
 	return wrapper, nil
 }
 
-// Update attempts to stop an existing stream and replace it with a new version
-// of the same stream.
+// Update attempts to replace an existing stream with a new version of the same
+// stream. The existing stream continues to run until the new version is
+// connected, and if the new version fails to start then the existing stream
+// config remains active.
 func (m *Type) Update(id string, conf stream.Config, timeout time.Duration) error {
 	m.lock.Lock()
 	wrapper, exists := m.streams[id]
@@ -197,10 +210,36 @@ func (m *Type) Update(id string, conf stream.Config, timeout time.Duration) erro
 		return nil
 	}
 
-	if err := m.Delete(id, timeout); err != nil {
+	// Components that are unchanged are reused by the new stream, and so the
+	// metrics of the existing stream carry over.
+	strmFlatMetrics := wrapper.metrics
+	sMgr := m.manager.ForStream(id).WithAddedMetrics(strmFlatMetrics)
+
+	// The new stream is brought up alongside the existing stream, which is
+	// only drained once the new stream is connected.
+	newWrapper := newStreamStatus(conf, strmFlatMetrics)
+	strm, err := stream.Swap(context.Background(), wrapper.strm, conf, sMgr, timeout, stream.OptOnClose(func() {
+		newWrapper.setClosed()
+	}))
+	if strm == wrapper.strm {
+		// The existing stream was left untouched.
 		return err
 	}
-	return m.Create(id, conf)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if strm == nil {
+		delete(m.streams, id)
+		return err
+	}
+	if err != nil {
+		// The config of the existing stream was restored.
+		newWrapper.config = wrapper.config
+	}
+	newWrapper.setStream(strm)
+	m.streams[id] = newWrapper
+	return err
 }
 
 // Delete attempts to stop and remove a stream by its ID. Returns an error if
@@ -0,0 +1,154 @@
+package stream
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	iinput "github.com/benthosdev/benthos/v4/internal/component/input"
+	ioutput "github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+const outputFlushPollPeriod = time.Millisecond * 10
+
+// ShutdownConfig contains timeouts for each phase of a graceful shut down of a
+// stream.
+type ShutdownConfig struct {
+	StopConsumingTimeout string `json:"stop_consuming_timeout" yaml:"stop_consuming_timeout"`
+	DrainTimeout         string `json:"drain_timeout" yaml:"drain_timeout"`
+	FlushTimeout         string `json:"flush_timeout" yaml:"flush_timeout"`
+	CloseTimeout         string `json:"close_timeout" yaml:"close_timeout"`
+}
+
+// NewShutdownConfig returns a ShutdownConfig with default values.
+func NewShutdownConfig() ShutdownConfig {
+	return ShutdownConfig{
+		StopConsumingTimeout: "",
+		DrainTimeout:         "",
+		FlushTimeout:         "",
+		CloseTimeout:         "",
+	}
+}
+
+func shutdownSpec() docs.FieldSpec {
+	return docs.FieldObject(
+		"shutdown", "Configures the phases of a graceful shut down of the stream, where each phase can be given its own timeout. When a timeout is left empty the phase may use whatever remains of the overall shut down timeout, which always takes precedence. If a phase times out the remaining components of the stream are closed forcefully, and the number of messages still in flight within the input and output is logged.",
+	).WithChildren(
+		docs.FieldString("stop_consuming_timeout", "The maximum period of time to wait for the input to stop consuming new messages.").HasDefault(""),
+		docs.FieldString("drain_timeout", "The maximum period of time to wait for the buffer and processing pipelines to process the messages they hold.").HasDefault(""),
+		docs.FieldString("flush_timeout", "The maximum period of time to wait for the output to flush pending batches and write the messages it holds.").HasDefault(""),
+		docs.FieldString("close_timeout", "The maximum period of time to wait for the output to close and the input to acknowledge pending messages and close.").HasDefault(""),
+	).Advanced()
+}
+
+//------------------------------------------------------------------------------
+
+type shutdownPhases struct {
+	stopConsuming time.Duration
+	drain         time.Duration
+	flush         time.Duration
+	close         time.Duration
+}
+
+func parseShutdownPhases(conf ShutdownConfig) (p shutdownPhases, err error) {
+	for _, f := range []struct {
+		name  string
+		value string
+		d     *time.Duration
+	}{
+		{name: "stop_consuming_timeout", value: conf.StopConsumingTimeout, d: &p.stopConsuming},
+		{name: "drain_timeout", value: conf.DrainTimeout, d: &p.drain},
+		{name: "flush_timeout", value: conf.FlushTimeout, d: &p.flush},
+		{name: "close_timeout", value: conf.CloseTimeout, d: &p.close},
+	} {
+		if f.value == "" {
+			continue
+		}
+		if *f.d, err = time.ParseDuration(f.value); err != nil {
+			return p, fmt.Errorf("failed to parse shutdown %v: %w", f.name, err)
+		}
+	}
+	return p, nil
+}
+
+// runShutdownPhase executes a phase of a graceful shut down, which is given
+// either its configured timeout or the remaining time until stopBy, whichever
+// is shortest.
+func (t *Type) runShutdownPhase(name string, timeout time.Duration, stopBy time.Time, fn func(timeout time.Duration) error) error {
+	remaining := time.Until(stopBy)
+	if timeout <= 0 || timeout > remaining {
+		timeout = remaining
+	}
+	if timeout <= 0 {
+		return component.ErrTimeout
+	}
+
+	t.manager.Logger().Debugf("Entering shutdown phase '%v' with a timeout of %v", name, timeout)
+	if err := fn(timeout); err != nil {
+		if report := t.inFlightReport(); report != "" {
+			t.manager.Logger().Warnf("Shutdown phase '%v' failed: %v, messages still in flight: %v", name, err, report)
+		} else {
+			t.manager.Logger().Warnf("Shutdown phase '%v' failed: %v", name, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// waitForOutputFlush blocks until all messages consumed by the input have been
+// acknowledged, or the output has closed. Inputs that report the messages they
+// hold are the most reliable measure of this, as outputs only count messages
+// once they have received them. Otherwise outputs that report the messages
+// they hold are used, and as a last resort we wait until the input closes,
+// which it only does once all of its messages are acknowledged.
+func waitForOutputFlush(in iinput.Streamed, inputClosed <-chan struct{}, out ioutput.Streamed, timeout time.Duration) error {
+	stopBy := time.Now().Add(timeout)
+	for {
+		if n, ok := component.InFlightOf(in); ok {
+			if n == 0 {
+				return nil
+			}
+		} else if n, ok := component.InFlightOf(out); ok && n == 0 {
+			return nil
+		}
+		select {
+		case <-inputClosed:
+			return nil
+		default:
+		}
+
+		wait := time.Until(stopBy)
+		if wait <= 0 {
+			return component.ErrTimeout
+		}
+		if wait > outputFlushPollPeriod {
+			wait = outputFlushPollPeriod
+		}
+		if err := out.WaitForClose(wait); err == nil {
+			return nil
+		}
+	}
+}
+
+// inFlightReport returns a summary of the messages still in flight within the
+// components of the stream that are able to report them.
+func (t *Type) inFlightReport() string {
+	var parts []string
+	for _, c := range []struct {
+		path      string
+		component interface{}
+	}{
+		{path: "input", component: t.inputLayer},
+		{path: "output", component: t.outputLayer},
+	} {
+		if c.component == nil {
+			continue
+		}
+		if n, ok := component.InFlightOf(c.component); ok && n > 0 {
+			parts = append(parts, fmt.Sprintf("%v: %v", c.path, n))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,384 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// Swap replaces a running stream with a new stream of a provided config whilst
+// minimising downtime.
+//
+// Components that are configured identically in the old and new configs are
+// reused along with their connections. When only the input has changed the new
+// input is connected and then takes over from the old input, which is drained
+// of in-flight transactions. When the input is unchanged the buffer, pipeline
+// and output layers of the new stream are created alongside the old stream,
+// and once the new output is connected the input is switched over to them and
+// the old layers are drained. Resources are owned by the manager and are
+// therefore reused by both streams.
+//
+// When both the input and the other layers have changed, the buffer, pipeline
+// and output layers of the new stream are created alongside the old stream,
+// and only once the new output is connected is the old stream drained of
+// in-flight transactions and its input replaced with the new input.
+//
+// If the new output or input fails to connect within the timeout, or the
+// context is cancelled, whilst the old stream is still intact then the old
+// stream is left running. If the new stream fails to start after the old
+// stream has been drained, including when the new input fails to connect
+// within the timeout, then the config of the old stream is restarted. In either
+// case an error is returned along with the stream that remains running, which
+// is only nil when the old config also fails to restart.
+func Swap(ctx context.Context, old *Type, conf Config, mgr bundle.NewManagement, timeout time.Duration, opts ...func(*Type)) (*Type, error) {
+	t := &Type{
+		conf:    conf,
+		manager: mgr,
+		onClose: func() {},
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	var err error
+	if t.phases, err = parseShutdownPhases(conf.Shutdown); err != nil {
+		return old, err
+	}
+
+	inputChanged := !reflect.DeepEqual(old.conf.Input, conf.Input)
+	downstreamChanged := !reflect.DeepEqual(old.conf.Buffer, conf.Buffer) ||
+		!reflect.DeepEqual(old.conf.Pipeline, conf.Pipeline) ||
+		!reflect.DeepEqual(old.conf.Output, conf.Output)
+
+	switch {
+	case !inputChanged && !downstreamChanged:
+		t.reuseInput(old)
+		t.reuseDownstream(old)
+		t.watchForClose()
+		t.registerReadyEndpoint()
+		return t, nil
+	case !downstreamChanged:
+		if swapped, err := t.swapInput(ctx, old, timeout); swapped || err != nil {
+			return old, err
+		}
+		return t, nil
+	case !inputChanged:
+		if swapped, err := t.swapDownstream(ctx, old, timeout); swapped || err != nil {
+			return old, err
+		}
+		return t, nil
+	}
+	return t.swapAll(ctx, old, timeout, opts...)
+}
+
+// swapInput replaces the input of the old stream with a new input, reusing the
+// buffer, pipeline and output layers. Returns true along with an error when
+// the old stream remains unchanged.
+func (t *Type) swapInput(ctx context.Context, old *Type, timeout time.Duration) (bool, error) {
+	in, err := t.manager.IntoPath("input").NewInput(t.conf.Input)
+	if err != nil {
+		return true, fmt.Errorf("failed to create new input, the previous stream remains active: %w", err)
+	}
+	if !waitForConnected(ctx, in.Connected, timeout) {
+		in.CloseAsync()
+		_ = in.WaitForClose(timeout)
+		return true, errors.New("new input failed to connect within the timeout, the previous stream remains active")
+	}
+
+	if !old.forwarder.replaceInput(in.TransactionChan()) {
+		in.CloseAsync()
+		_ = in.WaitForClose(timeout)
+		return true, errors.New("the previous stream has already closed")
+	}
+
+	old.inputLayer.CloseAsync()
+	if err := old.inputLayer.WaitForClose(timeout); err != nil {
+		t.manager.Logger().Errorf("Failed to drain previous input: %v", err)
+	}
+
+	t.inputLayer = in
+	t.forwarder = old.forwarder
+	t.reuseDownstream(old)
+	t.watchForClose()
+	t.registerReadyEndpoint()
+	return false, nil
+}
+
+// swapDownstream replaces the buffer, pipeline and output layers of the old
+// stream with new layers, reusing the input. Returns true along with an error
+// when the old stream remains unchanged.
+func (t *Type) swapDownstream(ctx context.Context, old *Type, timeout time.Duration) (bool, error) {
+	inTranChan := make(chan message.Transaction)
+	if err := t.startDownstream(inTranChan); err != nil {
+		close(inTranChan)
+		t.closeDownstream(timeout)
+		return true, fmt.Errorf("failed to create new stream, the previous stream remains active: %w", err)
+	}
+	if !t.waitForOutput(ctx, timeout) {
+		close(inTranChan)
+		t.closeDownstream(timeout)
+		return true, errors.New("new output failed to connect within the timeout, the previous stream remains active")
+	}
+
+	// The old stream no longer represents the running stream once its
+	// downstream layers are replaced, and so their closure is not reported.
+	old.stopWatchingClose()
+	if !old.forwarder.replaceOutput(inTranChan) {
+		old.watchForClose()
+		close(inTranChan)
+		t.closeDownstream(timeout)
+		return true, errors.New("the previous stream has already closed")
+	}
+
+	// The old layers close by proxy once they've resolved the transactions
+	// they hold.
+	if err := old.outputLayer.WaitForClose(timeout); err != nil {
+		t.manager.Logger().Errorf("Failed to drain previous stream: %v", err)
+		old.closeDownstream(timeout)
+	}
+
+	t.reuseInput(old)
+	t.watchForClose()
+	t.registerReadyEndpoint()
+	return false, nil
+}
+
+// swapAll replaces every layer of the old stream.
+func (t *Type) swapAll(ctx context.Context, old *Type, timeout time.Duration, opts ...func(*Type)) (*Type, error) {
+	inTranChan := make(chan message.Transaction)
+	if err := t.startDownstream(inTranChan); err != nil {
+		close(inTranChan)
+		t.closeDownstream(timeout)
+
+		// Components such as servers may conflict with those of the old
+		// stream, in which case we fall back to a full restart.
+		t.manager.Logger().Warnf("Unable to create new stream alongside the previous stream, falling back to a full restart: %v", err)
+		return restart(old, t.conf, t.manager, timeout, opts...)
+	}
+
+	if !t.waitForOutput(ctx, timeout) {
+		close(inTranChan)
+		t.closeDownstream(timeout)
+		return old, errors.New("new output failed to connect within the timeout, the previous stream remains active")
+	}
+
+	if err := old.Stop(timeout); err != nil {
+		t.manager.Logger().Errorf("Failed to drain previous stream: %v", err)
+	}
+
+	var err error
+	if t.inputLayer, err = t.manager.IntoPath("input").NewInput(t.conf.Input); err != nil {
+		close(inTranChan)
+		t.closeDownstream(timeout)
+		return rollback(old, t.manager, err, opts...)
+	}
+	t.forwarder = newInputForwarder(t.inputLayer.TransactionChan(), inTranChan)
+
+	if !t.waitForInput(ctx, timeout) {
+		t.inputLayer.CloseAsync()
+		_ = t.inputLayer.WaitForClose(timeout)
+		t.closeDownstream(timeout)
+		return rollback(old, t.manager, errors.New("new input failed to connect within the timeout"), opts...)
+	}
+
+	t.watchForClose()
+	t.registerReadyEndpoint()
+	return t, nil
+}
+
+// reuseInput adopts the input layer of the old stream.
+func (t *Type) reuseInput(old *Type) {
+	t.inputLayer = old.inputLayer
+	t.forwarder = old.forwarder
+}
+
+// reuseDownstream adopts the buffer, pipeline and output layers of the old
+// stream, taking over from the old stream in watching the output for closure.
+func (t *Type) reuseDownstream(old *Type) {
+	old.stopWatchingClose()
+	t.bufferLayer = old.bufferLayer
+	t.pipelineLayer = old.pipelineLayer
+	t.outputLayer = old.outputLayer
+}
+
+func restart(old *Type, conf Config, mgr bundle.NewManagement, timeout time.Duration, opts ...func(*Type)) (*Type, error) {
+	if err := old.Stop(timeout); err != nil {
+		mgr.Logger().Errorf("Failed to drain previous stream: %v", err)
+	}
+	t, err := New(conf, mgr, opts...)
+	if err != nil {
+		return rollback(old, mgr, err, opts...)
+	}
+	return t, nil
+}
+
+func rollback(old *Type, mgr bundle.NewManagement, cause error, opts ...func(*Type)) (*Type, error) {
+	restored, err := New(old.conf, mgr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new stream: %v, and failed to restore the previous stream: %w", cause, err)
+	}
+	return restored, fmt.Errorf("failed to create new stream, the previous stream has been restored: %w", cause)
+}
+
+// waitForOutput blocks until the output layer of the stream is connected, the
+// timeout elapses or the context is cancelled, and returns whether it
+// connected.
+func (t *Type) waitForOutput(ctx context.Context, timeout time.Duration) bool {
+	return waitForConnected(ctx, t.outputLayer.Connected, timeout)
+}
+
+// waitForInput blocks until the input layer of the stream is connected, the
+// timeout elapses or the context is cancelled, and returns whether it
+// connected. An input that has already ended, such as a generate input with a
+// count, is considered connected.
+func (t *Type) waitForInput(ctx context.Context, timeout time.Duration) bool {
+	return waitForConnected(ctx, func() bool {
+		select {
+		case <-t.forwarder.inputEnded:
+			return true
+		default:
+		}
+		return t.inputLayer.Connected()
+	}, timeout)
+}
+
+func waitForConnected(ctx context.Context, connected func() bool, timeout time.Duration) bool {
+	ctx, done := context.WithTimeout(ctx, timeout)
+	defer done()
+
+	ticker := time.NewTicker(time.Millisecond * 10)
+	defer ticker.Stop()
+
+	for !connected() {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// closeDownstream closes the buffer, pipeline and output layers of a stream
+// that has not been fully started.
+func (t *Type) closeDownstream(timeout time.Duration) {
+	if t.bufferLayer != nil {
+		t.bufferLayer.CloseAsync()
+	}
+	if t.pipelineLayer != nil {
+		t.pipelineLayer.CloseAsync()
+	}
+	if t.outputLayer != nil {
+		t.outputLayer.CloseAsync()
+	}
+
+	started := time.Now()
+	if t.bufferLayer != nil {
+		_ = t.bufferLayer.WaitForClose(timeout)
+	}
+	if t.pipelineLayer != nil {
+		_ = t.pipelineLayer.WaitForClose(timeout - time.Since(started))
+	}
+	if t.outputLayer != nil {
+		_ = t.outputLayer.WaitForClose(timeout - time.Since(started))
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// inputForwarder feeds transactions from the input layer of a stream to its
+// downstream layers, and allows either side to be replaced during a swap
+// without closing the other.
+type inputForwarder struct {
+	mut       sync.Mutex
+	nextInput <-chan message.Transaction
+	ended     bool
+
+	nextOutput chan chan message.Transaction
+	inputEnded chan struct{}
+}
+
+func newInputForwarder(in <-chan message.Transaction, out chan message.Transaction) *inputForwarder {
+	f := &inputForwarder{
+		nextOutput: make(chan chan message.Transaction),
+		inputEnded: make(chan struct{}),
+	}
+	go f.loop(in, out)
+	return f
+}
+
+func (f *inputForwarder) loop(in <-chan message.Transaction, out chan message.Transaction) {
+	var tran message.Transaction
+	var pending bool
+	for {
+		var inChan <-chan message.Transaction
+		var outChan chan<- message.Transaction
+		if pending {
+			outChan = out
+		} else {
+			inChan = in
+		}
+
+		select {
+		case t, open := <-inChan:
+			if open {
+				tran, pending = t, true
+				continue
+			}
+			if in = f.takeNextInput(); in == nil {
+				close(out)
+				close(f.inputEnded)
+				return
+			}
+		case outChan <- tran:
+			pending = false
+		case newOut := <-f.nextOutput:
+			// Closing the previous channel allows the previous layers to
+			// drain and close by proxy.
+			close(out)
+			out = newOut
+		}
+	}
+}
+
+// takeNextInput returns the input queued to replace an input that has ended,
+// or nil if there isn't one, in which case the forwarder has ended.
+func (f *inputForwarder) takeNextInput() <-chan message.Transaction {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	next := f.nextInput
+	f.nextInput = nil
+	f.ended = next == nil
+	return next
+}
+
+// replaceInput queues a transaction channel to forward from once the current
+// input has closed, and returns false if the forwarder has already ended.
+func (f *inputForwarder) replaceInput(in <-chan message.Transaction) bool {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	if f.ended {
+		return false
+	}
+	f.nextInput = in
+	return true
+}
+
+// replaceOutput switches the forwarder to a new transaction channel, closing
+// the previous one, and returns false if the forwarder has already ended.
+func (f *inputForwarder) replaceOutput(out chan message.Transaction) bool {
+	select {
+	case f.nextOutput <- out:
+		return true
+	case <-f.inputEnded:
+		return false
+	}
+}
@@ -0,0 +1,337 @@
+package stream_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/stream"
+)
+
+func streamConfFromYAML(t *testing.T, confStr string) stream.Config {
+	t.Helper()
+
+	conf := stream.NewConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(confStr), &conf))
+	return conf
+}
+
+func TestTypeSwap(t *testing.T) {
+	outPath := filepath.Join(t.TempDir(), "out.txt")
+
+	oldConf := streamConfFromYAML(t, `
+input:
+  generate:
+    interval: 1ms
+    mapping: 'root = "old"'
+output:
+  drop: {}
+`)
+
+	newConf := streamConfFromYAML(t, `
+input:
+  generate:
+    count: 1
+    interval: ""
+    mapping: 'root = "new"'
+output:
+  file:
+    path: `+outPath+`
+    codec: lines
+`)
+
+	mgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	oldStrm, err := stream.New(oldConf, mgr)
+	require.NoError(t, err)
+
+	newStrm, err := stream.Swap(context.Background(), oldStrm, newConf, mgr, time.Second*5)
+	require.NoError(t, err)
+	require.NotNil(t, newStrm)
+	assert.NotSame(t, oldStrm, newStrm)
+
+	assert.Eventually(t, func() bool {
+		outBytes, _ := os.ReadFile(outPath)
+		return string(outBytes) == "new\n"
+	}, time.Second*5, time.Millisecond*10)
+
+	require.NoError(t, newStrm.Stop(time.Second*5))
+}
+
+func TestTypeSwapOutputNotConnected(t *testing.T) {
+	outPath := filepath.Join(t.TempDir(), "out.txt")
+
+	oldConf := streamConfFromYAML(t, `
+input:
+  generate:
+    interval: 1ms
+    mapping: 'root = "old"'
+output:
+  file:
+    path: `+outPath+`
+    codec: lines
+`)
+
+	newConf := streamConfFromYAML(t, `
+input:
+  generate:
+    interval: 1ms
+    mapping: 'root = "new"'
+output:
+  nats:
+    urls: [ nats://127.0.0.1:1 ]
+    subject: foo
+`)
+
+	mgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	oldStrm, err := stream.New(oldConf, mgr)
+	require.NoError(t, err)
+
+	strm, err := stream.Swap(context.Background(), oldStrm, newConf, mgr, time.Millisecond*200)
+	require.Error(t, err)
+	assert.Same(t, oldStrm, strm)
+
+	// The old stream should continue to run.
+	outBytes, err := os.ReadFile(outPath)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		newOutBytes, _ := os.ReadFile(outPath)
+		return len(newOutBytes) > len(outBytes)
+	}, time.Second*5, time.Millisecond*10)
+
+	require.NoError(t, strm.Stop(time.Second*5))
+}
+
+func TestTypeSwapRollback(t *testing.T) {
+	oldConf := streamConfFromYAML(t, `
+input:
+  generate:
+    interval: 1ms
+    mapping: 'root = "old"'
+output:
+  drop: {}
+`)
+
+	newConf := streamConfFromYAML(t, `
+input:
+  generate:
+    interval: 1ms
+    mapping: 'root = this.nope.not.a.func('
+output:
+  drop: {}
+  processors:
+    - bloblang: 'root = content()'
+`)
+
+	mgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	oldStrm, err := stream.New(oldConf, mgr)
+	require.NoError(t, err)
+
+	strm, err := stream.Swap(context.Background(), oldStrm, newConf, mgr, time.Second*5)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the previous stream has been restored")
+	require.NotNil(t, strm)
+	assert.NotSame(t, oldStrm, strm)
+
+	require.NoError(t, strm.Stop(time.Second*5))
+}
+
+func TestTypeSwapInputNotConnected(t *testing.T) {
+	outPath := filepath.Join(t.TempDir(), "out.txt")
+
+	oldConf := streamConfFromYAML(t, `
+input:
+  generate:
+    interval: 1ms
+    mapping: 'root = "old"'
+output:
+  file:
+    path: `+outPath+`
+    codec: lines
+`)
+
+	newConf := streamConfFromYAML(t, `
+input:
+  nats:
+    urls: [ nats://127.0.0.1:1 ]
+    subject: foo
+output:
+  drop: {}
+`)
+
+	mgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	oldStrm, err := stream.New(oldConf, mgr)
+	require.NoError(t, err)
+
+	strm, err := stream.Swap(context.Background(), oldStrm, newConf, mgr, time.Millisecond*200)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "new input failed to connect within the timeout")
+	assert.Contains(t, err.Error(), "the previous stream has been restored")
+	require.NotNil(t, strm)
+	assert.NotSame(t, oldStrm, strm)
+
+	// The previous config should be running again.
+	outBytes, err := os.ReadFile(outPath)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		newOutBytes, _ := os.ReadFile(outPath)
+		return len(newOutBytes) > len(outBytes)
+	}, time.Second*5, time.Millisecond*10)
+
+	require.NoError(t, strm.Stop(time.Second*5))
+}
+
+func TestTypeSwapReusesOutput(t *testing.T) {
+	outPath := filepath.Join(t.TempDir(), "out.txt")
+
+	oldConf := streamConfFromYAML(t, `
+input:
+  generate:
+    interval: 1ms
+    mapping: 'root = "old"'
+output:
+  file:
+    path: `+outPath+`
+    codec: lines
+`)
+
+	newConf := streamConfFromYAML(t, `
+input:
+  generate:
+    interval: 1ms
+    mapping: 'root = "new"'
+output:
+  file:
+    path: `+outPath+`
+    codec: lines
+`)
+
+	stats := metrics.NewLocal()
+	mgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetMetrics(metrics.NewNamespaced(stats)))
+	require.NoError(t, err)
+
+	oldStrm, err := stream.New(oldConf, mgr)
+	require.NoError(t, err)
+
+	newStrm, err := stream.Swap(context.Background(), oldStrm, newConf, mgr, time.Second*5)
+	require.NoError(t, err)
+	assert.NotSame(t, oldStrm, newStrm)
+
+	assert.Eventually(t, func() bool {
+		outBytes, _ := os.ReadFile(outPath)
+		return strings.HasSuffix(string(outBytes), "new\n")
+	}, time.Second*5, time.Millisecond*10)
+
+	// The output connected once and was reused by the new stream.
+	assert.Equal(t, int64(1), stats.GetCounters()[`output_connection_up{label="",path="root.output"}`])
+	assert.Equal(t, int64(2), stats.GetCounters()[`input_connection_up{label="",path="root.input"}`])
+
+	require.NoError(t, newStrm.Stop(time.Second*5))
+}
+
+func TestTypeSwapReusesInput(t *testing.T) {
+	outPathOld := filepath.Join(t.TempDir(), "old.txt")
+	outPathNew := filepath.Join(t.TempDir(), "new.txt")
+
+	oldConf := streamConfFromYAML(t, `
+input:
+  generate:
+    interval: 1ms
+    mapping: 'root = "foo"'
+output:
+  file:
+    path: `+outPathOld+`
+    codec: lines
+`)
+
+	newConf := streamConfFromYAML(t, `
+input:
+  generate:
+    interval: 1ms
+    mapping: 'root = "foo"'
+output:
+  file:
+    path: `+outPathNew+`
+    codec: lines
+`)
+
+	stats := metrics.NewLocal()
+	mgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetMetrics(metrics.NewNamespaced(stats)))
+	require.NoError(t, err)
+
+	oldStrm, err := stream.New(oldConf, mgr)
+	require.NoError(t, err)
+
+	newStrm, err := stream.Swap(context.Background(), oldStrm, newConf, mgr, time.Second*5)
+	require.NoError(t, err)
+	assert.NotSame(t, oldStrm, newStrm)
+
+	assert.Eventually(t, func() bool {
+		outBytes, _ := os.ReadFile(outPathNew)
+		return len(outBytes) > 0
+	}, time.Second*5, time.Millisecond*10)
+
+	// The input connected once and was reused by the new stream.
+	assert.Equal(t, int64(1), stats.GetCounters()[`input_connection_up{label="",path="root.input"}`])
+	assert.Equal(t, int64(2), stats.GetCounters()[`output_connection_up{label="",path="root.output"}`])
+
+	require.NoError(t, newStrm.Stop(time.Second*5))
+}
+
+func TestTypeSwapCancelled(t *testing.T) {
+	oldConf := streamConfFromYAML(t, `
+input:
+  generate:
+    interval: 1ms
+    mapping: 'root = "old"'
+output:
+  drop: {}
+`)
+
+	newConf := streamConfFromYAML(t, `
+input:
+  generate:
+    interval: 1ms
+    mapping: 'root = "old"'
+output:
+  nats:
+    urls: [ nats://127.0.0.1:1 ]
+    subject: foo
+`)
+
+	mgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	oldStrm, err := stream.New(oldConf, mgr)
+	require.NoError(t, err)
+
+	ctx, done := context.WithCancel(context.Background())
+	go func() {
+		<-time.After(time.Millisecond * 50)
+		done()
+	}()
+
+	started := time.Now()
+	strm, err := stream.Swap(ctx, oldStrm, newConf, mgr, time.Minute)
+	require.Error(t, err)
+	assert.Same(t, oldStrm, strm)
+	assert.Less(t, int64(time.Since(started)), int64(time.Second*30))
+
+	require.NoError(t, strm.Stop(time.Second*5))
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"net/http"
 	"runtime/pprof"
+	"sync"
 	"time"
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
@@ -26,9 +27,14 @@ type Type struct {
 	pipelineLayer pipeline.Type
 	outputLayer   ioutput.Streamed
 
+	forwarder *inputForwarder
+
 	manager bundle.NewManagement
+	phases  shutdownPhases
 
-	onClose func()
+	onClose       func()
+	closeWatchMut sync.Mutex
+	closeWatched  chan struct{}
 }
 
 // New creates a new stream.Type.
@@ -41,10 +47,18 @@ func New(conf Config, mgr bundle.NewManagement, opts ...func(*Type)) (*Type, err
 	for _, opt := range opts {
 		opt(t)
 	}
-	if err := t.start(); err != nil {
+	var err error
+	if t.phases, err = parseShutdownPhases(conf.Shutdown); err != nil {
+		return nil, err
+	}
+	if err = t.start(); err != nil {
 		return nil, err
 	}
+	t.registerReadyEndpoint()
+	return t, nil
+}
 
+func (t *Type) registerReadyEndpoint() {
 	healthCheck := func(w http.ResponseWriter, r *http.Request) {
 		inputConnected := t.inputLayer.Connected()
 		outputConnected := t.outputLayer.Connected()
@@ -67,7 +81,6 @@ func New(conf Config, mgr bundle.NewManagement, opts ...func(*Type)) (*Type, err
 		"Returns 200 OK if all inputs and outputs are connected, otherwise a 503 is returned.",
 		healthCheck,
 	)
-	return t, nil
 }
 
 //------------------------------------------------------------------------------
@@ -88,11 +101,23 @@ func (t *Type) IsReady() bool {
 }
 
 func (t *Type) start() (err error) {
-	// Constructors
 	iMgr := t.manager.IntoPath("input")
 	if t.inputLayer, err = iMgr.NewInput(t.conf.Input); err != nil {
 		return
 	}
+	inTranChan := make(chan message.Transaction)
+	if err = t.startDownstream(inTranChan); err != nil {
+		return
+	}
+	t.forwarder = newInputForwarder(t.inputLayer.TransactionChan(), inTranChan)
+	t.watchForClose()
+	return nil
+}
+
+// startDownstream constructs the buffer, pipeline and output layers of the
+// stream and chains them to consume from a provided transaction channel.
+func (t *Type) startDownstream(inTranChan <-chan message.Transaction) (err error) {
+	// Constructors
 	if t.conf.Buffer.Type != "none" {
 		bMgr := t.manager.IntoPath("buffer")
 		if t.bufferLayer, err = bMgr.NewBuffer(t.conf.Buffer); err != nil {
@@ -111,9 +136,7 @@ func (t *Type) start() (err error) {
 	}
 
 	// Start chaining components
-	var nextTranChan <-chan message.Transaction
-
-	nextTranChan = t.inputLayer.TransactionChan()
+	nextTranChan := inTranChan
 	if t.bufferLayer != nil {
 		if err = t.bufferLayer.Consume(nextTranChan); err != nil {
 			return
@@ -129,67 +152,121 @@ func (t *Type) start() (err error) {
 	if err = t.outputLayer.Consume(nextTranChan); err != nil {
 		return
 	}
+	return nil
+}
+
+func (t *Type) watchForClose() {
+	stopWatching := make(chan struct{})
+
+	t.closeWatchMut.Lock()
+	t.closeWatched = stopWatching
+	t.closeWatchMut.Unlock()
 
 	go func(out ioutput.Streamed) {
 		for {
+			select {
+			case <-stopWatching:
+				return
+			default:
+			}
 			if err := out.WaitForClose(time.Second); err == nil {
-				t.onClose()
+				select {
+				case <-stopWatching:
+				default:
+					t.onClose()
+				}
 				return
 			}
 		}
 	}(t.outputLayer)
+}
 
-	return nil
+// stopWatchingClose prevents the closure of the output layer from being
+// reported, which is used when a swapped stream takes over the layer.
+func (t *Type) stopWatchingClose() {
+	t.closeWatchMut.Lock()
+	defer t.closeWatchMut.Unlock()
+	if t.closeWatched != nil {
+		close(t.closeWatched)
+		t.closeWatched = nil
+	}
 }
 
 // StopGracefully attempts to close the stream in the most graceful way by only
 // closing the input layer and waiting for all other layers to terminate by
 // proxy. This should guarantee that all in-flight and buffered data is resolved
 // before shutting down.
+//
+// The shut down is split into phases, each of which may have its own timeout
+// configured. First the input stops consuming new messages, then the buffer and
+// pipeline layers are drained, then the output flushes any pending batches and
+// finally the output is closed and the input finishes acknowledging messages.
 func (t *Type) StopGracefully(timeout time.Duration) (err error) {
+	stopBy := time.Now().Add(timeout)
 	t.inputLayer.CloseAsync()
-	started := time.Now()
-	if err = t.inputLayer.WaitForClose(timeout); err != nil {
-		return
-	}
-
-	var remaining time.Duration
 
-	// If we have a buffer then wait right here. We want to try and allow the
-	// buffer to empty out before prompting the other layers to shut down.
-	if t.bufferLayer != nil {
-		t.bufferLayer.StopConsuming()
-		remaining = timeout - time.Since(started)
-		if remaining < 0 {
-			return component.ErrTimeout
-		}
-		if err = t.bufferLayer.WaitForClose(remaining); err != nil {
-			return
+	if err = t.runShutdownPhase("stop_consuming", t.phases.stopConsuming, stopBy, func(timeout time.Duration) error {
+		// Buffers acknowledge messages as they are received, and so inputs
+		// unable to signal that they have stopped consuming can be waited on
+		// to close completely.
+		if _, ok := t.inputLayer.(iinput.StopConsumingWaiter); !ok && t.bufferLayer != nil {
+			return t.inputLayer.WaitForClose(timeout)
 		}
+		return iinput.WaitForStopConsuming(t.inputLayer, timeout)
+	}); err != nil {
+		return
 	}
 
-	// After this point we can start closing the remaining components.
-	if t.pipelineLayer != nil {
-		t.pipelineLayer.CloseAsync()
-		remaining = timeout - time.Since(started)
-		if remaining < 0 {
-			return component.ErrTimeout
+	// If we have a buffer then wait right here. We want to try and allow the
+	// buffer to empty out before waiting for the other layers to shut down.
+	if err = t.runShutdownPhase("drain", t.phases.drain, stopBy, func(timeout time.Duration) error {
+		started := time.Now()
+		if t.bufferLayer != nil {
+			t.bufferLayer.StopConsuming()
+			if err := t.bufferLayer.WaitForClose(timeout); err != nil {
+				return err
+			}
 		}
-		if err = t.pipelineLayer.WaitForClose(remaining); err != nil {
-			return
+		if t.pipelineLayer != nil {
+			return t.pipelineLayer.WaitForClose(timeout - time.Since(started))
 		}
+		return nil
+	}); err != nil {
+		return
 	}
 
-	t.outputLayer.CloseAsync()
-	remaining = timeout - time.Since(started)
-	if remaining < 0 {
-		return component.ErrTimeout
+	// The output closes by proxy once it has consumed all messages from the
+	// layers before it, which includes flushing any pending batches. Batchers
+	// are signalled to flush immediately rather than waiting for their
+	// policies. However, some outputs only close when asked to, and so we also
+	// finish flushing once all messages consumed by the input are resolved.
+	//
+	// Inputs that can't report the messages they hold close once all of them
+	// are acknowledged, and the wait shares the deadline of the close phase so
+	// that it doesn't cut short the acknowledgement of messages.
+	inputClosed := make(chan struct{})
+	if _, ok := component.InFlightOf(t.inputLayer); !ok {
+		go func() {
+			if err := t.inputLayer.WaitForClose(time.Until(stopBy)); err == nil {
+				close(inputClosed)
+			}
+		}()
 	}
-	if err = t.outputLayer.WaitForClose(remaining); err != nil {
+	if err = t.runShutdownPhase("flush", t.phases.flush, stopBy, func(timeout time.Duration) error {
+		ioutput.TriggerFlush(t.outputLayer)
+		return waitForOutputFlush(t.inputLayer, inputClosed, t.outputLayer, timeout)
+	}); err != nil {
 		return
 	}
 
-	return nil
+	return t.runShutdownPhase("close", t.phases.close, stopBy, func(timeout time.Duration) error {
+		started := time.Now()
+		t.outputLayer.CloseAsync()
+		if err := t.outputLayer.WaitForClose(timeout); err != nil {
+			return err
+		}
+		return t.inputLayer.WaitForClose(timeout - time.Since(started))
+	})
 }
 
 // StopOrdered attempts to close all components of the stream in the order of
@@ -313,6 +390,9 @@ func (t *Type) Stop(timeout time.Duration) error {
 	}
 	if err == component.ErrTimeout {
 		t.manager.Logger().Errorln("Failed to stop stream gracefully within target time.")
+		if report := t.inFlightReport(); report != "" {
+			t.manager.Logger().Errorf("Messages still in flight at shut down: %v", report)
+		}
 
 		dumpBuf := bytes.NewBuffer(nil)
 		_ = pprof.Lookup("goroutine").WriteTo(dumpBuf, 1)
@@ -1,17 +1,23 @@
 package stream_test
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/stream"
 
@@ -120,6 +126,138 @@ func TestTypeCloseUnordered(t *testing.T) {
 	assert.NoError(t, strm.StopUnordered(time.Minute))
 }
 
+func batchedGenerateConf(period string) stream.Config {
+	conf := stream.NewConfig()
+	conf.Input.Type = "generate"
+	conf.Input.Generate.Mapping = `root = "hello world"`
+	conf.Input.Generate.Interval = "1ms"
+
+	dropConf := output.NewConfig()
+	dropConf.Type = "drop"
+
+	conf.Output.Type = "broker"
+	conf.Output.Broker.Outputs = []output.Config{dropConf}
+	conf.Output.Broker.Batching.Count = 1000
+	conf.Output.Broker.Batching.Period = period
+	return conf
+}
+
+func TestTypeShutdownFlushesBatches(t *testing.T) {
+	newMgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	strm, err := stream.New(batchedGenerateConf("1h"), newMgr)
+	require.NoError(t, err)
+
+	// Messages held within a batch are flushed and acknowledged rather than
+	// blocking the input from closing, even when the batch period is long.
+	<-time.After(time.Millisecond * 50)
+
+	started := time.Now()
+	assert.NoError(t, strm.StopGracefully(time.Second*5))
+	assert.Less(t, time.Since(started), time.Second*2)
+}
+
+func TestTypeShutdownSlowOutput(t *testing.T) {
+	stats := metrics.NewLocal()
+	newMgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetMetrics(metrics.NewNamespaced(stats)))
+	require.NoError(t, err)
+
+	var received int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&received, 1)
+	}))
+	defer server.Close()
+
+	conf := stream.NewConfig()
+	conf.Input.Type = "generate"
+	conf.Input.Generate.Mapping = `root = "hello world"`
+	conf.Input.Generate.Interval = ""
+
+	procConf := processor.NewConfig()
+	procConf.Type = "bloblang"
+	procConf.Bloblang = "root = content()"
+	conf.Pipeline.Processors = []processor.Config{procConf}
+
+	// Messages are held by the slow output processors before the output is
+	// able to count them, and so the shut down begins during the hand over.
+	sleepConf := processor.NewConfig()
+	sleepConf.Type = "sleep"
+	sleepConf.Sleep.Duration = "20ms"
+
+	conf.Output.Type = "http_client"
+	conf.Output.HTTPClient.URL = server.URL
+	conf.Output.HTTPClient.Batching.Count = 4
+	conf.Output.Processors = []processor.Config{sleepConf}
+
+	strm, err := stream.New(conf, newMgr)
+	require.NoError(t, err)
+
+	<-time.After(time.Millisecond * 30)
+
+	assert.NoError(t, strm.StopGracefully(time.Second*5))
+
+	// Every message that made it through the pipeline is delivered.
+	processed := stats.GetCounters()[`processor_received{label="",path="root.pipeline.processors.0"}`]
+	assert.Greater(t, processed, int64(0))
+	assert.Equal(t, processed, atomic.LoadInt64(&received))
+}
+
+func TestTypeShutdownPhaseTimeout(t *testing.T) {
+	logConf := log.NewConfig()
+	logConf.LogLevel = "WARN"
+
+	var logBuf bytes.Buffer
+	logger, err := log.NewV2(&logBuf, logConf)
+	require.NoError(t, err)
+
+	newMgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetLogger(logger))
+	require.NoError(t, err)
+
+	// The server blocks requests until the test ends, and so the output is
+	// never able to flush the messages it holds.
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer server.Close()
+
+	conf := stream.NewConfig()
+	conf.Input.Type = "generate"
+	conf.Input.Generate.Mapping = `root = "hello world"`
+	conf.Input.Generate.Interval = "1ms"
+	conf.Output.Type = "http_client"
+	conf.Output.HTTPClient.URL = server.URL
+	conf.Output.HTTPClient.BatchAsMultipart = true
+	conf.Shutdown.FlushTimeout = "100ms"
+
+	strm, err := stream.New(conf, newMgr)
+	require.NoError(t, err)
+
+	<-time.After(time.Millisecond * 50)
+
+	started := time.Now()
+	assert.Equal(t, component.ErrTimeout, strm.StopGracefully(time.Second*5))
+	assert.Less(t, time.Since(started), time.Second*2)
+	assert.Contains(t, logBuf.String(), "Shutdown phase 'flush' failed")
+	assert.Regexp(t, "messages still in flight: .*output: [1-9]", logBuf.String())
+
+	close(unblock)
+	require.NoError(t, strm.StopUnordered(time.Second*2))
+}
+
+func TestTypeShutdownBadConfig(t *testing.T) {
+	newMgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	conf := batchedGenerateConf("")
+	conf.Shutdown.DrainTimeout = "nope"
+
+	_, err = stream.New(conf, newMgr)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "drain_timeout")
+}
+
 type mockAPIReg struct {
 	server *httptest.Server
 }
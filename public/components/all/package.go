@@ -41,6 +41,7 @@ import (
 	_ "github.com/benthosdev/benthos/v4/internal/impl/snowflake"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/sql"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/statsd"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/vault"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/xml"
 	"github.com/benthosdev/benthos/v4/internal/template"
 
@@ -64,9 +64,19 @@ type airGapCache struct {
 }
 
 func newAirGapCache(c Cache, stats metrics.Type) cache.V1 {
+	return cache.MetricsForCache(newAirGapCacheUnobserved(c), stats)
+}
+
+// newObservedAirGapCache wraps a cache with metrics as well as health tracking
+// when supported by the manager.
+func newObservedAirGapCache(c Cache, mgr component.Observability) cache.V1 {
+	return cache.MetricsAndHealthForCache(newAirGapCacheUnobserved(c), mgr)
+}
+
+func newAirGapCacheUnobserved(c Cache) *airGapCache {
 	ag := &airGapCache{c, nil}
 	ag.cm, _ = c.(batchedCache)
-	return cache.MetricsForCache(ag, stats)
+	return ag
 }
 
 func (a *airGapCache) Get(ctx context.Context, key string) ([]byte, error) {
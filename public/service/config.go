@@ -186,6 +186,16 @@ func (c *ConfigField) Deprecated() *ConfigField {
 	return c
 }
 
+// Secret marks a config field as being a secret, which means it represents
+// information that is generally considered sensitive such as passwords or
+// access tokens. The values of secret fields are scrubbed from configs exposed
+// by debug endpoints and other outputs, and the linter warns when they are set
+// inline rather than with environment variables or secrets providers.
+func (c *ConfigField) Secret() *ConfigField {
+	c.field = c.field.Secret()
+	return c
+}
+
 // Default specifies a default value that this field will assume if it is
 // omitted from a provided config. Fields that do not have a default value are
 // considered mandatory, and so parsing a config will fail in their absence.
@@ -141,7 +141,7 @@ func (e *Environment) RegisterCache(name string, spec *ConfigSpec, ctor CacheCon
 		if err != nil {
 			return nil, err
 		}
-		return newAirGapCache(c, nm.Metrics()), nil
+		return newObservedAirGapCache(c, nm), nil
 	}, componentSpec)
 }
 
@@ -376,7 +376,7 @@ func (e *Environment) RegisterRateLimit(name string, spec *ConfigSpec, ctor Rate
 		if err != nil {
 			return nil, err
 		}
-		return newAirGapRateLimit(r, nm.Metrics()), nil
+		return newObservedAirGapRateLimit(r, nm), nil
 	}, componentSpec)
 }
 
@@ -147,3 +147,35 @@ logger:
 	require.NoError(t, strm.StopWithin(time.Second))
 	assert.Equal(t, []string{"meow"}, received)
 }
+
+func TestEnvironmentSecretsProvider(t *testing.T) {
+	envOne := service.NewEnvironment()
+	envTwo := envOne.Clone()
+
+	require.NoError(t, envOne.RegisterSecretsProvider("one_secrets", func(ctx context.Context, path string) (string, error) {
+		if path == "bad" {
+			return "", errors.New("secret one err")
+		}
+		return "secret one " + path, nil
+	}))
+
+	sb := envOne.NewStreamBuilder()
+	require.NoError(t, sb.AddInputYAML(`
+generate:
+  mapping: 'root = "${secret:one_secrets:foo}"'
+`))
+
+	yamlStr, err := sb.AsYAML()
+	require.NoError(t, err)
+	assert.Contains(t, yamlStr, `root = "secret one foo"`)
+
+	assert.EqualError(t, envOne.NewStreamBuilder().AddInputYAML(`
+generate:
+  mapping: 'root = "${secret:one_secrets:bad}"'
+`), "failed to obtain secret 'one_secrets:bad': secret one err")
+
+	assert.EqualError(t, envTwo.NewStreamBuilder().AddInputYAML(`
+generate:
+  mapping: 'root = "${secret:one_secrets:foo}"'
+`), "failed to obtain secret 'one_secrets:foo': secrets provider 'one_secrets' was not recognised")
+}
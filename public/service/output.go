@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	ibatch "github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	ioutput "github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/message"
@@ -140,6 +141,12 @@ func (a *airGapBatchWriter) WriteWithContext(ctx context.Context, msg *message.B
 	if err != nil && errors.Is(err, ErrNotConnected) {
 		err = component.ErrNotConnected
 	}
+	// Internal plugins are able to indicate which messages of the batch
+	// failed, but only have access to a copy of the batch.
+	var bErr *ibatch.Error
+	if err != nil && errors.As(err, &bErr) {
+		err = bErr.WithSource(msg)
+	}
 	return err
 }
 
@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	ibatch "github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
@@ -177,3 +179,33 @@ func TestBatchOutputAirGapHappy(t *testing.T) {
 
 	assert.Equal(t, "hello world", wroteMsg)
 }
+
+func TestBatchOutputAirGapBatchError(t *testing.T) {
+	o := &fnBatchOutput{
+		connect: func() error {
+			return nil
+		},
+		writeBatch: func(m MessageBatch) error {
+			return ibatch.NewError(nil, errors.New("bad write")).Failed(1, errors.New("bad message"))
+		},
+	}
+	agi := newAirGapBatchWriter(o)
+
+	inMsg := message.QuickBatch([][]byte{[]byte("foo"), []byte("bar"), []byte("baz")})
+
+	err := agi.WriteWithContext(context.Background(), inMsg)
+	assert.EqualError(t, err, "bad write")
+
+	walkable, ok := err.(ibatch.WalkableError)
+	require.True(t, ok, err)
+	assert.Equal(t, 1, walkable.IndexedErrors())
+
+	var failed []string
+	walkable.WalkParts(func(i int, p *message.Part, err error) bool {
+		if err != nil {
+			failed = append(failed, string(p.Get())+": "+err.Error())
+		}
+		return true
+	})
+	assert.Equal(t, []string{"bar: bad message"}, failed)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
 )
@@ -25,6 +26,12 @@ func newAirGapRateLimit(c RateLimit, stats metrics.Type) ratelimit.V1 {
 	return ratelimit.MetricsForRateLimit(c, stats)
 }
 
+// newObservedAirGapRateLimit wraps a rate limit with metrics as well as health
+// tracking when supported by the manager.
+func newObservedAirGapRateLimit(c RateLimit, mgr component.Observability) ratelimit.V1 {
+	return ratelimit.MetricsAndHealthForRateLimit(c, mgr)
+}
+
 //------------------------------------------------------------------------------
 
 // Implements RateLimit around a types.RateLimit
@@ -0,0 +1,16 @@
+package service
+
+import "github.com/benthosdev/benthos/v4/internal/bundle"
+
+type resourcesUnwrapper struct {
+	child bundle.NewManagement
+}
+
+func (r resourcesUnwrapper) Unwrap() bundle.NewManagement {
+	return r.child
+}
+
+// XUnwrapper is for internal use only, do not use this.
+func (r *Resources) XUnwrapper() interface{} {
+	return resourcesUnwrapper{child: r.mgr}
+}
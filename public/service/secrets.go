@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+)
+
+// SecretLookupFunc is a func that's provided the path of a secret and must
+// return its value, or an error. The format of the path is specific to the
+// provider, and is the remainder of a config interpolation of the form
+// `${secret:provider:path}`.
+type SecretLookupFunc func(ctx context.Context, path string) (string, error)
+
+// RegisterSecretsProvider attempts to register a new secrets provider plugin,
+// which resolves config interpolations of the form `${secret:name:path}`.
+// Secrets are resolved each time a config is loaded, including when a config
+// is reloaded due to changes.
+//
+// Experimental: This type signature is experimental and therefore subject to
+// change outside of major version releases.
+func RegisterSecretsProvider(name string, fn SecretLookupFunc) error {
+	return globalEnvironment.RegisterSecretsProvider(name, fn)
+}
+
+// RegisterSecretsProvider attempts to register a new secrets provider plugin,
+// which resolves config interpolations of the form `${secret:name:path}`.
+// Secrets are resolved each time a config is loaded, including when a config
+// is reloaded due to changes.
+//
+// Experimental: This type signature is experimental and therefore subject to
+// change outside of major version releases.
+func (e *Environment) RegisterSecretsProvider(name string, fn SecretLookupFunc) error {
+	return e.internal.SecretsAdd(name, func(ctx context.Context, path string) (string, error) {
+		return fn(ctx, path)
+	})
+}
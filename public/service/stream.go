@@ -79,6 +79,9 @@ func (s *Stream) Run(ctx context.Context) (err error) {
 // Initially the attempt is graceful, but as the timeout draws close the attempt
 // becomes progressively less graceful.
 //
+// The graceful attempt is split into phases, which are given the timeouts of
+// the `shutdown` field of the stream config where set.
+//
 // An ungraceful shutdown increases the likelihood of processing duplicate
 // messages on the next start up, but never results in dropped messages as long
 // as the input source supports at-least-once delivery.
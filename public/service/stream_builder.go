@@ -240,7 +240,7 @@ func (s *StreamBuilder) AddBatchProducerFunc() (MessageBatchHandlerFunc, error)
 // If more than one input configuration is added they will automatically be
 // composed within a broker when the pipeline is built.
 func (s *StreamBuilder) AddInputYAML(conf string) error {
-	nconf, err := getYAMLNode([]byte(conf))
+	nconf, err := s.getYAMLNode([]byte(conf))
 	if err != nil {
 		return err
 	}
@@ -262,7 +262,7 @@ func (s *StreamBuilder) AddInputYAML(conf string) error {
 // builder to be executed within the pipeline.processors section, after all
 // prior added processor configs.
 func (s *StreamBuilder) AddProcessorYAML(conf string) error {
-	nconf, err := getYAMLNode([]byte(conf))
+	nconf, err := s.getYAMLNode([]byte(conf))
 	if err != nil {
 		return err
 	}
@@ -359,7 +359,7 @@ func (s *StreamBuilder) AddBatchConsumerFunc(fn MessageBatchHandlerFunc) error {
 // If more than one output configuration is added they will automatically be
 // composed within a fan out broker when the pipeline is built.
 func (s *StreamBuilder) AddOutputYAML(conf string) error {
-	nconf, err := getYAMLNode([]byte(conf))
+	nconf, err := s.getYAMLNode([]byte(conf))
 	if err != nil {
 		return err
 	}
@@ -380,7 +380,7 @@ func (s *StreamBuilder) AddOutputYAML(conf string) error {
 // AddCacheYAML parses a cache YAML configuration and adds it to the builder as
 // a resource.
 func (s *StreamBuilder) AddCacheYAML(conf string) error {
-	nconf, err := getYAMLNode([]byte(conf))
+	nconf, err := s.getYAMLNode([]byte(conf))
 	if err != nil {
 		return err
 	}
@@ -409,7 +409,7 @@ func (s *StreamBuilder) AddCacheYAML(conf string) error {
 // AddRateLimitYAML parses a rate limit YAML configuration and adds it to the
 // builder as a resource.
 func (s *StreamBuilder) AddRateLimitYAML(conf string) error {
-	nconf, err := getYAMLNode([]byte(conf))
+	nconf, err := s.getYAMLNode([]byte(conf))
 	if err != nil {
 		return err
 	}
@@ -437,7 +437,7 @@ func (s *StreamBuilder) AddRateLimitYAML(conf string) error {
 
 // AddResourcesYAML parses resource configurations and adds them to the config.
 func (s *StreamBuilder) AddResourcesYAML(conf string) error {
-	node, err := getYAMLNode([]byte(conf))
+	node, err := s.getYAMLNode([]byte(conf))
 	if err != nil {
 		return err
 	}
@@ -467,7 +467,7 @@ func (s *StreamBuilder) SetYAML(conf string) error {
 		return errors.New("attempted to override outputs config after adding a func consumer")
 	}
 
-	node, err := getYAMLNode([]byte(conf))
+	node, err := s.getYAMLNode([]byte(conf))
 	if err != nil {
 		return err
 	}
@@ -558,7 +558,7 @@ func (s *StreamBuilder) setFromConfig(sconf config.Type) {
 // to be placed between the input and the pipeline (processors) sections. This
 // config will replace any prior configured buffer.
 func (s *StreamBuilder) SetBufferYAML(conf string) error {
-	nconf, err := getYAMLNode([]byte(conf))
+	nconf, err := s.getYAMLNode([]byte(conf))
 	if err != nil {
 		return err
 	}
@@ -579,7 +579,7 @@ func (s *StreamBuilder) SetBufferYAML(conf string) error {
 // SetMetricsYAML parses a metrics YAML configuration and adds it to the builder
 // such that all stream components emit metrics through it.
 func (s *StreamBuilder) SetMetricsYAML(conf string) error {
-	nconf, err := getYAMLNode([]byte(conf))
+	nconf, err := s.getYAMLNode([]byte(conf))
 	if err != nil {
 		return err
 	}
@@ -600,7 +600,7 @@ func (s *StreamBuilder) SetMetricsYAML(conf string) error {
 // SetTracerYAML parses a tracer YAML configuration and adds it to the builder
 // such that all stream components emit tracing spans through it.
 func (s *StreamBuilder) SetTracerYAML(conf string) error {
-	nconf, err := getYAMLNode([]byte(conf))
+	nconf, err := s.getYAMLNode([]byte(conf))
 	if err != nil {
 		return err
 	}
@@ -621,7 +621,7 @@ func (s *StreamBuilder) SetTracerYAML(conf string) error {
 // SetLoggerYAML parses a logger YAML configuration and adds it to the builder
 // such that all stream components emit logs through it.
 func (s *StreamBuilder) SetLoggerYAML(conf string) error {
-	node, err := getYAMLNode([]byte(conf))
+	node, err := s.getYAMLNode([]byte(conf))
 	if err != nil {
 		return err
 	}
@@ -759,6 +759,7 @@ func (s *StreamBuilder) buildWithEnv(env *bundle.Environment) (*Stream, error) {
 		if err == nil {
 			sanitConf := docs.NewSanitiseConfig()
 			sanitConf.RemoveTypeField = true
+			sanitConf.ScrubSecrets = true
 			sanitConf.DocsProvider = env
 			_ = config.Spec().SanitiseYAML(&sanitNode, sanitConf)
 		}
@@ -842,8 +843,12 @@ func (s *StreamBuilder) buildConfig() builderConfig {
 
 //------------------------------------------------------------------------------
 
-func getYAMLNode(b []byte) (*yaml.Node, error) {
-	b = config.ReplaceEnvVariables(b)
+func (s *StreamBuilder) getYAMLNode(b []byte) (*yaml.Node, error) {
+	b, err := config.ReplaceEnvVariablesWithSecrets(b, s.env.internal)
+	if err != nil {
+		return nil, err
+	}
+
 	var nconf yaml.Node
 	if err := yaml.Unmarshal(b, &nconf); err != nil {
 		return nil, err
@@ -25,6 +25,7 @@ module.exports = {
         'configuration/windowed_processing',
         'configuration/metadata',
         'configuration/error_handling',
+        'configuration/graceful_shutdown',
         'configuration/interpolation',
         'configuration/field_paths',
         'configuration/processing_pipelines',
@@ -117,6 +118,7 @@ module.exports = {
           items: [
             'guides/serverless/about',
             'guides/serverless/lambda',
+            'guides/serverless/http',
           ],
         },
         {